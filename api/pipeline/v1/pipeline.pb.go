@@ -0,0 +1,598 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: api/pipeline/v1/pipeline.proto
+
+package pipelinev1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type SubmitJobRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Channel       string                 `protobuf:"bytes,1,opt,name=channel,proto3" json:"channel,omitempty"`
+	VideoId       string                 `protobuf:"bytes,2,opt,name=video_id,json=videoId,proto3" json:"video_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubmitJobRequest) Reset() {
+	*x = SubmitJobRequest{}
+	mi := &file_api_pipeline_v1_pipeline_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubmitJobRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubmitJobRequest) ProtoMessage() {}
+
+func (x *SubmitJobRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_pipeline_v1_pipeline_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubmitJobRequest.ProtoReflect.Descriptor instead.
+func (*SubmitJobRequest) Descriptor() ([]byte, []int) {
+	return file_api_pipeline_v1_pipeline_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *SubmitJobRequest) GetChannel() string {
+	if x != nil {
+		return x.Channel
+	}
+	return ""
+}
+
+func (x *SubmitJobRequest) GetVideoId() string {
+	if x != nil {
+		return x.VideoId
+	}
+	return ""
+}
+
+type SubmitJobResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Position      int32                  `protobuf:"varint,1,opt,name=position,proto3" json:"position,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubmitJobResponse) Reset() {
+	*x = SubmitJobResponse{}
+	mi := &file_api_pipeline_v1_pipeline_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubmitJobResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubmitJobResponse) ProtoMessage() {}
+
+func (x *SubmitJobResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_pipeline_v1_pipeline_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubmitJobResponse.ProtoReflect.Descriptor instead.
+func (*SubmitJobResponse) Descriptor() ([]byte, []int) {
+	return file_api_pipeline_v1_pipeline_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *SubmitJobResponse) GetPosition() int32 {
+	if x != nil {
+		return x.Position
+	}
+	return 0
+}
+
+type StreamEventsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Channel       string                 `protobuf:"bytes,1,opt,name=channel,proto3" json:"channel,omitempty"`
+	VideoId       string                 `protobuf:"bytes,2,opt,name=video_id,json=videoId,proto3" json:"video_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamEventsRequest) Reset() {
+	*x = StreamEventsRequest{}
+	mi := &file_api_pipeline_v1_pipeline_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamEventsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamEventsRequest) ProtoMessage() {}
+
+func (x *StreamEventsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_pipeline_v1_pipeline_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamEventsRequest.ProtoReflect.Descriptor instead.
+func (*StreamEventsRequest) Descriptor() ([]byte, []int) {
+	return file_api_pipeline_v1_pipeline_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *StreamEventsRequest) GetChannel() string {
+	if x != nil {
+		return x.Channel
+	}
+	return ""
+}
+
+func (x *StreamEventsRequest) GetVideoId() string {
+	if x != nil {
+		return x.VideoId
+	}
+	return ""
+}
+
+type Event struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	Time          string                 `protobuf:"bytes,2,opt,name=time,proto3" json:"time,omitempty"`
+	Channel       string                 `protobuf:"bytes,3,opt,name=channel,proto3" json:"channel,omitempty"`
+	VideoId       string                 `protobuf:"bytes,4,opt,name=video_id,json=videoId,proto3" json:"video_id,omitempty"`
+	Cut           string                 `protobuf:"bytes,5,opt,name=cut,proto3" json:"cut,omitempty"`
+	Path          string                 `protobuf:"bytes,6,opt,name=path,proto3" json:"path,omitempty"`
+	Count         int32                  `protobuf:"varint,7,opt,name=count,proto3" json:"count,omitempty"`
+	Code          string                 `protobuf:"bytes,8,opt,name=code,proto3" json:"code,omitempty"`
+	Error         string                 `protobuf:"bytes,9,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Event) Reset() {
+	*x = Event{}
+	mi := &file_api_pipeline_v1_pipeline_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Event) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Event) ProtoMessage() {}
+
+func (x *Event) ProtoReflect() protoreflect.Message {
+	mi := &file_api_pipeline_v1_pipeline_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Event.ProtoReflect.Descriptor instead.
+func (*Event) Descriptor() ([]byte, []int) {
+	return file_api_pipeline_v1_pipeline_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Event) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Event) GetTime() string {
+	if x != nil {
+		return x.Time
+	}
+	return ""
+}
+
+func (x *Event) GetChannel() string {
+	if x != nil {
+		return x.Channel
+	}
+	return ""
+}
+
+func (x *Event) GetVideoId() string {
+	if x != nil {
+		return x.VideoId
+	}
+	return ""
+}
+
+func (x *Event) GetCut() string {
+	if x != nil {
+		return x.Cut
+	}
+	return ""
+}
+
+func (x *Event) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *Event) GetCount() int32 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *Event) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+func (x *Event) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type GetResultsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Channel       string                 `protobuf:"bytes,1,opt,name=channel,proto3" json:"channel,omitempty"`
+	VideoId       string                 `protobuf:"bytes,2,opt,name=video_id,json=videoId,proto3" json:"video_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetResultsRequest) Reset() {
+	*x = GetResultsRequest{}
+	mi := &file_api_pipeline_v1_pipeline_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetResultsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetResultsRequest) ProtoMessage() {}
+
+func (x *GetResultsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_pipeline_v1_pipeline_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetResultsRequest.ProtoReflect.Descriptor instead.
+func (*GetResultsRequest) Descriptor() ([]byte, []int) {
+	return file_api_pipeline_v1_pipeline_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *GetResultsRequest) GetChannel() string {
+	if x != nil {
+		return x.Channel
+	}
+	return ""
+}
+
+func (x *GetResultsRequest) GetVideoId() string {
+	if x != nil {
+		return x.VideoId
+	}
+	return ""
+}
+
+type GetResultsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Clips         []*Clip                `protobuf:"bytes,1,rep,name=clips,proto3" json:"clips,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetResultsResponse) Reset() {
+	*x = GetResultsResponse{}
+	mi := &file_api_pipeline_v1_pipeline_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetResultsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetResultsResponse) ProtoMessage() {}
+
+func (x *GetResultsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_pipeline_v1_pipeline_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetResultsResponse.ProtoReflect.Descriptor instead.
+func (*GetResultsResponse) Descriptor() ([]byte, []int) {
+	return file_api_pipeline_v1_pipeline_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *GetResultsResponse) GetClips() []*Clip {
+	if x != nil {
+		return x.Clips
+	}
+	return nil
+}
+
+type Clip struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	VideoId       string                 `protobuf:"bytes,1,opt,name=video_id,json=videoId,proto3" json:"video_id,omitempty"`
+	Title         string                 `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
+	Description   string                 `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
+	Tags          []string               `protobuf:"bytes,4,rep,name=tags,proto3" json:"tags,omitempty"`
+	Path          string                 `protobuf:"bytes,5,opt,name=path,proto3" json:"path,omitempty"`
+	VerticalPath  string                 `protobuf:"bytes,6,opt,name=vertical_path,json=verticalPath,proto3" json:"vertical_path,omitempty"`
+	CoverPath     string                 `protobuf:"bytes,7,opt,name=cover_path,json=coverPath,proto3" json:"cover_path,omitempty"`
+	Link          string                 `protobuf:"bytes,8,opt,name=link,proto3" json:"link,omitempty"`
+	Uploaded      bool                   `protobuf:"varint,9,opt,name=uploaded,proto3" json:"uploaded,omitempty"`
+	Rendered      string                 `protobuf:"bytes,10,opt,name=rendered,proto3" json:"rendered,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Clip) Reset() {
+	*x = Clip{}
+	mi := &file_api_pipeline_v1_pipeline_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Clip) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Clip) ProtoMessage() {}
+
+func (x *Clip) ProtoReflect() protoreflect.Message {
+	mi := &file_api_pipeline_v1_pipeline_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Clip.ProtoReflect.Descriptor instead.
+func (*Clip) Descriptor() ([]byte, []int) {
+	return file_api_pipeline_v1_pipeline_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Clip) GetVideoId() string {
+	if x != nil {
+		return x.VideoId
+	}
+	return ""
+}
+
+func (x *Clip) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *Clip) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *Clip) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *Clip) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *Clip) GetVerticalPath() string {
+	if x != nil {
+		return x.VerticalPath
+	}
+	return ""
+}
+
+func (x *Clip) GetCoverPath() string {
+	if x != nil {
+		return x.CoverPath
+	}
+	return ""
+}
+
+func (x *Clip) GetLink() string {
+	if x != nil {
+		return x.Link
+	}
+	return ""
+}
+
+func (x *Clip) GetUploaded() bool {
+	if x != nil {
+		return x.Uploaded
+	}
+	return false
+}
+
+func (x *Clip) GetRendered() string {
+	if x != nil {
+		return x.Rendered
+	}
+	return ""
+}
+
+var File_api_pipeline_v1_pipeline_proto protoreflect.FileDescriptor
+
+const file_api_pipeline_v1_pipeline_proto_rawDesc = "" +
+	"\n" +
+	"\x1eapi/pipeline/v1/pipeline.proto\x12\x16godeogoker.pipeline.v1\"G\n" +
+	"\x10SubmitJobRequest\x12\x18\n" +
+	"\achannel\x18\x01 \x01(\tR\achannel\x12\x19\n" +
+	"\bvideo_id\x18\x02 \x01(\tR\avideoId\"/\n" +
+	"\x11SubmitJobResponse\x12\x1a\n" +
+	"\bposition\x18\x01 \x01(\x05R\bposition\"J\n" +
+	"\x13StreamEventsRequest\x12\x18\n" +
+	"\achannel\x18\x01 \x01(\tR\achannel\x12\x19\n" +
+	"\bvideo_id\x18\x02 \x01(\tR\avideoId\"\xca\x01\n" +
+	"\x05Event\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\x12\x12\n" +
+	"\x04time\x18\x02 \x01(\tR\x04time\x12\x18\n" +
+	"\achannel\x18\x03 \x01(\tR\achannel\x12\x19\n" +
+	"\bvideo_id\x18\x04 \x01(\tR\avideoId\x12\x10\n" +
+	"\x03cut\x18\x05 \x01(\tR\x03cut\x12\x12\n" +
+	"\x04path\x18\x06 \x01(\tR\x04path\x12\x14\n" +
+	"\x05count\x18\a \x01(\x05R\x05count\x12\x12\n" +
+	"\x04code\x18\b \x01(\tR\x04code\x12\x14\n" +
+	"\x05error\x18\t \x01(\tR\x05error\"H\n" +
+	"\x11GetResultsRequest\x12\x18\n" +
+	"\achannel\x18\x01 \x01(\tR\achannel\x12\x19\n" +
+	"\bvideo_id\x18\x02 \x01(\tR\avideoId\"H\n" +
+	"\x12GetResultsResponse\x122\n" +
+	"\x05clips\x18\x01 \x03(\v2\x1c.godeogoker.pipeline.v1.ClipR\x05clips\"\x91\x02\n" +
+	"\x04Clip\x12\x19\n" +
+	"\bvideo_id\x18\x01 \x01(\tR\avideoId\x12\x14\n" +
+	"\x05title\x18\x02 \x01(\tR\x05title\x12 \n" +
+	"\vdescription\x18\x03 \x01(\tR\vdescription\x12\x12\n" +
+	"\x04tags\x18\x04 \x03(\tR\x04tags\x12\x12\n" +
+	"\x04path\x18\x05 \x01(\tR\x04path\x12#\n" +
+	"\rvertical_path\x18\x06 \x01(\tR\fverticalPath\x12\x1d\n" +
+	"\n" +
+	"cover_path\x18\a \x01(\tR\tcoverPath\x12\x12\n" +
+	"\x04link\x18\b \x01(\tR\x04link\x12\x1a\n" +
+	"\buploaded\x18\t \x01(\bR\buploaded\x12\x1a\n" +
+	"\brendered\x18\n" +
+	" \x01(\tR\brendered2\xaf\x02\n" +
+	"\bPipeline\x12`\n" +
+	"\tSubmitJob\x12(.godeogoker.pipeline.v1.SubmitJobRequest\x1a).godeogoker.pipeline.v1.SubmitJobResponse\x12\\\n" +
+	"\fStreamEvents\x12+.godeogoker.pipeline.v1.StreamEventsRequest\x1a\x1d.godeogoker.pipeline.v1.Event0\x01\x12c\n" +
+	"\n" +
+	"GetResults\x12).godeogoker.pipeline.v1.GetResultsRequest\x1a*.godeogoker.pipeline.v1.GetResultsResponseBBZ@github.com/rogersilvasouza/godeogoker/api/pipeline/v1;pipelinev1b\x06proto3"
+
+var (
+	file_api_pipeline_v1_pipeline_proto_rawDescOnce sync.Once
+	file_api_pipeline_v1_pipeline_proto_rawDescData []byte
+)
+
+func file_api_pipeline_v1_pipeline_proto_rawDescGZIP() []byte {
+	file_api_pipeline_v1_pipeline_proto_rawDescOnce.Do(func() {
+		file_api_pipeline_v1_pipeline_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_api_pipeline_v1_pipeline_proto_rawDesc), len(file_api_pipeline_v1_pipeline_proto_rawDesc)))
+	})
+	return file_api_pipeline_v1_pipeline_proto_rawDescData
+}
+
+var file_api_pipeline_v1_pipeline_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_api_pipeline_v1_pipeline_proto_goTypes = []any{
+	(*SubmitJobRequest)(nil),    // 0: godeogoker.pipeline.v1.SubmitJobRequest
+	(*SubmitJobResponse)(nil),   // 1: godeogoker.pipeline.v1.SubmitJobResponse
+	(*StreamEventsRequest)(nil), // 2: godeogoker.pipeline.v1.StreamEventsRequest
+	(*Event)(nil),               // 3: godeogoker.pipeline.v1.Event
+	(*GetResultsRequest)(nil),   // 4: godeogoker.pipeline.v1.GetResultsRequest
+	(*GetResultsResponse)(nil),  // 5: godeogoker.pipeline.v1.GetResultsResponse
+	(*Clip)(nil),                // 6: godeogoker.pipeline.v1.Clip
+}
+var file_api_pipeline_v1_pipeline_proto_depIdxs = []int32{
+	6, // 0: godeogoker.pipeline.v1.GetResultsResponse.clips:type_name -> godeogoker.pipeline.v1.Clip
+	0, // 1: godeogoker.pipeline.v1.Pipeline.SubmitJob:input_type -> godeogoker.pipeline.v1.SubmitJobRequest
+	2, // 2: godeogoker.pipeline.v1.Pipeline.StreamEvents:input_type -> godeogoker.pipeline.v1.StreamEventsRequest
+	4, // 3: godeogoker.pipeline.v1.Pipeline.GetResults:input_type -> godeogoker.pipeline.v1.GetResultsRequest
+	1, // 4: godeogoker.pipeline.v1.Pipeline.SubmitJob:output_type -> godeogoker.pipeline.v1.SubmitJobResponse
+	3, // 5: godeogoker.pipeline.v1.Pipeline.StreamEvents:output_type -> godeogoker.pipeline.v1.Event
+	5, // 6: godeogoker.pipeline.v1.Pipeline.GetResults:output_type -> godeogoker.pipeline.v1.GetResultsResponse
+	4, // [4:7] is the sub-list for method output_type
+	1, // [1:4] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_api_pipeline_v1_pipeline_proto_init() }
+func file_api_pipeline_v1_pipeline_proto_init() {
+	if File_api_pipeline_v1_pipeline_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_api_pipeline_v1_pipeline_proto_rawDesc), len(file_api_pipeline_v1_pipeline_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   7,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_api_pipeline_v1_pipeline_proto_goTypes,
+		DependencyIndexes: file_api_pipeline_v1_pipeline_proto_depIdxs,
+		MessageInfos:      file_api_pipeline_v1_pipeline_proto_msgTypes,
+	}.Build()
+	File_api_pipeline_v1_pipeline_proto = out.File
+	file_api_pipeline_v1_pipeline_proto_goTypes = nil
+	file_api_pipeline_v1_pipeline_proto_depIdxs = nil
+}
@@ -0,0 +1,883 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rogersilvasouza/godeogoker/internal/auth"
+	"github.com/rogersilvasouza/godeogoker/internal/cli"
+	"github.com/rogersilvasouza/godeogoker/internal/config"
+	"github.com/rogersilvasouza/godeogoker/internal/state"
+	"github.com/rogersilvasouza/godeogoker/internal/videos"
+)
+
+// newRootCommand returns the command tree of the CLI.
+func newRootCommand() *cli.Command {
+	root := &cli.Command{
+		Name: "godeogoker",
+		Note: "💡 Tip: Start with 'godeogoker login' to authenticate!",
+	}
+	flags := root.Flags()
+	flags.Func("profile", "Use profiles/`name`/ for config, credentials, token and state", config.UseProfile)
+	flags.Func("config", "Load the configuration from `path` instead of config.json, as YAML for .yaml and .yml or TOML for .toml", func(path string) error {
+		config.UseFile(path)
+		return nil
+	})
+	flags.BoolVar(&nonInteractive, "non-interactive", false, "Fail instead of prompting (automatic when stdin is not a terminal or CI is set)")
+
+	root.AddCommand(
+		loginCommand(),
+		execCommand(),
+		channelsCommand(),
+		configCommand(),
+		cutsCommand(),
+		promptsCommand(),
+		uploadCommand(),
+		reviewCommand(),
+		communityCommand(),
+		compileCommand(),
+		bestOfCommand(),
+		backfillCommand(),
+		exportCommand(),
+		digestCommand(),
+		workerCommand(),
+		daemonCommand(),
+		runJobCommand(),
+		serveCommand(),
+		cli.CompletionCommand(root),
+		helpCommand(root),
+	)
+	return root
+}
+
+// loginCommand returns the login command.
+func loginCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "login",
+		Short: "Authenticate with Google (you'll need this first!)",
+		Run: func(ctx context.Context, args []string) error {
+			fmt.Println(subtitleStyle.Render("🔑 Starting Google authentication process..."))
+			if err := auth.Login(ctx, loadConfig()); err != nil {
+				return fmt.Errorf("login error: %v", err)
+			}
+			fmt.Println(successStyle.Render("🎉 Login successful! You're ready to download videos!"))
+			return nil
+		},
+	}
+}
+
+// execOptions are the flags of the exec command.
+type execOptions struct {
+	stages      videos.StageOptions // Stages forced, repeated or left for the next run
+	from        string              // First stage repeated
+	until       string              // Last stage run
+	retryFailed bool                // Retry only the failed steps
+	changed     bool                // Regenerate only the outputs whose settings changed
+	enqueue     bool                // Queue the videos instead of processing them
+	dryRun      bool                // Print the plan of the run without processing anything
+	videoID     string              // Process this video instead of the latest ones
+	events      string              // Event target overriding the configured one
+	topics      string              // Topics overriding the channel topics
+	promptExtra string              // Instructions overriding the channel ones
+	parallel    int                 // Channels processed at once, 0 for parallel.channels
+}
+
+// execCommand returns the exec command.
+func execCommand() *cli.Command {
+	var options execOptions
+	cmd := &cli.Command{
+		Name:     "exec",
+		Args:     "[channelID]",
+		Short:    "Download videos and cut them into clips",
+		MaxArgs:  1,
+		Complete: channelIDs,
+	}
+	flags := cmd.Flags()
+	flags.Var((*forceValue)(&options.stages), "force", "Force reprocessing even if folder exists, or only the listed stages, e.g. --force=render,upload")
+	flags.StringVar(&options.from, "from", "", "Repeat the stages of processed videos from this `stage` on: "+strings.Join(videos.PipelineStages, ", "))
+	flags.StringVar(&options.until, "until", "", "Stop after this `stage`, leaving the later ones for the next run")
+	flags.StringVar(&options.videoID, "v", "", "Specific `videoID` for processing")
+	flags.StringVar(&options.topics, "topics", "", "Look for cuts about these `topics` instead of the channel topics, for this run only")
+	flags.StringVar(&options.promptExtra, "prompt-extra", "", "Add these `instructions` to the cut prompt, for this run only")
+	flags.StringVar(&options.events, "events", "", "Write lifecycle events as JSON lines to a file, tcp:// or unix:// `target`")
+	flags.BoolVar(&options.retryFailed, "retry-failed", false, "Retry only the failed steps recorded in previous runs")
+	flags.BoolVar(&options.changed, "changed", false, "Regenerate only the outputs of processed videos whose settings changed")
+	flags.BoolVar(&options.enqueue, "enqueue", false, "Send new videos to the distributed queue instead of processing them")
+	flags.BoolVar(&options.dryRun, "dry-run", false, "List the videos and stages a run would process and estimate its language model tokens, without downloading or calling anything")
+	flags.IntVar(&options.parallel, "parallel", 0, "Process up to `n` channels at once, defaults to parallel.channels or 1")
+
+	cmd.Run = func(ctx context.Context, args []string) error {
+		if options.parallel < 0 {
+			return fmt.Errorf("--parallel must be a positive number")
+		}
+		if options.dryRun && (options.retryFailed || options.changed || options.enqueue) {
+			return fmt.Errorf("--dry-run cannot be combined with --retry-failed, --changed or --enqueue")
+		}
+		if options.from != "" || options.until != "" {
+			if options.retryFailed || options.changed || options.enqueue {
+				return fmt.Errorf("--from and --until cannot be combined with --retry-failed, --changed or --enqueue")
+			}
+			if options.from != "" && (options.stages.Force || len(options.stages.Repeat) > 0) {
+				return fmt.Errorf("--from cannot be combined with --force")
+			}
+			repeat, err := videos.RepeatStages(options.from, options.until)
+			if err != nil {
+				return fmt.Errorf("--from/--until: %v", err)
+			}
+			if options.from != "" {
+				options.stages.Repeat = repeat
+			}
+			options.stages.Until = options.until
+		}
+		if !options.dryRun {
+			fmt.Println(subtitleStyle.Render("🚀 Preparing to download awesome content..."))
+		}
+		handleExec(ctx, options, firstArg(args))
+		return nil
+	}
+	return cmd
+}
+
+// channelsCommand returns the channels command.
+func channelsCommand() *cli.Command {
+	list := func(ctx context.Context, args []string) error {
+		for _, channel := range loadConfig().Channels {
+			description := fmt.Sprintf("%s (%s) in %s", channel.Name, channel.ChannelID, channel.Folder)
+			if channel.Disabled {
+				description += ", disabled"
+			}
+			fmt.Println(optionStyle.Render(channel.ID), descriptionStyle.Render(description))
+		}
+		return nil
+	}
+	toggle := func(name string, short string, enabled bool) *cli.Command {
+		return &cli.Command{
+			Name:     name,
+			Args:     "<channelID>",
+			Short:    short,
+			MinArgs:  1,
+			MaxArgs:  1,
+			Complete: channelIDs,
+			Run: func(ctx context.Context, args []string) error {
+				handleChannelsEnable(args[0], enabled)
+				return nil
+			},
+		}
+	}
+
+	cmd := &cli.Command{
+		Name:  "channels",
+		Short: "List, add and remove the configured channels",
+		Run:   list,
+	}
+	cmd.AddCommand(
+		&cli.Command{
+			Name:  "list",
+			Short: "List the ID, name, YouTube channel and folder of each channel",
+			Run:   list,
+		},
+		channelsAddCommand(),
+		&cli.Command{
+			Name:     "remove",
+			Args:     "<channelID>",
+			Short:    "Remove a channel from the configuration file, keeping its folder and state",
+			MinArgs:  1,
+			MaxArgs:  1,
+			Complete: channelIDs,
+			Run: func(ctx context.Context, args []string) error {
+				handleChannelsRemove(args[0])
+				return nil
+			},
+		},
+		toggle("enable", "Process a disabled channel again in exec, daemon and digest", true),
+		toggle("disable", "Skip a channel in exec, daemon and digest unless it is named", false),
+	)
+	return cmd
+}
+
+// channelsAddCommand returns the channels add command.
+func channelsAddCommand() *cli.Command {
+	var options channelsAddOptions
+	cmd := &cli.Command{
+		Name:  "add",
+		Short: "Look up a YouTube channel by URL or @handle and add it to the configuration file",
+	}
+	flags := cmd.Flags()
+	flags.StringVar(&options.url, "url", "", "Channel `URL`, @handle or channel ID")
+	flags.StringVar(&options.topics, "topics", "", "Topics the cuts of the channel are about")
+	flags.StringVar(&options.id, "id", "", "Channel `ID` used in the commands, defaults to the handle")
+	flags.StringVar(&options.name, "name", "", "Display `name`, defaults to the name of the YouTube channel")
+	flags.StringVar(&options.folder, "folder", "", "Output `folder`, defaults to the channel ID")
+	flags.StringVar(&options.like, "like", "", "Copy the settings of this configured `channelID`")
+
+	cmd.Run = func(ctx context.Context, args []string) error {
+		if options.url == "" {
+			return fmt.Errorf("--url is required")
+		}
+		handleChannelsAdd(ctx, options)
+		return nil
+	}
+	return cmd
+}
+
+// configCommand returns the config command.
+func configCommand() *cli.Command {
+	cmd := &cli.Command{
+		Name:  "config",
+		Short: "Inspect the configuration",
+	}
+	cmd.AddCommand(
+		&cli.Command{
+			Name:  "path",
+			Short: "Print the path of the configuration file in use",
+			Run: func(ctx context.Context, args []string) error {
+				fmt.Println(config.Path())
+				return nil
+			},
+		},
+		&cli.Command{
+			Name:  "check",
+			Short: "Load the configuration and report missing tools, bad channel IDs and font settings",
+			Run: func(ctx context.Context, args []string) error {
+				handleConfigCheck()
+				return nil
+			},
+		},
+		configShowCommand(),
+		configConvertCommand(),
+	)
+	return cmd
+}
+
+// configShowCommand returns the config show command.
+func configShowCommand() *cli.Command {
+	var format string
+	cmd := &cli.Command{
+		Name:  "show",
+		Short: "Print the configuration in use, environment overrides included, with its credentials redacted",
+		Run: func(ctx context.Context, args []string) error {
+			handleConfigShow(format)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&format, "format", config.FormatJSON, "Output `format`: json, yaml or toml")
+	return cmd
+}
+
+// configConvertCommand returns the config convert command.
+func configConvertCommand() *cli.Command {
+	var overwrite bool
+	cmd := &cli.Command{
+		Name:    "convert",
+		Args:    "<output>",
+		Short:   "Write the configuration in use to a JSON, YAML or TOML file, chosen by its extension",
+		MinArgs: 1,
+		MaxArgs: 1,
+	}
+	cmd.Flags().BoolVar(&overwrite, "overwrite", false, "Replace the output file when it exists")
+
+	cmd.Run = func(ctx context.Context, args []string) error {
+		handleConfigConvert(args[0], overwrite)
+		return nil
+	}
+	return cmd
+}
+
+// cutsCommand returns the cuts command.
+func cutsCommand() *cli.Command {
+	return &cli.Command{
+		Name:     "cuts",
+		Args:     "<channelID> [videoID]",
+		Short:    "List the cuts found in the processed videos of a channel",
+		MinArgs:  1,
+		MaxArgs:  2,
+		Complete: videoIDs,
+		Run: func(ctx context.Context, args []string) error {
+			channel, ok := findChannel(loadConfig(), args[0])
+			if !ok {
+				return fmt.Errorf("channel with ID '%s' not found", args[0])
+			}
+			handleCuts(channel, secondArg(args))
+			return nil
+		},
+	}
+}
+
+// promptsCommand returns the prompts command.
+func promptsCommand() *cli.Command {
+	var full bool
+	cmd := &cli.Command{
+		Name:     "prompts",
+		Args:     "<channelID> <videoID>",
+		Short:    "List the language model requests made for a video, to reproduce or audit its results",
+		MinArgs:  2,
+		MaxArgs:  2,
+		Complete: videoIDs,
+	}
+	cmd.Flags().BoolVar(&full, "full", false, "Print the whole system prompt, user prompt and response of each request")
+
+	cmd.Run = func(ctx context.Context, args []string) error {
+		cfg := loadConfig()
+		channel, ok := findChannel(cfg, args[0])
+		if !ok {
+			return fmt.Errorf("channel with ID '%s' not found", args[0])
+		}
+		handlePrompts(cfg, channel, args[1], full)
+		return nil
+	}
+	return cmd
+}
+
+// uploadCommand returns the upload command.
+func uploadCommand() *cli.Command {
+	var drain, applyEdits bool
+	var every time.Duration
+	var events string
+	cmd := &cli.Command{
+		Name:     "upload",
+		Args:     "[channelID]",
+		Short:    "Upload the clips waiting in the spool of failed uploads, or apply the edits of clips",
+		MaxArgs:  1,
+		Complete: channelIDs,
+	}
+	flags := cmd.Flags()
+	flags.BoolVar(&drain, "drain", false, "Upload the clips waiting in the spool")
+	flags.BoolVar(&applyEdits, "apply-edits", false, "Apply the .edit.json files of the clips to their metadata, covers and uploads")
+	flags.DurationVar(&every, "every", 0, "Keep draining at this `interval`, e.g. 30m, until the spool is empty")
+	flags.StringVar(&events, "events", "", "Write lifecycle events as JSON lines to a file, tcp:// or unix:// `target`")
+
+	cmd.Run = func(ctx context.Context, args []string) error {
+		if !drain && !applyEdits {
+			return fmt.Errorf("upload requires --drain or --apply-edits")
+		}
+		if every < 0 {
+			return fmt.Errorf("--every must be a positive duration such as 30m")
+		}
+		if every > 0 && !drain {
+			return fmt.Errorf("--every requires --drain")
+		}
+		handleUpload(ctx, firstArg(args), drain, applyEdits, every, events)
+		return nil
+	}
+	return cmd
+}
+
+// reviewCommand returns the review command.
+func reviewCommand() *cli.Command {
+	var approve, preview string
+	cmd := &cli.Command{
+		Name:     "review",
+		Args:     "[channelID]",
+		Short:    "List the clips held back by the quality gate, approve them or adjust their boundaries",
+		MaxArgs:  1,
+		Complete: channelIDs,
+	}
+	flags := cmd.Flags()
+	flags.StringVar(&approve, "approve", "", "Allow the clip `key`, or all held clips, to be uploaded by 'exec --retry-failed'")
+	flags.StringVar(&preview, "preview", "", "Show the waveform and transcript around the boundaries of the clip `key` and move them a second at a time")
+
+	cmd.Run = func(ctx context.Context, args []string) error {
+		handleReview(ctx, firstArg(args), approve, preview)
+		return nil
+	}
+	return cmd
+}
+
+// communityCommand returns the community command.
+func communityCommand() *cli.Command {
+	last := 7 * 24 * time.Hour
+	cmd := &cli.Command{
+		Name:     "community",
+		Args:     "[channelID]",
+		Short:    "List the Community post teasers written for the uploaded clips",
+		MaxArgs:  1,
+		Complete: channelIDs,
+	}
+	cmd.Flags().Var((*windowValue)(&last), "last", "List the posts written in this `period`, e.g. 30d or 72h")
+
+	cmd.Run = func(ctx context.Context, args []string) error {
+		handleCommunity(firstArg(args), last)
+		return nil
+	}
+	return cmd
+}
+
+// compileCommand returns the compile command.
+func compileCommand() *cli.Command {
+	options := videos.CompileOptions{Since: 7 * 24 * time.Hour, Fade: 1}
+	var clips string
+	cmd := &cli.Command{
+		Name:     "compile",
+		Args:     "<channelID>",
+		Short:    "Join clips into a compilation with chapter markers",
+		MinArgs:  1,
+		MaxArgs:  1,
+		Complete: channelIDs,
+	}
+	flags := cmd.Flags()
+	flags.StringVar(&clips, "clips", "", "Comma-separated `names` or keys of the clips to join, in order; defaults to the clips rendered in --since")
+	flags.DurationVar(&options.Since, "since", options.Since, "Join the clips rendered in this `period`")
+	flags.StringVar(&options.Transition, "transition", "", "ffmpeg xfade transition `name` such as fade or wipeleft, none for hard cuts; defaults to fade")
+	flags.Float64Var(&options.Fade, "fade", options.Fade, "Length of each transition in `seconds`")
+	flags.StringVar(&options.Title, "title", "", "Compilation `title`, defaults to Best of <channel name>")
+	flags.StringVar(&options.Output, "out", "", "Destination `file`, defaults to compilations/<date> inside the channel folder")
+	flags.BoolVar(&options.Upload, "upload", false, "Upload the compilation to YouTube as unlisted")
+
+	cmd.Run = func(ctx context.Context, args []string) error {
+		if options.Since <= 0 {
+			return fmt.Errorf("--since must be a positive duration such as 168h")
+		}
+		if options.Fade <= 0 {
+			return fmt.Errorf("--fade must be a positive number of seconds")
+		}
+		if clips != "" {
+			options.Clips = strings.Split(clips, ",")
+		}
+		handleCompile(ctx, args[0], options)
+		return nil
+	}
+	return cmd
+}
+
+// backfillCommand returns the backfill command.
+func backfillCommand() *cli.Command {
+	options := videos.BackfillOptions{Max: 500, Batch: 10, Pause: 5 * time.Minute}
+	var events string
+	cmd := &cli.Command{
+		Name:     "backfill",
+		Args:     "<channelID>",
+		Short:    "Process the whole upload history of a channel, oldest first",
+		MinArgs:  1,
+		MaxArgs:  1,
+		Complete: channelIDs,
+	}
+	flags := cmd.Flags()
+	flags.IntVar(&options.Max, "max", options.Max, "Process at most this `number` of videos in this run, 0 for no limit")
+	flags.IntVar(&options.Batch, "batch", options.Batch, "Pause after every `number` of videos processed")
+	flags.DurationVar(&options.Pause, "pause", options.Pause, "Wait this `period` between batches")
+	flags.BoolVar(&options.Restart, "restart", false, "Forget the checkpoint and start over from the oldest video")
+	flags.StringVar(&events, "events", "", "Write lifecycle events as JSON lines to a file, tcp:// or unix:// `target`")
+
+	cmd.Run = func(ctx context.Context, args []string) error {
+		if options.Max < 0 {
+			return fmt.Errorf("--max must be a positive number, or 0 for no limit")
+		}
+		if options.Batch <= 0 {
+			return fmt.Errorf("--batch must be a positive number")
+		}
+		if options.Pause < 0 {
+			return fmt.Errorf("--pause must be a positive duration such as 5m")
+		}
+		handleBackfill(ctx, args[0], options, events)
+		return nil
+	}
+	return cmd
+}
+
+// windowValue is a flag value holding a period such as 72h or 30d.
+type windowValue time.Duration
+
+// String returns the period in the form of time.Duration.
+func (w *windowValue) String() string {
+	return time.Duration(*w).String()
+}
+
+// Set parses a period with parseWindow.
+func (w *windowValue) Set(value string) error {
+	d, err := parseWindow(value)
+	if err != nil || d <= 0 {
+		return fmt.Errorf("must be a positive period such as 30d or 72h")
+	}
+	*w = windowValue(d)
+	return nil
+}
+
+// forceValue is the flag value of --force: alone it reprocesses everything,
+// with a comma-separated list of stages only those stages.
+type forceValue videos.StageOptions
+
+// String returns the repeated stages, or whether everything is forced.
+func (f *forceValue) String() string {
+	if len(f.Repeat) > 0 {
+		return strings.Join(f.Repeat, ",")
+	}
+	return strconv.FormatBool(f.Force)
+}
+
+// Set parses true, false or a list of stages with videos.ParseStages.
+func (f *forceValue) Set(value string) error {
+	if force, err := strconv.ParseBool(value); err == nil {
+		f.Force, f.Repeat = force, nil
+		return nil
+	}
+	stages, err := videos.ParseStages(value)
+	if err != nil {
+		return err
+	}
+	f.Force, f.Repeat = false, stages
+	return nil
+}
+
+// IsBoolFlag lets --force be given without a value.
+func (f *forceValue) IsBoolFlag() bool {
+	return true
+}
+
+// bestOfCommand returns the bestof command.
+func bestOfCommand() *cli.Command {
+	options := videos.BestOfOptions{Last: 7 * 24 * time.Hour, Top: 10}
+	var channelID string
+	cmd := &cli.Command{
+		Name:     "bestof",
+		Args:     "[channelID]",
+		Short:    "Rank the clips of a period and report the winners",
+		MaxArgs:  1,
+		Complete: channelIDs,
+	}
+	flags := cmd.Flags()
+	flags.StringVar(&channelID, "channel", "", "`channelID` of the clips ranked, instead of the argument")
+	flags.Var((*windowValue)(&options.Last), "last", "Rank the clips rendered in this `period`, e.g. 30d or 72h")
+	flags.IntVar(&options.Top, "top", options.Top, "Keep this `number` of winners")
+	flags.StringVar(&options.By, "by", "both", "Rank by the AI score of the cuts (ai), the YouTube views per day (views) or `both`")
+	flags.StringVar(&options.Report, "report", "", "Report `file`, defaults to compilations/bestof-<date>.csv inside the channel folder")
+	flags.BoolVar(&options.Compile, "compile", false, "Join the winners into a compilation")
+	flags.BoolVar(&options.Upload, "upload", false, "Upload the compilation to YouTube as unlisted, with --compile")
+
+	cmd.Run = func(ctx context.Context, args []string) error {
+		if channelID == "" {
+			channelID = firstArg(args)
+		}
+		if channelID == "" {
+			return fmt.Errorf("bestof requires --channel")
+		}
+		if options.Top <= 0 {
+			return fmt.Errorf("--top must be a positive number")
+		}
+		handleBestOf(ctx, channelID, options)
+		return nil
+	}
+	return cmd
+}
+
+// exportCommand returns the export command and its site and metadata subcommands.
+func exportCommand() *cli.Command {
+	cmd := &cli.Command{
+		Name:  "export",
+		Short: "Export the rendered clips as a gallery or a spreadsheet",
+	}
+
+	var siteOut string
+	site := &cli.Command{
+		Name:     "site",
+		Args:     "[channelID]",
+		Short:    "Export a static HTML gallery of the rendered clips",
+		MaxArgs:  1,
+		Complete: channelIDs,
+		Run: func(ctx context.Context, args []string) error {
+			handleExportSite(firstArg(args), siteOut)
+			return nil
+		},
+	}
+	site.Flags().StringVar(&siteOut, "out", "site", "Destination `folder`")
+
+	var format, metadataOut string
+	var stats bool
+	metadata := &cli.Command{
+		Name:     "metadata",
+		Args:     "[channelID]",
+		Short:    "Export the metadata of every clip as a spreadsheet",
+		MaxArgs:  1,
+		Complete: channelIDs,
+		Run: func(ctx context.Context, args []string) error {
+			if format != "csv" && format != "xlsx" {
+				return fmt.Errorf("--format must be csv or xlsx")
+			}
+			if metadataOut == "" {
+				metadataOut = "metadata." + format
+			}
+			handleExportMetadata(ctx, firstArg(args), format, metadataOut, stats)
+			return nil
+		},
+	}
+	metadata.Flags().StringVar(&format, "format", "csv", "Spreadsheet `format`, csv or xlsx")
+	metadata.Flags().StringVar(&metadataOut, "out", "", "Destination `file`, defaults to metadata.csv or metadata.xlsx")
+	metadata.Flags().BoolVar(&stats, "stats", false, "Also read the views, likes and comments of uploaded clips from YouTube")
+
+	cmd.AddCommand(site, metadata)
+	return cmd
+}
+
+// digestCommand returns the digest command.
+func digestCommand() *cli.Command {
+	var period time.Duration
+	var dryRun bool
+	cmd := &cli.Command{
+		Name:     "digest",
+		Args:     "[channelID]",
+		Short:    "Email the digest of what happened since the last digest",
+		MaxArgs:  1,
+		Complete: channelIDs,
+	}
+	cmd.Flags().DurationVar(&period, "since", 0, "Cover this `period` instead, e.g. 24h or 168h")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Print the digests instead of sending them")
+
+	cmd.Run = func(ctx context.Context, args []string) error {
+		if period < 0 {
+			return fmt.Errorf("--since must be a positive duration such as 24h")
+		}
+		handleDigest(ctx, firstArg(args), period, dryRun)
+		return nil
+	}
+	return cmd
+}
+
+// workerCommand returns the worker command.
+func workerCommand() *cli.Command {
+	var events string
+	cmd := &cli.Command{
+		Name:  "worker",
+		Short: "Process videos claimed from the distributed queue",
+		Run: func(ctx context.Context, args []string) error {
+			handleWorker(ctx, events)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&events, "events", "", "Write lifecycle events as JSON lines to a file, tcp:// or unix:// `target`")
+	return cmd
+}
+
+// daemonCommand returns the daemon command.
+func daemonCommand() *cli.Command {
+	var events string
+	cmd := &cli.Command{
+		Name:     "daemon",
+		Args:     "[channelID]",
+		Short:    "Poll the feed of each channel on its schedule and process new videos",
+		MaxArgs:  1,
+		Complete: channelIDs,
+		Run: func(ctx context.Context, args []string) error {
+			handleDaemon(ctx, firstArg(args), events)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&events, "events", "", "Write lifecycle events as JSON lines to a file, tcp:// or unix:// `target`")
+	return cmd
+}
+
+// runJobOptions are the flags of the run-job command.
+type runJobOptions struct {
+	channel    string              // Channel ID from the configuration
+	videoID    string              // Video to process
+	stages     videos.StageOptions // Stages forced or repeated
+	resultFile string              // File the JSON result is also written to
+	events     string              // Event target overriding the configured one
+}
+
+// runJobCommand returns the run-job command.
+func runJobCommand() *cli.Command {
+	var options runJobOptions
+	cmd := &cli.Command{
+		Name:  "run-job",
+		Short: "Process one video and print a JSON result, for container jobs",
+		Note:  "Exit codes: 0 completed, 1 setup error, 2 invalid arguments, 3 failed steps, 4 spending limit, 130 interrupted",
+		Run: func(ctx context.Context, args []string) error {
+			handleRunJob(ctx, options)
+			return nil
+		},
+	}
+	flags := cmd.Flags()
+	flags.StringVar(&options.channel, "channel", os.Getenv("GODEOGOKER_CHANNEL"), "`channelID` of the video, defaults to GODEOGOKER_CHANNEL")
+	flags.StringVar(&options.videoID, "video-id", os.Getenv("GODEOGOKER_VIDEO_ID"), "`videoID` to process, defaults to GODEOGOKER_VIDEO_ID")
+	flags.Var((*forceValue)(&options.stages), "force", "Reprocess the video even if its folder exists, or only the listed stages, e.g. --force=render,upload")
+	flags.StringVar(&options.resultFile, "result", "", "Also write the JSON result to `path`, e.g. /dev/termination-log")
+	flags.StringVar(&options.events, "events", "", "Write lifecycle events as JSON lines to a file, tcp:// or unix:// `target`")
+	return cmd
+}
+
+// serveCommand returns the serve command.
+func serveCommand() *cli.Command {
+	var addr, grpcAddr, events string
+	cmd := &cli.Command{
+		Name:  "serve",
+		Short: "Run the webhook server processing videos on demand",
+		Run: func(ctx context.Context, args []string) error {
+			handleServe(ctx, addr, grpcAddr, events)
+			return nil
+		},
+	}
+	flags := cmd.Flags()
+	flags.StringVar(&addr, "addr", "", "Listen `address`, defaults to server.addr or :8080")
+	flags.StringVar(&grpcAddr, "grpc-addr", "", "Also serve the gRPC API on this `address`, defaults to server.grpc_addr")
+	flags.StringVar(&events, "events", "", "Write lifecycle events as JSON lines to a file, tcp:// or unix:// `target`")
+	return cmd
+}
+
+// helpCommand returns the help command, showing the extended help with
+// examples, or the help of a command.
+func helpCommand(root *cli.Command) *cli.Command {
+	return &cli.Command{
+		Name:    "help",
+		Args:    "[command]",
+		Short:   "Show extended help with examples, or the help of a command",
+		MaxArgs: -1,
+		Run: func(ctx context.Context, args []string) error {
+			if len(args) == 0 {
+				printExtendedHelp()
+				return nil
+			}
+			command := root.Lookup(args)
+			if command == nil {
+				return fmt.Errorf("unknown command: %s", strings.Join(args, " "))
+			}
+			command.PrintHelp()
+			return nil
+		},
+	}
+}
+
+// videoIDs completes the channel ID and then the IDs of the videos of that
+// channel with cuts on disk.
+func videoIDs(args []string) []string {
+	if len(args) == 0 {
+		return channelIDs(nil)
+	}
+	if len(args) > 1 {
+		return nil
+	}
+	channel, ok := findChannel(loadConfig(), args[0])
+	if !ok {
+		return nil
+	}
+	cuts, _ := videos.CachedCuts(channel.Folder)
+	var ids []string
+	for id := range cuts {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// channelIDs completes the IDs of the configured channels. It completes
+// nothing when there is no configuration file to read them from.
+func channelIDs([]string) []string {
+	if _, err := os.Stat(config.Path()); err != nil {
+		return nil
+	}
+	var ids []string
+	for _, channel := range loadConfig().Channels {
+		ids = append(ids, channel.ID)
+	}
+	return ids
+}
+
+// firstArg returns the first positional argument, empty when there is none.
+func firstArg(args []string) string {
+	if len(args) == 0 {
+		return ""
+	}
+	return args[0]
+}
+
+// secondArg returns the second positional argument, empty when there is none.
+func secondArg(args []string) string {
+	if len(args) < 2 {
+		return ""
+	}
+	return args[1]
+}
+
+// handleCuts prints the cuts found in the processed videos of channel, or
+// in the video videoID only.
+func handleCuts(channel config.Channel, videoID string) {
+	cuts, err := videos.CachedCuts(channel.Folder)
+	if err != nil {
+		fmt.Println(errorStyle.Render(fmt.Sprintf("Error: %v", err)))
+		os.Exit(1)
+	}
+
+	var ids []string
+	for id := range cuts {
+		if videoID == "" || id == videoID {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	if len(ids) == 0 {
+		fmt.Println(subtitleStyle.Render("No cuts found in " + filepath.Join(channel.Folder, videoID)))
+		return
+	}
+
+	for _, id := range ids {
+		fmt.Println(commandStyle.Render(id))
+		for _, cut := range cuts[id] {
+			line := fmt.Sprintf("  %s-%s  %s", clock(cut.Begin), clock(cut.End), cut.Title)
+			if cut.Score > 0 {
+				fmt.Println(optionStyle.Render(line), descriptionStyle.Render(fmt.Sprintf("(score %g)", cut.Score)))
+			} else {
+				fmt.Println(optionStyle.Render(line))
+			}
+		}
+	}
+}
+
+// handlePrompts prints the language model requests recorded for videoID of
+// channel with their model and parameters, and with full the prompts and
+// responses themselves.
+func handlePrompts(cfg *config.Config, channel config.Channel, videoID string, full bool) {
+	store, err := state.Open(cfg.StateDir())
+	if err != nil {
+		fmt.Println(errorStyle.Render(fmt.Sprintf("Error: %v", err)))
+		os.Exit(1)
+	}
+
+	prompts, err := videos.LoadPrompts(store.PromptLog(channel.ID, videoID))
+	if err != nil {
+		fmt.Println(errorStyle.Render(fmt.Sprintf("Error: %v", err)))
+		os.Exit(1)
+	}
+	if len(prompts) == 0 {
+		fmt.Println(subtitleStyle.Render("No prompts recorded for video " + videoID))
+		return
+	}
+
+	for _, prompt := range prompts {
+		title := prompt.Purpose
+		if prompt.Cut != "" {
+			title += ": " + prompt.Cut
+		}
+		fmt.Println(commandStyle.Render(title))
+
+		var parameters []string
+		for name, value := range prompt.Parameters {
+			parameters = append(parameters, name+"="+value)
+		}
+		sort.Strings(parameters)
+		fmt.Println(optionStyle.Render("  Time:"), descriptionStyle.Render(prompt.Time.Local().Format("2006-01-02 15:04:05")))
+		fmt.Println(optionStyle.Render("  Model:"), descriptionStyle.Render(prompt.Model))
+		if len(parameters) > 0 {
+			fmt.Println(optionStyle.Render("  Parameters:"), descriptionStyle.Render(strings.Join(parameters, ", ")))
+		}
+
+		if !full {
+			fmt.Println(optionStyle.Render("  Prompts:"), descriptionStyle.Render(fmt.Sprintf("%d characters of system prompt, %d of user prompt", len(prompt.System), len(prompt.User))))
+			continue
+		}
+		fmt.Println(optionStyle.Render("  System prompt:"))
+		fmt.Println(descriptionStyle.Render(prompt.System))
+		fmt.Println(optionStyle.Render("  User prompt:"))
+		fmt.Println(descriptionStyle.Render(prompt.User))
+		fmt.Println(optionStyle.Render("  Response:"))
+		fmt.Println(descriptionStyle.Render(prompt.Response))
+	}
+}
+
+// clock formats seconds as h:mm:ss.
+func clock(seconds int) string {
+	return fmt.Sprintf("%d:%02d:%02d", seconds/3600, seconds%3600/60, seconds%60)
+}
@@ -0,0 +1,179 @@
+// Package ai sends the prompts of the pipeline to a language model provider:
+// OpenAI, Anthropic, Google Gemini or an OpenAI-compatible local server such
+// as Ollama or LM Studio. Every provider is asked for a single JSON object
+// and returns the reply with the tokens it was billed for.
+package ai
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rogersilvasouza/godeogoker/internal/config"
+)
+
+// Default base URLs of the providers.
+const (
+	DefaultOpenAIURL    = "https://api.openai.com/v1"
+	DefaultAnthropicURL = "https://api.anthropic.com/v1"
+	DefaultGeminiURL    = "https://generativelanguage.googleapis.com/v1beta"
+)
+
+// DefaultMaxTokens is the reply length limit of the providers requiring one.
+const DefaultMaxTokens = 4096
+
+// anthropicVersion is the version of the Anthropic API the requests follow.
+const anthropicVersion = "2023-06-01"
+
+// Doer sends HTTP requests. *http.Client satisfies it, and tests can provide
+// a fake returning canned responses.
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// Usage is the token count reported by a provider for a single request.
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+}
+
+// maxErrorBody is the part of the body of a failed response kept in its
+// StatusError.
+const maxErrorBody = 500
+
+// StatusError is a response of a provider with a status other than 200 OK.
+type StatusError struct {
+	StatusCode int           // HTTP status code
+	Body       string        // Start of the response body, which tells why the request failed
+	RetryAfter time.Duration // Wait asked for by the Retry-After header, 0 without one
+}
+
+// newStatusError returns the StatusError of res, whose body was read into body.
+func newStatusError(res *http.Response, body []byte) *StatusError {
+	text := strings.Join(strings.Fields(string(body)), " ")
+	if runes := []rune(text); len(runes) > maxErrorBody {
+		text = string(runes[:maxErrorBody]) + "..."
+	}
+	return &StatusError{StatusCode: res.StatusCode, Body: text, RetryAfter: retryAfter(res.Header.Get("Retry-After"))}
+}
+
+// Error returns the status code with the body.
+func (e *StatusError) Error() string {
+	if e.Body == "" {
+		return fmt.Sprintf("status code %d", e.StatusCode)
+	}
+	return fmt.Sprintf("status code %d: %s", e.StatusCode, e.Body)
+}
+
+// RateLimited reports whether the provider refused the request for being
+// over its rate limits or the quota of the account.
+func (e *StatusError) RateLimited() bool {
+	return e.StatusCode == http.StatusTooManyRequests
+}
+
+// QuotaExhausted reports whether the account has run out of credit, which
+// OpenAI also answers with 429 but does not go away by waiting.
+func (e *StatusError) QuotaExhausted() bool {
+	return e.RateLimited() && strings.Contains(e.Body, "insufficient_quota")
+}
+
+// Unauthorized reports whether the provider rejected the key.
+func (e *StatusError) Unauthorized() bool {
+	return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+}
+
+// Temporary reports whether the same request may succeed later: rate limits
+// and server errors, not the requests the provider rejected.
+func (e *StatusError) Temporary() bool {
+	return e.RateLimited() && !e.QuotaExhausted() || e.StatusCode == http.StatusRequestTimeout || e.StatusCode >= 500
+}
+
+// retryAfter parses a Retry-After header, given in seconds or as a date.
+func retryAfter(header string) time.Duration {
+	if header == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(header); err == nil {
+		return max(0, time.Duration(seconds)*time.Second)
+	}
+	if date, err := http.ParseTime(header); err == nil {
+		return max(0, time.Until(date))
+	}
+	return 0
+}
+
+// Provider sends chat requests to a language model. Implementations must
+// honor ctx.
+type Provider interface {
+	// Complete sends the system and user prompts and returns the JSON object
+	// of the reply with the tokens the request was billed for.
+	Complete(ctx context.Context, system string, user string) (string, Usage, error)
+	// Model returns the name of the model the requests are sent to.
+	Model() string
+	// Parameters returns the settings of the requests besides the prompts
+	// and the model, recorded with them.
+	Parameters() map[string]string
+}
+
+// New returns the Provider configured by settings, sending its requests
+// through doer. Supported values for settings.Provider are "openai",
+// "anthropic", "gemini" and "local", an OpenAI-compatible server whose url
+// must be set.
+func New(settings config.AI, doer Doer) (Provider, error) {
+	if settings.Model == "" {
+		return nil, fmt.Errorf("model is required")
+	}
+	base := strings.TrimSuffix(settings.URL, "/")
+
+	switch settings.Provider {
+	case "openai":
+		if base == "" {
+			base = DefaultOpenAIURL
+		}
+		if settings.Key == "" {
+			return nil, fmt.Errorf("provider 'openai' requires a key")
+		}
+		return &OpenAI{HTTP: doer, URL: base + "/chat/completions", Key: settings.Key, Name: settings.Model, JSONMode: true}, nil
+	case "local":
+		if base == "" {
+			return nil, fmt.Errorf("provider 'local' requires url, e.g. http://localhost:11434/v1")
+		}
+		return &OpenAI{HTTP: doer, URL: base + "/chat/completions", Key: settings.Key, Name: settings.Model}, nil
+	case "anthropic":
+		if base == "" {
+			base = DefaultAnthropicURL
+		}
+		if settings.Key == "" {
+			return nil, fmt.Errorf("provider 'anthropic' requires a key")
+		}
+		return &Anthropic{HTTP: doer, URL: base + "/messages", Key: settings.Key, Name: settings.Model}, nil
+	case "gemini":
+		if base == "" {
+			base = DefaultGeminiURL
+		}
+		if settings.Key == "" {
+			return nil, fmt.Errorf("provider 'gemini' requires a key")
+		}
+		return &Gemini{HTTP: doer, URL: base, Key: settings.Key, Name: settings.Model}, nil
+	default:
+		return nil, fmt.Errorf("unknown provider: %s", settings.Provider)
+	}
+}
+
+// jsonInstruction is added to the system prompt of the providers without a
+// JSON mode.
+const jsonInstruction = "\n\nReply with a single JSON object and nothing else."
+
+// jsonObject returns the JSON object of a reply, dropping the Markdown code
+// fence or the text models without a JSON mode sometimes wrap it in.
+func jsonObject(content string) string {
+	start := strings.Index(content, "{")
+	end := strings.LastIndex(content, "}")
+	if start == -1 || end < start {
+		return content
+	}
+	return content[start : end+1]
+}
@@ -0,0 +1,103 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Anthropic sends requests to the Anthropic messages API.
+type Anthropic struct {
+	HTTP Doer   // Transport of the requests
+	URL  string // Messages endpoint
+	Key  string // API key
+	Name string // Model name
+}
+
+// anthropicResponse is the subset of the messages response used.
+type anthropicResponse struct {
+	Content []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+	Usage struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
+}
+
+// Model returns the model name.
+func (a *Anthropic) Model() string {
+	return a.Name
+}
+
+// Parameters returns the reply length limit.
+func (a *Anthropic) Parameters() map[string]string {
+	return map[string]string{"max_tokens": strconv.Itoa(DefaultMaxTokens)}
+}
+
+// Complete sends a single messages request.
+func (a *Anthropic) Complete(ctx context.Context, system string, user string) (string, Usage, error) {
+	requestBody := map[string]interface{}{
+		"model":      a.Name,
+		"max_tokens": DefaultMaxTokens,
+		"system":     system + jsonInstruction,
+		"messages": []map[string]string{
+			{
+				"role":    "user",
+				"content": user,
+			},
+		},
+	}
+
+	body, err := json.Marshal(requestBody)
+	if err != nil {
+		return "", Usage{}, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.URL, bytes.NewReader(body))
+	if err != nil {
+		return "", Usage{}, err
+	}
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("x-api-key", a.Key)
+	req.Header.Add("anthropic-version", anthropicVersion)
+
+	res, err := a.HTTP.Do(req)
+	if err != nil {
+		return "", Usage{}, err
+	}
+	defer res.Body.Close()
+
+	respBody, err := io.ReadAll(res.Body)
+	if err != nil {
+		return "", Usage{}, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return "", Usage{}, newStatusError(res, respBody)
+	}
+
+	var apiResponse anthropicResponse
+	if err := json.Unmarshal(respBody, &apiResponse); err != nil {
+		return "", Usage{}, err
+	}
+	usage := Usage{PromptTokens: apiResponse.Usage.InputTokens, CompletionTokens: apiResponse.Usage.OutputTokens}
+
+	var text strings.Builder
+	for _, block := range apiResponse.Content {
+		if block.Type == "text" {
+			text.WriteString(block.Text)
+		}
+	}
+	if text.Len() == 0 {
+		return "", usage, fmt.Errorf("response has no text")
+	}
+
+	return jsonObject(text.String()), usage, nil
+}
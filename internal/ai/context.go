@@ -0,0 +1,47 @@
+package ai
+
+import "strings"
+
+// DefaultContextWindow is the context window, in tokens, assumed for models
+// of unknown size, such as most local models.
+const DefaultContextWindow = 8192
+
+// contextWindows are the context windows, in tokens, of known model
+// families, keyed by model name prefix. Longer prefixes are listed first.
+var contextWindows = []struct {
+	prefix string
+	tokens int
+}{
+	{"gpt-5", 400000},
+	{"gpt-4.1", 1047576},
+	{"gpt-4o", 128000},
+	{"gpt-4-turbo", 128000},
+	{"gpt-4", 8192},
+	{"gpt-3.5-turbo", 16385},
+	{"o1-mini", 128000},
+	{"o1", 200000},
+	{"o3", 200000},
+	{"o4-mini", 200000},
+	{"claude", 200000},
+	{"gemini-1.5-pro", 2097152},
+	{"gemini", 1048576},
+}
+
+// ContextWindow returns the context window, in tokens, of the model name,
+// or DefaultContextWindow when the model is unknown.
+func ContextWindow(name string) int {
+	name = strings.ToLower(name)
+	for _, window := range contextWindows {
+		if strings.HasPrefix(name, window.prefix) {
+			return window.tokens
+		}
+	}
+	return DefaultContextWindow
+}
+
+// EstimateTokens returns a conservative estimate of the tokens of text, at
+// three bytes per token. Captions tokenize worse than prose, since their
+// timestamps and accented words take more tokens per character.
+func EstimateTokens(text string) int {
+	return (len(text) + 2) / 3
+}
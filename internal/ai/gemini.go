@@ -0,0 +1,105 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Gemini sends requests to the Google Gemini generateContent API.
+type Gemini struct {
+	HTTP Doer   // Transport of the requests
+	URL  string // Base URL of the API, the model path is added to it
+	Key  string // API key
+	Name string // Model name
+}
+
+// geminiResponse is the subset of the generateContent response used.
+type geminiResponse struct {
+	Candidates []struct {
+		Content struct {
+			Parts []struct {
+				Text string `json:"text"`
+			} `json:"parts"`
+		} `json:"content"`
+	} `json:"candidates"`
+	UsageMetadata struct {
+		PromptTokenCount     int `json:"promptTokenCount"`
+		CandidatesTokenCount int `json:"candidatesTokenCount"`
+	} `json:"usageMetadata"`
+}
+
+// Model returns the model name.
+func (g *Gemini) Model() string {
+	return g.Name
+}
+
+// Parameters returns the response format asked for.
+func (g *Gemini) Parameters() map[string]string {
+	return map[string]string{"response_mime_type": "application/json"}
+}
+
+// Complete sends a single generateContent request.
+func (g *Gemini) Complete(ctx context.Context, system string, user string) (string, Usage, error) {
+	text := func(s string) map[string]interface{} {
+		return map[string]interface{}{"parts": []map[string]string{{"text": s}}}
+	}
+	userContent := text(user)
+	userContent["role"] = "user"
+	requestBody := map[string]interface{}{
+		"systemInstruction": text(system),
+		"contents":          []map[string]interface{}{userContent},
+		"generationConfig": map[string]string{
+			"responseMimeType": "application/json",
+		},
+	}
+
+	body, err := json.Marshal(requestBody)
+	if err != nil {
+		return "", Usage{}, err
+	}
+
+	endpoint := g.URL + "/models/" + url.PathEscape(g.Name) + ":generateContent"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return "", Usage{}, err
+	}
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("x-goog-api-key", g.Key)
+
+	res, err := g.HTTP.Do(req)
+	if err != nil {
+		return "", Usage{}, err
+	}
+	defer res.Body.Close()
+
+	respBody, err := io.ReadAll(res.Body)
+	if err != nil {
+		return "", Usage{}, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return "", Usage{}, newStatusError(res, respBody)
+	}
+
+	var apiResponse geminiResponse
+	if err := json.Unmarshal(respBody, &apiResponse); err != nil {
+		return "", Usage{}, err
+	}
+	usage := Usage{PromptTokens: apiResponse.UsageMetadata.PromptTokenCount, CompletionTokens: apiResponse.UsageMetadata.CandidatesTokenCount}
+
+	if len(apiResponse.Candidates) == 0 {
+		return "", usage, fmt.Errorf("response has no candidates")
+	}
+	var reply strings.Builder
+	for _, part := range apiResponse.Candidates[0].Content.Parts {
+		reply.WriteString(part.Text)
+	}
+
+	return jsonObject(reply.String()), usage, nil
+}
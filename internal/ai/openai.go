@@ -0,0 +1,110 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// OpenAI sends requests to the OpenAI chat completions API, or to a local
+// server implementing it such as Ollama or LM Studio.
+type OpenAI struct {
+	HTTP     Doer   // Transport of the requests
+	URL      string // Chat completions endpoint
+	Key      string // API key, empty for servers without authentication
+	Name     string // Model name
+	JSONMode bool   // Ask for a JSON object through response_format, which local servers may reject
+}
+
+// openAIResponse is the subset of the chat completions response used.
+type openAIResponse struct {
+	Choices []struct {
+		Message struct {
+			Content string `json:"content"`
+		} `json:"message"`
+	} `json:"choices"`
+	Usage Usage `json:"usage"`
+}
+
+// Model returns the model name.
+func (o *OpenAI) Model() string {
+	return o.Name
+}
+
+// Parameters returns the response format asked for, if any.
+func (o *OpenAI) Parameters() map[string]string {
+	if !o.JSONMode {
+		return map[string]string{}
+	}
+	return map[string]string{"response_format": "json_object"}
+}
+
+// Complete sends a single chat completions request.
+func (o *OpenAI) Complete(ctx context.Context, system string, user string) (string, Usage, error) {
+	if !o.JSONMode {
+		system += jsonInstruction
+	}
+	requestBody := map[string]interface{}{
+		"model": o.Name,
+		"messages": []map[string]string{
+			{
+				"role":    "system",
+				"content": system,
+			},
+			{
+				"role":    "user",
+				"content": user,
+			},
+		},
+	}
+	if o.JSONMode {
+		requestBody["response_format"] = map[string]string{"type": "json_object"}
+	}
+
+	body, err := json.Marshal(requestBody)
+	if err != nil {
+		return "", Usage{}, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.URL, bytes.NewReader(body))
+	if err != nil {
+		return "", Usage{}, err
+	}
+	req.Header.Add("Content-Type", "application/json")
+	if o.Key != "" {
+		req.Header.Add("Authorization", "Bearer "+o.Key)
+	}
+
+	res, err := o.HTTP.Do(req)
+	if err != nil {
+		return "", Usage{}, err
+	}
+	defer res.Body.Close()
+
+	respBody, err := io.ReadAll(res.Body)
+	if err != nil {
+		return "", Usage{}, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return "", Usage{}, newStatusError(res, respBody)
+	}
+
+	var apiResponse openAIResponse
+	if err := json.Unmarshal(respBody, &apiResponse); err != nil {
+		return "", Usage{}, err
+	}
+
+	if len(apiResponse.Choices) == 0 {
+		return "", apiResponse.Usage, fmt.Errorf("response has no choices")
+	}
+
+	content := apiResponse.Choices[0].Message.Content
+	if !o.JSONMode {
+		content = jsonObject(content)
+	}
+	return content, apiResponse.Usage, nil
+}
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/rogersilvasouza/godeogoker/internal/config"
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
 	"google.golang.org/api/youtube/v3"
@@ -26,15 +27,16 @@ type ClientConfig struct {
 	} `json:"installed"`
 }
 
-// getTokenPath returns the file path where OAuth tokens are stored.
-func getTokenPath() string {
-	return "youtube-token.json"
-}
-
 // Login initiates the OAuth2 authentication flow for YouTube API access.
 // It prompts the user to authorize access in a browser and captures the authorization code.
-func Login() error {
-	config, err := loadClientConfig()
+// The token exchange is bound to ctx so it can be cancelled. In non-interactive
+// mode it fails immediately, since nobody can paste the code.
+func Login(ctx context.Context, cfg *config.Config) error {
+	if cfg.NonInteractive {
+		return fmt.Errorf("login requires an interactive terminal. Run 'godeogoker login' on a workstation and provide the resulting token file at %s (configurable with the 'token' setting)", cfg.TokenPath())
+	}
+
+	config, err := loadClientConfig(cfg.CredentialsPath())
 	if err != nil {
 		return err
 	}
@@ -59,19 +61,18 @@ func Login() error {
 		return fmt.Errorf("unable to read authorization code: %v", err)
 	}
 
-	token, err := oauthConfig.Exchange(context.Background(), code)
+	token, err := oauthConfig.Exchange(ctx, code)
 	if err != nil {
 		return fmt.Errorf("unable to exchange code for token: %v", err)
 	}
 
-	return saveToken(token)
+	return saveToken(cfg.TokenPath(), token)
 }
 
 // loadClientConfig reads and parses the OAuth client configuration file.
 // Returns the parsed client configuration or an error if the file cannot be read or parsed.
-func loadClientConfig() (*ClientConfig, error) {
+func loadClientConfig(configFile string) (*ClientConfig, error) {
 	config := &ClientConfig{}
-	configFile := "credentials.json"
 
 	data, err := os.ReadFile(configFile)
 	if err != nil {
@@ -86,9 +87,8 @@ func loadClientConfig() (*ClientConfig, error) {
 }
 
 // saveToken persists an OAuth token to the filesystem for future use.
-// The token is stored in the file specified by getTokenPath().
-func saveToken(token *oauth2.Token) error {
-	tokenPath := getTokenPath()
+// The token is stored in the file at tokenPath.
+func saveToken(tokenPath string, token *oauth2.Token) error {
 	f, err := os.OpenFile(tokenPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
 	if err != nil {
 		return fmt.Errorf("unable to create token file: %v", err)
@@ -98,10 +98,10 @@ func saveToken(token *oauth2.Token) error {
 	return json.NewEncoder(f).Encode(token)
 }
 
-// GetClient retrieves the stored OAuth token.
+// GetClient retrieves the OAuth token stored at the configured token path.
 // Returns an error if the token doesn't exist or can't be parsed.
-func GetClient() (*oauth2.Token, error) {
-	tokenPath := getTokenPath()
+func GetClient(cfg *config.Config) (*oauth2.Token, error) {
+	tokenPath := cfg.TokenPath()
 	data, err := os.ReadFile(tokenPath)
 	if err != nil {
 		return nil, fmt.Errorf("token not found. Run 'godeogoker login' first: %v", err)
@@ -0,0 +1,339 @@
+// Package cli parses the command line into a tree of subcommands, each with
+// its own typed flags, help and shell completion. Flags are declared with the
+// standard flag package and accepted as -name or --name, with their value
+// after = or as the next argument, before or after the positional arguments.
+// Everything after -- is a positional argument, even when it starts with a
+// dash. Unknown flags and extra arguments are errors instead of being ignored.
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Styles of the help, matching the rest of the CLI.
+var (
+	commandStyle = lipgloss.NewStyle().
+			Bold(true).
+			Foreground(lipgloss.Color("#5FFFAF"))
+
+	optionStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#FFFF87"))
+
+	descriptionStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("#D7D7D7"))
+
+	subtitleStyle = lipgloss.NewStyle().
+			Italic(true).
+			Foreground(lipgloss.Color("#5F87FF"))
+
+	errorStyle = lipgloss.NewStyle().
+			Bold(true).
+			Foreground(lipgloss.Color("#FF0000"))
+)
+
+// ErrUsage is returned by Execute for a command line that does not parse,
+// once the error and the usage of the command were printed.
+var ErrUsage = errors.New("invalid usage")
+
+// Command is a node of the command tree. The flags of the root command are
+// global: every command accepts them, before or after its name.
+type Command struct {
+	Name     string                                         // Word selecting the command
+	Args     string                                         // Positional arguments shown in the usage, e.g. [channelID]
+	Short    string                                         // One-line description
+	Note     string                                         // Printed at the end of the help
+	MinArgs  int                                            // Positional arguments required
+	MaxArgs  int                                            // Positional arguments accepted, -1 for any number
+	Hidden   bool                                           // Left out of the help and of completion
+	Run      func(ctx context.Context, args []string) error // Runs the command with its positional arguments
+	Complete func(args []string) []string                   // Candidates for the positional argument after args
+
+	parent   *Command
+	commands []*Command
+	flags    *flag.FlagSet
+	globals  map[string]bool // Global flags added to flags, nil until they are
+}
+
+// Flags returns the flag set of the command, to declare its flags on.
+func (c *Command) Flags() *flag.FlagSet {
+	if c.flags == nil {
+		c.flags = flag.NewFlagSet(c.Name, flag.ContinueOnError)
+		c.flags.SetOutput(io.Discard)
+		c.flags.Usage = func() {}
+	}
+	return c.flags
+}
+
+// AddCommand adds subcommands to the command.
+func (c *Command) AddCommand(commands ...*Command) {
+	for _, command := range commands {
+		command.parent = c
+		c.commands = append(c.commands, command)
+	}
+}
+
+// Path returns the words selecting the command, starting with the program name.
+func (c *Command) Path() string {
+	if c.parent == nil {
+		return c.Name
+	}
+	return c.parent.Path() + " " + c.Name
+}
+
+// root returns the root of the tree of the command.
+func (c *Command) root() *Command {
+	for c.parent != nil {
+		c = c.parent
+	}
+	return c
+}
+
+// command returns the subcommand named name, nil when there is none.
+func (c *Command) command(name string) *Command {
+	for _, command := range c.commands {
+		if command.Name == name {
+			return command
+		}
+	}
+	return nil
+}
+
+// Lookup returns the command selected by the words of path below c, nil when
+// one of them is not a command.
+func (c *Command) Lookup(path []string) *Command {
+	for _, name := range path {
+		if c = c.command(name); c == nil {
+			return nil
+		}
+	}
+	return c
+}
+
+// flagSet returns the flags of the command with the global flags added.
+func (c *Command) flagSet() *flag.FlagSet {
+	flags := c.Flags()
+	if root := c.root(); root != c && c.globals == nil {
+		c.globals = make(map[string]bool)
+		root.Flags().VisitAll(func(f *flag.Flag) {
+			if flags.Lookup(f.Name) == nil {
+				flags.Var(f.Value, f.Name, f.Usage)
+				c.globals[f.Name] = true
+			}
+		})
+	}
+	return flags
+}
+
+// ownFlags returns the flags declared on the command, without the global ones.
+func (c *Command) ownFlags() []*flag.Flag {
+	var flags []*flag.Flag
+	c.Flags().VisitAll(func(f *flag.Flag) {
+		if !c.globals[f.Name] {
+			flags = append(flags, f)
+		}
+	})
+	return flags
+}
+
+// Execute parses args, the command line without the program name, and runs
+// the command they select. --help prints the help of the command instead. A
+// command line that does not parse prints the error and the usage and
+// returns ErrUsage; otherwise the error of the command is returned.
+func (c *Command) Execute(ctx context.Context, args []string) error {
+	if len(args) > 0 && args[0] == completeCommand {
+		for _, candidate := range c.complete(args[1:]) {
+			fmt.Println(candidate)
+		}
+		return nil
+	}
+
+	command, positional, err := c.parse(args)
+	switch {
+	case errors.Is(err, flag.ErrHelp):
+		command.PrintHelp()
+		return nil
+	case err != nil:
+		fmt.Println(errorStyle.Render("Error: " + err.Error()))
+		fmt.Println(descriptionStyle.Render(fmt.Sprintf("Run '%s --help' for usage", command.Path())))
+		return ErrUsage
+	case command.Run == nil:
+		command.PrintHelp()
+		return ErrUsage
+	}
+	return command.Run(ctx, positional)
+}
+
+// parse walks args down the tree, parsing the flags of each command on the
+// way, and returns the selected command with its positional arguments. The
+// arguments after -- are positional, neither flags nor subcommands.
+func (c *Command) parse(args []string) (*Command, []string, error) {
+	command := c
+	var positional []string
+	for {
+		flags := command.flagSet()
+		if err := flags.Parse(args); err != nil {
+			return command, nil, err
+		}
+		parsed := args[:len(args)-flags.NArg()]
+		args = flags.Args()
+		if terminated(flags, parsed) {
+			positional = append(positional, args...)
+			break
+		}
+		if len(args) == 0 {
+			break
+		}
+
+		// Subcommands are selected before any positional argument.
+		if len(positional) == 0 {
+			if sub := command.command(args[0]); sub != nil {
+				command, args = sub, args[1:]
+				continue
+			}
+			if len(command.commands) > 0 && command.MaxArgs == 0 {
+				return command, nil, fmt.Errorf("unknown command: %s", args[0])
+			}
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+
+	if len(positional) < command.MinArgs {
+		return command, nil, fmt.Errorf("%s requires %s", command.Path(), command.Args)
+	}
+	if command.MaxArgs >= 0 && len(positional) > command.MaxArgs {
+		return command, nil, fmt.Errorf("unexpected argument: %s", positional[command.MaxArgs])
+	}
+	return command, positional, nil
+}
+
+// terminated reports whether flags stopped parsing at --, given the
+// arguments it parsed. A -- following a flag that takes a value is that value.
+func terminated(flags *flag.FlagSet, parsed []string) bool {
+	for i := 0; i < len(parsed); i++ {
+		if parsed[i] == "--" {
+			return true
+		}
+		name := strings.TrimLeft(parsed[i], "-")
+		if strings.Contains(name, "=") {
+			continue
+		}
+		if f := flags.Lookup(name); f != nil && !isBoolFlag(f) {
+			i++
+		}
+	}
+	return false
+}
+
+// PrintHelp prints the usage of the command, its subcommands and its flags.
+func (c *Command) PrintHelp() {
+	usage := c.Path()
+	if c.parent == nil {
+		usage += " [global options]"
+	}
+	if len(c.commands) > 0 {
+		usage += " <command>"
+	}
+	if c.parent != nil && len(c.ownFlags()) > 0 {
+		usage += " [options]"
+	}
+	if c.Args != "" {
+		usage += " " + c.Args
+	}
+	fmt.Println(commandStyle.Render("Usage:"), descriptionStyle.Render(usage))
+	if c.Short != "" {
+		fmt.Println(descriptionStyle.Render(c.Short))
+	}
+
+	if len(c.commands) > 0 {
+		fmt.Println()
+		fmt.Println(commandStyle.Render("Commands:"))
+		for _, command := range c.commands {
+			if command.Hidden {
+				continue
+			}
+			name := command.Name
+			if command.Args != "" {
+				name += " " + command.Args
+			}
+			fmt.Println(optionStyle.Render("  - "+name+":"), descriptionStyle.Render(command.Short))
+		}
+	}
+
+	if flags := c.ownFlags(); len(flags) > 0 {
+		fmt.Println()
+		if c.parent == nil {
+			fmt.Println(commandStyle.Render("Global options:"))
+		} else {
+			fmt.Println(commandStyle.Render("Options:"))
+		}
+		printFlags(flags)
+	}
+	if root := c.root(); root != c {
+		if flags := root.ownFlags(); len(flags) > 0 {
+			fmt.Println()
+			fmt.Println(commandStyle.Render("Global options:"))
+			printFlags(flags)
+		}
+	}
+
+	if c.Note != "" {
+		fmt.Println()
+		fmt.Println(subtitleStyle.Render(c.Note))
+	}
+}
+
+// printFlags prints a line per flag, with its value type and default.
+func printFlags(flags []*flag.Flag) {
+	for _, f := range flags {
+		name, usage := flag.UnquoteUsage(f)
+		option := "--" + f.Name
+		if !isBoolFlag(f) {
+			if name == "" {
+				name = "value"
+			}
+			option += "=" + name
+		}
+		if f.DefValue != "" && f.DefValue != "false" && f.DefValue != "0" && f.DefValue != "0s" {
+			usage += fmt.Sprintf(" (default %s)", f.DefValue)
+		}
+		fmt.Println(optionStyle.Render("  "+option+":"), descriptionStyle.Render(usage))
+	}
+}
+
+// isBoolFlag reports whether f takes no value.
+func isBoolFlag(f *flag.Flag) bool {
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
+
+// sortedNames returns the names of the visible subcommands of c, sorted.
+func (c *Command) sortedNames() []string {
+	var names []string
+	for _, command := range c.commands {
+		if !command.Hidden {
+			names = append(names, command.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// withPrefix returns the candidates starting with prefix.
+func withPrefix(candidates []string, prefix string) []string {
+	var matches []string
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate, prefix) {
+			matches = append(matches, candidate)
+		}
+	}
+	return matches
+}
@@ -0,0 +1,112 @@
+package cli
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testTree returns a root command with a global --config flag and a prompts
+// subcommand taking two arguments and --full and --format flags.
+func testTree() *Command {
+	root := &Command{Name: "godeogoker"}
+	root.Flags().String("config", "", "Configuration file")
+
+	prompts := &Command{Name: "prompts", MinArgs: 2, MaxArgs: 2}
+	prompts.Flags().Bool("full", false, "Print everything")
+	prompts.Flags().String("format", "text", "Output format")
+
+	list := &Command{Name: "list", MaxArgs: -1}
+	root.AddCommand(prompts, list)
+	return root
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		command    string
+		positional []string
+		flags      map[string]string
+		err        string
+	}{
+		{
+			name:       "flags before and after the arguments",
+			args:       []string{"--config=a.json", "prompts", "channel", "--full", "video", "--format", "json"},
+			command:    "godeogoker prompts",
+			positional: []string{"channel", "video"},
+			flags:      map[string]string{"config": "a.json", "full": "true", "format": "json"},
+		},
+		{
+			name:       "argument starting with a dash after --",
+			args:       []string{"prompts", "--full", "channel", "--", "-x7Kq2bYc1A"},
+			command:    "godeogoker prompts",
+			positional: []string{"channel", "-x7Kq2bYc1A"},
+			flags:      map[string]string{"full": "true"},
+		},
+		{
+			name:       "flags after -- are arguments",
+			args:       []string{"list", "--", "--full", "-", "--"},
+			command:    "godeogoker list",
+			positional: []string{"--full", "-", "--"},
+		},
+		{
+			name:    "-- before the command name",
+			args:    []string{"--", "prompts"},
+			command: "godeogoker",
+			err:     "unexpected argument: prompts",
+		},
+		{
+			name:       "-- as the value of a flag",
+			args:       []string{"prompts", "--format", "--", "channel", "--full", "video"},
+			command:    "godeogoker prompts",
+			positional: []string{"channel", "video"},
+			flags:      map[string]string{"format": "--", "full": "true"},
+		},
+		{
+			name:       "bool flag before --",
+			args:       []string{"prompts", "--full", "--", "channel", "-video"},
+			command:    "godeogoker prompts",
+			positional: []string{"channel", "-video"},
+			flags:      map[string]string{"full": "true"},
+		},
+		{
+			name:    "dash argument without --",
+			args:    []string{"prompts", "channel", "-x7Kq2bYc1A"},
+			command: "godeogoker prompts",
+			err:     "flag provided but not defined: -x7Kq2bYc1A",
+		},
+		{
+			name:    "too many arguments after --",
+			args:    []string{"prompts", "channel", "--", "video", "-extra"},
+			command: "godeogoker prompts",
+			err:     "unexpected argument: -extra",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			command, positional, err := testTree().parse(test.args)
+			if command.Path() != test.command {
+				t.Errorf("command = %s, want %s", command.Path(), test.command)
+			}
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Errorf("error = %v, want %q", err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(positional, test.positional) {
+				t.Errorf("arguments = %q, want %q", positional, test.positional)
+			}
+			for name, want := range test.flags {
+				if got := command.flagSet().Lookup(name).Value.String(); got != want {
+					t.Errorf("--%s = %q, want %q", name, got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// completeCommand is the hidden command the completion scripts call with the
+// words of the command line, the last one being the word completed. It prints
+// a candidate per line.
+const completeCommand = "__complete"
+
+// completionScripts are the completion scripts by shell, with %[1]s standing
+// for the program name.
+var completionScripts = map[string]string{
+	"bash": `_%[1]s() {
+    local IFS=$'\n'
+    COMPREPLY=($(%[1]s __complete "${COMP_WORDS[@]:1:$COMP_CWORD}" 2>/dev/null))
+    if [[ ${#COMPREPLY[@]} -eq 1 && ${COMPREPLY[0]} == *= ]]; then
+        compopt -o nospace
+    fi
+}
+complete -F _%[1]s %[1]s
+`,
+	"zsh": `#compdef %[1]s
+_%[1]s() {
+    local -a candidates
+    candidates=(${(f)"$(%[1]s __complete "${(@)words[2,CURRENT]}" 2>/dev/null)"})
+    compadd -Q -S '' -- ${(M)candidates:#*=}
+    compadd -Q -- ${candidates:#*=}
+}
+compdef _%[1]s %[1]s
+`,
+	"fish": `complete -c %[1]s -f -a '(%[1]s __complete (commandline -opc)[2..-1] (commandline -ct) 2>/dev/null)'
+`,
+}
+
+// CompletionCommand returns the completion command of the tree of root,
+// printing the completion script of a shell.
+func CompletionCommand(root *Command) *Command {
+	shells := make([]string, 0, len(completionScripts))
+	for shell := range completionScripts {
+		shells = append(shells, shell)
+	}
+	sort.Strings(shells)
+
+	return &Command{
+		Name:     "completion",
+		Args:     "<" + strings.Join(shells, "|") + ">",
+		Short:    "Print the shell completion script, e.g. source <(godeogoker completion bash)",
+		MinArgs:  1,
+		MaxArgs:  1,
+		Complete: func([]string) []string { return shells },
+		Run: func(ctx context.Context, args []string) error {
+			script, ok := completionScripts[args[0]]
+			if !ok {
+				return fmt.Errorf("unsupported shell: %s", args[0])
+			}
+			fmt.Printf(script, root.Name)
+			return nil
+		},
+	}
+}
+
+// complete returns the candidates for the last of words, given the words
+// before it: flags of the selected command when it starts with -, otherwise
+// its subcommands and positional argument candidates.
+func (c *Command) complete(words []string) []string {
+	if len(words) == 0 {
+		words = []string{""}
+	}
+	current := words[len(words)-1]
+
+	command := c
+	var positional []string
+	for i := 0; i < len(words)-1; i++ {
+		word := words[i]
+		if strings.HasPrefix(word, "-") {
+			// The value of a flag may be the next word.
+			name := strings.TrimLeft(word, "-")
+			if f := command.flagSet().Lookup(name); f != nil && !isBoolFlag(f) {
+				i++
+			}
+			continue
+		}
+		if len(positional) == 0 {
+			if sub := command.command(word); sub != nil && !sub.Hidden {
+				command = sub
+				continue
+			}
+		}
+		positional = append(positional, word)
+	}
+
+	var candidates []string
+	if strings.HasPrefix(current, "-") {
+		command.flagSet().VisitAll(func(f *flag.Flag) {
+			if isBoolFlag(f) {
+				candidates = append(candidates, "--"+f.Name)
+			} else {
+				candidates = append(candidates, "--"+f.Name+"=")
+			}
+		})
+		sort.Strings(candidates)
+		return withPrefix(candidates, current)
+	}
+
+	if len(positional) == 0 {
+		candidates = append(candidates, command.sortedNames()...)
+	}
+	if command.Complete != nil && (command.MaxArgs < 0 || len(positional) < command.MaxArgs) {
+		candidates = append(candidates, command.Complete(positional)...)
+	}
+	return withPrefix(candidates, current)
+}
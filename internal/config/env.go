@@ -0,0 +1,207 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// EnvPrefix starts the names of the environment variables overriding the
+// settings of the configuration file, such as GODEOGOKER_OPENAI_KEY.
+const EnvPrefix = "GODEOGOKER_"
+
+// RedactedValue replaces the credentials of the configuration wherever they
+// would be printed.
+const RedactedValue = "********"
+
+// minSecretLength is the length below which a credential is not redacted
+// from printed text, where it would replace unrelated words.
+const minSecretLength = 4
+
+// secretKeys are the keys of the settings holding credentials.
+var secretKeys = map[string]bool{
+	"key":            true,
+	"password":       true,
+	"sendgrid_key":   true,
+	"webhook_secret": true,
+	"po_token":       true,
+}
+
+// ApplyEnv overrides the settings of config with the environment variables
+// named after their keys: EnvPrefix followed by the keys from the top of the
+// file, upper-cased and joined by underscores. GODEOGOKER_OPENAI_KEY sets
+// openai.key and GODEOGOKER_DIGEST_SMTP_PORT sets digest.smtp_port; channels
+// are numbered from 0, as in GODEOGOKER_CHANNELS_0_AI_KEY. Lists are comma
+// separated. Blocks missing from the file are not created and maps cannot be
+// overridden.
+func ApplyEnv(config *Config) error {
+	return applyEnv(config, os.LookupEnv)
+}
+
+// applyEnv overrides the settings of config with the values lookup finds.
+func applyEnv(config *Config, lookup func(string) (string, bool)) error {
+	return walkSettings(reflect.ValueOf(config).Elem(), strings.TrimSuffix(EnvPrefix, "_"), "", func(setting reflect.Value, name string, key string) error {
+		value, ok := lookup(name)
+		if !ok {
+			return nil
+		}
+		if err := setSetting(setting, value); err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+		return nil
+	})
+}
+
+// walkSettings calls visit with every setting under v, with the name of its
+// environment variable and its key. Nil blocks and maps are skipped.
+func walkSettings(v reflect.Value, name string, key string, visit func(setting reflect.Value, name string, key string) error) error {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return nil
+		}
+		return walkSettings(v.Elem(), name, key, visit)
+	case reflect.Struct:
+		for i := range v.NumField() {
+			field := v.Type().Field(i)
+			tag, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if !field.IsExported() || tag == "" || tag == "-" {
+				continue
+			}
+			if err := walkSettings(v.Field(i), name+"_"+strings.ToUpper(tag), tag, visit); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.Struct {
+			return visit(v, name, key)
+		}
+		for i := range v.Len() {
+			if err := walkSettings(v.Index(i), name+"_"+strconv.Itoa(i), key, visit); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Map:
+		return nil
+	default:
+		return visit(v, name, key)
+	}
+}
+
+// setSetting parses value into setting, a string, number, boolean or list
+// of them.
+func setSetting(setting reflect.Value, value string) error {
+	switch setting.Kind() {
+	case reflect.String:
+		setting.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", value)
+		}
+		setting.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", value)
+		}
+		setting.SetInt(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", value)
+		}
+		setting.SetFloat(f)
+	case reflect.Slice:
+		list := reflect.MakeSlice(setting.Type(), 0, 0)
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item == "" {
+				continue
+			}
+			element := reflect.New(setting.Type().Elem()).Elem()
+			if err := setSetting(element, item); err != nil {
+				return err
+			}
+			list = reflect.Append(list, element)
+		}
+		setting.Set(list)
+	default:
+		return fmt.Errorf("cannot be set from the environment")
+	}
+	return nil
+}
+
+// Secrets returns the credentials set in c: its API keys, passwords and
+// tokens, and the passwords of its URLs.
+func (c *Config) Secrets() []string {
+	var secrets []string
+	walkSettings(reflect.ValueOf(c).Elem(), "", "", func(setting reflect.Value, name string, key string) error {
+		if setting.Kind() != reflect.String || setting.String() == "" {
+			return nil
+		}
+		if secretKeys[key] {
+			secrets = append(secrets, setting.String())
+		} else if password := urlPassword(setting.String()); password != "" {
+			secrets = append(secrets, password)
+		}
+		return nil
+	})
+	return secrets
+}
+
+// urlPassword returns the password of value when it is a URL with one.
+func urlPassword(value string) string {
+	if !strings.Contains(value, "://") {
+		return ""
+	}
+	u, err := url.Parse(value)
+	if err != nil || u.User == nil {
+		return ""
+	}
+	password, _ := u.User.Password()
+	return password
+}
+
+// Redact returns text with the credentials of c replaced by RedactedValue,
+// for messages that may quote them, such as errors of the APIs they are sent
+// to.
+func (c *Config) Redact(text string) string {
+	if c == nil {
+		return text
+	}
+	for _, secret := range c.Secrets() {
+		if len(secret) >= minSecretLength {
+			text = strings.ReplaceAll(text, secret, RedactedValue)
+		}
+	}
+	return text
+}
+
+// Redacted returns a copy of c with its credentials replaced by
+// RedactedValue, safe to print.
+func (c *Config) Redacted() *Config {
+	content, _ := json.Marshal(c)
+	var redacted Config
+	json.Unmarshal(content, &redacted)
+	redacted.Dir = c.Dir
+
+	walkSettings(reflect.ValueOf(&redacted).Elem(), "", "", func(setting reflect.Value, name string, key string) error {
+		if setting.Kind() != reflect.String || setting.String() == "" {
+			return nil
+		}
+		if secretKeys[key] {
+			setting.SetString(RedactedValue)
+		} else if password := urlPassword(setting.String()); password != "" {
+			u, _ := url.Parse(setting.String())
+			setting.SetString(strings.Replace(u.Redacted(), "xxxxx", RedactedValue, 1))
+		}
+		return nil
+	})
+	return &redacted
+}
@@ -0,0 +1,192 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Formats of the configuration file, told apart by its extension.
+const (
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+	FormatTOML = "toml"
+)
+
+// configNames are the names of the configuration file looked for when none
+// is chosen, in order of preference.
+var configNames = []string{"config.json", "config.yaml", "config.yml", "config.toml"}
+
+// Format returns the format of the configuration file path from its
+// extension: .yaml and .yml are YAML, .toml is TOML and anything else JSON.
+func Format(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return FormatYAML
+	case ".toml":
+		return FormatTOML
+	default:
+		return FormatJSON
+	}
+}
+
+// Load reads the configuration file at path in the format of its extension.
+// YAML and TOML files use the keys of the JSON configuration.
+func Load(path string) (*Config, error) {
+	file, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s file: %w", strings.ToUpper(Format(path)), err)
+	}
+
+	var config Config
+	if err := decode(file, Format(path), &config); err != nil {
+		return nil, fmt.Errorf("error parsing %s file: %w", strings.ToUpper(Format(path)), err)
+	}
+
+	return &config, nil
+}
+
+// decode parses data in format into config. YAML and TOML documents are
+// turned into JSON first, so every format follows the json tags of Config.
+func decode(data []byte, format string, config *Config) error {
+	switch format {
+	case FormatYAML:
+		var document any
+		if err := yaml.Unmarshal(data, &document); err != nil {
+			return err
+		}
+		content, err := json.Marshal(jsonValue(document))
+		if err != nil {
+			return err
+		}
+		data = content
+	case FormatTOML:
+		document, err := decodeTOML(data)
+		if err != nil {
+			return err
+		}
+		content, err := json.Marshal(document)
+		if err != nil {
+			return err
+		}
+		data = content
+	}
+	return json.Unmarshal(data, config)
+}
+
+// jsonValue converts the maps YAML decodes with non-string keys, such as
+// numbers, into maps with string keys that marshal to JSON.
+func jsonValue(value any) any {
+	switch value := value.(type) {
+	case map[string]any:
+		for key, item := range value {
+			value[key] = jsonValue(item)
+		}
+		return value
+	case map[any]any:
+		converted := make(map[string]any, len(value))
+		for key, item := range value {
+			converted[fmt.Sprint(key)] = jsonValue(item)
+		}
+		return converted
+	case []any:
+		for i, item := range value {
+			value[i] = jsonValue(item)
+		}
+		return value
+	default:
+		return value
+	}
+}
+
+// Encode returns config written in format, with its keys in the order of
+// the fields of Config and the empty optional settings left out.
+func Encode(config *Config, format string) ([]byte, error) {
+	content, err := json.MarshalIndent(config, "", "    ")
+	if err != nil {
+		return nil, err
+	}
+	if format == FormatJSON {
+		return append(content, '\n'), nil
+	}
+
+	// JSON is valid YAML, and decoding it into a node keeps the order of the keys.
+	var document yaml.Node
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		return nil, err
+	}
+	root := document.Content[0]
+	clearStyle(root)
+
+	switch format {
+	case FormatYAML:
+		var out bytes.Buffer
+		encoder := yaml.NewEncoder(&out)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(root); err != nil {
+			return nil, err
+		}
+		return out.Bytes(), nil
+	case FormatTOML:
+		return encodeTOML(root)
+	default:
+		return nil, fmt.Errorf("unknown format %q, expected json, yaml or toml", format)
+	}
+}
+
+// Save writes config to the file at path in the format of its extension,
+// replacing it at once so an interrupted write leaves the previous file. The
+// file keeps its permissions; a new one is only readable by its owner.
+func Save(config *Config, path string) error {
+	content, err := Encode(config, Format(path))
+	if err != nil {
+		return err
+	}
+
+	mode := os.FileMode(0600)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// clearStyle drops the flow style and quotes the JSON source gave to node and
+// its children, so YAML is written in block style.
+func clearStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		clearStyle(child)
+	}
+}
+
+// findConfig returns the configuration file of dir: the first of
+// configNames that exists, or config.json when none does.
+func findConfig(dir string) string {
+	for _, name := range configNames {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return filepath.Join(dir, configNames[0])
+}
@@ -1,110 +1,602 @@
 // Package config provides functionality for loading and accessing application configuration.
-// The configuration is loaded from a JSON file and stored in memory for easy access.
+// The configuration is loaded from a JSON, YAML or TOML file and stored in memory for easy access.
+// Named profiles keep the configuration, credentials, token and state of each
+// tenant in their own folder under profiles/.
 package config
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"io/fs"
+	"net/url"
 	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 )
 
+// ProfilesDir is the folder holding one sub-folder per named profile.
+const ProfilesDir = "profiles"
+
+// FileEnv names the environment variable holding the configuration file path,
+// such as a secret mounted in a container. --config and --profile take precedence.
+const FileEnv = "GODEOGOKER_CONFIG"
+
 // OpenAI represents configuration settings for the OpenAI API integration.
 type OpenAI struct {
-	Key   string `json:"key"`   // API key for authentication with OpenAI services
-	Model string `json:"model"` // The name of the model to be used for AI operations
+	Key           string  `json:"key"`                      // API key for authentication with OpenAI services
+	Model         string  `json:"model"`                    // The name of the model to be used for AI operations
+	InputPrice    float64 `json:"input_price,omitempty"`    // USD per million prompt tokens, used for spending limits
+	OutputPrice   float64 `json:"output_price,omitempty"`   // USD per million completion tokens, used for spending limits
+	ContextWindow int     `json:"context_window,omitempty"` // Context window of model in tokens, 0 for its known size (8192 when unknown)
+	LongModel     string  `json:"long_model,omitempty"`     // Larger-context model used for prompts too long for model, e.g. gpt-4.1-mini
+}
+
+// AI represents the language model provider of a channel, finding its cuts
+// and writing its metadata instead of the model of the openai block.
+type AI struct {
+	Provider      string  `json:"provider"`                 // openai, anthropic, gemini or local (an OpenAI-compatible server such as Ollama or LM Studio)
+	Model         string  `json:"model"`                    // Model name, e.g. claude-sonnet-4-5, gemini-2.5-flash or llama3.1
+	Key           string  `json:"key,omitempty"`            // API key, defaults to openai.key for openai; local servers usually need none
+	URL           string  `json:"url,omitempty"`            // Base URL of the API, required for local, e.g. http://localhost:11434/v1
+	InputPrice    float64 `json:"input_price,omitempty"`    // USD per million prompt tokens, used for spending limits
+	OutputPrice   float64 `json:"output_price,omitempty"`   // USD per million completion tokens, used for spending limits
+	ContextWindow int     `json:"context_window,omitempty"` // Context window of model in tokens, 0 for its known size (8192 when unknown)
+	LongModel     string  `json:"long_model,omitempty"`     // Larger-context model of the provider used for prompts too long for model
+}
+
+// Server represents the settings of the serve command.
+type Server struct {
+	Addr          string `json:"addr,omitempty"`           // Listen address, defaults to :8080
+	GRPCAddr      string `json:"grpc_addr,omitempty"`      // Listen address of the gRPC API, empty to disable it
+	WebhookSecret string `json:"webhook_secret,omitempty"` // Shared secret signing webhook requests and authorizing gRPC calls
+	QueueSize     int    `json:"queue_size,omitempty"`     // Videos waiting to be processed before requests are refused, defaults to 100
+}
+
+// Queue represents the broker shared by the coordinator and the workers of
+// the distributed mode.
+type Queue struct {
+	Type   string `json:"type"`             // Broker: nats or redis
+	URL    string `json:"url"`              // Broker URL, e.g. nats://host:4222 or redis://host:6379/0
+	Name   string `json:"name,omitempty"`   // Subject or key of the queue, defaults to godeogoker.jobs
+	Worker string `json:"worker,omitempty"` // Unique ID of this worker for redis, defaults to the hostname
+}
+
+// Digest represents the email digest of the digest command, sent per channel
+// through an SMTP server or the SendGrid API.
+type Digest struct {
+	Provider      string   `json:"provider"`                 // Sender: smtp or sendgrid
+	From          string   `json:"from"`                     // Sender address
+	To            []string `json:"to"`                       // Recipients, unless the channel sets digest_to
+	SMTPHost      string   `json:"smtp_host,omitempty"`      // SMTP server host
+	SMTPPort      int      `json:"smtp_port,omitempty"`      // SMTP server port, defaults to 587 (465 uses implicit TLS)
+	Username      string   `json:"username,omitempty"`       // SMTP user name
+	Password      string   `json:"password,omitempty"`       // SMTP password
+	SendGridKey   string   `json:"sendgrid_key,omitempty"`   // SendGrid API key
+	MaxThumbnails int      `json:"max_thumbnails,omitempty"` // Clip covers embedded in each email, defaults to 12
+}
+
+// Daemon represents the settings of the daemon command, which polls the feed
+// of every channel on a schedule.
+type Daemon struct {
+	Interval int `json:"interval,omitempty"` // Minutes between polls of channels without a schedule, defaults to 15
+	Workers  int `json:"workers,omitempty"`  // Videos processed at once across the channels, defaults to 1
+}
+
+// FeedFetch represents how the YouTube RSS feeds of the channels are
+// requested, for networks that block requests from the default Go client.
+type FeedFetch struct {
+	UserAgent string            `json:"user_agent,omitempty"` // User-Agent header of the feed requests, defaults to a godeogoker one
+	Headers   map[string]string `json:"headers,omitempty"`    // Extra headers of the feed requests, e.g. {"Accept-Language": "en-US"}
+	Timeout   int               `json:"timeout,omitempty"`    // Seconds each feed request may take, defaults to 30; negative for no limit
+	Proxy     string            `json:"proxy,omitempty"`      // Proxy URL of the feed requests, defaults to HTTPS_PROXY, HTTP_PROXY and NO_PROXY
+}
+
+// Parallel represents how many channels exec processes at once, and how many
+// downloads and renders may run at the same time across them.
+type Parallel struct {
+	Channels  int `json:"channels,omitempty"`  // Channels processed at once, defaults to 1
+	Downloads int `json:"downloads,omitempty"` // Video downloads running at once, 0 for no limit
+	Renders   int `json:"renders,omitempty"`   // Clip, cover and overlay renders running at once, defaults to a quarter of the CPU cores
+}
+
+// Priorities represents the CPU and I/O priority of the external processes of
+// each stage, so batch runs leave the machine usable for other work.
+type Priorities struct {
+	Download      Priority `json:"download,omitempty"`      // yt-dlp calls, with the ffmpeg merges they start
+	Render        Priority `json:"render,omitempty"`        // ffmpeg and ffprobe calls
+	Transcription Priority `json:"transcription,omitempty"` // whisper.cpp transcriptions
+}
+
+// Priority represents the niceness and I/O scheduling of a process. The zero
+// value leaves the process at the priority of godeogoker.
+type Priority struct {
+	Nice    int    `json:"nice,omitempty"`     // Niceness from -20 (highest, needs root) to 19 (lowest), 0 to leave it unchanged
+	IOClass string `json:"io_class,omitempty"` // I/O scheduling class on Linux: idle, best-effort or realtime (needs root); empty to leave it unchanged
+	IOLevel int    `json:"io_level,omitempty"` // Level within best-effort or realtime, from 1 (higher) to 7 (lowest); 0 for the default of the class, 4
+}
+
+// Transcription represents the speech recognition backend generating the
+// captions of the videos yt-dlp finds none for.
+type Transcription struct {
+	Provider string `json:"provider"`           // Backend: whisper-cpp or openai, empty to never transcribe
+	Model    string `json:"model,omitempty"`    // ggml model file for whisper-cpp, or OpenAI model defaulting to whisper-1
+	Binary   string `json:"binary,omitempty"`   // Path to the whisper.cpp executable, defaults to whisper-cli
+	Language string `json:"language,omitempty"` // Language code of the speech, defaults to pt
+}
+
+// Publish represents when the YouTube uploads of a channel go public: they
+// are uploaded as private and scheduled for the next free publish hour.
+type Publish struct {
+	Hours      []int `json:"hours,omitempty"`       // Hours of the day to publish at, in the channel timezone; defaults to 18
+	BestHours  int   `json:"best_hours,omitempty"`  // Publish at this many hours with the most views per day of the earlier uploads instead, 0 to keep hours
+	MinUploads int   `json:"min_uploads,omitempty"` // Public uploads with statistics needed before the best hours are used, defaults to 20
+}
+
+// Audiogram represents the layout of the vertical version of cuts from
+// sources that are audio over a static image, such as radio show uploads:
+// the image as artwork above a waveform of the audio and the captions.
+type Audiogram struct {
+	Background string `json:"background,omitempty"` // Image or video behind the layout, defaults to video_base_vertical
+	WaveColor  string `json:"wave_color,omitempty"` // Color of the waveform, defaults to white
+	ArtworkY   int    `json:"artwork_y,omitempty"`  // Top of the artwork in pixels, defaults to 260
+	WaveY      int    `json:"wave_y,omitempty"`     // Top of the waveform in pixels, defaults to 1240
+}
+
+// Split represents the split layout of vertical videos: the clip in one half
+// of the frame and a secondary video, such as a recorded reaction or a
+// gameplay loop, in the other.
+type Split struct {
+	Video  string  `json:"video"`            // Secondary video, looped for as long as the clip lasts
+	Swap   bool    `json:"swap,omitempty"`   // Put the clip in the bottom half instead of the top one
+	Volume float64 `json:"volume,omitempty"` // Volume of the secondary video mixed under the clip, e.g. 0.3; 0 mutes it
+}
+
+// EpisodeIndex represents the index of the clips uploaded from a source
+// video, linking each of them, and where it is published.
+type EpisodeIndex struct {
+	Header     string `json:"header,omitempty"`      // First line with {title}, {guest}, {episode} and {date} placeholders, defaults to "Clips from this episode:"
+	Update     string `json:"update,omitempty"`      // Description the index is added to: source, playlist or empty to only write index.txt
+	PlaylistID string `json:"playlist_id,omitempty"` // Playlist whose description gets the index, with update playlist
+}
+
+// QualityGate represents the checks a clip must pass before it is uploaded.
+type QualityGate struct {
+	MaxBlack  float64 `json:"max_black,omitempty"`  // Largest share of the clip that may be black, defaults to 0.5
+	MaxFrozen float64 `json:"max_frozen,omitempty"` // Largest share of the clip that may be frozen, defaults to 0.5
+}
+
+// Keywords represents the phrases the keywords cut detector looks for and
+// how much of the transcript around them each cut keeps.
+type Keywords struct {
+	Phrases []string `json:"phrases,omitempty"` // Words or phrases to look for, e.g. "pergunta do dia"; defaults to the topics
+	Before  int      `json:"before,omitempty"`  // Seconds kept before the first mention, defaults to 5
+	After   int      `json:"after,omitempty"`   // Seconds kept after the last mention, defaults to stretch_time
+}
+
+// TitleRules represents the style guide the generated titles of a channel are
+// corrected to follow.
+type TitleRules struct {
+	MaxLength int      `json:"max_length,omitempty"` // Longest title in characters, prefix included, 0 for no limit
+	Banned    []string `json:"banned,omitempty"`     // Words and clickbait phrases removed from titles, ignoring case
+	Prefix    string   `json:"prefix,omitempty"`     // Start of every title with {guest}, {episode} and {date} placeholders, e.g. "EP {episode} |"
+	Case      string   `json:"case,omitempty"`       // Capitalization: sentence, title, upper or lower; empty to keep the generated one
+}
+
+// Hashtags represents the evergreen hashtags of a channel, merged with the
+// hashtags generated for each clip.
+type Hashtags struct {
+	Pool    []string       `json:"pool"`               // Evergreen hashtags of the channel, with or without the # symbol
+	PerClip int            `json:"per_clip,omitempty"` // Pool hashtags added to each clip, rotating through the pool, defaults to 3
+	Limits  map[string]int `json:"limits,omitempty"`   // Most hashtags per platform, e.g. {"youtube": 3, "tiktok": 5}; youtube defaults to 15
+}
+
+// Translations represents the languages the metadata of a channel is
+// translated into for localized uploads.
+type Translations struct {
+	Languages map[string]string `json:"languages"`        // Target languages by YouTube language code, e.g. {"en": "English", "es": "Spanish"}
+	Source    string            `json:"source,omitempty"` // Language code of the generated metadata, e.g. "pt-BR", required by localizations
+	Mode      string            `json:"mode,omitempty"`   // localizations (default) attaches the translations to the upload, uploads uploads a copy per language
+}
+
+// Community represents the Community posts written for the uploaded clips
+// of a channel, teasing them to its subscribers.
+type Community struct {
+	Poll bool `json:"poll,omitempty"` // Suggest a poll about the clip with each post
+}
+
+// YtdlpOptions represents extra options passed to every yt-dlp call of a
+// channel, so YouTube changes requiring new flags are handled in the configuration.
+type YtdlpOptions struct {
+	ExtractorArgs []string `json:"extractor_args,omitempty"` // Values of --extractor-args, e.g. youtube:player_client=web,mweb
+	Impersonate   string   `json:"impersonate,omitempty"`    // Client impersonated with --impersonate, e.g. chrome
+	UserAgent     string   `json:"user_agent,omitempty"`     // User-Agent header sent instead of the yt-dlp default
+	POToken       string   `json:"po_token,omitempty"`       // YouTube proof-of-origin token as CLIENT.CONTEXT+TOKEN, e.g. web.gvs+XXX
+	Args          []string `json:"args,omitempty"`           // Any other arguments, added before the URL
+}
+
+// YtdlpAuth represents the YouTube account yt-dlp signs in with to download
+// the age-restricted videos of the channels that opt in with age_restricted.
+type YtdlpAuth struct {
+	Cookies            string `json:"cookies,omitempty"`              // Netscape cookies file exported from a signed-in browser, passed as --cookies
+	CookiesFromBrowser string `json:"cookies_from_browser,omitempty"` // Browser whose cookies are read with --cookies-from-browser, e.g. firefox or chrome:Profile 1
+	OAuth              bool   `json:"oauth,omitempty"`                // Sign in with the OAuth device flow of the yt-dlp-youtube-oauth2 plugin
+}
+
+// Timeouts bounds how long each stage may run, in minutes, so a stuck process
+// cannot hang a batch. Zero uses the default and a negative value disables the limit,
+// unlike in ErrorBudget where zero disables it.
+type Timeouts struct {
+	Download int `json:"download,omitempty"` // Minutes each yt-dlp call may run, defaults to 60
+	FFmpeg   int `json:"ffmpeg,omitempty"`   // Minutes each ffmpeg or ffprobe call may run, defaults to 30
+	Upload   int `json:"upload,omitempty"`   // Minutes each YouTube upload may run, defaults to 30
+	Video    int `json:"video,omitempty"`    // Minutes the whole processing of a video may run, defaults to 240
+}
+
+// ErrorBudget stops processing when videos keep failing, so a systemic failure
+// such as an expired cookie or a broken yt-dlp does not fail every video in
+// turn. Both limits are disabled unless set to a positive value: zero means
+// no limit here, unlike in Timeouts where it keeps the default.
+type ErrorBudget struct {
+	Channel int `json:"channel,omitempty"` // Consecutive failed videos that stop a channel, 0 for no limit
+	Run     int `json:"run,omitempty"`     // Failed videos that stop the whole run, 0 for no limit
+}
+
+// Storage represents where the processed outputs of a channel are published.
+// An empty Type keeps everything on the local disk inside the channel folder.
+type Storage struct {
+	Type            string `json:"type"`                       // Backend: local (default), s3, gcs or webdav
+	Bucket          string `json:"bucket,omitempty"`           // Bucket name for s3 and gcs
+	Prefix          string `json:"prefix,omitempty"`           // Key prefix prepended to every stored file
+	Region          string `json:"region,omitempty"`           // Region for s3
+	Endpoint        string `json:"endpoint,omitempty"`         // Custom s3 endpoint or WebDAV base URL
+	Username        string `json:"username,omitempty"`         // Access key for s3 or user for webdav
+	Password        string `json:"password,omitempty"`         // Secret key for s3 or password for webdav
+	CredentialsFile string `json:"credentials_file,omitempty"` // Service account file for gcs
+}
+
+// Feed represents the feeds listing the rendered clips of a channel, written
+// to the channel folder and published with the other outputs.
+type Feed struct {
+	Formats []string `json:"formats,omitempty"`  // Feeds to write: rss, atom and/or json
+	BaseURL string   `json:"base_url,omitempty"` // Public URL of the stored outputs, prefixed to every link
+	Limit   int      `json:"limit,omitempty"`    // Number of newest clips listed, defaults to 50
+}
+
+// Audio represents the enhancement filters applied to the audio of every clip,
+// for sources recorded with poor microphones.
+type Audio struct {
+	HighPass int  `json:"high_pass,omitempty"` // Cut-off frequency in Hz removing rumble and hum, 0 to disable
+	Denoise  int  `json:"denoise,omitempty"`   // Background noise reduction in dB, 0 to disable
+	DeEss    bool `json:"de_ess,omitempty"`    // Soften harsh sibilants
+	Compress bool `json:"compress,omitempty"`  // Even out loud and quiet speech with a compressor
+}
+
+// OutputFormat represents the frame rate and audio sample rate an output is
+// encoded with.
+type OutputFormat struct {
+	FPS        int    `json:"fps,omitempty"`         // Frames per second, e.g. 30 or 60, 0 to keep the rate of the clip
+	SampleRate int    `json:"sample_rate,omitempty"` // Audio sample rate in Hz, e.g. 48000, 0 to keep the rate of the clip
+	Codec      string `json:"codec,omitempty"`       // Video codec: h264 (default), hevc or av1
+	Container  string `json:"container,omitempty"`   // File format: mp4 (default) or webm, which needs the av1 codec
+	Profile    string `json:"profile,omitempty"`     // Encoding profile, e.g. yt-shorts, tiktok or archive
+}
+
+// EncodingProfile represents the encoder settings of a named encoding
+// profile, such as the bitrate ceiling and level a platform expects.
+type EncodingProfile struct {
+	Codec        string `json:"codec"`                   // Video codec: h264, hevc or av1
+	Preset       string `json:"preset,omitempty"`        // Encoder preset, e.g. fast for h264 and hevc or 6 for av1
+	CRF          int    `json:"crf,omitempty"`           // Constant rate factor, lower for better quality
+	MaxRate      string `json:"max_rate,omitempty"`      // Video bitrate ceiling, e.g. 8M, empty for none
+	BufSize      string `json:"buf_size,omitempty"`      // Rate control buffer, defaults to twice max_rate
+	CodecProfile string `json:"codec_profile,omitempty"` // Codec profile, e.g. high for h264 or main for hevc
+	Level        string `json:"level,omitempty"`         // Codec level, e.g. 4.1, for h264 and hevc
+	AudioBitrate string `json:"audio_bitrate,omitempty"` // Audio bitrate, e.g. 128k
+}
+
+// Outputs represents the format of each rendered output of a channel.
+type Outputs struct {
+	Clip       OutputFormat `json:"clip,omitempty"`       // Plain clip cut from the source video
+	Horizontal OutputFormat `json:"horizontal,omitempty"` // Clip composed over the horizontal base
+	Vertical   OutputFormat `json:"vertical,omitempty"`   // Clip composed over the vertical base
+}
+
+// Cover represents the text styling of the generated cover images.
+type Cover struct {
+	BorderWidth  int     `json:"border_width,omitempty"`   // Outline width in pixels, 0 for no outline
+	BorderColor  string  `json:"border_color,omitempty"`   // Outline color, defaults to black
+	ShadowOffset int     `json:"shadow_offset,omitempty"`  // Drop shadow offset in pixels, 0 for no shadow
+	ShadowColor  string  `json:"shadow_color,omitempty"`   // Drop shadow color, defaults to black@0.6
+	Box          bool    `json:"box,omitempty"`            // Draw a semi-transparent box behind the text
+	BoxColor     string  `json:"box_color,omitempty"`      // Box color, defaults to black@0.5
+	BoxPadding   int     `json:"box_padding,omitempty"`    // Space around the text inside the box, defaults to 20
+	Position     string  `json:"position,omitempty"`       // Vertical placement: center (default), top or bottom
+	SafeMargin   float64 `json:"safe_margin,omitempty"`    // Fraction of the height kept clear at the edges, defaults to 0.1
+	WordsPerLine int     `json:"words_per_line,omitempty"` // Words per line the cover text is broken into, defaults to 3
+}
+
+// Captions represents the style of the captions burned into vertical videos.
+// Sizes are relative to a 288 pixel tall frame, the reference height libass
+// uses for SRT subtitles, and scale with the actual video.
+type Captions struct {
+	Font     string `json:"font,omitempty"`      // Font name, defaults to the libass default font
+	FontSize int    `json:"font_size,omitempty"` // Font size, defaults to 12
+	MarginV  int    `json:"margin_v,omitempty"`  // Distance from the bottom edge, defaults to 60
+	Outline  int    `json:"outline,omitempty"`   // Outline width, defaults to 1
+}
+
+// CoverLayer is a single image or text element drawn on a cover template.
+type CoverLayer struct {
+	Type      string `json:"type"`                 // Layer kind: image or text
+	Source    string `json:"source,omitempty"`     // Image file for image layers, e.g. the channel logo
+	Text      string `json:"text,omitempty"`       // Text with {title}, {cut_title}, {hook}, {channel}, {video_id}, {guest}, {episode} and {date} placeholders
+	X         string `json:"x,omitempty"`          // Horizontal position as an ffmpeg expression, centered by default
+	Y         string `json:"y,omitempty"`          // Vertical position as an ffmpeg expression
+	Width     int    `json:"width,omitempty"`      // Width an image is scaled to, keeping its aspect ratio
+	Font      string `json:"font,omitempty"`       // Font file for text layers, defaults to the channel font
+	FontSize  string `json:"font_size,omitempty"`  // Font size for text layers, defaults to the channel font size
+	FontColor string `json:"font_color,omitempty"` // Font color for text layers, defaults to the channel font color
+}
+
+// CoverTemplate describes a cover composed of several layers over a background.
+type CoverTemplate struct {
+	Background string       `json:"background,omitempty"` // Image or video used as background, "clip" for a frame of the cut, defaults to video_cover
+	Layers     []CoverLayer `json:"layers"`               // Layers drawn in order over the background
+}
+
+// Source is an extra YouTube channel or playlist whose videos are processed
+// as part of a channel, for creators splitting content across channels.
+// URL accepts any page yt-dlp can list, such as a Vimeo user or showcase.
+type Source struct {
+	ChannelID  string `json:"channel_id,omitempty"`  // YouTube channel ID
+	PlaylistID string `json:"playlist_id,omitempty"` // YouTube playlist ID, list=... parameter or playlist URL, used instead of ChannelID when set
+	URL        string `json:"url,omitempty"`         // Any yt-dlp supported channel or playlist URL, such as Twitch VODs or a Vimeo channel, used instead of the IDs when set
+	RSS        string `json:"rss,omitempty"`         // Podcast RSS feed whose video episodes are listed
+	Dir        string `json:"dir,omitempty"`         // Local folder whose MP4 files are listed
+	Live       bool   `json:"live,omitempty"`        // List the finished live streams of ChannelID, or of the channel without one, instead of its uploads
+}
+
+// Playlist returns the playlist ID of s, taken from the list parameter when
+// PlaylistID is a playlist URL or a list=... parameter.
+func (s Source) Playlist() string {
+	value := strings.TrimSpace(s.PlaylistID)
+	if !strings.Contains(value, "list=") {
+		return value
+	}
+	if _, query, ok := strings.Cut(value, "?"); ok {
+		value = query
+	}
+	if values, err := url.ParseQuery(value); err == nil && values.Get("list") != "" {
+		return values.Get("list")
+	}
+	return value
 }
 
 // Channel represents configuration for a media channel that the application processes.
 // It contains all necessary information to handle videos from this channel.
 type Channel struct {
-	ID                  string `json:"id"`                    // Unique identifier for the channel
-	Name                string `json:"name"`                  // Display name of the channel
-	ChannelID           string `json:"Channel_id"`            // Platform-specific channel identifier
-	URL                 string `json:"url"`                   // URL to the channel
-	Folder              string `json:"folder"`                // Local folder where channel content is stored
-	VerticalVideoBase   string `json:"video_base_vertical"`   // Base template for vertical video format
-	HorizontalVideoBase string `json:"video_base_horizontal"` // Base template for horizontal video format
-	CoverVideoBase      string `json:"video_cover"`           // Base template for video covers
-	Description         string `json:"description"`           // Channel description
-	LastCheck           string `json:"last_check,omitempty"`  // Timestamp of the last content check
-	Topics              string `json:"topics"`                // Topics or categories for the channel
-	Excerpts            int    `json:"excerpts"`              // Number of excerpts to generate
-	StretchTime         int    `json:"stretch_time"`          // Time to stretch content in seconds
-	VideoLimit          int    `json:"video_limit"`           // Maximum number of videos to process
-	Font                string `json:"font"`                  // Font to use for text overlays
-	FontSize            string `json:"font_size"`             // Font size for text overlays
-	FontColor           string `json:"font_color"`            // Font color for text overlays
-	FontEffect          string `json:"font_effect"`           // Special effects to apply to text
-	UploadToYouTube     bool   `json:"upload_to_youtube"`     // Whether to upload processed videos to YouTube
-	YtdlpFormat         string `json:"ytdlp_format"`          // Format string for yt-dlp
+	ID                  string         `json:"id"`                          // Unique identifier for the channel
+	Name                string         `json:"name"`                        // Display name of the channel
+	Disabled            bool           `json:"disabled,omitempty"`          // Leave the channel out of the commands run for every channel, such as exec and daemon
+	ChannelID           string         `json:"Channel_id"`                  // Platform-specific channel identifier
+	URL                 string         `json:"url"`                         // URL to the channel
+	Sources             []Source       `json:"sources,omitempty"`           // Extra channels, playlists, sites, podcasts and folders merged with the channel feed
+	Folder              string         `json:"folder"`                      // Local folder where channel content is stored
+	VerticalVideoBase   string         `json:"video_base_vertical"`         // Base template for vertical video format
+	HorizontalVideoBase string         `json:"video_base_horizontal"`       // Base template for horizontal video format
+	CoverVideoBase      string         `json:"video_cover"`                 // Base template for video covers
+	Description         string         `json:"description"`                 // Channel description
+	LastCheck           string         `json:"last_check,omitempty"`        // Timestamp of the last content check
+	Topics              string         `json:"topics"`                      // Topics or categories for the channel
+	PromptExtra         string         `json:"prompt_extra,omitempty"`      // Extra instructions appended to the cut prompt
+	Excerpts            int            `json:"excerpts"`                    // Number of excerpts to generate
+	StretchTime         int            `json:"stretch_time"`                // Time to stretch content in seconds
+	VideoLimit          int            `json:"video_limit"`                 // Maximum number of videos to process
+	Font                string         `json:"font"`                        // Font to use for text overlays
+	FontSize            string         `json:"font_size"`                   // Font size for text overlays
+	FontColor           string         `json:"font_color"`                  // Font color for text overlays
+	FontEffect          string         `json:"font_effect"`                 // Special effects to apply to text
+	Cover               Cover          `json:"cover,omitempty"`             // Outline, shadow, box and placement of the cover text
+	CoverTemplate       *CoverTemplate `json:"cover_template,omitempty"`    // Layered cover composition, replacing the single title text
+	CoverText           string         `json:"cover_text,omitempty"`        // Cover text with {cut_title}, {hook}, {guest}, {episode}, {date} and {channel} placeholders, defaults to {cut_title}
+	VerticalCaptions    Captions       `json:"vertical_captions,omitempty"` // Size and position of the captions burned into vertical videos
+	SafeZone            string         `json:"safe_zone,omitempty"`         // Platform interface kept clear in vertical videos: shorts, reels, tiktok or all
+	StillImage          string         `json:"still_image,omitempty"`       // Sources that are audio over a static image: auto to detect them, always, or never (default)
+	Audiogram           *Audiogram     `json:"audiogram,omitempty"`         // Layout of the vertical version of cuts from still-image sources
+	VerticalLayout      string         `json:"vertical_layout,omitempty"`   // Layout of vertical videos: background (default) or split
+	Split               *Split         `json:"split,omitempty"`             // Secondary video of the split layout
+	UploadToYouTube     bool           `json:"upload_to_youtube"`           // Whether to upload processed videos to YouTube
+	YtdlpFormat         string         `json:"ytdlp_format"`                // Format string for yt-dlp
+	YtdlpOptions        YtdlpOptions   `json:"ytdlp_options,omitempty"`     // Extractor arguments, impersonation and extra flags of every yt-dlp call
+	AgeRestricted       bool           `json:"age_restricted,omitempty"`    // Download the age-restricted videos signed in with ytdlp_auth
+	CaptionLanguages    []string       `json:"caption_languages,omitempty"` // Caption languages tried in order, e.g. ["pt", "pt-BR", "en"]; defaults to ["pt"]
+	Downloader          string         `json:"downloader,omitempty"`        // Download backend: ytdlp (default), local or youtube-api
+	SourceDir           string         `json:"source_dir,omitempty"`        // Folder with pre-downloaded files for the local downloader
+	Renderer            string         `json:"renderer,omitempty"`          // Render backend: moviego (default) or ffmpeg
+	VideoFilters        string         `json:"video_filters,omitempty"`     // Extra ffmpeg video filter chain applied to every clip, e.g. eq=saturation=1.2
+	AudioFilters        string         `json:"audio_filters,omitempty"`     // Extra ffmpeg audio filter chain applied to every clip, e.g. loudnorm
+	Audio               Audio          `json:"audio,omitempty"`             // Denoise, de-ess, compression and high-pass applied to every clip
+	LUT                 string         `json:"lut,omitempty"`               // Color grading .cube LUT applied to every clip
+	Outputs             Outputs        `json:"outputs,omitempty"`           // Frame rate and audio sample rate of each output
+	Language            string         `json:"language,omitempty"`          // Output language of titles and metadata, empty to follow the subtitles
+	TitleTemplate       string         `json:"title_template,omitempty"`    // Upload title with {title}, {guest}, {episode} and {date} placeholders
+	CutDetectors        []string       `json:"cut_detectors,omitempty"`     // Cut detection strategies combined in order: llm (default), chapters, interval, keywords or energy
+	Keywords            *Keywords      `json:"keywords,omitempty"`          // Phrases and padding of the keywords cut detector
+	TitleRules          *TitleRules    `json:"title_rules,omitempty"`       // Length, banned words, prefix and capitalization enforced on generated titles
+	Translations        *Translations  `json:"translations,omitempty"`      // Languages the metadata is translated into for localized uploads
+	AI                  *AI            `json:"ai,omitempty"`                // Language model provider of the channel, nil to use the openai block
+	Hashtags            *Hashtags      `json:"hashtags,omitempty"`          // Evergreen hashtags merged with the generated ones, with rotation and per-platform limits
+	IgnoreChapters      bool           `json:"ignore_chapters,omitempty"`   // Do not pass the chapters of the description to the cut prompt
+	UseHeatmap          bool           `json:"use_heatmap,omitempty"`       // Weight cuts towards the "most replayed" peaks of the source video
+	MineComments        bool           `json:"mine_comments,omitempty"`     // Add timestamps mentioned in top comments to the cut prompt
+	DetectReactions     bool           `json:"detect_reactions,omitempty"`  // Favor cuts with the laughter, applause and shouting found in the captions and audio
+	ExcludeMusic        bool           `json:"exclude_music,omitempty"`     // Drop cuts overlapping music sections to avoid Content ID claims
+	PartLength          int            `json:"part_length,omitempty"`       // Split cuts longer than this many seconds into a numbered series, 0 to keep them whole
+	Hook                string         `json:"hook,omitempty"`              // Hook of each cut: detect to add it to the metadata, cold_open to also start the clip with it
+	Pacing              float64        `json:"pacing,omitempty"`            // Speed factor of quiet passages, e.g. 1.3, with pitch-corrected audio; 0 to keep normal speed
+	CaptionsFirst       bool           `json:"captions_first,omitempty"`    // Analyze the captions before downloading the video, skipping videos without cuts
+	MinSpeechRatio      float64        `json:"min_speech_ratio,omitempty"`  // Skip videos whose captions cover less of the video with speech, e.g. 0.3; 0 to process every video
+	MaxRunCost          float64        `json:"max_run_cost,omitempty"`      // OpenAI spending ceiling per run in USD, 0 for no limit
+	MaxMonthlyCost      float64        `json:"max_monthly_cost,omitempty"`  // OpenAI spending ceiling per calendar month in USD, 0 for no limit
+	QualityGate         *QualityGate   `json:"quality_gate,omitempty"`      // Hold back uploads of clips mostly black or frozen, nil to upload without checking
+	Storage             Storage        `json:"storage,omitempty"`           // Destination of the processed outputs
+	Feed                Feed           `json:"feed,omitempty"`              // RSS, Atom and JSON feeds of the rendered clips
+	DigestTo            []string       `json:"digest_to,omitempty"`         // Recipients of the digest of this channel, overriding digest.to
+	EpisodeIndex        *EpisodeIndex  `json:"episode_index,omitempty"`     // Index of the uploaded clips of each source video, nil to write none
+	Schedule            string         `json:"schedule,omitempty"`          // Cron expression of the daemon polls, e.g. "*/30 * * * *" or "@hourly"; defaults to every daemon.interval minutes
+	MaxInFlight         int            `json:"max_in_flight,omitempty"`     // Videos of the channel the daemon processes at once, defaults to 1
+	Timezone            string         `json:"timezone,omitempty"`          // IANA time zone of the schedule and publish hours, e.g. "America/Sao_Paulo"; defaults to the local one
+	Publish             *Publish       `json:"publish,omitempty"`           // Hours the YouTube uploads go public at, nil to upload them as unlisted
+	Community           *Community     `json:"community,omitempty"`         // Community post teasers of the uploaded clips, nil to write none
 }
 
 // Config represents the main application configuration structure.
 // It contains paths to required external tools and application settings.
 type Config struct {
-	YtDlp    string    `json:"ytdlp"`    // Path to the yt-dlp executable
-	FFmpeg   string    `json:"ffmpeg"`   // Path to the FFmpeg executable
-	FFprobe  string    `json:"ffprobe"`  // Path to the FFprobe executable
-	OpenAI   OpenAI    `json:"openai"`   // OpenAI API configuration
-	Channels []Channel `json:"channels"` // List of channels to process
-}
-
-// configInstance holds the singleton instance of loaded configuration
-var configInstance *Config
-
-// loadConfig reads and parses the configuration file from the specified path.
-// It returns a pointer to the Config structure and any error encountered.
-func loadConfig(filePath string) (*Config, error) {
-	file, err := os.ReadFile(filePath)
-	if err != nil {
-		return nil, fmt.Errorf("error reading JSON file: %w", err)
+	YtDlp            string                     `json:"ytdlp"`                       // Path to the yt-dlp executable
+	FFmpeg           string                     `json:"ffmpeg"`                      // Path to the FFmpeg executable
+	FFprobe          string                     `json:"ffprobe"`                     // Path to the FFprobe executable
+	Credentials      string                     `json:"credentials,omitempty"`       // Path to the Google OAuth client file
+	Token            string                     `json:"token,omitempty"`             // Path where the OAuth token is stored
+	Events           string                     `json:"events,omitempty"`            // JSON-lines event destination: file path, tcp:// or unix://
+	State            string                     `json:"state_dir,omitempty"`         // Folder where run state is kept
+	NonInteractive   bool                       `json:"non_interactive,omitempty"`   // Fail instead of prompting, for containers and CI
+	OpenAI           OpenAI                     `json:"openai"`                      // OpenAI API configuration
+	Server           Server                     `json:"server,omitempty"`            // Webhook server of the serve command
+	Queue            Queue                      `json:"queue,omitempty"`             // Broker of the distributed mode
+	Digest           Digest                     `json:"digest,omitempty"`            // Email digest of the processing results
+	Daemon           Daemon                     `json:"daemon,omitempty"`            // Feed polling of the daemon command
+	FeedFetch        FeedFetch                  `json:"feed_fetch,omitempty"`        // User-Agent, headers, timeout and proxy of the RSS feed requests
+	YtdlpAuth        YtdlpAuth                  `json:"ytdlp_auth,omitempty"`        // YouTube account of the downloads of age-restricted videos
+	Parallel         Parallel                   `json:"parallel,omitempty"`          // Channels processed at once and the limits of their downloads and renders
+	Timeouts         Timeouts                   `json:"timeouts,omitempty"`          // Time limits of downloads, ffmpeg calls, uploads and videos
+	Priorities       Priorities                 `json:"priorities,omitempty"`        // CPU and I/O priority of the yt-dlp, ffmpeg and whisper.cpp processes
+	Transcription    Transcription              `json:"transcription,omitempty"`     // Speech recognition of the videos without captions
+	ErrorBudget      ErrorBudget                `json:"error_budget,omitempty"`      // Failed videos that stop a channel or the run
+	EncodingProfiles map[string]EncodingProfile `json:"encoding_profiles,omitempty"` // Encoding profiles by name, added to or replacing the built-in ones
+	Channels         []Channel                  `json:"channels"`                    // List of channels to process
+
+	Dir string `json:"-"` // Folder of the active profile, empty for the working directory
+}
+
+// resolve makes a relative path relative to the profile folder.
+func (c *Config) resolve(path string) string {
+	if c.Dir == "" || filepath.IsAbs(path) {
+		return path
 	}
+	return filepath.Join(c.Dir, path)
+}
 
-	var config Config
-	if err := json.Unmarshal(file, &config); err != nil {
-		return nil, fmt.Errorf("error parsing JSON file: %w", err)
+// CredentialsPath returns the OAuth client file, defaulting to credentials.json.
+func (c *Config) CredentialsPath() string {
+	if c.Credentials == "" {
+		return c.resolve("credentials.json")
 	}
+	return c.resolve(c.Credentials)
+}
 
-	return &config, nil
+// TokenPath returns the OAuth token file, defaulting to youtube-token.json.
+func (c *Config) TokenPath() string {
+	if c.Token == "" {
+		return c.resolve("youtube-token.json")
+	}
+	return c.resolve(c.Token)
 }
 
-// init is automatically called when the package is imported.
-// It loads the configuration from the default location.
-func init() {
-	var err error
-	configInstance, err = loadConfig("config.json")
-	if err != nil {
-		log.Fatalf("Error loading JSON configuration file: %v", err)
+// CookiesPath returns the cookies file of ytdlp_auth, empty when none is set.
+func (c *Config) CookiesPath() string {
+	if c.YtdlpAuth.Cookies == "" {
+		return ""
 	}
+	return c.resolve(c.YtdlpAuth.Cookies)
 }
 
-// GetChannels returns the list of configured channels.
-func GetChannels() []Channel {
-	return configInstance.Channels
+// StateDir returns the folder where run state of the profile is kept, defaulting to state.
+func (c *Config) StateDir() string {
+	if c.State == "" {
+		return c.resolve("state")
+	}
+	return c.resolve(c.State)
 }
 
-// GetYtDlp returns the path to the yt-dlp executable.
-func GetYtDlp() string {
-	return configInstance.YtDlp
+// Enabled returns the channels that are not disabled.
+func (c *Config) Enabled() []Channel {
+	var channels []Channel
+	for _, channel := range c.Channels {
+		if !channel.Disabled {
+			channels = append(channels, channel)
+		}
+	}
+	return channels
 }
 
-// GetFFmpeg returns the path to the FFmpeg executable.
-func GetFFmpeg() string {
-	return configInstance.FFmpeg
+var (
+	configInstance *Config   // Singleton instance of loaded configuration
+	configErr      error     // Error loading configInstance
+	configOnce     sync.Once // Guards the lazy load of configInstance
+	configPath     string    // File loaded by Get, empty to look for one in the working directory
+	configChosen   bool      // Whether configPath was set by UseFile or UseProfile
+	profileDir     string    // Folder of the selected profile, if any
+)
+
+// UseProfile selects the named profile, loading profiles/{name}/config.json
+// (or config.yaml, config.yml or config.toml) instead of the configuration of
+// the working directory. It must be called before the first call to Get.
+func UseProfile(name string) error {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return fmt.Errorf("invalid profile name: %q", name)
+	}
+
+	profileDir = filepath.Join(ProfilesDir, name)
+	configPath = findConfig(profileDir)
+	configChosen = true
+	return nil
 }
 
-// GetFFprobe returns the path to the FFprobe executable.
-func GetFFprobe() string {
-	return configInstance.FFprobe
+// UseFile loads the configuration from path instead of config.json, in the
+// format of its extension. It must be called before the first call to Get.
+func UseFile(path string) {
+	configPath = path
+	configChosen = true
 }
 
-// GetOpenAIKey returns the OpenAI API key.
-func GetOpenAIKey() string {
-	return configInstance.OpenAI.Key
+// Path returns the file Get loads the configuration from: the one selected
+// by UseFile or UseProfile, the file named by FileEnv, or the first of
+// config.json, config.yaml, config.yml and config.toml found.
+func Path() string {
+	if env := os.Getenv(FileEnv); env != "" && !configChosen {
+		return env
+	}
+	if configPath == "" {
+		return findConfig(".")
+	}
+	return configPath
 }
 
-// GetOpenAIModel returns the name of the OpenAI model to use.
-func GetOpenAIModel() string {
-	return configInstance.OpenAI.Model
+// Get returns the configuration, loading it on first use from Path and
+// overriding its settings with the environment variables of ApplyEnv.
+// Packages receive it as a value instead of reading it globally, so several
+// configurations can coexist in one process. Nothing is loaded before the
+// first call, so commands that need no configuration run from any folder.
+func Get() (*Config, error) {
+	configOnce.Do(func() {
+		path := Path()
+		cfg, err := Load(path)
+		if errors.Is(err, fs.ErrNotExist) && !configChosen && os.Getenv(FileEnv) == "" {
+			configErr = fmt.Errorf("no configuration file found in the working directory: copy config.json.example to config.json, or pass --config, --profile or %s", FileEnv)
+			return
+		}
+		if err != nil {
+			configErr = fmt.Errorf("error loading configuration file: %w", err)
+			return
+		}
+		if err := ApplyEnv(cfg); err != nil {
+			configErr = fmt.Errorf("error reading environment variables: %w", err)
+			return
+		}
+		cfg.Dir = profileDir
+		configInstance = cfg
+	})
+	return configInstance, configErr
 }
@@ -0,0 +1,194 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// decodeTOML parses data into its top-level table, with maps, slices and
+// scalars that marshal to JSON. Dates and times marshal as RFC 3339 strings.
+func decodeTOML(data []byte) (map[string]any, error) {
+	document := make(map[string]any)
+	if err := toml.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+	return document, nil
+}
+
+// isBareKey reports whether c may appear in a bare key.
+func isBareKey(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-'
+}
+
+// encodeTOML writes the mapping node of a document as TOML: the plain values
+// of each table first, then its tables and its arrays of tables, keeping the
+// order of the keys. Null values are left out, since TOML has no null. The
+// TOML encoders of libraries sort the keys of maps and follow toml tags, so
+// they would lose the order of the fields of Config.
+func encodeTOML(node *yaml.Node) ([]byte, error) {
+	var out bytes.Buffer
+	if err := encodeTOMLTable(&out, nil, node, false); err != nil {
+		return nil, err
+	}
+	return bytes.TrimLeft(out.Bytes(), "\n"), nil
+}
+
+// encodeTOMLTable writes the table at path. The header is written unless the
+// table is the root, or only holds other tables.
+func encodeTOMLTable(out *bytes.Buffer, path []string, node *yaml.Node, element bool) error {
+	var plain, tables, arrays []int
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		value := node.Content[i+1]
+		switch {
+		case value.Tag == "!!null":
+		case value.Kind == yaml.MappingNode && len(value.Content) > 0:
+			tables = append(tables, i)
+		case isTableArray(value):
+			arrays = append(arrays, i)
+		default:
+			plain = append(plain, i)
+		}
+	}
+
+	if element || len(path) > 0 && (len(plain) > 0 || len(tables)+len(arrays) == 0) {
+		brackets := "[%s]\n"
+		if element {
+			brackets = "[[%s]]\n"
+		}
+		fmt.Fprintf(out, "\n"+brackets, tomlPath(path))
+	}
+	for _, i := range plain {
+		value, err := tomlInline(node.Content[i+1])
+		if err != nil {
+			return fmt.Errorf("%s: %v", tomlPath(append(path, node.Content[i].Value)), err)
+		}
+		fmt.Fprintf(out, "%s = %s\n", tomlKey(node.Content[i].Value), value)
+	}
+	for _, i := range tables {
+		if err := encodeTOMLTable(out, append(slices.Clone(path), node.Content[i].Value), node.Content[i+1], false); err != nil {
+			return err
+		}
+	}
+	for _, i := range arrays {
+		for _, item := range node.Content[i+1].Content {
+			if err := encodeTOMLTable(out, append(slices.Clone(path), node.Content[i].Value), item, true); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// isTableArray reports whether node is a non-empty sequence of mappings.
+func isTableArray(node *yaml.Node) bool {
+	if node.Kind != yaml.SequenceNode || len(node.Content) == 0 {
+		return false
+	}
+	for _, item := range node.Content {
+		if item.Kind != yaml.MappingNode {
+			return false
+		}
+	}
+	return true
+}
+
+// tomlInline returns the inline TOML form of a scalar, sequence or mapping.
+func tomlInline(node *yaml.Node) (string, error) {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		switch node.Tag {
+		case "!!str":
+			return tomlQuote(node.Value), nil
+		case "!!int", "!!float", "!!bool":
+			return node.Value, nil
+		default:
+			return "", fmt.Errorf("unsupported value %q", node.Value)
+		}
+	case yaml.SequenceNode:
+		var items []string
+		for _, item := range node.Content {
+			if item.Tag == "!!null" {
+				return "", fmt.Errorf("null in array")
+			}
+			value, err := tomlInline(item)
+			if err != nil {
+				return "", err
+			}
+			items = append(items, value)
+		}
+		return "[" + strings.Join(items, ", ") + "]", nil
+	case yaml.MappingNode:
+		var pairs []string
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i+1].Tag == "!!null" {
+				continue
+			}
+			value, err := tomlInline(node.Content[i+1])
+			if err != nil {
+				return "", err
+			}
+			pairs = append(pairs, tomlKey(node.Content[i].Value)+" = "+value)
+		}
+		if len(pairs) == 0 {
+			return "{}", nil
+		}
+		return "{ " + strings.Join(pairs, ", ") + " }", nil
+	default:
+		return "", fmt.Errorf("unsupported node")
+	}
+}
+
+// tomlKey returns key bare when possible, quoted otherwise.
+func tomlKey(key string) string {
+	if key == "" {
+		return `""`
+	}
+	for i := 0; i < len(key); i++ {
+		if !isBareKey(key[i]) {
+			return tomlQuote(key)
+		}
+	}
+	return key
+}
+
+// tomlPath returns the dotted header of a table path.
+func tomlPath(path []string) string {
+	keys := make([]string, len(path))
+	for i, key := range path {
+		keys[i] = tomlKey(key)
+	}
+	return strings.Join(keys, ".")
+}
+
+// tomlQuote returns s as a basic string, escaping quotes, backslashes and
+// control characters.
+func tomlQuote(s string) string {
+	var out strings.Builder
+	out.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"', '\\':
+			out.WriteByte('\\')
+			out.WriteRune(r)
+		case '\n':
+			out.WriteString(`\n`)
+		case '\t':
+			out.WriteString(`\t`)
+		case '\r':
+			out.WriteString(`\r`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&out, `\u%04X`, r)
+			} else {
+				out.WriteRune(r)
+			}
+		}
+	}
+	out.WriteByte('"')
+	return out.String()
+}
@@ -0,0 +1,199 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestDecodeTOML(t *testing.T) {
+	tests := []struct {
+		name     string
+		document string
+		want     string // JSON of the decoded table
+	}{
+		{
+			name:     "scalars",
+			document: "name = \"Clips\"\nexcerpts = 5\nprice = 0.15\nupload = true\n",
+			want:     `{"excerpts":5,"name":"Clips","price":0.15,"upload":true}`,
+		},
+		{
+			name:     "tables and dotted keys",
+			document: "[ai]\nmodel = \"gpt-4o\"\nlimits.max_tokens = 100\n\n[ai.retry]\nattempts = 3\n",
+			want:     `{"ai":{"limits":{"max_tokens":100},"model":"gpt-4o","retry":{"attempts":3}}}`,
+		},
+		{
+			name:     "arrays of tables",
+			document: "[[channels]]\nid = \"a\"\n\n[[channels]]\nid = \"b\"\ntopics = [\"tech\", \"games\"]\n",
+			want:     `{"channels":[{"id":"a"},{"id":"b","topics":["tech","games"]}]}`,
+		},
+		{
+			name:     "inline tables",
+			document: "storage = { type = \"s3\", bucket = \"clips\" }\nempty = {}\n",
+			want:     `{"empty":{},"storage":{"bucket":"clips","type":"s3"}}`,
+		},
+		{
+			name:     "strings",
+			document: "basic = \"tab\\there \\\"quoted\\\" \\u00e9\"\nliteral = 'C:\\path'\nmulti = \"\"\"\nline one\nline two\"\"\"\nquoted.\"odd key\" = 1\n",
+			want:     `{"basic":"tab\there \"quoted\" é","literal":"C:\\path","multi":"line one\nline two","quoted":{"odd key":1}}`,
+		},
+		{
+			name:     "comments",
+			document: "# Channel settings\nname = \"Clips\" # inline comment\n",
+			want:     `{"name":"Clips"}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			document, err := decodeTOML([]byte(test.document))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := json.Marshal(document)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != test.want {
+				t.Errorf("got  %s\nwant %s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestDecodeTOMLErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		document string
+	}{
+		{"duplicate key", "name = \"a\"\nname = \"b\"\n"},
+		{"redefined table", "[ai]\nmodel = \"a\"\n[ai]\nmodel = \"b\"\n"},
+		{"missing value", "name =\n"},
+		{"unterminated string", "name = \"clips\n"},
+		{"unclosed header", "[ai\nmodel = \"a\"\n"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := decodeTOML([]byte(test.document)); err == nil {
+				t.Error("decoded without error")
+			}
+		})
+	}
+}
+
+// encodeJSON returns the TOML encodeTOML writes for the JSON object document.
+func encodeJSON(t *testing.T, document string) string {
+	t.Helper()
+	var node yaml.Node
+	if err := yaml.Unmarshal([]byte(document), &node); err != nil {
+		t.Fatal(err)
+	}
+	content, err := encodeTOML(node.Content[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(content)
+}
+
+func TestEncodeTOML(t *testing.T) {
+	tests := []struct {
+		name     string
+		document string
+		want     string
+	}{
+		{
+			name:     "keys keep their order",
+			document: `{"zeta": 1, "alpha": "a", "upload": false}`,
+			want:     "zeta = 1\nalpha = \"a\"\nupload = false\n",
+		},
+		{
+			name:     "plain values before tables",
+			document: `{"ai": {"model": "gpt-4o"}, "name": "Clips"}`,
+			want:     "name = \"Clips\"\n\n[ai]\nmodel = \"gpt-4o\"\n",
+		},
+		{
+			name:     "tables holding only tables have no header",
+			document: `{"outer": {"inner": {"value": 1}}}`,
+			want:     "[outer.inner]\nvalue = 1\n",
+		},
+		{
+			name:     "arrays of tables",
+			document: `{"channels": [{"id": "a"}, {"id": "b", "tags": ["x", "y"]}]}`,
+			want:     "[[channels]]\nid = \"a\"\n\n[[channels]]\nid = \"b\"\ntags = [\"x\", \"y\"]\n",
+		},
+		{
+			name:     "nulls are left out",
+			document: `{"name": null, "ai": {"model": null, "key": "k"}}`,
+			want:     "[ai]\nkey = \"k\"\n",
+		},
+		{
+			name:     "empty tables are inline",
+			document: `{"limits": {}, "outputs": [{}]}`,
+			want:     "limits = {}\n\n[[outputs]]\n",
+		},
+		{
+			name:     "keys and strings are quoted",
+			document: `{"odd key": "line\none \"quoted\" \\ \u0001", "dotted.key": {"é": 1}}`,
+			want:     "\"odd key\" = \"line\\none \\\"quoted\\\" \\\\ \\u0001\"\n\n[\"dotted.key\"]\n\"é\" = 1\n",
+		},
+		{
+			name:     "arrays of scalars and inline tables",
+			document: `{"mixed": [[1, 2], {"a": 1, "b": null}]}`,
+			want:     "mixed = [[1, 2], { a = 1 }]\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := encodeJSON(t, test.document)
+			if got != test.want {
+				t.Errorf("got\n%s\nwant\n%s", got, test.want)
+			}
+			if _, err := decodeTOML([]byte(got)); err != nil {
+				t.Errorf("encoded TOML does not decode: %v", err)
+			}
+		})
+	}
+}
+
+func TestEncodeTOMLRejectsNullInArrays(t *testing.T) {
+	var node yaml.Node
+	if err := yaml.Unmarshal([]byte(`{"tags": ["a", null]}`), &node); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := encodeTOML(node.Content[0]); err == nil || !strings.Contains(err.Error(), "tags") {
+		t.Errorf("error = %v, want one naming the key", err)
+	}
+}
+
+func TestTOMLRoundTripsTheExampleConfiguration(t *testing.T) {
+	content, err := os.ReadFile("../../config.json.example")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want Config
+	if err := decode(content, FormatJSON, &want); err != nil {
+		t.Fatal(err)
+	}
+
+	encoded, err := Encode(&want, FormatTOML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got Config
+	if err := decode(encoded, FormatTOML, &got); err != nil {
+		t.Fatalf("decoding the encoded configuration: %v\n%s", err, encoded)
+	}
+
+	// Empty lists and maps come back as nil because they are omitempty, so
+	// compare the configurations as JSON.
+	gotJSON, _ := json.MarshalIndent(got, "", "  ")
+	wantJSON, _ := json.MarshalIndent(want, "", "  ")
+	if string(gotJSON) != string(wantJSON) {
+		t.Errorf("round trip changed the configuration:\ngot  %s\nwant %s", gotJSON, wantJSON)
+	}
+}
@@ -0,0 +1,186 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// youtubeChannelID matches the IDs of YouTube channels, as in their RSS feeds.
+	youtubeChannelID = regexp.MustCompile(`^UC[A-Za-z0-9_-]{22}$`)
+	// youtubePlaylistID matches the IDs of YouTube playlists, such as PL... or UU...
+	youtubePlaylistID = regexp.MustCompile(`^[A-Za-z0-9_-]{12,}$`)
+)
+
+// ValidationError lists the problems Validate found in a configuration.
+type ValidationError struct {
+	Problems []string // One message per problem, telling how to fix it
+}
+
+// Error returns the problems, one per line.
+func (e *ValidationError) Error() string {
+	if len(e.Problems) == 1 {
+		return e.Problems[0]
+	}
+	return fmt.Sprintf("%d problems:\n  - %s", len(e.Problems), strings.Join(e.Problems, "\n  - "))
+}
+
+// Validate reports the settings of c that keep the pipeline from running:
+// external tools that cannot be found, channels without an ID or a feed,
+// duplicate channel IDs, YouTube channels and playlist IDs in the wrong
+// format, podcast feeds that are not URLs, source folders that do not exist,
+// font files that do not exist, font sizes that are not positive,
+// age-restricted downloads without an account and process priorities out of
+// range.
+// It returns nil or a *ValidationError listing every problem found.
+func (c *Config) Validate() error {
+	var problems []string
+	add := func(format string, args ...any) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	tools := []struct{ name, key, path string }{
+		{"yt-dlp", "ytdlp", c.YtDlp},
+		{"ffmpeg", "ffmpeg", c.FFmpeg},
+		{"ffprobe", "ffprobe", c.FFprobe},
+	}
+	for _, tool := range tools {
+		if tool.path == "" {
+			add("%s is not configured: set %q to the output of `which %s`", tool.name, tool.key, tool.name)
+			continue
+		}
+		if _, err := exec.LookPath(tool.path); err != nil {
+			add("%s not found at %q: install it or set %q to the output of `which %s`", tool.name, tool.path, tool.key, tool.name)
+		}
+	}
+
+	if path := c.CookiesPath(); path != "" {
+		if _, err := os.Stat(path); err != nil {
+			add("ytdlp_auth cookies file %q not found: export the cookies of a signed-in browser in Netscape format", path)
+		}
+	}
+
+	stages := []struct {
+		name     string
+		priority Priority
+	}{
+		{"download", c.Priorities.Download},
+		{"render", c.Priorities.Render},
+		{"transcription", c.Priorities.Transcription},
+	}
+	for _, stage := range stages {
+		priority := stage.priority
+		if priority.Nice < -20 || priority.Nice > 19 {
+			add("priorities.%s has \"nice\" %d: use a niceness from -20 to 19, such as 10", stage.name, priority.Nice)
+		}
+		switch priority.IOClass {
+		case "", "idle", "best-effort", "realtime":
+		default:
+			add("priorities.%s has \"io_class\" %q: use idle, best-effort or realtime", stage.name, priority.IOClass)
+		}
+		if priority.IOLevel < 0 || priority.IOLevel > 7 {
+			add("priorities.%s has \"io_level\" %d: use a level from 1 to 7", stage.name, priority.IOLevel)
+		}
+	}
+
+	if len(c.Channels) == 0 {
+		add("no channels configured: add one to \"channels\", see config.json.example")
+	}
+	ids := make(map[string]bool)
+	for i, channel := range c.Channels {
+		name := fmt.Sprintf("channel %q", channel.ID)
+		switch {
+		case channel.ID == "":
+			name = fmt.Sprintf("channel %d", i+1)
+			add("%s has no \"id\": give it a unique short name, used in the commands and state", name)
+		case ids[channel.ID]:
+			add("%s is configured twice: give each channel its own \"id\"", name)
+		}
+		ids[channel.ID] = true
+
+		if channel.Folder == "" {
+			add("%s has no \"folder\": set the folder its outputs are written to", name)
+		}
+		if channel.ChannelID == "" && len(channel.Sources) == 0 {
+			add("%s has no \"channel_id\" or \"sources\": set the YouTube channel ID it processes", name)
+		} else if channel.ChannelID != "" && !isChannel(channel.ChannelID) {
+			add("%s has an invalid \"channel_id\" %q: use the @handle or URL of the channel, or its ID, which starts with UC and has 24 characters", name, channel.ChannelID)
+		}
+		for _, source := range channel.Sources {
+			switch {
+			case source.URL != "":
+			case source.RSS != "":
+				if !strings.HasPrefix(source.RSS, "http://") && !strings.HasPrefix(source.RSS, "https://") {
+					add("%s has an invalid source \"rss\" %q: use the http or https URL of the podcast feed", name, source.RSS)
+				}
+			case source.Dir != "":
+				if info, err := os.Stat(source.Dir); err != nil || !info.IsDir() {
+					add("%s has a source \"dir\" %q that is not a folder: set the folder holding the videos", name, source.Dir)
+				}
+			case source.PlaylistID != "":
+				if !youtubePlaylistID.MatchString(source.Playlist()) {
+					add("%s has an invalid source \"playlist_id\" %q: use the URL of the playlist or its list= parameter", name, source.PlaylistID)
+				}
+			case source.ChannelID != "":
+				if !isChannel(source.ChannelID) {
+					add("%s has an invalid source \"channel_id\" %q: use the @handle or URL of the channel, or its ID, which starts with UC and has 24 characters", name, source.ChannelID)
+				}
+			case source.Live:
+				if channel.ChannelID == "" {
+					add("%s has a \"live\" source without \"channel_id\": set the channel whose live streams are listed", name)
+				}
+			default:
+				add("%s has a source without \"channel_id\", \"playlist_id\", \"url\", \"rss\", \"dir\" or \"live\"", name)
+			}
+		}
+
+		problems = append(problems, fontProblems(name, "", channel.Font, channel.FontSize)...)
+		if template := channel.CoverTemplate; template != nil {
+			for j, layer := range template.Layers {
+				if layer.Type == "text" {
+					problems = append(problems, fontProblems(name, fmt.Sprintf("cover_template layer %d ", j+1), layer.Font, layer.FontSize)...)
+				}
+			}
+		}
+		if channel.AgeRestricted && c.YtdlpAuth == (YtdlpAuth{}) {
+			add("%s sets \"age_restricted\" without an account: set \"ytdlp_auth\" to a cookies file or browser", name)
+		}
+		if channel.VerticalCaptions.FontSize < 0 {
+			add("%s has a negative vertical_captions \"font_size\": use a positive size, or 0 for the default", name)
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	return &ValidationError{Problems: problems}
+}
+
+// isChannel reports whether value names a YouTube channel: a channel ID, an
+// @handle or a youtube.com URL, which the pipeline resolves to the ID.
+func isChannel(value string) bool {
+	return youtubeChannelID.MatchString(value) || strings.HasPrefix(value, "@") && len(value) > 1 || strings.Contains(strings.ToLower(value), "youtube.com/")
+}
+
+// fontProblems returns the problems of the font file and size of the text
+// drawn for the channel name, prefixing the keys with where.
+func fontProblems(name string, where string, font string, size string) []string {
+	var problems []string
+	if font != "" {
+		if info, err := os.Stat(font); err != nil || info.IsDir() {
+			problems = append(problems, fmt.Sprintf("%s has %s\"font\" %q, which is not a font file: set the path of a .ttf or .otf file, e.g. from `fc-list`", name, where, font))
+		}
+	}
+	if size != "" {
+		if n, err := strconv.ParseFloat(size, 64); err == nil && n <= 0 {
+			problems = append(problems, fmt.Sprintf("%s has %s\"font_size\" %s: use a positive size in pixels", name, where, size))
+		} else if strings.ContainsAny(size, ":,;'") {
+			problems = append(problems, fmt.Sprintf("%s has %s\"font_size\" %q: use a size in pixels or an ffmpeg expression such as h/12", name, where, size))
+		}
+	}
+	return problems
+}
@@ -0,0 +1,153 @@
+// Package events publishes structured lifecycle events of the processing pipeline.
+// Subscribers receive every event through a callback, a Go channel or as
+// JSON lines written to a file or socket, so external tools can follow a run
+// without scraping the console output.
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Type identifies the lifecycle stage an event refers to.
+type Type string
+
+// Event types emitted by the pipeline.
+const (
+	VideoDetected    Type = "video_detected"
+	DownloadStarted  Type = "download_started"
+	DownloadFinished Type = "download_finished"
+	CutsFound        Type = "cuts_found"
+	ClipRendered     Type = "clip_rendered"
+	Uploaded         Type = "uploaded"
+	VideoCompleted   Type = "video_completed"
+	BudgetExceeded   Type = "budget_exceeded"
+	Failed           Type = "failed"
+	CommunityPost    Type = "community_post"
+)
+
+// Event is a single pipeline lifecycle notification.
+type Event struct {
+	Type    Type      `json:"type"`               // Lifecycle stage
+	Time    time.Time `json:"time"`               // When the event happened
+	Channel string    `json:"channel,omitempty"`  // Configured channel ID
+	VideoID string    `json:"video_id,omitempty"` // Source video ID
+	Cut     string    `json:"cut,omitempty"`      // Title of the cut, if any
+	Path    string    `json:"path,omitempty"`     // Produced file, if any
+	Count   int       `json:"count,omitempty"`    // Number of items, e.g. cuts found
+	Code    string    `json:"code,omitempty"`     // Error code for failed events
+	Error   string    `json:"error,omitempty"`    // Error message for failed events
+}
+
+// Bus fans out events to its subscribers. A nil *Bus discards every event,
+// so emitters do not need to check whether events are enabled.
+type Bus struct {
+	mu       sync.Mutex
+	handlers []*func(Event)
+	redact   func(string) string // Removes credentials from the error of the events, nil to keep it
+}
+
+// NewBus returns a Bus without subscribers.
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// Redact makes the bus pass the error of every event through redact before
+// the subscribers see it, so credentials quoted by a failure are not logged.
+func (b *Bus) Redact(redact func(string) string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.redact = redact
+}
+
+// Subscribe registers a callback invoked synchronously for every event.
+// The returned function removes it, for subscribers that go away such as
+// the clients of the server.
+func (b *Bus) Subscribe(handler func(Event)) (unsubscribe func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	entry := &handler
+	b.handlers = append(b.handlers, entry)
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		for i, h := range b.handlers {
+			if h == entry {
+				b.handlers = append(b.handlers[:i:i], b.handlers[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// SubscribeChan forwards every event to ch. Events are dropped when ch is full
+// so a slow consumer never blocks the pipeline.
+func (b *Bus) SubscribeChan(ch chan<- Event) (unsubscribe func()) {
+	return b.Subscribe(func(e Event) {
+		select {
+		case ch <- e:
+		default:
+		}
+	})
+}
+
+// Emit stamps the event with the current time and delivers it to all subscribers.
+func (b *Bus) Emit(e Event) {
+	if b == nil {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.redact != nil && e.Error != "" {
+		e.Error = b.redact(e.Error)
+	}
+	for _, handler := range b.handlers {
+		(*handler)(e)
+	}
+}
+
+// JSONLines returns a handler that writes each event as one JSON line to w.
+// Write errors are ignored so a broken consumer never stops the pipeline.
+func JSONLines(w io.Writer) func(Event) {
+	encoder := json.NewEncoder(w)
+	return func(e Event) {
+		encoder.Encode(e)
+	}
+}
+
+// Open returns the destination for JSON-lines events described by target:
+// "tcp://host:port" or "unix:///path/to.sock" connect to a socket, anything
+// else is treated as a file path opened in append mode.
+func Open(target string) (io.WriteCloser, error) {
+	switch {
+	case strings.HasPrefix(target, "tcp://"):
+		conn, err := net.Dial("tcp", strings.TrimPrefix(target, "tcp://"))
+		if err != nil {
+			return nil, fmt.Errorf("error connecting to events socket: %v", err)
+		}
+		return conn, nil
+	case strings.HasPrefix(target, "unix://"):
+		conn, err := net.Dial("unix", strings.TrimPrefix(target, "unix://"))
+		if err != nil {
+			return nil, fmt.Errorf("error connecting to events socket: %v", err)
+		}
+		return conn, nil
+	default:
+		file, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("error opening events file: %v", err)
+		}
+		return file, nil
+	}
+}
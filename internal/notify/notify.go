@@ -0,0 +1,51 @@
+// Package notify sends email notifications, such as the digest of the
+// processing results of a channel, through an SMTP server or the SendGrid API.
+package notify
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rogersilvasouza/godeogoker/internal/config"
+)
+
+// Message is an email with an HTML body, a plain text alternative and images
+// embedded in the HTML through cid: links.
+type Message struct {
+	To      []string     // Recipients
+	Subject string       // Subject line
+	Text    string       // Plain text body
+	HTML    string       // HTML body
+	Inline  []Attachment // Images referenced by the HTML body
+}
+
+// Attachment is a file embedded in a message.
+type Attachment struct {
+	ContentID   string // ID referenced as cid:{ContentID} in the HTML body
+	Name        string // File name
+	ContentType string // MIME type, such as image/jpeg
+	Data        []byte // File content
+}
+
+// Mailer delivers messages.
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// New returns the Mailer configured in settings.
+func New(settings config.Digest) (Mailer, error) {
+	if settings.From == "" {
+		return nil, fmt.Errorf("digest.from must be set")
+	}
+
+	switch settings.Provider {
+	case "smtp":
+		return NewSMTP(settings)
+	case "sendgrid":
+		return NewSendGrid(settings)
+	case "":
+		return nil, fmt.Errorf("no digest provider configured")
+	default:
+		return nil, fmt.Errorf("unknown digest provider: %s", settings.Provider)
+	}
+}
@@ -0,0 +1,122 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/rogersilvasouza/godeogoker/internal/config"
+)
+
+// SendGridEndpoint is the mail send endpoint of the SendGrid v3 API.
+const SendGridEndpoint = "https://api.sendgrid.com/v3/mail/send"
+
+// SendGrid sends messages through the SendGrid v3 API.
+type SendGrid struct {
+	Key      string       // API key
+	From     string       // Sender address
+	Endpoint string       // Mail send URL
+	Client   *http.Client // HTTP client used for the API calls
+}
+
+// NewSendGrid returns a SendGrid mailer for settings.
+func NewSendGrid(settings config.Digest) (*SendGrid, error) {
+	if settings.SendGridKey == "" {
+		return nil, fmt.Errorf("digest.sendgrid_key must be set")
+	}
+	return &SendGrid{
+		Key:      settings.SendGridKey,
+		From:     settings.From,
+		Endpoint: SendGridEndpoint,
+		Client:   &http.Client{},
+	}, nil
+}
+
+// sendGridAddress is an email address of the API.
+type sendGridAddress struct {
+	Email string `json:"email"`
+}
+
+// sendGridPersonalization lists the recipients of a mail send call.
+type sendGridPersonalization struct {
+	To []sendGridAddress `json:"to"`
+}
+
+// sendGridContent is a body of a mail send call.
+type sendGridContent struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// sendGridRequest is the body of a mail send call.
+type sendGridRequest struct {
+	Personalizations []sendGridPersonalization `json:"personalizations"`
+	From             sendGridAddress           `json:"from"`
+	Subject          string                    `json:"subject"`
+	Content          []sendGridContent         `json:"content"`
+	Attachments      []sendGridAttachment      `json:"attachments,omitempty"`
+}
+
+// sendGridAttachment is an inline image of a mail send call.
+type sendGridAttachment struct {
+	Content     string `json:"content"`
+	Type        string `json:"type"`
+	Filename    string `json:"filename"`
+	Disposition string `json:"disposition"`
+	ContentID   string `json:"content_id"`
+}
+
+// Send delivers msg with a single API call.
+func (s *SendGrid) Send(ctx context.Context, msg Message) error {
+	var recipients sendGridPersonalization
+	for _, to := range msg.To {
+		recipients.To = append(recipients.To, sendGridAddress{Email: to})
+	}
+
+	request := sendGridRequest{
+		Personalizations: []sendGridPersonalization{recipients},
+		From:             sendGridAddress{Email: s.From},
+		Subject:          msg.Subject,
+		Content: []sendGridContent{
+			{Type: "text/plain", Value: msg.Text},
+			{Type: "text/html", Value: msg.HTML},
+		},
+	}
+	for _, attachment := range msg.Inline {
+		request.Attachments = append(request.Attachments, sendGridAttachment{
+			Content:     base64.StdEncoding.EncodeToString(attachment.Data),
+			Type:        attachment.ContentType,
+			Filename:    attachment.Name,
+			Disposition: "inline",
+			ContentID:   attachment.ContentID,
+		})
+	}
+
+	body, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+s.Key)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error calling sendgrid: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("sendgrid returned %s: %s", resp.Status, bytes.TrimSpace(detail))
+	}
+	return nil
+}
@@ -0,0 +1,199 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rogersilvasouza/godeogoker/internal/config"
+)
+
+// DefaultSMTPPort is the submission port used when none is configured.
+const DefaultSMTPPort = 587
+
+// SMTP sends messages through an SMTP server. Port 465 connects with implicit
+// TLS; other ports upgrade with STARTTLS when the server offers it.
+type SMTP struct {
+	Addr string    // host:port of the server
+	Host string    // Server host, checked against its certificate
+	TLS  bool      // Connect with implicit TLS instead of STARTTLS
+	Auth smtp.Auth // Credentials, nil when no user name is configured
+	From string    // Sender address
+}
+
+// NewSMTP returns an SMTP mailer for settings.
+func NewSMTP(settings config.Digest) (*SMTP, error) {
+	if settings.SMTPHost == "" {
+		return nil, fmt.Errorf("digest.smtp_host must be set")
+	}
+
+	port := settings.SMTPPort
+	if port == 0 {
+		port = DefaultSMTPPort
+	}
+
+	m := &SMTP{
+		Addr: net.JoinHostPort(settings.SMTPHost, strconv.Itoa(port)),
+		Host: settings.SMTPHost,
+		TLS:  port == 465,
+		From: settings.From,
+	}
+	if settings.Username != "" {
+		m.Auth = smtp.PlainAuth("", settings.Username, settings.Password, settings.SMTPHost)
+	}
+	return m, nil
+}
+
+// Send delivers msg as a MIME message.
+func (m *SMTP) Send(ctx context.Context, msg Message) error {
+	body, err := m.encode(msg)
+	if err != nil {
+		return err
+	}
+
+	dialer := &net.Dialer{Timeout: 30 * time.Second}
+	var conn net.Conn
+	if m.TLS {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: m.Host}}).DialContext(ctx, "tcp", m.Addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", m.Addr)
+	}
+	if err != nil {
+		return fmt.Errorf("error connecting to smtp server: %v", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, m.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("error connecting to smtp server: %v", err)
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: m.Host}); err != nil {
+			return fmt.Errorf("error starting tls: %v", err)
+		}
+	}
+	if m.Auth != nil {
+		if err := client.Auth(m.Auth); err != nil {
+			return fmt.Errorf("smtp authentication failed: %v", err)
+		}
+	}
+
+	if err := client.Mail(m.From); err != nil {
+		return fmt.Errorf("smtp sender refused: %v", err)
+	}
+	for _, to := range msg.To {
+		if err := client.Rcpt(to); err != nil {
+			return fmt.Errorf("smtp recipient %s refused: %v", to, err)
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("error sending message: %v", err)
+	}
+	if _, err := w.Write(body); err != nil {
+		return fmt.Errorf("error sending message: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("error sending message: %v", err)
+	}
+
+	return client.Quit()
+}
+
+// encode builds the MIME message: a multipart/alternative with the plain text
+// body and a multipart/related holding the HTML body and its inline images.
+func (m *SMTP) encode(msg Message) ([]byte, error) {
+	var buf bytes.Buffer
+	alternative := multipart.NewWriter(&buf)
+
+	fmt.Fprintf(&buf, "From: %s\r\n", m.From)
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(msg.To, ", "))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&buf, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", alternative.Boundary())
+
+	if err := writeQuotedPrintable(alternative, "text/plain; charset=utf-8", msg.Text); err != nil {
+		return nil, err
+	}
+
+	var related bytes.Buffer
+	relatedWriter := multipart.NewWriter(&related)
+	if err := writeQuotedPrintable(relatedWriter, "text/html; charset=utf-8", msg.HTML); err != nil {
+		return nil, err
+	}
+	for _, attachment := range msg.Inline {
+		part, err := relatedWriter.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {fmt.Sprintf("%s; name=%q", attachment.ContentType, attachment.Name)},
+			"Content-Transfer-Encoding": {"base64"},
+			"Content-Disposition":       {fmt.Sprintf("inline; filename=%q", attachment.Name)},
+			"Content-ID":                {"<" + attachment.ContentID + ">"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		writeBase64(part, attachment.Data)
+	}
+	if err := relatedWriter.Close(); err != nil {
+		return nil, err
+	}
+
+	part, err := alternative.CreatePart(textproto.MIMEHeader{
+		"Content-Type": {"multipart/related; boundary=" + relatedWriter.Boundary()},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := part.Write(related.Bytes()); err != nil {
+		return nil, err
+	}
+
+	if err := alternative.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeQuotedPrintable adds a text part of contentType to w.
+func writeQuotedPrintable(w *multipart.Writer, contentType string, text string) error {
+	part, err := w.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {contentType},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	if err != nil {
+		return err
+	}
+	qp := quotedprintable.NewWriter(part)
+	if _, err := qp.Write([]byte(text)); err != nil {
+		return err
+	}
+	return qp.Close()
+}
+
+// writeBase64 writes data base64 encoded in lines of 76 characters.
+func writeBase64(w io.Writer, data []byte) {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 76 {
+		fmt.Fprintf(w, "%s\r\n", encoded[:76])
+		encoded = encoded[76:]
+	}
+	fmt.Fprintf(w, "%s\r\n", encoded)
+}
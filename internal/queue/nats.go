@@ -0,0 +1,148 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// natsAckWait is how long a claimed job may go without a progress signal
+// before JetStream hands it to another worker.
+const natsAckWait = 5 * time.Minute
+
+// NATS is a queue kept in a JetStream work-queue stream. Claimed jobs are
+// kept alive with progress signals while they are processed, so a job whose
+// worker died is redelivered once natsAckWait passes.
+type NATS struct {
+	conn     *nats.Conn
+	js       jetstream.JetStream
+	consumer jetstream.Consumer
+	subject  string
+}
+
+// NewNATS connects to the NATS server at url and creates the stream and the
+// durable consumer shared by the workers when they do not exist yet.
+func NewNATS(ctx context.Context, url string, subject string) (*NATS, error) {
+	conn, err := nats.Connect(url)
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to nats: %v", err)
+	}
+
+	js, err := jetstream.New(conn)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("error opening jetstream: %v", err)
+	}
+
+	stream := streamName(subject)
+	_, err = js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:      stream,
+		Subjects:  []string{subject},
+		Retention: jetstream.WorkQueuePolicy,
+	})
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("error creating stream %s: %v", stream, err)
+	}
+
+	consumer, err := js.CreateOrUpdateConsumer(ctx, stream, jetstream.ConsumerConfig{
+		Durable:   "workers",
+		AckPolicy: jetstream.AckExplicitPolicy,
+		AckWait:   natsAckWait,
+	})
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("error creating consumer: %v", err)
+	}
+
+	return &NATS{conn: conn, js: js, consumer: consumer, subject: subject}, nil
+}
+
+// streamName derives a valid stream name from the subject of the queue.
+func streamName(subject string) string {
+	return strings.ToUpper(strings.NewReplacer(".", "_", "*", "_", ">", "_").Replace(subject))
+}
+
+// Enqueue publishes job to the stream.
+func (q *NATS) Enqueue(ctx context.Context, job Job) error {
+	payload, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	_, err = q.js.Publish(ctx, q.subject, payload)
+	return err
+}
+
+// Claim fetches the next job from the shared consumer.
+func (q *NATS) Claim(ctx context.Context) (Claim, error) {
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		batch, err := q.consumer.Fetch(1, jetstream.FetchMaxWait(claimTimeout))
+		if err != nil {
+			return nil, fmt.Errorf("error claiming job: %v", err)
+		}
+
+		for msg := range batch.Messages() {
+			var job Job
+			if err := json.Unmarshal(msg.Data(), &job); err != nil {
+				msg.Term()
+				return nil, fmt.Errorf("error parsing job %q: %v", msg.Data(), err)
+			}
+			return newNATSClaim(msg, job), nil
+		}
+	}
+}
+
+// Close drains the connection.
+func (q *NATS) Close() error {
+	return q.conn.Drain()
+}
+
+// natsClaim is a delivered message kept alive until it is acknowledged or released.
+type natsClaim struct {
+	msg  jetstream.Msg
+	job  Job
+	done chan struct{}
+}
+
+// newNATSClaim starts the progress signals of msg.
+func newNATSClaim(msg jetstream.Msg, job Job) *natsClaim {
+	c := &natsClaim{msg: msg, job: job, done: make(chan struct{})}
+	go func() {
+		ticker := time.NewTicker(natsAckWait / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-c.done:
+				return
+			case <-ticker.C:
+				msg.InProgress()
+			}
+		}
+	}()
+	return c
+}
+
+func (c *natsClaim) Job() Job {
+	return c.job
+}
+
+// Ack removes the job from the stream.
+func (c *natsClaim) Ack(ctx context.Context) error {
+	close(c.done)
+	return c.msg.DoubleAck(ctx)
+}
+
+// Release asks JetStream to deliver the job again.
+func (c *natsClaim) Release(ctx context.Context) error {
+	close(c.done)
+	return c.msg.Nak()
+}
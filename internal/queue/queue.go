@@ -0,0 +1,71 @@
+// Package queue distributes video jobs between machines. A coordinator
+// enqueues the new videos of every channel and any number of workers, sharing
+// the same object storage, claim and process them one at a time. NATS
+// JetStream and Redis are supported as brokers.
+package queue
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/rogersilvasouza/godeogoker/internal/config"
+	"github.com/rogersilvasouza/godeogoker/internal/videos"
+)
+
+// DefaultName is the subject or key of the queue when none is configured.
+const DefaultName = "godeogoker.jobs"
+
+// Job is a video of a channel waiting to be processed. The whole feed entry
+// travels with the job so workers can download videos from any source.
+type Job struct {
+	Channel string       `json:"channel"` // Channel ID from the configuration
+	Video   videos.Video `json:"video"`   // Video to process
+}
+
+// Claim is a job taken by a worker. It stays invisible to other workers until
+// it is acknowledged, or returns to the queue when released or when the worker dies.
+type Claim interface {
+	Job() Job
+	// Ack removes the job from the queue once it was processed.
+	Ack(ctx context.Context) error
+	// Release returns the job to the queue for another worker.
+	Release(ctx context.Context) error
+}
+
+// Queue is a distributed job queue.
+type Queue interface {
+	// Enqueue adds a job at the end of the queue.
+	Enqueue(ctx context.Context, job Job) error
+	// Claim waits until a job is available or ctx is cancelled.
+	Claim(ctx context.Context) (Claim, error)
+	// Close releases the connection to the broker.
+	Close() error
+}
+
+// Open connects to the queue configured in settings.
+func Open(ctx context.Context, settings config.Queue) (Queue, error) {
+	name := settings.Name
+	if name == "" {
+		name = DefaultName
+	}
+
+	switch settings.Type {
+	case "nats":
+		return NewNATS(ctx, settings.URL, name)
+	case "redis":
+		worker := settings.Worker
+		if worker == "" {
+			host, err := os.Hostname()
+			if err != nil {
+				return nil, fmt.Errorf("error reading hostname for the worker ID: %v", err)
+			}
+			worker = host
+		}
+		return NewRedis(ctx, settings.URL, name, worker)
+	case "":
+		return nil, fmt.Errorf("no queue configured")
+	default:
+		return nil, fmt.Errorf("unknown queue type: %s", settings.Type)
+	}
+}
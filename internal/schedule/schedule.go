@@ -0,0 +1,205 @@
+// Package schedule computes when recurring work is due, from standard
+// five-field cron expressions or fixed intervals.
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule returns the times a recurring task runs.
+type Schedule interface {
+	// Next returns the first time after t the task runs.
+	Next(t time.Time) time.Time
+}
+
+// descriptors are the cron shorthands accepted by Parse.
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// field is the range of values of a cron field.
+type field struct {
+	name     string
+	min, max int
+}
+
+// fields are the five fields of a cron expression, in order.
+var fields = []field{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 6},
+}
+
+// Interval runs every d, counted from the previous run.
+type Interval time.Duration
+
+// Next returns t plus the interval.
+func (i Interval) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(i))
+}
+
+// zoned evaluates a schedule in a time zone.
+type zoned struct {
+	Schedule
+	loc *time.Location
+}
+
+// In returns s evaluated in the time zone loc, so the hours of a cron
+// expression are those of loc rather than of the local time zone.
+func In(s Schedule, loc *time.Location) Schedule {
+	return zoned{Schedule: s, loc: loc}
+}
+
+// Next returns the first time after t the schedule runs in its time zone.
+func (z zoned) Next(t time.Time) time.Time {
+	return z.Schedule.Next(t.In(z.loc))
+}
+
+// Cron is a parsed cron expression, in the time zone of the times given to Next.
+type Cron struct {
+	minute, hour, dom, month, dow uint64 // Bit i set when value i matches
+	anyDOM, anyDOW                bool   // Whether the day fields started with *
+}
+
+// Parse parses a cron expression: five fields (minute, hour, day of month,
+// month and day of week, with *, lists, ranges and /steps), a shorthand such
+// as @hourly or @daily, or @every followed by a duration such as 30m.
+func Parse(expr string) (Schedule, error) {
+	expr = strings.TrimSpace(expr)
+	if every, ok := strings.CutPrefix(expr, "@every "); ok {
+		d, err := time.ParseDuration(strings.TrimSpace(every))
+		if err != nil || d < time.Minute {
+			return nil, fmt.Errorf("invalid interval %q, use a duration of at least 1m", every)
+		}
+		return Interval(d), nil
+	}
+	if full, ok := descriptors[expr]; ok {
+		expr = full
+	}
+
+	parts := strings.Fields(expr)
+	if len(parts) != len(fields) {
+		return nil, fmt.Errorf("invalid cron expression %q: expected 5 fields, got %d", expr, len(parts))
+	}
+
+	var values [5]uint64
+	for i, part := range parts {
+		bits, err := parseField(part, fields[i])
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron expression %q: %v", expr, err)
+		}
+		values[i] = bits
+	}
+	// 7 is also Sunday.
+	if values[4]&(1<<7) != 0 {
+		values[4] |= 1
+	}
+
+	return &Cron{
+		minute: values[0],
+		hour:   values[1],
+		dom:    values[2],
+		month:  values[3],
+		dow:    values[4],
+		anyDOM: strings.HasPrefix(parts[2], "*"),
+		anyDOW: strings.HasPrefix(parts[4], "*"),
+	}, nil
+}
+
+// parseField returns the values matched by a comma-separated list of *,
+// single values and ranges, each with an optional /step.
+func parseField(text string, f field) (uint64, error) {
+	highest := f.max
+	if f.name == "day of week" {
+		highest = 7
+	}
+
+	var bits uint64
+	for _, item := range strings.Split(text, ",") {
+		spec, stepText, hasStep := strings.Cut(item, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepText)
+			if err != nil || n < 1 {
+				return 0, fmt.Errorf("invalid step %q in %s field", stepText, f.name)
+			}
+			step = n
+		}
+
+		low, high := f.min, highest
+		if spec != "*" {
+			lowText, highText, isRange := strings.Cut(spec, "-")
+			var err error
+			if low, err = strconv.Atoi(lowText); err != nil {
+				return 0, fmt.Errorf("invalid value %q in %s field", spec, f.name)
+			}
+			high = low
+			if isRange {
+				if high, err = strconv.Atoi(highText); err != nil {
+					return 0, fmt.Errorf("invalid value %q in %s field", spec, f.name)
+				}
+			} else if hasStep {
+				high = highest
+			}
+		}
+		if low < f.min || high > highest || low > high {
+			return 0, fmt.Errorf("value %q out of range %d-%d in %s field", spec, f.min, highest, f.name)
+		}
+
+		for v := low; v <= high; v += step {
+			bits |= 1 << v
+		}
+	}
+	return bits, nil
+}
+
+// Next returns the first minute after t matching the expression, or the zero
+// time when none does within five years, such as for February 30.
+func (c *Cron) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		switch {
+		case c.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !c.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case c.hour&(1<<uint(t.Hour())) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case c.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// dayMatches reports whether the day of t matches. As in cron, when both day
+// fields are restricted a day matching either of them runs.
+func (c *Cron) dayMatches(t time.Time) bool {
+	dom := c.dom&(1<<uint(t.Day())) != 0
+	dow := c.dow&(1<<uint(t.Weekday())) != 0
+	switch {
+	case c.anyDOM && c.anyDOW:
+		return true
+	case c.anyDOM:
+		return dow
+	case c.anyDOW:
+		return dom
+	default:
+		return dom || dow
+	}
+}
@@ -0,0 +1,156 @@
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"strings"
+	"time"
+
+	pipelinev1 "github.com/rogersilvasouza/godeogoker/api/pipeline/v1"
+	"github.com/rogersilvasouza/godeogoker/internal/events"
+	"github.com/rogersilvasouza/godeogoker/internal/videos"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// eventBuffer is the number of events kept for a slow gRPC stream before
+// newer ones are dropped.
+const eventBuffer = 256
+
+// grpcService implements the Pipeline gRPC service on top of the server queue.
+type grpcService struct {
+	pipelinev1.UnimplementedPipelineServer
+	server *Server
+}
+
+// newGRPCServer returns a gRPC server exposing the Pipeline service. Every
+// call must carry the webhook secret as an "authorization: Bearer" token.
+func (s *Server) newGRPCServer() *grpc.Server {
+	grpcServer := grpc.NewServer(
+		grpc.UnaryInterceptor(func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+			if err := s.authorize(ctx); err != nil {
+				return nil, err
+			}
+			return handler(ctx, req)
+		}),
+		grpc.StreamInterceptor(func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			if err := s.authorize(stream.Context()); err != nil {
+				return err
+			}
+			return handler(srv, stream)
+		}),
+	)
+	pipelinev1.RegisterPipelineServer(grpcServer, &grpcService{server: s})
+	return grpcServer
+}
+
+// authorize checks the bearer token of an incoming call against the secret.
+func (s *Server) authorize(ctx context.Context) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, value := range md.Get("authorization") {
+		token, ok := strings.CutPrefix(value, "Bearer ")
+		if ok && subtle.ConstantTimeCompare([]byte(token), s.Secret) == 1 {
+			return nil
+		}
+	}
+	return status.Error(codes.Unauthenticated, "invalid or missing bearer token")
+}
+
+// SubmitJob enqueues a video like the webhook does.
+func (g *grpcService) SubmitJob(ctx context.Context, req *pipelinev1.SubmitJobRequest) (*pipelinev1.SubmitJobResponse, error) {
+	position, err := g.server.enqueue(Job{Channel: req.GetChannel(), VideoID: req.GetVideoId()})
+	switch {
+	case errors.Is(err, ErrQueueFull):
+		return nil, status.Error(codes.ResourceExhausted, err.Error())
+	case err != nil:
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return &pipelinev1.SubmitJobResponse{Position: int32(position)}, nil
+}
+
+// StreamEvents forwards the pipeline events matching the request filters
+// until the client cancels or the server stops.
+func (g *grpcService) StreamEvents(req *pipelinev1.StreamEventsRequest, stream grpc.ServerStreamingServer[pipelinev1.Event]) error {
+	ch := make(chan events.Event, eventBuffer)
+	unsubscribe := g.server.Client.Events.SubscribeChan(ch)
+	defer unsubscribe()
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case <-g.server.stopping:
+			return nil
+		case e := <-ch:
+			if req.GetChannel() != "" && e.Channel != req.GetChannel() {
+				continue
+			}
+			if req.GetVideoId() != "" && e.VideoID != req.GetVideoId() {
+				continue
+			}
+			err := stream.Send(&pipelinev1.Event{
+				Type:    string(e.Type),
+				Time:    e.Time.Format(time.RFC3339Nano),
+				Channel: e.Channel,
+				VideoId: e.VideoID,
+				Cut:     e.Cut,
+				Path:    e.Path,
+				Count:   int32(e.Count),
+				Code:    e.Code,
+				Error:   e.Error,
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// GetResults lists the rendered clips of a channel, newest first.
+func (g *grpcService) GetResults(ctx context.Context, req *pipelinev1.GetResultsRequest) (*pipelinev1.GetResultsResponse, error) {
+	channel, ok := g.server.channel(req.GetChannel())
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "unknown channel: %s", req.GetChannel())
+	}
+
+	clips, err := videos.ListClips(channel.Folder)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "error listing clips: %v", err)
+	}
+
+	store := g.server.Client.State
+	response := &pipelinev1.GetResultsResponse{}
+	for _, clip := range clips {
+		if req.GetVideoId() != "" && clip.Source.ID != req.GetVideoId() {
+			continue
+		}
+
+		result := &pipelinev1.Clip{
+			VideoId:     clip.Source.ID,
+			Title:       clip.Metadata.Title,
+			Description: clip.Metadata.Description,
+			Tags:        clip.Metadata.Tags,
+			Path:        videos.OutputKey(channel.Folder, clip.Clip),
+			Rendered:    clip.Rendered.Format(time.RFC3339),
+		}
+		if channel.Feed.BaseURL != "" {
+			result.Link = videos.PublicLink(channel, clip.Clip)
+		}
+		if clip.Vertical != "" {
+			result.VerticalPath = videos.OutputKey(channel.Folder, clip.Vertical)
+		}
+		if clip.Cover != "" {
+			result.CoverPath = videos.OutputKey(channel.Folder, clip.Cover)
+		}
+		if store != nil {
+			result.Uploaded = store.Uploaded(channel.ID, result.Path) ||
+				(result.VerticalPath != "" && store.Uploaded(channel.ID, result.VerticalPath))
+		}
+		response.Clips = append(response.Clips, result)
+	}
+
+	return response, nil
+}
@@ -0,0 +1,139 @@
+package server
+
+import (
+	"net/http"
+	"os/exec"
+	"time"
+
+	"github.com/rogersilvasouza/godeogoker/internal/auth"
+)
+
+// healthReport is the JSON answer of the health and readiness endpoints.
+type healthReport struct {
+	Status   string            `json:"status"`             // ok, not_ready or stopping
+	Problems []string          `json:"problems,omitempty"` // Why the server is not ready
+	Tools    map[string]string `json:"tools"`              // Availability of each external tool: ok or the error
+	Token    tokenHealth       `json:"token"`              // YouTube token state
+	Queue    queueHealth       `json:"queue"`              // Jobs waiting to be processed
+	Channels []channelHealth   `json:"channels"`           // Last successful run per channel
+}
+
+// tokenHealth describes the saved YouTube token.
+type tokenHealth struct {
+	Required bool       `json:"required"`         // Whether a channel uploads to YouTube
+	Valid    bool       `json:"valid"`            // Whether the token can be used now
+	Expiry   *time.Time `json:"expiry,omitempty"` // When the token expires
+	Error    string     `json:"error,omitempty"`  // Why the token cannot be used
+}
+
+// queueHealth describes the job queue.
+type queueHealth struct {
+	Depth    int `json:"depth"`    // Jobs waiting
+	Capacity int `json:"capacity"` // Jobs accepted before requests are refused
+}
+
+// channelHealth describes a configured channel.
+type channelHealth struct {
+	ID          string     `json:"id"`                     // Channel ID from the configuration
+	LastSuccess *time.Time `json:"last_success,omitempty"` // Last video processed without failures
+}
+
+// handleHealth answers liveness probes: 200 with the health report while the
+// server runs, whatever the state of its dependencies, and 503 once it stops.
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	report := s.health()
+	report.Status = "ok"
+
+	select {
+	case <-s.stopping:
+		report.Status = "stopping"
+		writeJSON(w, http.StatusServiceUnavailable, report)
+	default:
+		writeJSON(w, http.StatusOK, report)
+	}
+}
+
+// handleReady answers readiness probes: 200 when jobs can be accepted and
+// processed, 503 with the problems otherwise.
+func (s *Server) handleReady(w http.ResponseWriter, r *http.Request) {
+	report := s.health()
+
+	select {
+	case <-s.stopping:
+		report.Problems = append(report.Problems, "server is stopping")
+	default:
+	}
+
+	if len(report.Problems) > 0 {
+		report.Status = "not_ready"
+		writeJSON(w, http.StatusServiceUnavailable, report)
+		return
+	}
+	report.Status = "ok"
+	writeJSON(w, http.StatusOK, report)
+}
+
+// health checks the external tools, the YouTube token and the queue, and
+// collects the last successful run of each channel.
+func (s *Server) health() healthReport {
+	cfg := s.Config
+	report := healthReport{
+		Tools: make(map[string]string),
+		Queue: queueHealth{Depth: len(s.queue), Capacity: cap(s.queue)},
+	}
+
+	tools := []struct{ name, path string }{
+		{"yt-dlp", cfg.YtDlp},
+		{"ffmpeg", cfg.FFmpeg},
+		{"ffprobe", cfg.FFprobe},
+	}
+	for _, tool := range tools {
+		if tool.path == "" {
+			report.Tools[tool.name] = "not configured"
+			report.Problems = append(report.Problems, tool.name+" is not configured")
+			continue
+		}
+		if _, err := exec.LookPath(tool.path); err != nil {
+			report.Tools[tool.name] = err.Error()
+			report.Problems = append(report.Problems, tool.name+" is not available")
+			continue
+		}
+		report.Tools[tool.name] = "ok"
+	}
+
+	for _, channel := range cfg.Channels {
+		if channel.UploadToYouTube {
+			report.Token.Required = true
+		}
+
+		entry := channelHealth{ID: channel.ID}
+		if s.Client != nil && s.Client.State != nil {
+			if last := s.Client.State.LastRun(channel.ID); !last.IsZero() {
+				entry.LastSuccess = &last
+			}
+		}
+		report.Channels = append(report.Channels, entry)
+	}
+
+	token, err := auth.GetClient(cfg)
+	switch {
+	case err != nil:
+		report.Token.Error = err.Error()
+	case !token.Valid():
+		report.Token.Error = "token expired, run 'godeogoker login' again"
+	default:
+		report.Token.Valid = true
+	}
+	if err == nil && !token.Expiry.IsZero() {
+		report.Token.Expiry = &token.Expiry
+	}
+	if report.Token.Required && !report.Token.Valid {
+		report.Problems = append(report.Problems, "youtube token: "+report.Token.Error)
+	}
+
+	if report.Queue.Depth >= report.Queue.Capacity {
+		report.Problems = append(report.Problems, "queue is full")
+	}
+
+	return report
+}
@@ -0,0 +1,311 @@
+// Package server runs godeogoker as a long-lived service. External systems
+// such as chat bots enqueue videos through a webhook signed with a shared
+// secret or through the gRPC API, and a single worker processes the queued
+// videos in order. Health and readiness endpoints serve supervisor and
+// orchestrator probes.
+package server
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/rogersilvasouza/godeogoker/internal/config"
+	"github.com/rogersilvasouza/godeogoker/internal/events"
+	"github.com/rogersilvasouza/godeogoker/internal/videos"
+	"google.golang.org/grpc"
+)
+
+// Define styles for the server output
+var (
+	subtitleStyle = lipgloss.NewStyle().
+			Italic(true).
+			Foreground(lipgloss.Color("#5F87FF"))
+
+	descriptionStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("#D7D7D7"))
+
+	successStyle = lipgloss.NewStyle().
+			Bold(true).
+			Foreground(lipgloss.Color("#00FF00"))
+
+	errorStyle = lipgloss.NewStyle().
+			Bold(true).
+			Foreground(lipgloss.Color("#FF0000"))
+)
+
+// Defaults applied when the server settings leave them empty.
+const (
+	DefaultAddr      = ":8080"
+	DefaultQueueSize = 100
+)
+
+// SignatureHeader carries the HMAC-SHA256 of the request body, hex encoded and
+// prefixed with "sha256=", the format used by GitHub webhooks.
+const SignatureHeader = "X-Signature-256"
+
+// maxBodySize limits the size of webhook requests.
+const maxBodySize = 1 << 20
+
+// videoIDPattern accepts YouTube video IDs and the IDs yt-dlp reports for other sites.
+var videoIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// Errors returned when a job cannot be enqueued.
+var (
+	ErrUnknownChannel = errors.New("unknown channel")
+	ErrInvalidVideoID = errors.New("invalid video_id")
+	ErrQueueFull      = errors.New("queue full")
+)
+
+// Job is a request to process a single video of a channel.
+type Job struct {
+	Channel string `json:"channel"`  // Channel ID from the configuration
+	VideoID string `json:"video_id"` // Video to process
+}
+
+// Server accepts jobs over HTTP and gRPC and processes them one at a time.
+type Server struct {
+	Config   *config.Config // Application configuration
+	Client   *videos.Client // Client shared by every job
+	Addr     string         // Listen address
+	GRPCAddr string         // Listen address of the gRPC API, empty to disable it
+	Secret   []byte         // Shared secret of the webhook signatures and gRPC tokens
+	queue    chan Job       // Jobs waiting to be processed
+	mux      *http.ServeMux // Routes of the server
+	stopping chan struct{}  // Closed when the server shuts down
+}
+
+// New returns a server for cfg. An empty addr or grpcAddr uses the configured
+// address. The webhook secret is required so that nobody else can enqueue videos.
+// Pipeline events are always published on the client bus so gRPC clients can follow them.
+func New(cfg *config.Config, client *videos.Client, addr string, grpcAddr string) (*Server, error) {
+	settings := cfg.Server
+	if settings.WebhookSecret == "" {
+		return nil, fmt.Errorf("server.webhook_secret must be set")
+	}
+
+	if addr == "" {
+		addr = settings.Addr
+	}
+	if addr == "" {
+		addr = DefaultAddr
+	}
+
+	if grpcAddr == "" {
+		grpcAddr = settings.GRPCAddr
+	}
+
+	queueSize := settings.QueueSize
+	if queueSize <= 0 {
+		queueSize = DefaultQueueSize
+	}
+
+	if client != nil && client.Events == nil {
+		client.Events = events.NewBus()
+		client.Events.Redact(cfg.Redact)
+	}
+
+	s := &Server{
+		Config:   cfg,
+		Client:   client,
+		Addr:     addr,
+		GRPCAddr: grpcAddr,
+		Secret:   []byte(settings.WebhookSecret),
+		queue:    make(chan Job, queueSize),
+		mux:      http.NewServeMux(),
+		stopping: make(chan struct{}),
+	}
+	s.mux.HandleFunc("/webhook", s.handleWebhook)
+	s.mux.HandleFunc("/healthz", s.handleHealth)
+	s.mux.HandleFunc("/readyz", s.handleReady)
+	return s, nil
+}
+
+// Run serves requests and processes the queued jobs until ctx is cancelled.
+// In-flight requests are given a few seconds to finish, event streams are
+// closed, and the job being processed stops like an interrupted exec run,
+// staying resumable.
+func (s *Server) Run(ctx context.Context) error {
+	httpServer := &http.Server{
+		Addr:              s.Addr,
+		Handler:           s.mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer videos.RemoveTempFilesOnPanic()
+		defer close(done)
+		s.work(ctx)
+	}()
+
+	errs := make(chan error, 2)
+	go func() {
+		fmt.Println(successStyle.Render("Listening on " + s.Addr))
+		errs <- httpServer.ListenAndServe()
+	}()
+
+	var grpcServer *grpc.Server
+	if s.GRPCAddr != "" {
+		listener, err := net.Listen("tcp", s.GRPCAddr)
+		if err != nil {
+			httpServer.Close()
+			return fmt.Errorf("error listening for gRPC: %v", err)
+		}
+		grpcServer = s.newGRPCServer()
+		go func() {
+			fmt.Println(successStyle.Render("gRPC API listening on " + s.GRPCAddr))
+			errs <- grpcServer.Serve(listener)
+		}()
+	}
+
+	var runErr error
+	select {
+	case runErr = <-errs:
+	case <-ctx.Done():
+	}
+	close(s.stopping)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err := httpServer.Shutdown(shutdownCtx)
+	if grpcServer != nil {
+		grpcServer.GracefulStop()
+	}
+	<-done
+
+	if runErr != nil {
+		return runErr
+	}
+
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// work processes the queued jobs in order until ctx is cancelled.
+func (s *Server) work(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case job := <-s.queue:
+			s.process(ctx, job)
+		}
+	}
+}
+
+// process runs the pipeline for the video of job, as exec -v= would.
+func (s *Server) process(ctx context.Context, job Job) {
+	channel, ok := s.channel(job.Channel)
+	if !ok {
+		return
+	}
+
+	fmt.Println(subtitleStyle.Render(fmt.Sprintf("Processing queued video %s of channel %s", job.VideoID, channel.Name)))
+	channel.ChannelID = "v=" + job.VideoID
+	if err := videos.DownloadVideo(ctx, s.Client, channel, videos.StageOptions{}); err != nil {
+		fmt.Println(errorStyle.Render(fmt.Sprintf("Error processing queued video %s: %v", job.VideoID, err)))
+	}
+}
+
+// channel returns the configured channel with the given ID.
+func (s *Server) channel(id string) (config.Channel, bool) {
+	for _, channel := range s.Config.Channels {
+		if channel.ID == id {
+			return channel, true
+		}
+	}
+	return config.Channel{}, false
+}
+
+// handleWebhook verifies the signature of a job request and enqueues it.
+// It answers 202 with the queue position, 401 for a bad signature, 400 for
+// an invalid job and 503 when the queue is full.
+func (s *Server) handleWebhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxBodySize+1))
+	if err != nil || len(body) > maxBodySize {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unreadable or oversized body"})
+		return
+	}
+
+	if !s.validSignature(body, r.Header.Get(SignatureHeader)) {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid signature"})
+		return
+	}
+
+	var job Job
+	if err := json.Unmarshal(body, &job); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON: " + err.Error()})
+		return
+	}
+
+	position, err := s.enqueue(job)
+	switch {
+	case errors.Is(err, ErrQueueFull):
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": err.Error()})
+	case err != nil:
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+	default:
+		writeJSON(w, http.StatusAccepted, map[string]any{"status": "queued", "position": position})
+	}
+}
+
+// enqueue validates job and adds it to the queue, returning its position.
+func (s *Server) enqueue(job Job) (int, error) {
+	if _, ok := s.channel(job.Channel); !ok {
+		return 0, fmt.Errorf("%w: %s", ErrUnknownChannel, job.Channel)
+	}
+	if !videoIDPattern.MatchString(job.VideoID) {
+		return 0, ErrInvalidVideoID
+	}
+
+	select {
+	case s.queue <- job:
+		fmt.Println(descriptionStyle.Render(fmt.Sprintf("Queued video %s of channel %s", job.VideoID, job.Channel)))
+		return len(s.queue), nil
+	default:
+		return 0, ErrQueueFull
+	}
+}
+
+// validSignature reports whether header holds the HMAC-SHA256 of body under the secret.
+func (s *Server) validSignature(body []byte, header string) bool {
+	signature, ok := strings.CutPrefix(header, "sha256=")
+	if !ok {
+		return false
+	}
+	got, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, s.Secret)
+	mac.Write(body)
+	return hmac.Equal(got, mac.Sum(nil))
+}
+
+// writeJSON answers with status and value encoded as JSON.
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
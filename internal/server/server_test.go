@@ -0,0 +1,197 @@
+package server
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	pipelinev1 "github.com/rogersilvasouza/godeogoker/api/pipeline/v1"
+	"github.com/rogersilvasouza/godeogoker/internal/config"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const testSecret = "s3cr3t"
+
+// testServer returns a server of a single channel whose queue holds one job.
+func testServer(t *testing.T) *Server {
+	t.Helper()
+	cfg := &config.Config{
+		Server:   config.Server{WebhookSecret: testSecret, QueueSize: 1},
+		Channels: []config.Channel{{ID: "clips", Name: "Clips"}},
+	}
+	s, err := New(cfg, nil, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// sign returns the signature header of body under secret.
+func sign(secret string, body string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body))
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// post sends body to the webhook with the signature header and returns the status.
+func post(s *Server, body string, signature string) int {
+	req := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(body))
+	if signature != "" {
+		req.Header.Set(SignatureHeader, signature)
+	}
+	rec := httptest.NewRecorder()
+	s.mux.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func TestWebhookVerifiesTheSignature(t *testing.T) {
+	job := `{"channel": "clips", "video_id": "dQw4w9WgXcQ"}`
+	oversized := `{"channel": "clips", "video_id": "dQw4w9WgXcQ", "padding": "` + strings.Repeat("x", maxBodySize) + `"}`
+	tests := []struct {
+		name      string
+		body      string
+		signature string
+		want      int
+	}{
+		{"valid signature", job, sign(testSecret, job), http.StatusAccepted},
+		{"bad signature", job, sign("guess", job), http.StatusUnauthorized},
+		{"signature of another body", job, sign(testSecret, `{"channel": "clips", "video_id": "other"}`), http.StatusUnauthorized},
+		{"missing sha256= prefix", job, strings.TrimPrefix(sign(testSecret, job), "sha256="), http.StatusUnauthorized},
+		{"not hex", job, "sha256=not-hex", http.StatusUnauthorized},
+		{"no signature", job, "", http.StatusUnauthorized},
+		{"oversized body", oversized, sign(testSecret, oversized), http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := post(testServer(t), test.body, test.signature); got != test.want {
+				t.Errorf("status = %d, want %d", got, test.want)
+			}
+		})
+	}
+}
+
+func TestWebhookRefusesJobsWhenTheQueueIsFull(t *testing.T) {
+	s := testServer(t)
+	first := `{"channel": "clips", "video_id": "first"}`
+	second := `{"channel": "clips", "video_id": "second"}`
+
+	if got := post(s, first, sign(testSecret, first)); got != http.StatusAccepted {
+		t.Fatalf("status of the first job = %d, want %d", got, http.StatusAccepted)
+	}
+	if got := post(s, second, sign(testSecret, second)); got != http.StatusServiceUnavailable {
+		t.Errorf("status of the second job = %d, want %d", got, http.StatusServiceUnavailable)
+	}
+}
+
+func TestWebhookRejectsInvalidJobs(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"invalid JSON", `{"channel": `},
+		{"unknown channel", `{"channel": "other", "video_id": "dQw4w9WgXcQ"}`},
+		{"invalid video ID", `{"channel": "clips", "video_id": "../../etc"}`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := post(testServer(t), test.body, sign(testSecret, test.body)); got != http.StatusBadRequest {
+				t.Errorf("status = %d, want %d", got, http.StatusBadRequest)
+			}
+		})
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	tests := []struct {
+		name     string
+		metadata metadata.MD
+		valid    bool
+	}{
+		{"bearer token", metadata.Pairs("authorization", "Bearer "+testSecret), true},
+		{"no metadata", nil, false},
+		{"no authorization", metadata.Pairs("x-other", "Bearer "+testSecret), false},
+		{"wrong token", metadata.Pairs("authorization", "Bearer guess"), false},
+		{"not a bearer token", metadata.Pairs("authorization", "Basic "+testSecret), false},
+		{"bare secret", metadata.Pairs("authorization", testSecret), false},
+	}
+
+	s := testServer(t)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			if test.metadata != nil {
+				ctx = metadata.NewIncomingContext(ctx, test.metadata)
+			}
+			err := s.authorize(ctx)
+			if test.valid && err != nil {
+				t.Errorf("error = %v", err)
+			}
+			if !test.valid && status.Code(err) != codes.Unauthenticated {
+				t.Errorf("error = %v, want Unauthenticated", err)
+			}
+		})
+	}
+}
+
+// testGRPCClient serves the gRPC API of s in memory and returns a client of it.
+func testGRPCClient(t *testing.T, s *Server) pipelinev1.PipelineClient {
+	t.Helper()
+	listener := bufconn.Listen(1 << 20)
+	grpcServer := s.newGRPCServer()
+	go grpcServer.Serve(listener)
+	t.Cleanup(grpcServer.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return pipelinev1.NewPipelineClient(conn)
+}
+
+func TestGRPCCallsNeedABearerToken(t *testing.T) {
+	s := testServer(t)
+	client := testGRPCClient(t, s)
+	req := &pipelinev1.SubmitJobRequest{Channel: "clips", VideoId: "dQw4w9WgXcQ"}
+
+	if _, err := client.SubmitJob(context.Background(), req); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("call without a token: error = %v, want Unauthenticated", err)
+	}
+	stream, err := client.StreamEvents(context.Background(), &pipelinev1.StreamEventsRequest{})
+	if err == nil {
+		_, err = stream.Recv()
+	}
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("stream without a token: error = %v, want Unauthenticated", err)
+	}
+	if len(s.queue) != 0 {
+		t.Fatal("an unauthenticated call queued a job")
+	}
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+testSecret)
+	response, err := client.SubmitJob(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.GetPosition() != 1 {
+		t.Errorf("position = %d, want 1", response.GetPosition())
+	}
+}
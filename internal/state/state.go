@@ -0,0 +1,934 @@
+// Package state persists what the pipeline needs to remember between runs,
+// such as the OpenAI spend of each channel, the failed steps waiting to be
+// retried, the files already uploaded and their YouTube IDs, the videos sent
+// to the queue, when
+// the last digest email of each channel was sent, when each channel last
+// processed a video successfully, the failed uploads waiting in the spool,
+// the clips held back for manual review, the rotation of the hashtag pool
+// of each channel, the videos the daemon has seen in the feed of each
+// channel, with when it last polled it, where the language model requests
+// made for each video were logged, how far the backfill of each channel
+// went, the views of the uploads of each channel by publish hour, the publish
+// times taken, the stages each video went through and the channel IDs the
+// handles and URLs of the configuration resolved to.
+// Everything is kept in a bbolt database inside the state folder of the
+// active profile. The database is only open, and locked, while a change or a
+// read runs, so the processes sharing a profile, such as the daemon, the web
+// server and a manual exec, take turns instead of overwriting each other.
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// fileName is the name of the state database inside the state folder.
+const fileName = "state.db"
+
+// legacyFileName is the name of the JSON state file of earlier versions,
+// imported into the database the first time it is opened.
+const legacyFileName = "state.json"
+
+// lockTimeout is how long a change or a read waits for another process to
+// release the state database before it fails.
+const lockTimeout = time.Minute
+
+// Buckets of the state database. The records of a channel are kept under the
+// channel ID, either as a single value or, for the records kept per video or
+// output key, as a nested bucket of the channel. Values are JSON encoded.
+var (
+	spendBucket     = []byte("spend")       // USD spent per channel and month (YYYY-MM)
+	failuresBucket  = []byte("failures")    // Failed steps per channel
+	uploadsBucket   = []byte("uploads")     // Upload time per channel and output key
+	queuedBucket    = []byte("queued")      // Enqueue time per channel and video ID
+	digestsBucket   = []byte("digests")     // Time covered by the last digest per channel
+	runsBucket      = []byte("runs")        // Last video processed without failures per channel
+	spoolBucket     = []byte("spool")       // Failed uploads waiting in the spool per channel
+	reviewsBucket   = []byte("reviews")     // Clips held back for manual review per channel
+	hashtagsBucket  = []byte("hashtags")    // Position in the hashtag pool rotation per channel
+	youtubeBucket   = []byte("youtube")     // YouTube video ID per channel and uploaded output key
+	seenBucket      = []byte("seen")        // Time the daemon processed each video per channel and video ID
+	pollsBucket     = []byte("polls")       // Last daemon poll of the feed per channel
+	promptsBucket   = []byte("prompt_logs") // Prompt log file per channel and video ID
+	backfillBucket  = []byte("backfill")    // Backfill checkpoint per channel
+	analyticsBucket = []byte("analytics")   // Views by publish hour per channel
+	slotsBucket     = []byte("slots")       // Publish times taken by scheduled uploads per channel
+	progressBucket  = []byte("progress")    // Stages completed per channel and video ID
+	resolvedBucket  = []byte("resolved")    // YouTube channel ID per handle or channel URL
+)
+
+// buckets are all the buckets of the state database, created when it is opened.
+var buckets = [][]byte{
+	spendBucket, failuresBucket, uploadsBucket, queuedBucket, digestsBucket, runsBucket,
+	spoolBucket, reviewsBucket, hashtagsBucket, youtubeBucket, seenBucket, pollsBucket,
+	promptsBucket, backfillBucket, analyticsBucket, slotsBucket, progressBucket, resolvedBucket,
+}
+
+// Failure is a pipeline step that failed and can be retried.
+type Failure struct {
+	VideoID string    `json:"video_id"`      // Source video ID
+	Cut     string    `json:"cut,omitempty"` // Title of the cut, empty for video-level steps
+	Stage   string    `json:"stage"`         // Failed stage: download, cuts, metadata, render, storage or upload
+	Code    string    `json:"code"`          // Stable error code
+	Error   string    `json:"error"`         // Error message
+	Time    time.Time `json:"time"`          // When the failure happened
+}
+
+// Spooled is an upload that failed, kept in the spool folder until a drain
+// of the spool uploads it.
+type Spooled struct {
+	Key      string    `json:"key"`           // Output key of the clip, relative to the channel folder
+	VideoID  string    `json:"video_id"`      // Source video ID
+	Cut      string    `json:"cut,omitempty"` // Title of the cut
+	Clip     string    `json:"clip"`          // Copy of the clip in the spool folder
+	Metadata string    `json:"metadata"`      // Title, description and tags of the upload in the spool folder
+	Attempts int       `json:"attempts"`      // Failed upload attempts so far
+	Error    string    `json:"error"`         // Error of the last attempt
+	Next     time.Time `json:"next"`          // Earliest time of the next attempt
+}
+
+// Review is a clip held back from upload by the quality gate until it is approved.
+type Review struct {
+	Key      string    `json:"key"`                // Output key of the clip, relative to the channel folder
+	VideoID  string    `json:"video_id"`           // Source video ID
+	Cut      string    `json:"cut,omitempty"`      // Title of the cut
+	Reason   string    `json:"reason"`             // Why the clip was held back
+	Time     time.Time `json:"time"`               // When the clip was held back
+	Approved bool      `json:"approved,omitempty"` // Whether the clip may be uploaded anyway
+}
+
+// Backfill is the checkpoint of the backfill of a channel, walking its
+// upload history from the oldest video.
+type Backfill struct {
+	Last      string    `json:"last,omitempty"` // Last video of the history done, empty before the first
+	Processed int       `json:"processed"`      // Videos processed by the backfill so far
+	Total     int       `json:"total"`          // Videos in the history when it was last listed
+	Updated   time.Time `json:"updated"`        // When the checkpoint was saved
+}
+
+// Analytics is the performance of the public uploads of a channel by the
+// hour of the day they went public, in the time zone of the channel.
+type Analytics struct {
+	Hours   map[int]HourStats `json:"hours"`   // Statistics per hour of the day, 0 to 23
+	Updated time.Time         `json:"updated"` // When the statistics were read from YouTube
+}
+
+// HourStats is the performance of the uploads published at an hour of the day.
+type HourStats struct {
+	Uploads     int     `json:"uploads"`       // Uploads published at that hour
+	ViewsPerDay float64 `json:"views_per_day"` // Average views per day since publishing
+}
+
+// Progress is how far the pipeline got with a video, so an interrupted run is
+// resumed and a forced run can repeat single stages.
+type Progress struct {
+	Started   time.Time            `json:"started"`          // When the last run of the video started
+	Completed time.Time            `json:"completed"`        // When the last run finished, zero while it is in progress
+	Stages    map[string]time.Time `json:"stages,omitempty"` // When each stage was last completed: download, transcription, cuts, metadata, render or upload
+}
+
+// legacy is the document of the JSON state file of earlier versions.
+type legacy struct {
+	Spend     map[string]map[string]float64   `json:"spend,omitempty"`
+	Failures  map[string][]Failure            `json:"failures,omitempty"`
+	Uploads   map[string]map[string]time.Time `json:"uploads,omitempty"`
+	Queued    map[string]map[string]time.Time `json:"queued,omitempty"`
+	Digests   map[string]time.Time            `json:"digests,omitempty"`
+	Runs      map[string]time.Time            `json:"runs,omitempty"`
+	Spool     map[string][]Spooled            `json:"spool,omitempty"`
+	Reviews   map[string][]Review             `json:"reviews,omitempty"`
+	Hashtags  map[string]int                  `json:"hashtags,omitempty"`
+	YouTube   map[string]map[string]string    `json:"youtube,omitempty"`
+	Seen      map[string]map[string]time.Time `json:"seen,omitempty"`
+	Polls     map[string]time.Time            `json:"polls,omitempty"`
+	Prompts   map[string]map[string]string    `json:"prompt_logs,omitempty"`
+	Backfill  map[string]Backfill             `json:"backfill,omitempty"`
+	Analytics map[string]Analytics            `json:"analytics,omitempty"`
+	Slots     map[string][]time.Time          `json:"slots,omitempty"`
+	Progress  map[string]map[string]Progress  `json:"progress,omitempty"`
+	Resolved  map[string]string               `json:"resolved,omitempty"`
+}
+
+// Store is a state store backed by a bbolt database, safe for concurrent use
+// by goroutines and by the processes sharing the state folder. Every change
+// is written to disk immediately.
+type Store struct {
+	mu   sync.Mutex
+	path string
+}
+
+// Open opens the state kept in dir, creating the folder and the database when
+// needed and importing the JSON state file of earlier versions.
+func Open(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("error creating state folder: %v", err)
+	}
+
+	s := &Store{path: filepath.Join(dir, fileName)}
+	err := s.update(func(tx *bolt.Tx) error {
+		for _, name := range buckets {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return importLegacy(tx, filepath.Join(dir, legacyFileName))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// importLegacy copies the JSON state file at path into the database and
+// renames it with a .migrated suffix, so it is imported only once.
+func importLegacy(tx *bolt.Tx, path string) error {
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error reading state file: %v", err)
+	}
+
+	var old legacy
+	if err := json.Unmarshal(content, &old); err != nil {
+		return fmt.Errorf("error parsing state file: %v", err)
+	}
+
+	nested := []struct {
+		bucket []byte
+		values any
+	}{
+		{spendBucket, old.Spend}, {uploadsBucket, old.Uploads}, {queuedBucket, old.Queued},
+		{youtubeBucket, old.YouTube}, {seenBucket, old.Seen}, {promptsBucket, old.Prompts},
+		{progressBucket, old.Progress},
+	}
+	for _, section := range nested {
+		if err := importNested(tx, section.bucket, section.values); err != nil {
+			return err
+		}
+	}
+
+	flat := []struct {
+		bucket []byte
+		values any
+	}{
+		{failuresBucket, old.Failures}, {digestsBucket, old.Digests}, {runsBucket, old.Runs},
+		{spoolBucket, old.Spool}, {reviewsBucket, old.Reviews}, {hashtagsBucket, old.Hashtags},
+		{pollsBucket, old.Polls}, {backfillBucket, old.Backfill}, {analyticsBucket, old.Analytics},
+		{slotsBucket, old.Slots}, {resolvedBucket, old.Resolved},
+	}
+	for _, section := range flat {
+		if err := importFlat(tx.Bucket(section.bucket), section.values); err != nil {
+			return err
+		}
+	}
+
+	return os.Rename(path, path+".migrated")
+}
+
+// importNested stores a legacy section kept per channel and key, values being
+// a map of maps, in the nested buckets of the channels in bucket.
+func importNested(tx *bolt.Tx, bucket []byte, values any) error {
+	content, err := json.Marshal(values)
+	if err != nil {
+		return err
+	}
+	var channels map[string]map[string]json.RawMessage
+	if err := json.Unmarshal(content, &channels); err != nil {
+		return err
+	}
+
+	for channel, records := range channels {
+		nested, err := channelBucket(tx, bucket, channel)
+		if err != nil {
+			return err
+		}
+		for key, value := range records {
+			if err := nested.Put([]byte(key), value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// importFlat stores a legacy section kept per channel, values being a map,
+// under the channel keys of bucket.
+func importFlat(bucket *bolt.Bucket, values any) error {
+	content, err := json.Marshal(values)
+	if err != nil {
+		return err
+	}
+	var records map[string]json.RawMessage
+	if err := json.Unmarshal(content, &records); err != nil {
+		return err
+	}
+
+	for key, value := range records {
+		if err := bucket.Put([]byte(key), value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// update runs fn in a read-write transaction of the state database, which is
+// opened with an exclusive lock for it and closed right after.
+func (s *Store) update(fn func(tx *bolt.Tx) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	db, err := bolt.Open(s.path, 0644, &bolt.Options{Timeout: lockTimeout})
+	if err != nil {
+		return fmt.Errorf("error opening state database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.Update(fn); err != nil {
+		return fmt.Errorf("error writing state database: %v", err)
+	}
+	return nil
+}
+
+// view runs fn in a read-only transaction of the state database, which is
+// opened with a shared lock for it and closed right after. A database that
+// cannot be read leaves the results of fn at their zero values.
+func (s *Store) view(fn func(tx *bolt.Tx) error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	db, err := bolt.Open(s.path, 0644, &bolt.Options{Timeout: lockTimeout, ReadOnly: true})
+	if err != nil {
+		return
+	}
+	defer db.Close()
+
+	db.View(fn)
+}
+
+// channelBucket returns the nested bucket of channel in bucket, creating it
+// when needed.
+func channelBucket(tx *bolt.Tx, bucket []byte, channel string) (*bolt.Bucket, error) {
+	return tx.Bucket(bucket).CreateBucketIfNotExists([]byte(channel))
+}
+
+// readChannelBucket returns the nested bucket of channel in bucket, nil when
+// nothing was recorded for channel.
+func readChannelBucket(tx *bolt.Tx, bucket []byte, channel string) *bolt.Bucket {
+	return tx.Bucket(bucket).Bucket([]byte(channel))
+}
+
+// get decodes the value of key in bucket into value, reporting whether it was
+// found. bucket may be nil.
+func get(bucket *bolt.Bucket, key string, value any) bool {
+	if bucket == nil {
+		return false
+	}
+	content := bucket.Get([]byte(key))
+	if content == nil {
+		return false
+	}
+	return json.Unmarshal(content, value) == nil
+}
+
+// put stores value under key in bucket.
+func put(bucket *bolt.Bucket, key string, value any) error {
+	content, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return bucket.Put([]byte(key), content)
+}
+
+// MonthSpend returns the USD spent by channel during month (YYYY-MM).
+func (s *Store) MonthSpend(channel string, month string) float64 {
+	var spend float64
+	s.view(func(tx *bolt.Tx) error {
+		get(readChannelBucket(tx, spendBucket, channel), month, &spend)
+		return nil
+	})
+	return spend
+}
+
+// AddSpend records cost USD spent by channel during month (YYYY-MM).
+func (s *Store) AddSpend(channel string, month string, cost float64) error {
+	return s.update(func(tx *bolt.Tx) error {
+		spending, err := channelBucket(tx, spendBucket, channel)
+		if err != nil {
+			return err
+		}
+		var spend float64
+		get(spending, month, &spend)
+		return put(spending, month, spend+cost)
+	})
+}
+
+// AddFailure records a failed step of channel.
+func (s *Store) AddFailure(channel string, failure Failure) error {
+	return s.update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(failuresBucket)
+		var failures []Failure
+		get(bucket, channel, &failures)
+		return put(bucket, channel, append(failures, failure))
+	})
+}
+
+// Failures returns the failed steps recorded for channel, oldest first.
+func (s *Store) Failures(channel string) []Failure {
+	var failures []Failure
+	s.view(func(tx *bolt.Tx) error {
+		get(tx.Bucket(failuresBucket), channel, &failures)
+		return nil
+	})
+	return failures
+}
+
+// ClearFailures forgets the failed steps of a video, before it is processed again.
+func (s *Store) ClearFailures(channel string, videoID string) error {
+	return s.update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(failuresBucket)
+		var failures []Failure
+		get(bucket, channel, &failures)
+
+		kept := slices.DeleteFunc(slices.Clone(failures), func(failure Failure) bool {
+			return failure.VideoID == videoID
+		})
+		return putList(bucket, channel, len(failures), kept)
+	})
+}
+
+// putList stores the list kept under key in bucket after some of its count
+// entries were removed, deleting the key once the list is empty.
+func putList[T any](bucket *bolt.Bucket, key string, count int, kept []T) error {
+	if len(kept) == count {
+		return nil
+	}
+	if len(kept) == 0 {
+		return bucket.Delete([]byte(key))
+	}
+	return put(bucket, key, kept)
+}
+
+// Uploaded reports whether the output key of channel was already uploaded.
+func (s *Store) Uploaded(channel string, key string) bool {
+	var uploaded time.Time
+	ok := false
+	s.view(func(tx *bolt.Tx) error {
+		ok = get(readChannelBucket(tx, uploadsBucket, channel), key, &uploaded)
+		return nil
+	})
+	return ok
+}
+
+// MarkUploaded records that the output key of channel was uploaded as the
+// YouTube video youtubeID, which may be empty when unknown.
+func (s *Store) MarkUploaded(channel string, key string, youtubeID string) error {
+	return s.update(func(tx *bolt.Tx) error {
+		uploads, err := channelBucket(tx, uploadsBucket, channel)
+		if err != nil {
+			return err
+		}
+		if err := put(uploads, key, time.Now()); err != nil {
+			return err
+		}
+
+		if youtubeID == "" {
+			return nil
+		}
+		ids, err := channelBucket(tx, youtubeBucket, channel)
+		if err != nil {
+			return err
+		}
+		return put(ids, key, youtubeID)
+	})
+}
+
+// Upload returns when the output key of channel was uploaded and its YouTube
+// video ID, empty for uploads recorded before the IDs were kept. ok is false
+// when the output was not uploaded.
+func (s *Store) Upload(channel string, key string) (uploaded time.Time, youtubeID string, ok bool) {
+	s.view(func(tx *bolt.Tx) error {
+		ok = get(readChannelBucket(tx, uploadsBucket, channel), key, &uploaded)
+		get(readChannelBucket(tx, youtubeBucket, channel), key, &youtubeID)
+		return nil
+	})
+	return uploaded, youtubeID, ok
+}
+
+// UploadsSince returns the output keys of channel uploaded after since, sorted.
+func (s *Store) UploadsSince(channel string, since time.Time) []string {
+	var keys []string
+	s.view(func(tx *bolt.Tx) error {
+		uploads := readChannelBucket(tx, uploadsBucket, channel)
+		if uploads == nil {
+			return nil
+		}
+		return uploads.ForEach(func(key []byte, value []byte) error {
+			var uploaded time.Time
+			if json.Unmarshal(value, &uploaded) == nil && uploaded.After(since) {
+				keys = append(keys, string(key))
+			}
+			return nil
+		})
+	})
+	sort.Strings(keys)
+	return keys
+}
+
+// Queued reports whether videoID of channel was already sent to the distributed queue.
+func (s *Store) Queued(channel string, videoID string) bool {
+	return s.marked(queuedBucket, channel, videoID)
+}
+
+// MarkQueued records that videoID of channel was sent to the distributed queue.
+func (s *Store) MarkQueued(channel string, videoID string) error {
+	return s.mark(queuedBucket, channel, videoID)
+}
+
+// Seen reports whether the daemon already processed videoID of channel.
+func (s *Store) Seen(channel string, videoID string) bool {
+	return s.marked(seenBucket, channel, videoID)
+}
+
+// MarkSeen records that the daemon processed videoID of channel.
+func (s *Store) MarkSeen(channel string, videoID string) error {
+	return s.mark(seenBucket, channel, videoID)
+}
+
+// marked reports whether videoID of channel was marked in bucket.
+func (s *Store) marked(bucket []byte, channel string, videoID string) bool {
+	ok := false
+	s.view(func(tx *bolt.Tx) error {
+		videos := readChannelBucket(tx, bucket, channel)
+		ok = videos != nil && videos.Get([]byte(videoID)) != nil
+		return nil
+	})
+	return ok
+}
+
+// mark records the current time for videoID of channel in bucket.
+func (s *Store) mark(bucket []byte, channel string, videoID string) error {
+	return s.update(func(tx *bolt.Tx) error {
+		videos, err := channelBucket(tx, bucket, channel)
+		if err != nil {
+			return err
+		}
+		return put(videos, videoID, time.Now())
+	})
+}
+
+// LastPoll returns when the daemon last polled the feed of channel, or the
+// zero time when it never did.
+func (s *Store) LastPoll(channel string) time.Time {
+	return s.time(pollsBucket, channel)
+}
+
+// MarkPoll records that the daemon polled the feed of channel at at.
+func (s *Store) MarkPoll(channel string, at time.Time) error {
+	return s.save(pollsBucket, channel, at)
+}
+
+// LastDigest returns the time covered by the last digest of channel, or the
+// zero time when none was sent.
+func (s *Store) LastDigest(channel string) time.Time {
+	return s.time(digestsBucket, channel)
+}
+
+// MarkDigest records that the digest of channel covered everything up to at.
+func (s *Store) MarkDigest(channel string, at time.Time) error {
+	return s.save(digestsBucket, channel, at)
+}
+
+// LastRun returns when channel last processed a video without failures, or
+// the zero time when it never did.
+func (s *Store) LastRun(channel string) time.Time {
+	return s.time(runsBucket, channel)
+}
+
+// MarkRun records that channel just processed a video without failures.
+func (s *Store) MarkRun(channel string) error {
+	return s.save(runsBucket, channel, time.Now())
+}
+
+// time returns the time stored under key in bucket, the zero time when none is.
+func (s *Store) time(bucket []byte, key string) time.Time {
+	var at time.Time
+	s.load(bucket, key, &at)
+	return at
+}
+
+// load decodes the value stored under key in bucket into value, reporting
+// whether one was found.
+func (s *Store) load(bucket []byte, key string, value any) bool {
+	ok := false
+	s.view(func(tx *bolt.Tx) error {
+		ok = get(tx.Bucket(bucket), key, value)
+		return nil
+	})
+	return ok
+}
+
+// save stores value under key in bucket.
+func (s *Store) save(bucket []byte, key string, value any) error {
+	return s.update(func(tx *bolt.Tx) error {
+		return put(tx.Bucket(bucket), key, value)
+	})
+}
+
+// Spooled returns the failed uploads of channel waiting in the spool, oldest first.
+func (s *Store) Spooled(channel string) []Spooled {
+	var spool []Spooled
+	s.load(spoolBucket, channel, &spool)
+	return spool
+}
+
+// SaveSpooled records a failed upload of channel in the spool, replacing the
+// entry with the same key.
+func (s *Store) SaveSpooled(channel string, entry Spooled) error {
+	return s.update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(spoolBucket)
+		var spool []Spooled
+		get(bucket, channel, &spool)
+
+		if i := slices.IndexFunc(spool, func(spooled Spooled) bool { return spooled.Key == entry.Key }); i >= 0 {
+			spool[i] = entry
+		} else {
+			spool = append(spool, entry)
+		}
+		return put(bucket, channel, spool)
+	})
+}
+
+// RemoveSpooled forgets the spooled upload of channel with key, once it was uploaded.
+func (s *Store) RemoveSpooled(channel string, key string) error {
+	return s.update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(spoolBucket)
+		var spool []Spooled
+		get(bucket, channel, &spool)
+
+		kept := slices.DeleteFunc(slices.Clone(spool), func(spooled Spooled) bool {
+			return spooled.Key == key
+		})
+		return putList(bucket, channel, len(spool), kept)
+	})
+}
+
+// Reviews returns the clips of channel held back for manual review, oldest first.
+func (s *Store) Reviews(channel string) []Review {
+	var reviews []Review
+	s.load(reviewsBucket, channel, &reviews)
+	return reviews
+}
+
+// AddReview holds back a clip of channel for manual review, replacing the
+// entry with the same key.
+func (s *Store) AddReview(channel string, review Review) error {
+	return s.update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(reviewsBucket)
+		var reviews []Review
+		get(bucket, channel, &reviews)
+
+		if i := slices.IndexFunc(reviews, func(held Review) bool { return held.Key == review.Key }); i >= 0 {
+			reviews[i] = review
+		} else {
+			reviews = append(reviews, review)
+		}
+		return put(bucket, channel, reviews)
+	})
+}
+
+// Approved reports whether the clip key of channel was approved after a review.
+func (s *Store) Approved(channel string, key string) bool {
+	for _, held := range s.Reviews(channel) {
+		if held.Key == key {
+			return held.Approved
+		}
+	}
+	return false
+}
+
+// ApproveReview allows the held back clip key of channel to be uploaded.
+func (s *Store) ApproveReview(channel string, key string) error {
+	found := false
+	err := s.update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(reviewsBucket)
+		var reviews []Review
+		get(bucket, channel, &reviews)
+
+		i := slices.IndexFunc(reviews, func(held Review) bool { return held.Key == key })
+		if i < 0 {
+			return nil
+		}
+		found = true
+		reviews[i].Approved = true
+		return put(bucket, channel, reviews)
+	})
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("no clip held back for review: %s", key)
+	}
+	return nil
+}
+
+// RemoveReview forgets the review of the clip key of channel, once it was uploaded.
+func (s *Store) RemoveReview(channel string, key string) error {
+	return s.update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(reviewsBucket)
+		var reviews []Review
+		get(bucket, channel, &reviews)
+
+		kept := slices.DeleteFunc(slices.Clone(reviews), func(held Review) bool {
+			return held.Key == key
+		})
+		return putList(bucket, channel, len(reviews), kept)
+	})
+}
+
+// RotateHashtags returns the position in the hashtag pool of channel where
+// the next count hashtags start and moves the rotation past them, wrapping
+// around a pool of size hashtags.
+func (s *Store) RotateHashtags(channel string, count int, size int) (int, error) {
+	if size <= 0 {
+		return 0, nil
+	}
+
+	var start int
+	err := s.update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(hashtagsBucket)
+		var position int
+		get(bucket, channel, &position)
+		start = position % size
+		return put(bucket, channel, (start+count)%size)
+	})
+	return start, err
+}
+
+// SavePromptLog records path as the file holding the language model requests
+// made for videoID of channel.
+func (s *Store) SavePromptLog(channel string, videoID string, path string) error {
+	if s.PromptLog(channel, videoID) == path {
+		return nil
+	}
+	return s.update(func(tx *bolt.Tx) error {
+		logs, err := channelBucket(tx, promptsBucket, channel)
+		if err != nil {
+			return err
+		}
+		return put(logs, videoID, path)
+	})
+}
+
+// PromptLog returns the file holding the language model requests made for
+// videoID of channel, empty when none were recorded.
+func (s *Store) PromptLog(channel string, videoID string) string {
+	var path string
+	s.view(func(tx *bolt.Tx) error {
+		get(readChannelBucket(tx, promptsBucket, channel), videoID, &path)
+		return nil
+	})
+	return path
+}
+
+// Backfill returns the backfill checkpoint of channel, the zero value when
+// its backfill never started.
+func (s *Store) Backfill(channel string) Backfill {
+	var checkpoint Backfill
+	s.load(backfillBucket, channel, &checkpoint)
+	return checkpoint
+}
+
+// SaveBackfill records the backfill checkpoint of channel.
+func (s *Store) SaveBackfill(channel string, checkpoint Backfill) error {
+	checkpoint.Updated = time.Now()
+	return s.save(backfillBucket, channel, checkpoint)
+}
+
+// ClearBackfill forgets the backfill checkpoint of channel, so the next
+// backfill starts over from the oldest video.
+func (s *Store) ClearBackfill(channel string) error {
+	return s.update(func(tx *bolt.Tx) error {
+		return tx.Bucket(backfillBucket).Delete([]byte(channel))
+	})
+}
+
+// ResolvedChannel returns the YouTube channel ID target, a handle or channel
+// URL, resolved to before.
+func (s *Store) ResolvedChannel(target string) (string, bool) {
+	var id string
+	ok := s.load(resolvedBucket, target, &id)
+	return id, ok
+}
+
+// SaveResolvedChannel records that target, a handle or channel URL, resolved
+// to the YouTube channel ID id.
+func (s *Store) SaveResolvedChannel(target string, id string) error {
+	return s.save(resolvedBucket, target, id)
+}
+
+// YouTubeIDs returns the YouTube video IDs of the uploads of channel, sorted.
+func (s *Store) YouTubeIDs(channel string) []string {
+	var ids []string
+	s.view(func(tx *bolt.Tx) error {
+		uploads := readChannelBucket(tx, youtubeBucket, channel)
+		if uploads == nil {
+			return nil
+		}
+		return uploads.ForEach(func(key []byte, value []byte) error {
+			var id string
+			if json.Unmarshal(value, &id) == nil {
+				ids = append(ids, id)
+			}
+			return nil
+		})
+	})
+	sort.Strings(ids)
+	return ids
+}
+
+// Analytics returns the analytics of channel, the zero value when they were
+// never collected.
+func (s *Store) Analytics(channel string) Analytics {
+	var analytics Analytics
+	s.load(analyticsBucket, channel, &analytics)
+	return analytics
+}
+
+// SaveAnalytics records the analytics of channel.
+func (s *Store) SaveAnalytics(channel string, analytics Analytics) error {
+	return s.save(analyticsBucket, channel, analytics)
+}
+
+// ReserveSlot takes the first of candidates not taken yet by another upload
+// of channel and returns it, the zero time when all of them are taken.
+// Publish times already past are forgotten.
+func (s *Store) ReserveSlot(channel string, candidates []time.Time) (time.Time, error) {
+	var reserved time.Time
+	err := s.update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(slotsBucket)
+		var slots []time.Time
+		get(bucket, channel, &slots)
+
+		now := time.Now()
+		var taken []time.Time
+		for _, slot := range slots {
+			if slot.After(now) {
+				taken = append(taken, slot)
+			}
+		}
+
+		for _, candidate := range candidates {
+			if slices.ContainsFunc(taken, candidate.Equal) {
+				continue
+			}
+			reserved = candidate
+			return put(bucket, channel, append(taken, candidate))
+		}
+		return nil
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+	return reserved, nil
+}
+
+// Progress returns the progress of videoID of channel. ok is false when no
+// run of the video was recorded.
+func (s *Store) Progress(channel string, videoID string) (progress Progress, ok bool) {
+	s.view(func(tx *bolt.Tx) error {
+		ok = get(readChannelBucket(tx, progressBucket, channel), videoID, &progress)
+		return nil
+	})
+	return progress, ok
+}
+
+// StartVideo records that a run of videoID of channel started, keeping the
+// stages completed by earlier runs.
+func (s *Store) StartVideo(channel string, videoID string) error {
+	return s.changeProgress(channel, videoID, true, func(progress *Progress) {
+		progress.Started = time.Now()
+		progress.Completed = time.Time{}
+	})
+}
+
+// CompleteStage records that stage of videoID of channel completed.
+func (s *Store) CompleteStage(channel string, videoID string, stage string) error {
+	return s.changeProgress(channel, videoID, true, func(progress *Progress) {
+		if progress.Stages == nil {
+			progress.Stages = make(map[string]time.Time)
+		}
+		progress.Stages[stage] = time.Now()
+	})
+}
+
+// CompleteVideo records that the run of videoID of channel finished.
+func (s *Store) CompleteVideo(channel string, videoID string) error {
+	return s.changeProgress(channel, videoID, true, func(progress *Progress) {
+		progress.Completed = time.Now()
+	})
+}
+
+// ResetStages forgets that stages of videoID of channel completed.
+func (s *Store) ResetStages(channel string, videoID string, stages []string) error {
+	return s.changeProgress(channel, videoID, false, func(progress *Progress) {
+		for _, stage := range stages {
+			delete(progress.Stages, stage)
+		}
+	})
+}
+
+// changeProgress applies change to the progress of videoID of channel in a
+// single transaction. Without create, a video with no recorded progress is
+// left alone.
+func (s *Store) changeProgress(channel string, videoID string, create bool, change func(progress *Progress)) error {
+	return s.update(func(tx *bolt.Tx) error {
+		videos, err := channelBucket(tx, progressBucket, channel)
+		if err != nil {
+			return err
+		}
+		var progress Progress
+		if !get(videos, videoID, &progress) && !create {
+			return nil
+		}
+		change(&progress)
+		return put(videos, videoID, progress)
+	})
+}
+
+// ClearUploads forgets the uploads of channel whose output key starts with
+// prefix, so they are uploaded again.
+func (s *Store) ClearUploads(channel string, prefix string) error {
+	return s.update(func(tx *bolt.Tx) error {
+		for _, bucket := range [][]byte{uploadsBucket, youtubeBucket} {
+			records := readChannelBucket(tx, bucket, channel)
+			if records == nil {
+				continue
+			}
+			var keys [][]byte
+			records.ForEach(func(key []byte, value []byte) error {
+				if strings.HasPrefix(string(key), prefix) {
+					keys = append(keys, slices.Clone(key))
+				}
+				return nil
+			})
+			for _, key := range keys {
+				if err := records.Delete(key); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,138 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStoresSharingAFolderKeepEveryChange(t *testing.T) {
+	dir := t.TempDir()
+	first, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for _, store := range []*Store{first, second} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 20 {
+				if err := store.AddSpend("channel", "2026-10", 0.5); err != nil {
+					t.Error(err)
+				}
+				if err := store.AddFailure("channel", Failure{VideoID: "video"}); err != nil {
+					t.Error(err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if spend := first.MonthSpend("channel", "2026-10"); spend != 20 {
+		t.Errorf("spend = %v, want 20", spend)
+	}
+	if failures := second.Failures("channel"); len(failures) != 40 {
+		t.Errorf("failures = %d, want 40", len(failures))
+	}
+}
+
+func TestOpenImportsTheLegacyStateFile(t *testing.T) {
+	dir := t.TempDir()
+	legacyState := `{
+		"spend": {"channel": {"2026-09": 1.5}},
+		"uploads": {"channel": {"video/clip.mp4": "2026-09-01T10:00:00Z"}},
+		"youtube": {"channel": {"video/clip.mp4": "abc123"}},
+		"reviews": {"channel": [{"key": "video/other.mp4", "video_id": "video", "reason": "too short", "time": "2026-09-01T10:00:00Z"}]},
+		"resolved": {"@handle": "UC123"},
+		"prompts": {"channel": [{"video_id": "video", "purpose": "cuts"}]}
+	}`
+	if err := os.WriteFile(filepath.Join(dir, legacyFileName), []byte(legacyState), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if spend := store.MonthSpend("channel", "2026-09"); spend != 1.5 {
+		t.Errorf("spend = %v, want 1.5", spend)
+	}
+	uploaded, id, ok := store.Upload("channel", "video/clip.mp4")
+	if !ok || id != "abc123" || !uploaded.Equal(time.Date(2026, 9, 1, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("upload = %v, %q, %v", uploaded, id, ok)
+	}
+	if reviews := store.Reviews("channel"); len(reviews) != 1 || reviews[0].Reason != "too short" {
+		t.Errorf("reviews = %+v", reviews)
+	}
+	if id, ok := store.ResolvedChannel("@handle"); !ok || id != "UC123" {
+		t.Errorf("resolved = %q, %v", id, ok)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, legacyFileName)); !os.IsNotExist(err) {
+		t.Errorf("legacy state file still in place: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, legacyFileName+".migrated")); err != nil {
+		t.Errorf("legacy state file not kept: %v", err)
+	}
+}
+
+func TestProgressAndLists(t *testing.T) {
+	store, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.ResetStages("channel", "video", []string{"render"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := store.Progress("channel", "video"); ok {
+		t.Error("resetting an unknown video recorded progress")
+	}
+
+	store.StartVideo("channel", "video")
+	store.CompleteStage("channel", "video", "download")
+	store.CompleteStage("channel", "video", "render")
+	store.ResetStages("channel", "video", []string{"render"})
+	progress, ok := store.Progress("channel", "video")
+	if !ok || len(progress.Stages) != 1 || progress.Stages["download"].IsZero() || !progress.Completed.IsZero() {
+		t.Errorf("progress = %+v, %v", progress, ok)
+	}
+
+	store.AddReview("channel", Review{Key: "a"})
+	store.AddReview("channel", Review{Key: "b"})
+	if err := store.ApproveReview("channel", "b"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.ApproveReview("channel", "c"); err == nil {
+		t.Error("approving an unknown clip succeeded")
+	}
+	if store.Approved("channel", "a") || !store.Approved("channel", "b") {
+		t.Error("wrong clip approved")
+	}
+	store.RemoveReview("channel", "a")
+	store.RemoveReview("channel", "b")
+	if reviews := store.Reviews("channel"); len(reviews) != 0 {
+		t.Errorf("reviews = %+v, want none", reviews)
+	}
+
+	store.MarkUploaded("channel", "video/a.mp4", "id-a")
+	store.MarkUploaded("channel", "other/b.mp4", "id-b")
+	if err := store.ClearUploads("channel", "video/"); err != nil {
+		t.Fatal(err)
+	}
+	if store.Uploaded("channel", "video/a.mp4") || !store.Uploaded("channel", "other/b.mp4") {
+		t.Error("wrong uploads cleared")
+	}
+	if ids := store.YouTubeIDs("channel"); len(ids) != 1 || ids[0] != "id-b" {
+		t.Errorf("YouTube IDs = %v", ids)
+	}
+}
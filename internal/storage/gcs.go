@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/rogersilvasouza/godeogoker/internal/config"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
+	gcs "google.golang.org/api/storage/v1"
+)
+
+// GCS stores files in a Google Cloud Storage bucket.
+type GCS struct {
+	Bucket string // Bucket name
+	Prefix string // Object name prefix inside the bucket
+
+	service *gcs.Service
+}
+
+// NewGCS creates a Google Cloud Storage client using the service account file in
+// settings.CredentialsFile, or Application Default Credentials when it is empty.
+func NewGCS(ctx context.Context, settings config.Storage) (*GCS, error) {
+	if settings.Bucket == "" {
+		return nil, fmt.Errorf("gcs storage requires a bucket")
+	}
+
+	var opts []option.ClientOption
+	if settings.CredentialsFile != "" {
+		opts = append(opts, option.WithAuthCredentialsFile(option.ServiceAccount, settings.CredentialsFile))
+	}
+
+	service, err := gcs.NewService(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("error creating GCS client: %v", err)
+	}
+
+	return &GCS{
+		Bucket:  settings.Bucket,
+		Prefix:  settings.Prefix,
+		service: service,
+	}, nil
+}
+
+// Put uploads localPath as a new object, replacing any previous version.
+func (g *GCS) Put(ctx context.Context, key string, localPath string) error {
+	file, err := os.Open(localPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	object := &gcs.Object{Name: objectName(g.Prefix, key)}
+	if _, err := g.service.Objects.Insert(g.Bucket, object).Media(file).Context(ctx).Do(); err != nil {
+		return fmt.Errorf("error uploading to gs://%s/%s: %v", g.Bucket, object.Name, err)
+	}
+
+	return nil
+}
+
+// Exists fetches the object metadata to check for its presence.
+func (g *GCS) Exists(ctx context.Context, key string) (bool, error) {
+	_, err := g.service.Objects.Get(g.Bucket, objectName(g.Prefix, key)).Context(ctx).Do()
+	if err == nil {
+		return true, nil
+	}
+
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) && apiErr.Code == http.StatusNotFound {
+		return false, nil
+	}
+	return false, err
+}
@@ -0,0 +1,98 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/rogersilvasouza/godeogoker/internal/config"
+)
+
+// S3 stores files in an Amazon S3 (or S3-compatible, such as MinIO) bucket.
+type S3 struct {
+	Bucket string // Bucket name
+	Prefix string // Key prefix inside the bucket
+
+	client   *s3.Client
+	uploader *manager.Uploader
+}
+
+// NewS3 creates an S3 storage. Credentials come from username/password when set,
+// otherwise from the standard AWS environment variables and shared config.
+func NewS3(ctx context.Context, settings config.Storage) (*S3, error) {
+	if settings.Bucket == "" {
+		return nil, fmt.Errorf("s3 storage requires a bucket")
+	}
+
+	var options []func(*awsconfig.LoadOptions) error
+	if settings.Region != "" {
+		options = append(options, awsconfig.WithRegion(settings.Region))
+	}
+	if settings.Username != "" {
+		options = append(options, awsconfig.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(settings.Username, settings.Password, "")))
+	}
+
+	awsConfig, err := awsconfig.LoadDefaultConfig(ctx, options...)
+	if err != nil {
+		return nil, fmt.Errorf("error loading S3 configuration: %v", err)
+	}
+
+	client := s3.NewFromConfig(awsConfig, func(o *s3.Options) {
+		if settings.Endpoint != "" {
+			o.BaseEndpoint = aws.String(settings.Endpoint)
+			o.UsePathStyle = true
+		}
+	})
+
+	return &S3{
+		Bucket:   settings.Bucket,
+		Prefix:   settings.Prefix,
+		client:   client,
+		uploader: manager.NewUploader(client),
+	}, nil
+}
+
+// Put uploads localPath to the bucket, using multipart uploads for large clips.
+func (s *S3) Put(ctx context.Context, key string, localPath string) error {
+	file, err := os.Open(localPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = s.uploader.Upload(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(s.Bucket),
+		Key:    aws.String(objectName(s.Prefix, key)),
+		Body:   file,
+	})
+	if err != nil {
+		return fmt.Errorf("error uploading to s3://%s/%s: %v", s.Bucket, objectName(s.Prefix, key), err)
+	}
+
+	return nil
+}
+
+// Exists issues a HEAD request for the object.
+func (s *S3) Exists(ctx context.Context, key string) (bool, error) {
+	_, err := s.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(s.Bucket),
+		Key:    aws.String(objectName(s.Prefix, key)),
+	})
+	if err == nil {
+		return true, nil
+	}
+
+	var responseErr *awshttp.ResponseError
+	if errors.As(err, &responseErr) && responseErr.HTTPStatusCode() == http.StatusNotFound {
+		return false, nil
+	}
+	return false, err
+}
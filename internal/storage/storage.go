@@ -0,0 +1,103 @@
+// Package storage provides the destinations where processed outputs are published.
+// Clips, covers and metadata are rendered locally and then stored through a
+// Storage, which can be the local disk or a remote bucket or share.
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/rogersilvasouza/godeogoker/internal/config"
+)
+
+// Storage publishes local files under a slash-separated key such as
+// "{video_id}/horizontal/{title}.mp4".
+type Storage interface {
+	// Put stores the local file at localPath under key.
+	Put(ctx context.Context, key string, localPath string) error
+	// Exists reports whether an object with the given key is already stored.
+	Exists(ctx context.Context, key string) (bool, error)
+}
+
+// New returns the Storage configured for the channel.
+// Local storage rooted at the channel folder is used when no type is set.
+func New(ctx context.Context, channel config.Channel) (Storage, error) {
+	settings := channel.Storage
+
+	switch settings.Type {
+	case "", "local":
+		return &Local{Root: channel.Folder}, nil
+	case "s3":
+		return NewS3(ctx, settings)
+	case "gcs":
+		return NewGCS(ctx, settings)
+	case "webdav":
+		return NewWebDAV(settings)
+	default:
+		return nil, fmt.Errorf("unknown storage type: %s", settings.Type)
+	}
+}
+
+// Local stores files on the local disk below Root.
+type Local struct {
+	Root string // Base folder of the stored keys
+}
+
+// Put copies localPath to Root/key. Files that are already in place are left untouched.
+func (l *Local) Put(ctx context.Context, key string, localPath string) error {
+	dst := filepath.Join(l.Root, filepath.FromSlash(key))
+
+	srcAbs, err := filepath.Abs(localPath)
+	if err != nil {
+		return err
+	}
+	dstAbs, err := filepath.Abs(dst)
+	if err != nil {
+		return err
+	}
+	if srcAbs == dstAbs {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return fmt.Errorf("error creating storage folder: %v", err)
+	}
+
+	in, err := os.Open(localPath)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	if _, err := io.Copy(out, in); err != nil {
+		return fmt.Errorf("error copying %s: %v", localPath, err)
+	}
+
+	return nil
+}
+
+// Exists checks whether Root/key is present on disk.
+func (l *Local) Exists(ctx context.Context, key string) (bool, error) {
+	_, err := os.Stat(filepath.Join(l.Root, filepath.FromSlash(key)))
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// objectName joins the configured prefix and key into a remote object name.
+func objectName(prefix string, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "/" + key
+}
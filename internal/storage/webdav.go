@@ -0,0 +1,139 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/rogersilvasouza/godeogoker/internal/config"
+)
+
+// WebDAV stores files on a WebDAV share such as Nextcloud or a NAS.
+type WebDAV struct {
+	BaseURL  string // URL of the destination folder
+	Prefix   string // Sub-folder below BaseURL
+	Username string // Basic auth user
+	Password string // Basic auth password
+
+	client *http.Client
+}
+
+// NewWebDAV creates a WebDAV storage for the share at settings.Endpoint.
+func NewWebDAV(settings config.Storage) (*WebDAV, error) {
+	if settings.Endpoint == "" {
+		return nil, fmt.Errorf("webdav storage requires an endpoint")
+	}
+
+	return &WebDAV{
+		BaseURL:  strings.TrimSuffix(settings.Endpoint, "/"),
+		Prefix:   settings.Prefix,
+		Username: settings.Username,
+		Password: settings.Password,
+		client:   http.DefaultClient,
+	}, nil
+}
+
+// Put creates the parent collections and uploads localPath with a PUT request.
+func (w *WebDAV) Put(ctx context.Context, key string, localPath string) error {
+	name := objectName(w.Prefix, key)
+
+	parts := strings.Split(name, "/")
+	for i := 1; i < len(parts); i++ {
+		if err := w.mkcol(ctx, strings.Join(parts[:i], "/")); err != nil {
+			return err
+		}
+	}
+
+	file, err := os.Open(localPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	req, err := w.newRequest(ctx, http.MethodPut, name)
+	if err != nil {
+		return err
+	}
+	req.Body = file
+	req.ContentLength = info.Size()
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error uploading %s: %v", name, err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("error uploading %s: status %s", name, resp.Status)
+	}
+
+	return nil
+}
+
+// Exists sends a HEAD request for the file.
+func (w *WebDAV) Exists(ctx context.Context, key string) (bool, error) {
+	req, err := w.newRequest(ctx, http.MethodHead, objectName(w.Prefix, key))
+	if err != nil {
+		return false, err
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return false, nil
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return true, nil
+	default:
+		return false, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+}
+
+// mkcol creates a collection, treating an already existing one as success.
+func (w *WebDAV) mkcol(ctx context.Context, name string) error {
+	req, err := w.newRequest(ctx, "MKCOL", name+"/")
+	if err != nil {
+		return err
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error creating folder %s: %v", name, err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= 400 && resp.StatusCode != http.StatusMethodNotAllowed {
+		return fmt.Errorf("error creating folder %s: status %s", name, resp.Status)
+	}
+
+	return nil
+}
+
+// newRequest builds an authenticated request for the given path below BaseURL.
+func (w *WebDAV) newRequest(ctx context.Context, method string, name string) (*http.Request, error) {
+	segments := strings.Split(name, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, w.BaseURL+"/"+strings.Join(segments, "/"), nil)
+	if err != nil {
+		return nil, err
+	}
+	if w.Username != "" {
+		req.SetBasicAuth(w.Username, w.Password)
+	}
+	return req, nil
+}
@@ -0,0 +1,155 @@
+package videos
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/rogersilvasouza/godeogoker/internal/config"
+	"github.com/rogersilvasouza/godeogoker/internal/state"
+)
+
+// BackfillOptions are the settings of a backfill run.
+type BackfillOptions struct {
+	Max     int           // Videos processed by this run, 0 for no limit
+	Batch   int           // Videos processed between two pauses
+	Pause   time.Duration // Wait between batches, throttling the API and model usage
+	Restart bool          // Forget the checkpoint and start over from the oldest video
+}
+
+// historyURL returns the URL listing every upload of channel: its uploads
+// playlist, which also holds shorts and live recordings like the feed does,
+// or its videos tab when the channel ID is not a UC... ID.
+func historyURL(channel config.Channel) string {
+	if id, ok := strings.CutPrefix(channel.ChannelID, "UC"); ok {
+		return "https://www.youtube.com/playlist?list=UU" + id
+	}
+	return "https://www.youtube.com/channel/" + channel.ChannelID + "/videos"
+}
+
+// Backfill walks the whole upload history of channel from the oldest video,
+// processing the videos not processed yet in batches with a pause between
+// them. The last video done is checkpointed in the state store after each
+// video, so a stopped backfill resumes where it left off, even days later.
+// It stops after options.Max videos, once the language model spending limit or the
+// upload quota is reached, and when the error budget is spent. It returns an
+// error when there is no state store, the backends of the channel cannot be
+// configured or the history cannot be listed.
+func Backfill(ctx context.Context, client *Client, channel config.Channel, options BackfillOptions) error {
+	store := client.State
+	if store == nil {
+		return fmt.Errorf("the backfill needs a state store")
+	}
+	if options.Restart {
+		if err := store.ClearBackfill(channel.ID); err != nil {
+			return fmt.Errorf("error clearing the checkpoint: %v", err)
+		}
+	}
+
+	p, err := newPipeline(ctx, client, channel)
+	if err != nil {
+		return fmt.Errorf("error configuring %v", err)
+	}
+	p.errorBudget = client.Errors
+	if channel.ChannelID, err = client.resolveChannelID(ctx, channel.ChannelID); err != nil {
+		return err
+	}
+
+	fmt.Println(titleStyle.Render("Listing the upload history of channel: " + channel.Name))
+	history := client.fetchPlaylist(ctx, historyURL(channel), channel.YtdlpOptions)
+	if len(history) == 0 {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return newError(ErrFeedFailed, "", "", fmt.Errorf("no videos listed in the history of channel %s", channel.ID))
+	}
+	// The history is listed from the newest, and holds YouTube videos.
+	slices.Reverse(history)
+	for i := range history {
+		history[i].URL = ""
+	}
+
+	checkpoint := store.Backfill(channel.ID)
+	checkpoint.Total = len(history)
+	start := 0
+	if checkpoint.Last != "" {
+		start = slices.IndexFunc(history, func(video Video) bool { return video.ID == checkpoint.Last }) + 1
+		if start == 0 {
+			fmt.Println(subtitleStyle.Render("The last video of the checkpoint left the history, walking it from the oldest video"))
+		}
+	}
+	fmt.Println(subtitleStyle.Render(fmt.Sprintf("%d videos in the history, %d left after the checkpoint", len(history), len(history)-start)))
+
+	batch := 0
+	processed := 0
+	for i := start; i < len(history); i++ {
+		video := history[i]
+		if ctx.Err() != nil {
+			break
+		}
+		if options.Max > 0 && processed >= options.Max {
+			fmt.Println(subtitleStyle.Render(fmt.Sprintf("Processed %d videos, run the backfill again to go on", processed)))
+			break
+		}
+		if batch == options.Batch && options.Batch > 0 {
+			batch = 0
+			if options.Pause > 0 {
+				fmt.Println(subtitleStyle.Render(fmt.Sprintf("Batch done, pausing for %s", options.Pause)))
+				if sleepContext(ctx, options.Pause) != nil {
+					break
+				}
+			}
+		}
+
+		outputDir := filepath.Join(channel.Folder, video.ID)
+		if _, done := p.progress(outputDir, video.ID); !done {
+			fmt.Println(titleStyle.Render(fmt.Sprintf("Backfilling video %d/%d (ID: %s)", i+1, len(history), video.ID)))
+			ok := p.process(ctx, video, StageOptions{})
+			if _, done := p.progress(outputDir, video.ID); ctx.Err() != nil || !done {
+				break
+			}
+			processed++
+			batch++
+			checkpoint.Processed++
+			if !ok {
+				saveBackfill(store, channel.ID, checkpoint, video.ID)
+				break
+			}
+		}
+		checkpoint = saveBackfill(store, channel.ID, checkpoint, video.ID)
+
+		if p.uploadsBlocked && channel.UploadToYouTube {
+			fmt.Println(errorStyle.Render("Upload quota exhausted, stopping the backfill. Run it again once the quota resets."))
+			break
+		}
+	}
+
+	if ctx.Err() == nil {
+		p.writeFeeds(ctx)
+	}
+
+	checkpoint = store.Backfill(channel.ID)
+	fmt.Println(titleStyle.Render(fmt.Sprintf("Backfill of %s: %d videos processed in this run, %d/%d of the history done", channel.Name, processed, backfillPosition(history, checkpoint), len(history))))
+	return p.budgetSpent
+}
+
+// saveBackfill records that the backfill of channel is done up to videoID.
+// Errors are reported, since the videos done are skipped anyway on resume.
+func saveBackfill(store *state.Store, channel string, checkpoint state.Backfill, videoID string) state.Backfill {
+	checkpoint.Last = videoID
+	if err := store.SaveBackfill(channel, checkpoint); err != nil {
+		fmt.Println(errorStyle.Render("Error saving the backfill checkpoint: " + err.Error()))
+	}
+	return checkpoint
+}
+
+// backfillPosition returns how many videos of history the checkpoint covers.
+func backfillPosition(history []Video, checkpoint state.Backfill) int {
+	if checkpoint.Last == "" {
+		return 0
+	}
+	return slices.IndexFunc(history, func(video Video) bool { return video.ID == checkpoint.Last }) + 1
+}
@@ -0,0 +1,98 @@
+package videos
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// basesFolder is the folder inside the state folder caching the stills of
+// the base videos.
+const basesFolder = "bases"
+
+// stillExtensions are the extensions of the bases that are images already.
+var stillExtensions = map[string]bool{".png": true, ".jpg": true, ".jpeg": true, ".bmp": true, ".webp": true}
+
+// Still saves the first frame of input as an image with even dimensions, as
+// yuv420p encoding requires.
+func (r *FFmpegRenderer) Still(ctx context.Context, input string, output string) error {
+	return r.render(
+		ctx,
+		output,
+		"-i", input,
+		"-vf", "scale=trunc(iw/2)*2:trunc(ih/2)*2",
+		"-frames:v", "1",
+	)
+}
+
+// baseCache holds the stills of the base videos prepared during a run, keyed
+// by base path.
+type baseCache struct {
+	mu     sync.Mutex
+	stills map[string]string
+}
+
+// base returns the still to compose with in place of the base video at path.
+// Composition only uses the first frame of a base, so it is saved once as a
+// still in the state folder and reused by every cut, and by later runs until
+// the base changes, instead of decoding the template again for each output.
+// path itself is returned when it is an image already or its still could not
+// be prepared.
+func (p *pipeline) base(ctx context.Context, path string) string {
+	if path == "" || stillExtensions[strings.ToLower(filepath.Ext(path))] {
+		return path
+	}
+
+	p.bases.mu.Lock()
+	defer p.bases.mu.Unlock()
+
+	if still, ok := p.bases.stills[path]; ok {
+		return still
+	}
+
+	still, err := p.prepareBase(ctx, path)
+	if err != nil {
+		fmt.Println(subtitleStyle.Render("Using the base video as is: " + err.Error()))
+		still = path
+	}
+	if p.bases.stills == nil {
+		p.bases.stills = make(map[string]string)
+	}
+	p.bases.stills[path] = still
+	return still
+}
+
+// prepareBase returns the cached still of the base video at path, rendering
+// it when the base is new or was changed since. The still is named after the
+// base path, size and modification time.
+func (p *pipeline) prepareBase(ctx context.Context, path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+
+	absolute, err := filepath.Abs(path)
+	if err != nil {
+		absolute = path
+	}
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s|%d|%d", absolute, info.Size(), info.ModTime().UnixNano())))
+	dir := filepath.Join(p.client.Config.StateDir(), basesFolder)
+	still := filepath.Join(dir, hex.EncodeToString(sum[:8])+".png")
+	if fileExists(still) {
+		return still, nil
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("error creating bases folder: %v", err)
+	}
+	fmt.Println(commandStyle.Render("Preparing base " + filepath.Base(path) + "..."))
+	if err := p.renderer.Still(ctx, path, still); err != nil {
+		return "", err
+	}
+	return still, nil
+}
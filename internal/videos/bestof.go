@@ -0,0 +1,228 @@
+package videos
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rogersilvasouza/godeogoker/internal/config"
+)
+
+// bestOfColumns are the columns of the best of report, one row per winner.
+var bestOfColumns = []string{
+	"rank", "clip", "title", "source_title", "rendered", "uploaded",
+	"ai_score", "views", "likes", "comments", "views_per_day", "rank_score",
+}
+
+// BestOfOptions selects the window and the ranking of a best of.
+type BestOfOptions struct {
+	Last    time.Duration // Rank the clips rendered in this period
+	Top     int           // Number of winners
+	By      string        // Ranking: ai, views or both
+	Report  string        // Report file, defaults to compilations/bestof-{date}.csv inside the channel folder
+	Compile bool          // Compile the winners right away
+	Upload  bool          // Upload the compilation to YouTube as unlisted
+}
+
+// rankedClip is a clip with the signals it is ranked by.
+type rankedClip struct {
+	clip     LibraryClip
+	key      string
+	uploaded time.Time
+	stats    videoStats
+	hasStats bool
+	perDay   float64 // Views per day since the upload
+	score    float64 // Rank score from 0 to 1
+}
+
+// BestOf ranks the clips of channel rendered within options.Last by the
+// score the model gave their cut, by their YouTube views per day since the
+// upload or by both, and writes a report of the options.Top winners. With
+// options.Compile the winners are joined into a compilation, best first;
+// otherwise the report ends with the compile command that joins them.
+func BestOf(ctx context.Context, client *Client, channel config.Channel, options BestOfOptions) error {
+	if options.By == "" {
+		options.By = "both"
+	}
+	if options.By != "ai" && options.By != "views" && options.By != "both" {
+		return fmt.Errorf("unknown ranking: %s", options.By)
+	}
+
+	library, err := ListClips(channel.Folder)
+	if err != nil {
+		return fmt.Errorf("error listing clips of %s: %v", channel.Name, err)
+	}
+	clips, err := selectClips(channel, library, CompileOptions{Since: options.Last})
+	if err != nil {
+		return err
+	}
+	if len(clips) == 0 {
+		return fmt.Errorf("no clips rendered in the last %s", options.Last)
+	}
+
+	ranked := make([]rankedClip, len(clips))
+	for i, clip := range clips {
+		ranked[i] = rankedClip{clip: clip, key: OutputKey(channel.Folder, clip.Clip)}
+	}
+	if options.By != "ai" {
+		if err := client.rankStats(ctx, channel, ranked); err != nil {
+			if options.By == "views" {
+				return err
+			}
+			fmt.Println(errorStyle.Render("Ranking by AI score only: " + err.Error()))
+		}
+	}
+	rankClips(ranked, options.By)
+	if options.Top > 0 && len(ranked) > options.Top {
+		ranked = ranked[:options.Top]
+	}
+
+	if options.Report == "" {
+		options.Report = filepath.Join(channel.Folder, compilationsFolder, "bestof-"+time.Now().Format("2006-01-02")+".csv")
+	}
+	if err := os.MkdirAll(filepath.Dir(options.Report), 0755); err != nil {
+		return fmt.Errorf("error creating report folder: %v", err)
+	}
+	file, err := os.Create(options.Report)
+	if err != nil {
+		return fmt.Errorf("error creating %s: %v", options.Report, err)
+	}
+	defer file.Close()
+
+	rows := make([][]string, len(ranked))
+	keys := make([]string, len(ranked))
+	fmt.Println(titleStyle.Render(fmt.Sprintf("Best of %s, last %s", channel.Name, options.Last)))
+	for i, winner := range ranked {
+		rows[i] = winner.row(i + 1)
+		keys[i] = winner.key
+		fmt.Println(optionStyle.Render(fmt.Sprintf("  %d. %s", i+1, winner.clip.Metadata.Title)), descriptionStyle.Render(winner.summary()))
+	}
+	if err := writeCSV(file, bestOfColumns, rows); err != nil {
+		return fmt.Errorf("error writing %s: %v", options.Report, err)
+	}
+	fmt.Println(successStyle.Render("Best of report written to " + options.Report))
+
+	if !options.Compile {
+		fmt.Println(subtitleStyle.Render("Compile the winners with:"))
+		fmt.Println(descriptionStyle.Render(fmt.Sprintf("  godeogoker compile %s --clips=%s", channel.ID, strings.Join(keys, ","))))
+		return nil
+	}
+	if len(ranked) < 2 {
+		return fmt.Errorf("a compilation needs at least two clips, found %d", len(ranked))
+	}
+	return CompileClips(ctx, client, channel, CompileOptions{Clips: keys, Upload: options.Upload})
+}
+
+// rankStats fills the upload time and YouTube statistics of the uploaded
+// clips among ranked.
+func (c *Client) rankStats(ctx context.Context, channel config.Channel, ranked []rankedClip) error {
+	if c.State == nil {
+		return fmt.Errorf("no state store to find the uploads in")
+	}
+
+	ids := make([]string, len(ranked))
+	for i := range ranked {
+		for _, path := range []string{ranked[i].clip.Clip, ranked[i].clip.Vertical} {
+			if path == "" {
+				continue
+			}
+			if at, id, ok := c.State.Upload(channel.ID, OutputKey(channel.Folder, path)); ok && id != "" {
+				ranked[i].uploaded = at
+				ids[i] = id
+				break
+			}
+		}
+	}
+
+	stats, err := c.videoStats(ctx, ids)
+	if err != nil {
+		return err
+	}
+	for i, id := range ids {
+		if counters, ok := stats[id]; ok {
+			days := math.Max(time.Since(ranked[i].uploaded).Hours()/24, 1)
+			ranked[i].stats = counters
+			ranked[i].hasStats = true
+			ranked[i].perDay = float64(counters.Views) / days
+		}
+	}
+	return nil
+}
+
+// rankClips sorts ranked by their rank score, best first. The AI score is
+// scaled from its 1 to 10 range and the views per day relative to the most
+// viewed clip, and both averages the signals a clip has.
+func rankClips(ranked []rankedClip, by string) {
+	var mostViewed float64
+	for _, clip := range ranked {
+		mostViewed = math.Max(mostViewed, clip.perDay)
+	}
+
+	for i := range ranked {
+		var signals []float64
+		if by != "views" && ranked[i].clip.Metadata.Score > 0 {
+			signals = append(signals, ranked[i].clip.Metadata.Score/10)
+		}
+		if by != "ai" && ranked[i].hasStats && mostViewed > 0 {
+			signals = append(signals, ranked[i].perDay/mostViewed)
+		}
+		for _, signal := range signals {
+			ranked[i].score += signal / float64(len(signals))
+		}
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool { return ranked[i].score > ranked[j].score })
+}
+
+// row flattens a winner into a row of bestOfColumns.
+func (r rankedClip) row(rank int) []string {
+	var uploaded, aiScore, views, likes, comments, perDay string
+	if !r.uploaded.IsZero() {
+		uploaded = r.uploaded.UTC().Format(time.RFC3339)
+	}
+	if r.clip.Metadata.Score > 0 {
+		aiScore = strconv.FormatFloat(r.clip.Metadata.Score, 'f', -1, 64)
+	}
+	if r.hasStats {
+		views = strconv.FormatUint(r.stats.Views, 10)
+		likes = strconv.FormatUint(r.stats.Likes, 10)
+		comments = strconv.FormatUint(r.stats.Comments, 10)
+		perDay = strconv.FormatFloat(r.perDay, 'f', 1, 64)
+	}
+
+	return []string{
+		strconv.Itoa(rank),
+		r.key,
+		r.clip.Metadata.Title,
+		r.clip.Source.Title,
+		r.clip.Rendered.Format(time.RFC3339),
+		uploaded,
+		aiScore,
+		views,
+		likes,
+		comments,
+		perDay,
+		strconv.FormatFloat(r.score, 'f', 3, 64),
+	}
+}
+
+// summary describes the signals of a winner in a line.
+func (r rankedClip) summary() string {
+	var parts []string
+	if r.clip.Metadata.Score > 0 {
+		parts = append(parts, fmt.Sprintf("AI score %g", r.clip.Metadata.Score))
+	}
+	if r.hasStats {
+		parts = append(parts, fmt.Sprintf("%d views, %.0f a day", r.stats.Views, r.perDay))
+	}
+	if len(parts) == 0 {
+		return "(unranked)"
+	}
+	return "(" + strings.Join(parts, ", ") + ")"
+}
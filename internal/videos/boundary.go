@@ -0,0 +1,338 @@
+package videos
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/rogersilvasouza/godeogoker/internal/config"
+)
+
+// Boundary previews draw previewSpan seconds to each side of a boundary, in
+// previewColumns waveform columns per second. Peaks at previewFloor dB or
+// below are drawn as the lowest bar.
+const (
+	previewSpan    = 8
+	previewColumns = 4
+	previewFloor   = -48.0
+	waveformRate   = 8000 // Sample rate, in Hz, the waveform is measured at
+)
+
+// waveformBars are the bars of the waveform, from the quietest to the loudest.
+var waveformBars = []rune("▁▂▃▄▅▆▇█")
+
+// Keys of a boundary preview.
+const (
+	keyBeginEarlier = 'a'
+	keyBeginLater   = 'd'
+	keyEndEarlier   = 'j'
+	keyEndLater     = 'l'
+	keyQuit         = 'q'
+)
+
+// boundaryClip is a rendered clip whose cut boundaries are being previewed,
+// with the cached cut list it was rendered from.
+type boundaryClip struct {
+	dir     string           // Folder of the source video
+	videoID string           // Source video ID
+	name    string           // File name of the clip without extension
+	file    string           // Cache the cut was found in, cuts.json or parts.json
+	cuts    map[string][]Cut // Contents of file
+	group   string           // Key of the list of the cut in file
+	index   int              // Position of the cut in its list
+}
+
+// findBoundaryClip returns the clip of channel named key, or whose output key
+// is key, such as the key of a clip held back for review.
+func findBoundaryClip(channel config.Channel, key string) (*boundaryClip, error) {
+	if !strings.Contains(key, "/") {
+		library, err := ListClips(channel.Folder)
+		if err != nil {
+			return nil, fmt.Errorf("error listing clips of %s: %v", channel.Name, err)
+		}
+		clips, err := selectClips(channel, library, CompileOptions{Clips: []string{key}})
+		if err != nil {
+			return nil, err
+		}
+		key = OutputKey(channel.Folder, clips[0].Clip)
+	}
+
+	videoID, _, _ := strings.Cut(key, "/")
+	clip := &boundaryClip{
+		dir:     filepath.Join(channel.Folder, videoID),
+		videoID: videoID,
+		name:    strings.TrimSuffix(path.Base(key), path.Ext(key)),
+	}
+
+	for _, file := range []string{cutsFile, partsFile} {
+		content, err := os.ReadFile(filepath.Join(clip.dir, file))
+		if err != nil {
+			continue
+		}
+		cached := make(map[string][]Cut)
+		if err := json.Unmarshal(content, &cached); err != nil {
+			return nil, fmt.Errorf("error reading %s: %v", file, err)
+		}
+
+		groups := make([]string, 0, len(cached))
+		for group := range cached {
+			groups = append(groups, group)
+		}
+		sort.Strings(groups)
+		for _, group := range groups {
+			for i, cut := range cached[group] {
+				if safeFileName(cut.Title) == clip.name {
+					clip.file, clip.cuts, clip.group, clip.index = file, cached, group, i
+					return clip, nil
+				}
+			}
+		}
+	}
+	return nil, fmt.Errorf("no cached cut found for clip: %s", key)
+}
+
+// cut returns the cut of the clip.
+func (c *boundaryClip) cut() Cut {
+	return c.cuts[c.group][c.index]
+}
+
+// save replaces the cut of the clip in its cache, so later runs keep it.
+func (c *boundaryClip) save(cut Cut) error {
+	c.cuts[c.group][c.index] = cut
+	content, err := json.MarshalIndent(c.cuts, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(c.dir, c.file), content, 0644)
+}
+
+// Waveform returns the peak level, from 0 to 1, of each of columns equal
+// slices of the length seconds of the audio of input from start.
+func (r *FFmpegRenderer) Waveform(ctx context.Context, input string, start float64, length float64, columns int) ([]float64, error) {
+	ctx, cancel := withTimeout(ctx, r.Timeout, "ffmpeg")
+	defer cancel()
+
+	output, err := newCommand(
+		ctx,
+		r.FFmpeg,
+		"-ss", fmt.Sprintf("%.3f", start),
+		"-t", fmt.Sprintf("%.3f", length),
+		"-i", input,
+		"-vn",
+		"-ac", "1",
+		"-ar", fmt.Sprint(waveformRate),
+		"-f", "s16le",
+		"-",
+	).Output()
+	if err != nil {
+		return nil, fmt.Errorf("error reading audio: %v", timeoutCause(ctx, err))
+	}
+
+	peaks := make([]float64, columns)
+	total := int(length * waveformRate)
+	for i := 0; i+1 < len(output) && i/2 < total; i += 2 {
+		sample := math.Abs(float64(int16(binary.LittleEndian.Uint16(output[i:])))) / 32768
+		column := i / 2 * columns / total
+		peaks[column] = math.Max(peaks[column], sample)
+	}
+	return peaks, nil
+}
+
+// PreviewBoundaries shows the waveform and transcript around the begin and
+// end of the cut of the clip key of channel, and reads keys from input to
+// move them a second at a time. Each move is saved to the cached cuts and the
+// clip is rendered again right away; the video is marked resumable so the
+// next run refreshes its other versions. Keys pressed during a render are
+// applied together once it ends.
+func PreviewBoundaries(ctx context.Context, client *Client, channel config.Channel, key string, input io.Reader) error {
+	clip, err := findBoundaryClip(channel, key)
+	if err != nil {
+		return err
+	}
+
+	p, err := newPipeline(ctx, client, channel)
+	if err != nil {
+		return fmt.Errorf("error configuring %v", err)
+	}
+
+	videoFile := filepath.Join(clip.dir, clip.videoID+".mp4")
+	if _, err := os.Stat(videoFile); err != nil {
+		return fmt.Errorf("source video not found: %s", videoFile)
+	}
+	duration, err := p.renderer.Duration(ctx, videoFile)
+	if err != nil {
+		return err
+	}
+	entries, _ := parseVTTFile(p.media(clip.videoID, clip.dir).Captions)
+
+	keys := make(chan byte)
+	go func() {
+		defer close(keys)
+		buf := make([]byte, 1)
+		for {
+			if _, err := input.Read(buf); err != nil {
+				return
+			}
+			keys <- buf[0]
+		}
+	}()
+
+	cut := clip.cut()
+	for {
+		p.showBoundaries(ctx, videoFile, duration, entries, cut)
+		fmt.Println(descriptionStyle.Render(fmt.Sprintf("%c/%c: begin 1s earlier/later   %c/%c: end 1s earlier/later   %c: quit",
+			keyBeginEarlier, keyBeginLater, keyEndEarlier, keyEndLater, keyQuit)))
+
+		moved, quit := cut, false
+		for moved.Begin == cut.Begin && moved.End == cut.End && !quit {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case k, ok := <-keys:
+				quit = !ok || !moveBoundary(&moved, k, duration)
+			}
+		}
+		for pending := !quit; pending; {
+			select {
+			case k, ok := <-keys:
+				quit = !ok || !moveBoundary(&moved, k, duration)
+				pending = !quit
+			default:
+				pending = false
+			}
+		}
+
+		if moved.Begin != cut.Begin || moved.End != cut.End {
+			cut = moved
+			if err := p.rerenderClip(ctx, clip, videoFile, entries, cut); err != nil {
+				return err
+			}
+		}
+		if quit {
+			return nil
+		}
+	}
+}
+
+// moveBoundary applies the key k to cut, keeping it inside a source video of
+// duration seconds and at least a second long. It returns false when k ends
+// the preview.
+func moveBoundary(cut *Cut, k byte, duration float64) bool {
+	switch k {
+	case keyQuit:
+		return false
+	case keyBeginEarlier:
+		cut.Begin = max(cut.Begin-1, 0)
+	case keyBeginLater:
+		cut.Begin = min(cut.Begin+1, cut.End-1)
+	case keyEndEarlier:
+		cut.End = max(cut.End-1, cut.Begin+1)
+	case keyEndLater:
+		cut.End = min(cut.End+1, int(duration))
+	}
+	return true
+}
+
+// rerenderClip saves the moved cut of clip and renders the clip again from
+// videoFile, leaving the video resumable for its other versions.
+func (p *pipeline) rerenderClip(ctx context.Context, clip *boundaryClip, videoFile string, entries []SubtitleEntry, cut Cut) error {
+	if err := clip.save(cut); err != nil {
+		return fmt.Errorf("error saving the cut: %v", err)
+	}
+	if err := markResumable(clip.dir); err != nil {
+		return fmt.Errorf("error marking video as in progress: %v", err)
+	}
+
+	if entries != nil && p.channel.Hook != "" {
+		cut.Hook = p.findHook(ctx, clip.dir, clip.videoID, entries, cut)
+	}
+	cut.Pace = p.lowEnergy(ctx, clip.videoID, videoFile, cut)
+
+	ext := Format(p.channel.Outputs.Clip).ext()
+	defer os.Remove(filepath.Join(clip.dir, "temp_"+clip.name+ext))
+	if p.renderClip(ctx, clip.videoID, clip.dir, clip.name, videoFile, entries, cut, p.clipSettings(cut, entries != nil)) {
+		fmt.Println(subtitleStyle.Render("Run 'godeogoker exec' to render the other versions of the clip again"))
+	}
+	return nil
+}
+
+// showBoundaries prints the waveform and transcript around both boundaries of
+// cut, in a source video of duration seconds.
+func (p *pipeline) showBoundaries(ctx context.Context, videoFile string, duration float64, entries []SubtitleEntry, cut Cut) {
+	fmt.Println(titleStyle.Render(fmt.Sprintf("%s: %s to %s (%ds)", cut.Title, chapterClock(cut.Begin), chapterClock(cut.End), cut.End-cut.Begin)))
+	p.showBoundary(ctx, "Begin", cut.Begin, videoFile, duration, entries, cut)
+	p.showBoundary(ctx, "End", cut.End, videoFile, duration, entries, cut)
+}
+
+// showBoundary prints the waveform around the boundary at second at, marking
+// it, and the captions said around it. What falls inside cut is highlighted.
+func (p *pipeline) showBoundary(ctx context.Context, label string, at int, videoFile string, duration float64, entries []SubtitleEntry, cut Cut) {
+	start := max(at-previewSpan, 0)
+	end := min(at+previewSpan, int(duration))
+	fmt.Println(optionStyle.Render(fmt.Sprintf("%s at %s", label, chapterClock(at))))
+
+	if end > start {
+		peaks, err := p.renderer.Waveform(ctx, videoFile, float64(start), float64(end-start), (end-start)*previewColumns)
+		if err != nil {
+			fmt.Println(errorStyle.Render("Error drawing the waveform: " + err.Error()))
+		} else {
+			fmt.Println(waveformLine(peaks, start, cut))
+			fmt.Println(strings.Repeat(" ", (at-start)*previewColumns) + commandStyle.Render("▲"))
+		}
+	}
+
+	for _, entry := range entries {
+		if entry.EndTime.Seconds() <= float64(start) || entry.StartTime.Seconds() >= float64(end) {
+			continue
+		}
+		second := int(entry.StartTime.Seconds())
+		line := fmt.Sprintf("  %s  %s", chapterClock(second), entry.Text)
+		if second >= cut.Begin && second < cut.End {
+			fmt.Println(successStyle.Render(line))
+		} else {
+			fmt.Println(descriptionStyle.Render(line))
+		}
+	}
+}
+
+// waveformLine draws peaks measured from second start as bars, highlighting
+// the columns inside cut.
+func waveformLine(peaks []float64, start int, cut Cut) string {
+	var line strings.Builder
+	var run []rune
+	inside := false
+	flush := func() {
+		if inside {
+			line.WriteString(successStyle.Render(string(run)))
+		} else {
+			line.WriteString(descriptionStyle.Render(string(run)))
+		}
+		run = run[:0]
+	}
+
+	for i, peak := range peaks {
+		level := 0.0
+		if peak > 0 {
+			level = math.Min(math.Max((20*math.Log10(peak)-previewFloor)/-previewFloor, 0), 1)
+		}
+		at := float64(start) + float64(i)/previewColumns
+		in := at >= float64(cut.Begin) && at < float64(cut.End)
+		if in != inside && len(run) > 0 {
+			flush()
+		}
+		inside = in
+		run = append(run, waveformBars[int(level*float64(len(waveformBars)-1))])
+	}
+	if len(run) > 0 {
+		flush()
+	}
+	return line.String()
+}
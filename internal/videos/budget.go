@@ -0,0 +1,121 @@
+package videos
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rogersilvasouza/godeogoker/internal/ai"
+	"github.com/rogersilvasouza/godeogoker/internal/config"
+	"github.com/rogersilvasouza/godeogoker/internal/state"
+)
+
+// Default OpenAI prices in USD per million tokens, matching gpt-4o-mini.
+const (
+	DefaultInputPrice  = 0.15
+	DefaultOutputPrice = 0.60
+)
+
+// Usage is the token count reported by the language model for a single request.
+type Usage = ai.Usage
+
+// Budget tracks the language model spend of a channel against its per-run and
+// per-month ceilings. A nil *Budget allows every request.
+type Budget struct {
+	Channel     string       // Configured channel ID the spend is tracked for
+	MaxRun      float64      // USD ceiling for the current run, 0 for no limit
+	MaxMonth    float64      // USD ceiling for the calendar month, 0 for no limit
+	InputPrice  float64      // USD per million prompt tokens
+	OutputPrice float64      // USD per million completion tokens
+	Store       *state.Store // Persists the monthly spend across runs
+
+	mu  sync.Mutex
+	run float64 // USD spent during this run
+}
+
+// NewBudget returns the budget configured for channel, or nil when the channel
+// has no spending limits. The prices of the ai block of the channel replace
+// those of the openai block.
+func NewBudget(cfg *config.Config, channel config.Channel, store *state.Store) *Budget {
+	if channel.MaxRunCost <= 0 && channel.MaxMonthlyCost <= 0 {
+		return nil
+	}
+
+	budget := &Budget{
+		Channel:  channel.ID,
+		MaxRun:   channel.MaxRunCost,
+		MaxMonth: channel.MaxMonthlyCost,
+		Store:    store,
+	}
+	budget.InputPrice, budget.OutputPrice = modelPrices(cfg, channel)
+
+	return budget
+}
+
+// modelPrices returns the prices in USD per million prompt and completion
+// tokens of the language model of channel: those of its ai block, or of the
+// openai block, defaulting to the prices of gpt-4o-mini.
+func modelPrices(cfg *config.Config, channel config.Channel) (input float64, output float64) {
+	input, output = cfg.OpenAI.InputPrice, cfg.OpenAI.OutputPrice
+	if channel.AI != nil {
+		input, output = channel.AI.InputPrice, channel.AI.OutputPrice
+	}
+	if input == 0 && output == 0 {
+		return DefaultInputPrice, DefaultOutputPrice
+	}
+	return input, output
+}
+
+// month returns the key the monthly spend is stored under.
+func month() string {
+	return time.Now().Format("2006-01")
+}
+
+// cost converts a token count into USD.
+func (b *Budget) cost(usage Usage) float64 {
+	return (float64(usage.PromptTokens)*b.InputPrice + float64(usage.CompletionTokens)*b.OutputPrice) / 1e6
+}
+
+// Check returns ErrBudgetExceeded when a request of about promptTokens input
+// tokens would take the spend over one of the ceilings.
+func (b *Budget) Check(promptTokens int) error {
+	if b == nil {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	estimate := b.cost(Usage{PromptTokens: promptTokens})
+
+	if b.MaxRun > 0 && b.run+estimate > b.MaxRun {
+		return newError(ErrBudgetExceeded, "", "", fmt.Errorf("run limit of $%.2f reached ($%.4f spent)", b.MaxRun, b.run))
+	}
+
+	if b.MaxMonth > 0 && b.Store != nil {
+		spent := b.Store.MonthSpend(b.Channel, month())
+		if spent+estimate > b.MaxMonth {
+			return newError(ErrBudgetExceeded, "", "", fmt.Errorf("monthly limit of $%.2f reached ($%.4f spent)", b.MaxMonth, spent))
+		}
+	}
+
+	return nil
+}
+
+// Record adds the cost of a completed request to the run and monthly spend.
+func (b *Budget) Record(usage Usage) error {
+	if b == nil {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	cost := b.cost(usage)
+	b.run += cost
+
+	if b.Store == nil {
+		return nil
+	}
+	return b.Store.AddSpend(b.Channel, month(), cost)
+}
@@ -0,0 +1,205 @@
+package videos
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+var (
+	// youtubeChannelID matches the IDs of YouTube channels.
+	youtubeChannelID = regexp.MustCompile(`^UC[A-Za-z0-9_-]{22}$`)
+	// pageChannelID finds the channel ID in the HTML of a channel page: its
+	// canonical link, or the externalId of its embedded data.
+	pageChannelID = regexp.MustCompile(`<link rel="canonical" href="https://www\.youtube\.com/channel/(UC[A-Za-z0-9_-]{22})"|"externalId":"(UC[A-Za-z0-9_-]{22})"`)
+)
+
+// maxChannelPage is the part of a channel page read looking for its ID.
+const maxChannelPage = 4 << 20
+
+// YouTubeChannel is a channel found by ResolveChannel.
+type YouTubeChannel struct {
+	ID          string // Channel ID, starting with UC
+	Title       string // Name of the channel
+	Handle      string // @handle of the channel, empty when it has none
+	Description string // Description of the channel
+}
+
+// URL returns the address of the channel page, by handle when it has one.
+func (ch YouTubeChannel) URL() string {
+	if ch.Handle != "" {
+		return "https://www.youtube.com/" + ch.Handle
+	}
+	return "https://www.youtube.com/channel/" + ch.ID
+}
+
+// ResolveChannel finds the YouTube channel of target with the YouTube Data
+// API: a channel URL, an @handle or a channel ID. Legacy /c/ custom URLs are
+// looked up as handles, which most of them became, since the API does not
+// know them.
+func ResolveChannel(ctx context.Context, client *Client, target string) (YouTubeChannel, error) {
+	kind, value, err := parseChannelTarget(target)
+	if err != nil {
+		return YouTubeChannel{}, err
+	}
+
+	service, err := client.youtubeService(ctx)
+	if err != nil {
+		return YouTubeChannel{}, err
+	}
+	call := service.Channels.List([]string{"snippet"}).MaxResults(1).Context(ctx)
+	switch kind {
+	case "id":
+		call = call.Id(value)
+	case "username":
+		call = call.ForUsername(value)
+	case "custom":
+		call = call.ForHandle("@" + value)
+	default:
+		call = call.ForHandle(value)
+	}
+	response, err := call.Do()
+	if err != nil {
+		return YouTubeChannel{}, fmt.Errorf("error looking up channel %s: %v", target, err)
+	}
+	if len(response.Items) == 0 || response.Items[0].Snippet == nil {
+		return YouTubeChannel{}, fmt.Errorf("no YouTube channel found for %s", target)
+	}
+
+	item := response.Items[0]
+	channel := YouTubeChannel{ID: item.Id, Title: item.Snippet.Title, Description: item.Snippet.Description}
+	if strings.HasPrefix(item.Snippet.CustomUrl, "@") {
+		channel.Handle = item.Snippet.CustomUrl
+	}
+	return channel, nil
+}
+
+// resolveChannelID returns the YouTube channel ID of target, the channel_id of
+// a channel or source: a channel ID, or the URL of one, is returned as is,
+// while an @handle or another channel URL is resolved from the channel page, or with the YouTube Data API
+// when the page does not tell, and kept in the state store so it is only
+// resolved once.
+func (c *Client) resolveChannelID(ctx context.Context, target string) (string, error) {
+	target = strings.TrimSpace(target)
+	if target == "" || youtubeChannelID.MatchString(target) {
+		return target, nil
+	}
+	kind, value, err := parseChannelTarget(target)
+	if err != nil {
+		return "", newError(ErrFeedFailed, "", "", err)
+	}
+	if kind == "id" {
+		return value, nil
+	}
+	key := strings.ToLower(kind + ":" + value)
+	if c.State != nil {
+		if id, ok := c.State.ResolvedChannel(key); ok {
+			return id, nil
+		}
+	}
+
+	id, err := c.scrapeChannelID(ctx, channelPage(kind, value))
+	if err != nil {
+		fmt.Println(descriptionStyle.Render(fmt.Sprintf("%v, asking the YouTube Data API", err)))
+		found, apiErr := ResolveChannel(ctx, c, target)
+		if apiErr != nil {
+			return "", newError(ErrFeedFailed, "", "", fmt.Errorf("error resolving channel %s: %v", target, apiErr))
+		}
+		id = found.ID
+	}
+	fmt.Println(descriptionStyle.Render(fmt.Sprintf("Channel %s resolved to %s", target, id)))
+
+	if c.State != nil {
+		if err := c.State.SaveResolvedChannel(key, id); err != nil {
+			fmt.Println(errorStyle.Render("Error saving state: " + err.Error()))
+		}
+	}
+	return id, nil
+}
+
+// scrapeChannelID returns the channel ID found in the channel page at
+// address, requested like the RSS feeds.
+func (c *Client) scrapeChannelID(ctx context.Context, address string) (string, error) {
+	ctx, cancel := withTimeout(ctx, feedTimeout(c.Config.FeedFetch), "channel page request")
+	defer cancel()
+
+	req, doer, err := c.feedRequest(ctx, address)
+	if err != nil {
+		return "", err
+	}
+	resp, err := doer.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("error requesting %s: %v", address, timeoutCause(ctx, err))
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("channel page %s answered %s", address, resp.Status)
+	}
+
+	page, err := io.ReadAll(io.LimitReader(resp.Body, maxChannelPage))
+	if err != nil {
+		return "", fmt.Errorf("error reading %s: %v", address, err)
+	}
+	match := pageChannelID.FindSubmatch(page)
+	if match == nil {
+		return "", fmt.Errorf("no channel ID found in %s", address)
+	}
+	return string(match[1]) + string(match[2]), nil
+}
+
+// channelPage returns the URL of the page of the channel looked up by kind
+// with value, as returned by parseChannelTarget.
+func channelPage(kind string, value string) string {
+	switch kind {
+	case "username":
+		return "https://www.youtube.com/user/" + url.PathEscape(value)
+	case "custom":
+		return "https://www.youtube.com/c/" + url.PathEscape(value)
+	default:
+		return "https://www.youtube.com/" + url.PathEscape(value)
+	}
+}
+
+// parseChannelTarget returns how the channel of target is looked up, by id,
+// handle, username or custom URL name, and the value to look up.
+func parseChannelTarget(target string) (string, string, error) {
+	target = strings.TrimSpace(target)
+	switch {
+	case youtubeChannelID.MatchString(target):
+		return "id", target, nil
+	case strings.HasPrefix(target, "@") && len(target) > 1:
+		return "handle", target, nil
+	}
+
+	address := target
+	if !strings.Contains(address, "://") {
+		address = "https://" + address
+	}
+	u, err := url.Parse(address)
+	host := ""
+	if err == nil {
+		host = strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+		host = strings.TrimPrefix(host, "m.")
+	}
+	if host != "youtube.com" {
+		return "", "", fmt.Errorf("%q is not a YouTube channel URL, @handle or channel ID", target)
+	}
+
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	switch {
+	case strings.HasPrefix(segments[0], "@") && len(segments[0]) > 1:
+		return "handle", segments[0], nil
+	case len(segments) < 2:
+	case segments[0] == "channel" && youtubeChannelID.MatchString(segments[1]):
+		return "id", segments[1], nil
+	case segments[0] == "user":
+		return "username", segments[1], nil
+	case segments[0] == "c":
+		return "custom", segments[1], nil
+	}
+	return "", "", fmt.Errorf("%q is not a YouTube channel URL: use the address of the channel page, e.g. https://www.youtube.com/@handle", target)
+}
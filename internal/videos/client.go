@@ -0,0 +1,295 @@
+package videos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/rogersilvasouza/godeogoker/internal/ai"
+	"github.com/rogersilvasouza/godeogoker/internal/config"
+	"github.com/rogersilvasouza/godeogoker/internal/events"
+	"github.com/rogersilvasouza/godeogoker/internal/state"
+)
+
+// Default endpoints of the remote services used by the pipeline.
+const (
+	DefaultFeedURL          = "https://www.youtube.com/feeds/videos.xml"
+	DefaultOpenAIURL        = "https://api.openai.com/v1/chat/completions"
+	DefaultTranscriptionURL = "https://api.openai.com/v1/audio/transcriptions"
+)
+
+// Defaults of the RSS feed requests, used when the configuration does not set them.
+const (
+	DefaultFeedUserAgent = "Mozilla/5.0 (compatible; godeogoker; +https://github.com/rogersilvasouza/godeogoker)"
+	DefaultFeedTimeout   = 30 * time.Second
+)
+
+// Doer sends HTTP requests. *http.Client satisfies it, and tests can provide
+// a fake returning canned RSS feeds or language model responses.
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// Client performs the remote calls of the pipeline: fetching channel feeds and
+// talking to the language model. Both the transport and the endpoints can be replaced,
+// which makes feed and model calls testable offline. Config provides the tool
+// paths and credentials used by the whole pipeline.
+type Client struct {
+	Config           *config.Config // Application configuration
+	HTTP             Doer           // Transport used for every request
+	FeedHTTP         Doer           // Transport of the RSS feed requests, nil to use HTTP
+	Events           *events.Bus    // Receives pipeline lifecycle events, may be nil
+	State            *state.Store   // Run state persisted across executions, may be nil
+	Budget           *Budget        // Language model spending limits, nil for no limits
+	Errors           *ErrorBudget   // Failed videos allowed before a channel or the run stops, nil for no limits
+	Models           *ModelStats    // Language model requests of the run and their failed attempts, nil to not count them
+	Stages           *Stages        // Downloads and renders allowed at once across channels, nil for no limits
+	FeedURL          string         // Base URL of the YouTube channel RSS feed
+	OpenAIURL        string         // OpenAI chat completions endpoint
+	TranscriptionURL string         // OpenAI audio transcriptions endpoint
+	AI               ai.Provider    // Language model of the channel, nil to use the openai block
+	LongAI           ai.Provider    // Larger-context model of the channel for prompts too long for AI, nil for none
+	ContextWindow    int            // Context window of AI in tokens, 0 for its known size
+}
+
+// NewClient returns a Client for cfg using httpClient and the default endpoints.
+func NewClient(cfg *config.Config, httpClient Doer) *Client {
+	return &Client{
+		Config:           cfg,
+		HTTP:             httpClient,
+		Errors:           NewErrorBudget(cfg.ErrorBudget),
+		Models:           &ModelStats{},
+		Stages:           NewStages(cfg.Parallel),
+		FeedURL:          DefaultFeedURL,
+		OpenAIURL:        DefaultOpenAIURL,
+		TranscriptionURL: DefaultTranscriptionURL,
+	}
+}
+
+// NewFeedHTTP returns the HTTP client of the RSS feed requests of settings,
+// sent through its proxy or, without one, through the proxy of the
+// HTTPS_PROXY, HTTP_PROXY and NO_PROXY environment variables.
+func NewFeedHTTP(settings config.FeedFetch) (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyFromEnvironment
+	if settings.Proxy != "" {
+		proxy, err := url.Parse(settings.Proxy)
+		if err != nil || proxy.Host == "" {
+			return nil, fmt.Errorf("invalid feed_fetch.proxy %q", settings.Proxy)
+		}
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+	return &http.Client{Transport: transport}, nil
+}
+
+// feedTimeout returns the time limit of each RSS feed request, 0 for none.
+func feedTimeout(settings config.FeedFetch) time.Duration {
+	switch {
+	case settings.Timeout < 0:
+		return 0
+	case settings.Timeout == 0:
+		return DefaultFeedTimeout
+	default:
+		return time.Duration(settings.Timeout) * time.Second
+	}
+}
+
+// WithBudget returns a copy of the client whose model calls are charged to budget.
+func (c *Client) WithBudget(budget *Budget) *Client {
+	clone := *c
+	clone.Budget = budget
+	return &clone
+}
+
+// languageModel returns the language model provider of the client: the one
+// of its channel, or the model of the openai block sent to OpenAIURL.
+func (c *Client) languageModel() ai.Provider {
+	if c.AI != nil {
+		return c.AI
+	}
+	return &ai.OpenAI{HTTP: c.HTTP, URL: c.OpenAIURL, Key: c.Config.OpenAI.Key, Name: c.Config.OpenAI.Model, JSONMode: true}
+}
+
+// longModel returns the larger-context model prompts too long for the
+// language model are sent to, nil when none is configured.
+func (c *Client) longModel() ai.Provider {
+	if c.AI != nil {
+		return c.LongAI
+	}
+	if c.Config.OpenAI.LongModel == "" {
+		return nil
+	}
+	return &ai.OpenAI{HTTP: c.HTTP, URL: c.OpenAIURL, Key: c.Config.OpenAI.Key, Name: c.Config.OpenAI.LongModel, JSONMode: true}
+}
+
+// contextWindow returns the context window of the language model in tokens:
+// the configured one, or the known size of the model.
+func (c *Client) contextWindow() int {
+	window := c.ContextWindow
+	if c.AI == nil {
+		window = c.Config.OpenAI.ContextWindow
+	}
+	if window > 0 {
+		return window
+	}
+	return ai.ContextWindow(c.languageModel().Model())
+}
+
+// routeModel returns the model a prompt of tokens estimated tokens is sent
+// to: the language model when the prompt and the longest reply fit its
+// context window, the long model when they only fit its own. It returns nil
+// when the prompt fits neither.
+func (c *Client) routeModel(tokens int) ai.Provider {
+	if tokens+ai.DefaultMaxTokens <= c.contextWindow() {
+		return c.languageModel()
+	}
+	if long := c.longModel(); long != nil && tokens+ai.DefaultMaxTokens <= ai.ContextWindow(long.Model()) {
+		return long
+	}
+	return nil
+}
+
+// hasLanguageModel reports whether a language model is configured, either
+// for the channel of the client or through the openai key.
+func (c *Client) hasLanguageModel() bool {
+	return c.AI != nil || c.Config.OpenAI.Key != ""
+}
+
+// chatCompletion sends a chat request asking the language model for a JSON
+// object and hands the reply to parse. A prompt too long for the context
+// window of the language model goes to the long model, and fails with an
+// ErrLLMRequest error without one, instead of being truncated.
+// Transport errors, rate limits, server errors and parse failures are retried
+// up to three times with exponential backoff, or after the wait the provider
+// asks for when longer; each attempt is bounded by timeout. A rejected key
+// fails right away with an ErrAuth error, a rate limit that outlasts the
+// retries or an account out of credit with an ErrLLMRateLimit one, and other
+// rejected requests with an ErrLLMRequest one. Every failed attempt is printed
+// with the status and body of the response, recorded in the debug log of ctx
+// and counted in the model stats of the client.
+// Every attempt is checked against and charged to the client budget. The
+// request and the accepted response are recorded for purpose in the prompt
+// log of ctx, if any.
+func (c *Client) chatCompletion(ctx context.Context, purpose string, timeout time.Duration, systemPrompt string, userPrompt string, parse func(content string) error) error {
+	promptTokens := ai.EstimateTokens(systemPrompt) + ai.EstimateTokens(userPrompt)
+	model := c.routeModel(promptTokens)
+	if model == nil {
+		return newError(ErrLLMRequest, "", "", fmt.Errorf("prompt of about %d tokens exceeds the %d token context window of %s", promptTokens, c.contextWindow(), c.languageModel().Model()))
+	}
+	if model.Model() != c.languageModel().Model() {
+		fmt.Println(subtitleStyle.Render(fmt.Sprintf("Prompt of about %d tokens exceeds the %d token context window of %s, using %s", promptTokens, c.contextWindow(), c.languageModel().Model(), model.Model())))
+	}
+
+	maxRetries := 3
+	var lastErr error
+	var wait time.Duration
+	c.Models.request()
+
+	for attempt := 0; attempt < maxRetries; attempt++ {
+		if attempt > 0 {
+			if err := sleepContext(ctx, wait); err != nil {
+				return newError(ErrLLMRequest, "", "", err)
+			}
+		}
+
+		if err := c.Budget.Check(promptTokens); err != nil {
+			return err
+		}
+
+		requestCtx, cancel := context.WithTimeout(ctx, timeout)
+		content, usage, err := model.Complete(requestCtx, systemPrompt, userPrompt)
+		cancel()
+		if err != nil {
+			kind, retry := modelFailure(err)
+			lastErr = newError(kind, "", "", err)
+			if wait, retry = c.retryWait(ctx, purpose, model.Model(), attempt+1, maxRetries, lastErr, retry); !retry {
+				break
+			}
+			continue
+		}
+
+		if err := c.Budget.Record(usage); err != nil {
+			fmt.Println(errorStyle.Render("Error recording model spend: " + err.Error()))
+		}
+
+		if err := parse(content); err != nil {
+			lastErr = newError(ErrLLMParse, "", "", fmt.Errorf("%v in reply %q", err, replyExcerpt(content)))
+			var retry bool
+			if wait, retry = c.retryWait(ctx, purpose, model.Model(), attempt+1, maxRetries, lastErr, true); !retry {
+				break
+			}
+			continue
+		}
+
+		recordPrompt(ctx, purpose, model.Model(), model.Parameters(), systemPrompt, userPrompt, content)
+		return nil
+	}
+
+	c.Models.requestFailed()
+	return lastErr
+}
+
+// retryBackoff is the wait before the first retry of a model request, doubled
+// before each further one.
+var retryBackoff = 2 * time.Second
+
+// maxRetryAfter is the longest wait asked for by a provider that a request is
+// retried after; a longer one fails the request.
+const maxRetryAfter = 2 * time.Minute
+
+// maxReplyExcerpt is the part of a reply that could not be parsed quoted in
+// the error.
+const maxReplyExcerpt = 200
+
+// modelFailure returns the kind of the failed model request err and whether
+// it is worth retrying: transport errors, rate limits and server errors are,
+// rejected keys and requests are not.
+func modelFailure(err error) (*Kind, bool) {
+	var status *ai.StatusError
+	switch {
+	case !errors.As(err, &status):
+		return ErrLLMRequest, true
+	case status.Unauthorized():
+		return ErrAuth, false
+	case status.RateLimited():
+		return ErrLLMRateLimit, status.Temporary()
+	default:
+		return ErrLLMRequest, status.Temporary()
+	}
+}
+
+// retryWait reports the failed attempt of the purpose request to model, out
+// of maxAttempts, and returns how long to wait before the next one and whether
+// to make it: retry is false once the attempts are spent or when the provider
+// asks to wait longer than maxRetryAfter.
+func (c *Client) retryWait(ctx context.Context, purpose string, model string, attempt int, maxAttempts int, err error, retry bool) (time.Duration, bool) {
+	wait := retryBackoff << uint(attempt-1)
+	var status *ai.StatusError
+	if errors.As(err, &status) && status.RetryAfter > wait {
+		wait = status.RetryAfter
+	}
+	retry = retry && attempt < maxAttempts && wait <= maxRetryAfter
+
+	message := fmt.Sprintf("Attempt %d/%d of the %s request to %s failed [%s]: %v", attempt, maxAttempts, purpose, model, CodeOf(err), err)
+	if retry {
+		message += fmt.Sprintf(", retrying in %s", wait)
+	}
+	message = c.Config.Redact(message)
+	fmt.Println(errorStyle.Render(message))
+	debugLogOf(ctx).note(message)
+	c.Models.attemptFailed(CodeOf(err))
+	return wait, retry
+}
+
+// replyExcerpt returns the start of the reply content on a single line.
+func replyExcerpt(content string) string {
+	text := strings.Join(strings.Fields(content), " ")
+	if runes := []rune(text); len(runes) > maxReplyExcerpt {
+		text = string(runes[:maxReplyExcerpt]) + "..."
+	}
+	return text
+}
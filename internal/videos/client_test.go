@@ -0,0 +1,300 @@
+package videos
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rogersilvasouza/godeogoker/internal/ai"
+	"github.com/rogersilvasouza/godeogoker/internal/config"
+)
+
+// reply is a canned answer of fakeDoer: a response, or err when it is set.
+type reply struct {
+	status int
+	header http.Header
+	body   string
+	err    error
+}
+
+// fakeDoer answers each request with the next of its replies, repeating the
+// last one once they run out, and keeps the requests it received.
+type fakeDoer struct {
+	mu       sync.Mutex
+	replies  []reply
+	requests []*http.Request
+}
+
+func (d *fakeDoer) Do(req *http.Request) (*http.Response, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.requests = append(d.requests, req)
+	r := d.replies[min(len(d.requests), len(d.replies))-1]
+	if r.err != nil {
+		return nil, r.err
+	}
+	header := r.header
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		StatusCode: r.status,
+		Status:     http.StatusText(r.status),
+		Header:     header,
+		Body:       io.NopCloser(strings.NewReader(r.body)),
+		Request:    req,
+	}, nil
+}
+
+// completion returns the chat completions reply whose message is content.
+func completion(content string) reply {
+	message, _ := json.Marshal(content)
+	return reply{status: http.StatusOK, body: `{"choices": [{"message": {"content": ` + string(message) + `}}], "usage": {"prompt_tokens": 10, "completion_tokens": 5}}`}
+}
+
+// testClient returns a Client sending its requests to doer, with retries
+// waiting a millisecond instead of seconds.
+func testClient(t *testing.T, doer *fakeDoer) *Client {
+	t.Helper()
+	backoff := retryBackoff
+	retryBackoff = time.Millisecond
+	t.Cleanup(func() { retryBackoff = backoff })
+
+	cfg := &config.Config{OpenAI: config.OpenAI{Key: "key", Model: "gpt-4o-mini"}}
+	client := NewClient(cfg, doer)
+	client.FeedURL = "https://feeds.test/videos.xml"
+	client.OpenAIURL = "https://models.test/v1/chat/completions"
+	return client
+}
+
+// parseAnswer accepts replies holding an answer key.
+func parseAnswer(content string) error {
+	if !strings.Contains(content, "answer") {
+		return errors.New("no answer")
+	}
+	return nil
+}
+
+func TestChatCompletionRetriesUntilAnAttemptSucceeds(t *testing.T) {
+	doer := &fakeDoer{replies: []reply{
+		{status: http.StatusInternalServerError, body: "overloaded"},
+		completion(`{"other": 1}`),
+		completion(`{"answer": 42}`),
+	}}
+	client := testClient(t, doer)
+
+	if err := client.chatCompletion(context.Background(), "cuts", time.Second, "system", "user", parseAnswer); err != nil {
+		t.Fatal(err)
+	}
+	if len(doer.requests) != 3 {
+		t.Errorf("requests = %d, want 3", len(doer.requests))
+	}
+	if got := doer.requests[0].Header.Get("Authorization"); got != "Bearer key" {
+		t.Errorf("Authorization = %q", got)
+	}
+	want := "1 language model requests, 0 failed; failed attempts: llm_parse 1, llm_request 1"
+	if summary := client.Models.Summary(); summary != want {
+		t.Errorf("summary = %q, want %q", summary, want)
+	}
+}
+
+func TestChatCompletionClassifiesFailures(t *testing.T) {
+	tests := []struct {
+		name     string
+		reply    reply
+		kind     *Kind
+		attempts int
+	}{
+		{"rejected key", reply{status: http.StatusUnauthorized, body: "invalid key"}, ErrAuth, 1},
+		{"forbidden key", reply{status: http.StatusForbidden}, ErrAuth, 1},
+		{"rate limit", reply{status: http.StatusTooManyRequests, body: "slow down"}, ErrLLMRateLimit, 3},
+		{"out of credit", reply{status: http.StatusTooManyRequests, body: `{"error": {"code": "insufficient_quota"}}`}, ErrLLMRateLimit, 1},
+		{"long retry after", reply{status: http.StatusTooManyRequests, header: http.Header{"Retry-After": {"600"}}}, ErrLLMRateLimit, 1},
+		{"server error", reply{status: http.StatusBadGateway}, ErrLLMRequest, 3},
+		{"rejected request", reply{status: http.StatusBadRequest, body: "bad model"}, ErrLLMRequest, 1},
+		{"transport error", reply{err: errors.New("connection reset")}, ErrLLMRequest, 3},
+		{"unparsable reply", completion(`{"other": 1}`), ErrLLMParse, 3},
+		{"no choices", reply{status: http.StatusOK, body: `{"choices": []}`}, ErrLLMRequest, 3},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			doer := &fakeDoer{replies: []reply{test.reply}}
+			client := testClient(t, doer)
+
+			err := client.chatCompletion(context.Background(), "cuts", time.Second, "system", "user", parseAnswer)
+			if !errors.Is(err, test.kind) {
+				t.Errorf("error = %v, want kind %s", err, test.kind.Code)
+			}
+			if len(doer.requests) != test.attempts {
+				t.Errorf("attempts = %d, want %d", len(doer.requests), test.attempts)
+			}
+			if summary := client.Models.Summary(); !strings.HasPrefix(summary, "1 language model requests, 1 failed") {
+				t.Errorf("summary = %q", summary)
+			}
+		})
+	}
+}
+
+func TestChatCompletionRejectsPromptsOverTheContextWindow(t *testing.T) {
+	doer := &fakeDoer{replies: []reply{completion(`{"answer": 1}`)}}
+	client := testClient(t, doer)
+	client.Config.OpenAI.ContextWindow = 100
+
+	err := client.chatCompletion(context.Background(), "cuts", time.Second, "system", strings.Repeat("word ", 100), parseAnswer)
+	if !errors.Is(err, ErrLLMRequest) || !strings.Contains(err.Error(), "context window") {
+		t.Errorf("error = %v", err)
+	}
+	if len(doer.requests) != 0 {
+		t.Errorf("requests = %d, want none", len(doer.requests))
+	}
+
+	client.Config.OpenAI.LongModel = "gpt-4.1-mini"
+	if err := client.chatCompletion(context.Background(), "cuts", time.Second, "system", strings.Repeat("word ", 100), parseAnswer); err != nil {
+		t.Fatal(err)
+	}
+	if len(doer.requests) != 1 || !strings.Contains(requestBody(t, doer.requests[0]), "gpt-4.1-mini") {
+		t.Error("the long prompt was not sent to the long model")
+	}
+}
+
+func requestBody(t *testing.T, req *http.Request) string {
+	t.Helper()
+	body, err := req.GetBody()
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := io.ReadAll(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(content)
+}
+
+func TestChatCompletionStopsWhenCancelled(t *testing.T) {
+	doer := &fakeDoer{replies: []reply{{status: http.StatusServiceUnavailable}}}
+	client := testClient(t, doer)
+	retryBackoff = time.Minute
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+	err := client.chatCompletion(ctx, "cuts", time.Second, "system", "user", parseAnswer)
+	if !errors.Is(err, context.Canceled) || !errors.Is(err, ErrLLMRequest) {
+		t.Errorf("error = %v, want a cancelled ErrLLMRequest", err)
+	}
+	if len(doer.requests) != 1 {
+		t.Errorf("requests = %d, want 1", len(doer.requests))
+	}
+}
+
+func TestRetryWait(t *testing.T) {
+	client := testClient(t, &fakeDoer{})
+	retryBackoff = 2 * time.Second
+
+	tests := []struct {
+		name      string
+		attempt   int
+		err       error
+		retry     bool
+		wantWait  time.Duration
+		wantRetry bool
+	}{
+		{"first attempt", 1, newError(ErrLLMRequest, "", "", errors.New("reset")), true, 2 * time.Second, true},
+		{"second attempt doubles", 2, newError(ErrLLMRequest, "", "", errors.New("reset")), true, 4 * time.Second, true},
+		{"last attempt", 3, newError(ErrLLMRequest, "", "", errors.New("reset")), true, 8 * time.Second, false},
+		{"not worth retrying", 1, newError(ErrAuth, "", "", &ai.StatusError{StatusCode: 401}), false, 2 * time.Second, false},
+		{"longer retry after", 1, newError(ErrLLMRateLimit, "", "", &ai.StatusError{StatusCode: 429, RetryAfter: 30 * time.Second}), true, 30 * time.Second, true},
+		{"shorter retry after", 2, newError(ErrLLMRateLimit, "", "", &ai.StatusError{StatusCode: 429, RetryAfter: time.Second}), true, 4 * time.Second, true},
+		{"retry after too long", 1, newError(ErrLLMRateLimit, "", "", &ai.StatusError{StatusCode: 429, RetryAfter: maxRetryAfter + time.Second}), true, maxRetryAfter + time.Second, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			wait, retry := client.retryWait(context.Background(), "cuts", "gpt-4o-mini", test.attempt, 3, test.err, test.retry)
+			if wait != test.wantWait || retry != test.wantRetry {
+				t.Errorf("retryWait = %s, %v, want %s, %v", wait, retry, test.wantWait, test.wantRetry)
+			}
+		})
+	}
+}
+
+const testFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom" xmlns:media="http://search.yahoo.com/mrss/">
+  <entry>
+    <id>yt:video:abc123</id>
+    <title>First video</title>
+    <published>2026-10-01T10:00:00+00:00</published>
+    <media:group><media:description>About the first video</media:description></media:group>
+  </entry>
+</feed>`
+
+func TestFetchFeed(t *testing.T) {
+	doer := &fakeDoer{replies: []reply{{status: http.StatusOK, body: testFeed}}}
+	client := testClient(t, doer)
+	client.Config.FeedFetch = config.FeedFetch{UserAgent: "agent/1.0", Headers: map[string]string{"Accept-Language": "pt-BR"}}
+
+	videos, err := client.fetchFeed(context.Background(), "channel_id=UC123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Video{ID: "abc123", Title: "First video", Description: "About the first video", Published: "2026-10-01T10:00:00+00:00"}
+	if len(videos) != 1 || videos[0] != want {
+		t.Errorf("videos = %+v", videos)
+	}
+
+	req := doer.requests[0]
+	if req.URL.String() != "https://feeds.test/videos.xml?channel_id=UC123" {
+		t.Errorf("URL = %s", req.URL)
+	}
+	if req.Header.Get("User-Agent") != "agent/1.0" || req.Header.Get("Accept-Language") != "pt-BR" {
+		t.Errorf("headers = %v", req.Header)
+	}
+}
+
+func TestFetchFeedFailures(t *testing.T) {
+	tests := []struct {
+		name  string
+		reply reply
+		want  string
+	}{
+		{"transport error", reply{err: errors.New("no route to host")}, "no route to host"},
+		{"not found", reply{status: http.StatusNotFound}, "answered Not Found"},
+		{"server error", reply{status: http.StatusInternalServerError, body: testFeed}, "answered Internal Server Error"},
+		{"invalid feed", reply{status: http.StatusOK, body: "<html>consent</html>"}, "error parsing RSS feed"},
+		{"truncated feed", reply{status: http.StatusOK, body: testFeed[:200]}, "error parsing RSS feed"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := testClient(t, &fakeDoer{replies: []reply{test.reply}})
+
+			videos, err := client.fetchFeed(context.Background(), "channel_id=UC123")
+			if !errors.Is(err, ErrFeedFailed) || !strings.Contains(err.Error(), test.want) {
+				t.Errorf("error = %v, want an ErrFeedFailed one with %q", err, test.want)
+			}
+			if videos != nil {
+				t.Errorf("videos = %+v, want none", videos)
+			}
+		})
+	}
+}
+
+func TestFetchFeedUsesTheFeedTransport(t *testing.T) {
+	models := &fakeDoer{replies: []reply{{err: errors.New("wrong transport")}}}
+	feeds := &fakeDoer{replies: []reply{{status: http.StatusOK, body: testFeed}}}
+	client := testClient(t, models)
+	client.FeedHTTP = feeds
+
+	if _, err := client.fetchFeed(context.Background(), "channel_id=UC123"); err != nil {
+		t.Fatal(err)
+	}
+	if len(models.requests) != 0 || len(feeds.requests) != 1 {
+		t.Errorf("requests = %d through HTTP, %d through FeedHTTP", len(models.requests), len(feeds.requests))
+	}
+}
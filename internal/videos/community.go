@@ -0,0 +1,168 @@
+package videos
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rogersilvasouza/godeogoker/internal/config"
+	"github.com/rogersilvasouza/godeogoker/internal/events"
+)
+
+// communitySuffix is the suffix of the Community post teaser written next
+// to an uploaded clip, replacing its extension.
+const communitySuffix = ".community.json"
+
+// Poll is a poll suggested for a Community post.
+type Poll struct {
+	Question string   `json:"question"` // Question asked to the subscribers
+	Options  []string `json:"options"`  // Between two and four answers
+}
+
+// CommunityPost is the teaser of an uploaded clip for the Community tab of
+// the channel. The YouTube Data API cannot publish Community posts, so they
+// are kept next to the clip and announced with a community_post event for
+// whoever posts them.
+type CommunityPost struct {
+	Key       string    `json:"key"`                  // Output key of the clip, relative to the channel folder
+	Text      string    `json:"text"`                 // Teaser text of the post
+	Poll      *Poll     `json:"poll,omitempty"`       // Suggested poll, when the channel asks for one
+	URL       string    `json:"url"`                  // Link to the uploaded clip
+	PublishAt string    `json:"publish_at,omitempty"` // RFC 3339 time the clip goes public, when scheduled
+	Created   time.Time `json:"created"`              // When the teaser was written
+}
+
+// GenerateCommunityPost asks the language model for a Community post teasing
+// the upload titled title with description, and for a poll when poll is set.
+// The post is written in language, or in the language of the title when empty.
+// Failures are returned as *Error values of kind ErrLLMRequest or ErrLLMParse.
+func (c *Client) GenerateCommunityPost(ctx context.Context, title string, description string, poll bool, language string) (*CommunityPost, error) {
+	format := `{"text": "The teaser"}`
+	pollInstruction := ""
+	if poll {
+		format = `{"text": "The teaser", "poll": {"question": "A question about the clip", "options": ["2 to 4 short answers"]}}`
+		pollInstruction = `
+	Also suggest a poll about the subject of the clip: a question subscribers want to answer and 2 to 4 answers
+	of at most 65 characters each.`
+	}
+
+	systemPrompt := fmt.Sprintf(`You are a social media manager writing YouTube Community posts that promote the new clips of a channel to its subscribers.
+	Write a short teaser of at most three sentences that makes subscribers want to watch the clip, without spoiling how it ends,
+	ending with an invitation to watch it. Use at most two emojis and no hashtags.%s
+	Return only a JSON object in the format: %s
+
+	%s`, pollInstruction, format, languageInstruction(language))
+
+	userPrompt := fmt.Sprintf("Clip title: %s\n\nClip description:\n%s", title, description)
+
+	var post CommunityPost
+	err := c.chatCompletion(ctx, purposeCommunity, 60*time.Second, systemPrompt, userPrompt, func(content string) error {
+		if err := json.Unmarshal([]byte(content), &post); err != nil {
+			return err
+		}
+		if strings.TrimSpace(post.Text) == "" {
+			return fmt.Errorf("empty teaser")
+		}
+		if post.Poll != nil && (len(post.Poll.Options) < 2 || len(post.Poll.Options) > 4) {
+			return fmt.Errorf("poll with %d options, expected 2 to 4", len(post.Poll.Options))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !poll {
+		post.Poll = nil
+	}
+
+	return &post, nil
+}
+
+// clipURL returns the link to the uploaded clip youtubeID, as a Short when
+// it is a vertical clip.
+func clipURL(clip string, youtubeID string) string {
+	if filepath.Base(filepath.Dir(clip)) == "vertical" {
+		return "https://www.youtube.com/shorts/" + youtubeID
+	}
+	return "https://www.youtube.com/watch?v=" + youtubeID
+}
+
+// writeCommunityPost writes the Community post of the clip of channel
+// uploaded as youtubeID with details, unless the channel writes none, the
+// post already exists or the YouTube video ID is unknown. Only the uploads in
+// the language of the channel get a post.
+func (c *Client) writeCommunityPost(ctx context.Context, channel config.Channel, videoID string, cut string, clip string, details uploadDetails, youtubeID string) error {
+	if channel.Community == nil || youtubeID == "" {
+		return nil
+	}
+	path := strings.TrimSuffix(clip, filepath.Ext(clip)) + communitySuffix
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+
+	fmt.Println(commandStyle.Render("Writing the Community post of the clip..."))
+	post, err := c.GenerateCommunityPost(withPromptCut(ctx, cut), details.Title, details.Description, channel.Community.Poll, channel.Language)
+	if err != nil {
+		return withContext(err, ErrLLMRequest, videoID, cut)
+	}
+	post.Key = OutputKey(channel.Folder, clip)
+	post.URL = clipURL(clip, youtubeID)
+	post.PublishAt = details.PublishAt
+	post.Created = time.Now()
+
+	content, _ := json.MarshalIndent(post, "", "  ")
+	if err := writeFileAtomic(path, content, 0644); err != nil {
+		fmt.Println(errorStyle.Render("Error writing Community post: " + err.Error()))
+		return nil
+	}
+
+	fmt.Println(successStyle.Render("Community post written: " + filepath.Base(path)))
+	c.Events.Emit(events.Event{Type: events.CommunityPost, Channel: channel.ID, VideoID: videoID, Cut: cut, Path: path})
+	return nil
+}
+
+// communityPost writes the Community post of the clip uploaded under key,
+// reading its YouTube video ID from the state store.
+func (p *pipeline) communityPost(ctx context.Context, videoID string, cut Cut, clip string, details uploadDetails, key string) {
+	if p.channel.Community == nil || p.client.State == nil || key != p.outputKey(clip) {
+		return
+	}
+	_, youtubeID, _ := p.client.State.Upload(p.channel.ID, key)
+	if err := p.client.writeCommunityPost(ctx, p.channel, videoID, cut.Title, clip, details, youtubeID); err != nil {
+		p.fail(err)
+	}
+}
+
+// CommunityPosts returns the Community posts of channel written after since,
+// the most recent first.
+func CommunityPosts(channel config.Channel, since time.Time) ([]CommunityPost, error) {
+	matches, err := filepath.Glob(filepath.Join(channel.Folder, "*", "*", "*"+communitySuffix))
+	if err != nil {
+		return nil, err
+	}
+
+	var posts []CommunityPost
+	for _, match := range matches {
+		content, err := os.ReadFile(match)
+		if err != nil {
+			return nil, err
+		}
+		var post CommunityPost
+		if err := json.Unmarshal(content, &post); err != nil {
+			return nil, fmt.Errorf("error parsing %s: %v", match, err)
+		}
+		if post.Created.After(since) {
+			posts = append(posts, post)
+		}
+	}
+
+	sort.Slice(posts, func(i, j int) bool {
+		return posts[i].Created.After(posts[j].Created)
+	})
+	return posts, nil
+}
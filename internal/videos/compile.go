@@ -0,0 +1,294 @@
+package videos
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rogersilvasouza/godeogoker/internal/config"
+)
+
+// compilationsFolder is the folder inside the channel folder where the
+// compilations are written by default.
+const compilationsFolder = "compilations"
+
+// Compilations are rendered in 1920x1080 at compileFPS frames per second and
+// compileSampleRate Hz, unless the horizontal output of the channel sets the
+// rates, since joined clips must share their format.
+const (
+	compileWidth      = 1920
+	compileHeight     = 1080
+	compileFPS        = 30
+	compileSampleRate = 48000
+)
+
+// maxCompileTags is how many tags of the joined clips a compilation is
+// uploaded with.
+const maxCompileTags = 15
+
+// chapterEscaper escapes the characters with a meaning in ffmetadata files.
+var chapterEscaper = strings.NewReplacer(`\`, `\\`, "=", `\=`, ";", `\;`, "#", `\#`, "\n", `\`+"\n")
+
+// CompileOptions selects the clips of a compilation and how they are joined.
+type CompileOptions struct {
+	Clips      []string      // Names or output keys of the clips to join, in order; empty to select by Since
+	Since      time.Duration // Join the clips rendered in this period, oldest first, when Clips is empty
+	Transition string        // xfade transition between clips, e.g. fade or wipeleft, none for hard cuts
+	Fade       float64       // Length of each transition in seconds
+	Title      string        // Title of the compilation, defaults to "Best of {channel}"
+	Output     string        // Output video, defaults to compilations/{date}.mp4 inside the channel folder
+	Upload     bool          // Upload the compilation to YouTube as unlisted
+}
+
+// Compile joins inputs one after the other into output, crossing
+// each into the next with the xfade transition over fade seconds, or with
+// hard cuts when transition is "none". durations are the lengths of the
+// inputs in seconds. Every input is scaled, padded and resampled to the same
+// format first, and chapters are embedded as chapter markers.
+func (r *FFmpegRenderer) Compile(ctx context.Context, inputs []string, durations []float64, transition string, fade float64, chapters []Chapter, output string) error {
+	format := Format{FPS: compileFPS, SampleRate: compileSampleRate}
+	if r.Horizontal.FPS > 0 {
+		format.FPS = r.Horizontal.FPS
+	}
+	if r.Horizontal.SampleRate > 0 {
+		format.SampleRate = r.Horizontal.SampleRate
+	}
+
+	var args, filters []string
+	for i, input := range inputs {
+		args = append(args, "-i", input)
+		filters = append(filters,
+			fmt.Sprintf("[%d:v]scale=%d:%d:force_original_aspect_ratio=decrease,pad=%[2]d:%[3]d:(ow-iw)/2:(oh-ih)/2,setsar=1,fps=%d,format=yuv420p,settb=AVTB[v%[1]d]", i, compileWidth, compileHeight, format.FPS),
+			fmt.Sprintf("[%d:a]aresample=%d,aformat=sample_fmts=fltp:channel_layouts=stereo[a%[1]d]", i, format.SampleRate),
+		)
+	}
+
+	if transition == "none" {
+		var streams strings.Builder
+		for i := range inputs {
+			fmt.Fprintf(&streams, "[v%d][a%d]", i, i)
+		}
+		filters = append(filters, fmt.Sprintf("%sconcat=n=%d:v=1:a=1[outv][outa]", streams.String(), len(inputs)))
+	} else {
+		video, audio := "v0", "a0"
+		offset := 0.0
+		for i := 1; i < len(inputs); i++ {
+			offset += durations[i-1] - fade
+			nextVideo, nextAudio := fmt.Sprintf("x%d", i), fmt.Sprintf("y%d", i)
+			if i == len(inputs)-1 {
+				nextVideo, nextAudio = "outv", "outa"
+			}
+			filters = append(filters,
+				fmt.Sprintf("[%s][v%d]xfade=transition=%s:duration=%.3f:offset=%.3f[%s]", video, i, filterValue(transition), fade, offset, nextVideo),
+				fmt.Sprintf("[%s][a%d]acrossfade=d=%.3f[%s]", audio, i, fade, nextAudio),
+			)
+			video, audio = nextVideo, nextAudio
+		}
+	}
+
+	metadata, err := os.CreateTemp("", "chapters-*.txt")
+	if err != nil {
+		return fmt.Errorf("error writing chapters: %v", err)
+	}
+	defer os.Remove(metadata.Name())
+
+	var total float64
+	for _, duration := range durations {
+		total += duration
+	}
+	if transition != "none" {
+		total -= fade * float64(len(inputs)-1)
+	}
+	fmt.Fprintln(metadata, ";FFMETADATA1")
+	for i, chapter := range chapters {
+		end := int(total * 1000)
+		if i+1 < len(chapters) {
+			end = chapters[i+1].Start * 1000
+		}
+		fmt.Fprintf(metadata, "[CHAPTER]\nTIMEBASE=1/1000\nSTART=%d\nEND=%d\ntitle=%s\n", chapter.Start*1000, end, chapterEscaper.Replace(chapter.Title))
+	}
+	if err := metadata.Close(); err != nil {
+		return fmt.Errorf("error writing chapters: %v", err)
+	}
+
+	args = append(args,
+		"-i", metadata.Name(),
+		"-filter_complex", strings.Join(filters, ";"),
+		"-map", "[outv]",
+		"-map", "[outa]",
+		"-map_metadata", strconv.Itoa(len(inputs)),
+		"-map_chapters", strconv.Itoa(len(inputs)),
+	)
+	args = append(args, r.encode(r.Horizontal)...)
+	args = append(args, format.args()...)
+
+	return r.render(ctx, output, args...)
+}
+
+// CompileClips joins the clips of channel selected by options into a single
+// compilation video with chapter markers, writes its YouTube description
+// with the chapters next to it and, when asked, uploads it.
+func CompileClips(ctx context.Context, client *Client, channel config.Channel, options CompileOptions) error {
+	ctx = withPriorities(ctx, client.Config)
+	renderer, err := NewRenderer(client.Config, channel)
+	if err != nil {
+		return fmt.Errorf("renderer: %v", err)
+	}
+
+	library, err := ListClips(channel.Folder)
+	if err != nil {
+		return fmt.Errorf("error listing clips of %s: %v", channel.Name, err)
+	}
+	clips, err := selectClips(channel, library, options)
+	if err != nil {
+		return err
+	}
+	if len(clips) < 2 {
+		return fmt.Errorf("a compilation needs at least two clips, found %d", len(clips))
+	}
+
+	if options.Transition == "" {
+		options.Transition = "fade"
+	}
+	if options.Title == "" {
+		options.Title = "Best of " + channel.Name
+	}
+	if options.Output == "" {
+		options.Output = filepath.Join(channel.Folder, compilationsFolder, time.Now().Format("2006-01-02")+Format(channel.Outputs.Horizontal).ext())
+	}
+	if err := os.MkdirAll(filepath.Dir(options.Output), 0755); err != nil {
+		return fmt.Errorf("error creating compilation folder: %v", err)
+	}
+
+	var inputs []string
+	var durations []float64
+	var chapters []Chapter
+	start := 0.0
+	for _, clip := range clips {
+		duration, err := renderer.Duration(ctx, clip.Clip)
+		if err != nil {
+			return err
+		}
+		if options.Transition != "none" && duration <= 2*options.Fade {
+			return fmt.Errorf("%s is too short for a %.1fs transition", clip.Name, options.Fade)
+		}
+
+		inputs = append(inputs, clip.Clip)
+		durations = append(durations, duration)
+		chapters = append(chapters, Chapter{Start: int(start), Title: clip.Metadata.Title})
+		start += duration
+		if options.Transition != "none" {
+			start -= options.Fade
+		}
+	}
+
+	fmt.Println(commandStyle.Render(fmt.Sprintf("Compiling %d clips into %s...", len(clips), options.Output)))
+	if err := renderer.Compile(ctx, inputs, durations, options.Transition, options.Fade, chapters, options.Output); err != nil {
+		return newError(ErrRenderFailed, "", options.Title, err)
+	}
+
+	description := compilationDescription(clips, chapters)
+	descriptionFile := strings.TrimSuffix(options.Output, filepath.Ext(options.Output)) + ".txt"
+	if err := writeFileAtomic(descriptionFile, []byte(description), 0644); err != nil {
+		return fmt.Errorf("error writing description: %v", err)
+	}
+	fmt.Println(successStyle.Render("Compilation created: " + options.Output))
+
+	if !options.Upload {
+		return nil
+	}
+
+	fmt.Println(commandStyle.Render("Uploading compilation to YouTube..."))
+	details := uploadDetails{Title: options.Title, Description: description, Tags: compilationTags(clips)}
+	id, err := client.uploadClip(ctx, options.Output, details)
+	if err != nil {
+		return withContext(err, ErrUploadFailed, "", options.Title)
+	}
+	if client.State != nil {
+		if err := client.State.MarkUploaded(channel.ID, OutputKey(channel.Folder, options.Output), id); err != nil {
+			fmt.Println(errorStyle.Render("Error recording upload: " + err.Error()))
+		}
+	}
+	fmt.Println(successStyle.Render("Compilation uploaded to YouTube successfully"))
+	return nil
+}
+
+// selectClips returns the clips of library named in options.Clips, by name or
+// output key and in that order, or else the clips rendered within
+// options.Since, oldest first.
+func selectClips(channel config.Channel, library []LibraryClip, options CompileOptions) ([]LibraryClip, error) {
+	var clips []LibraryClip
+	if len(options.Clips) > 0 {
+		for _, wanted := range options.Clips {
+			found := false
+			for _, clip := range library {
+				if clip.Name == wanted || OutputKey(channel.Folder, clip.Clip) == wanted {
+					clips = append(clips, clip)
+					found = true
+					break
+				}
+			}
+			if !found {
+				return nil, fmt.Errorf("clip not found: %s", wanted)
+			}
+		}
+		return clips, nil
+	}
+
+	since := time.Now().Add(-options.Since)
+	for _, clip := range library {
+		if clip.Rendered.After(since) {
+			clips = append(clips, clip)
+		}
+	}
+	sort.SliceStable(clips, func(i, j int) bool { return clips[i].Rendered.Before(clips[j].Rendered) })
+	return clips, nil
+}
+
+// compilationDescription lists the chapters of a compilation in the format
+// YouTube turns into chapters, followed by the source videos of its clips.
+func compilationDescription(clips []LibraryClip, chapters []Chapter) string {
+	var description strings.Builder
+	for _, chapter := range chapters {
+		fmt.Fprintf(&description, "%s %s\n", chapterClock(chapter.Start), chapter.Title)
+	}
+
+	description.WriteString("\nSources:\n")
+	seen := make(map[string]bool)
+	for _, clip := range clips {
+		if link := clip.Source.PageURL(); link != "" && !seen[link] {
+			seen[link] = true
+			fmt.Fprintf(&description, "%s %s\n", clip.Source.Title, link)
+		}
+	}
+	return description.String()
+}
+
+// compilationTags returns the first maxCompileTags distinct tags of clips.
+func compilationTags(clips []LibraryClip) []string {
+	var tags []string
+	seen := make(map[string]bool)
+	for _, clip := range clips {
+		for _, tag := range clip.Metadata.Tags {
+			if key := strings.ToLower(tag); !seen[key] && len(tags) < maxCompileTags {
+				seen[key] = true
+				tags = append(tags, tag)
+			}
+		}
+	}
+	return tags
+}
+
+// chapterClock formats a chapter start for a YouTube description, e.g. 04:05
+// or 1:02:03.
+func chapterClock(seconds int) string {
+	if seconds >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", seconds/3600, seconds%3600/60, seconds%60)
+	}
+	return fmt.Sprintf("%02d:%02d", seconds/60, seconds%60)
+}
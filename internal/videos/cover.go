@@ -0,0 +1,126 @@
+package videos
+
+import (
+	"strings"
+
+	"github.com/rogersilvasouza/godeogoker/internal/config"
+)
+
+// defaultWordsPerLine is how many words each line of the cover text holds
+// unless the channel sets words_per_line.
+const defaultWordsPerLine = 3
+
+// wrapTitle breaks a title into lines of at most perLine words so it fits a cover.
+func wrapTitle(title string, perLine int) string {
+	words := strings.Fields(title)
+	if len(words) <= perLine {
+		return title
+	}
+
+	var lines []string
+	for i := 0; i < len(words); i += perLine {
+		end := i + perLine
+		if end > len(words) {
+			end = len(words)
+		}
+		lines = append(lines, strings.Join(words[i:end], " "))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// coverVars returns the placeholders of the cover text and cover templates
+// for cut of the video videoID.
+func coverVars(channel config.Channel, videoID string, details *VideoDetails, cut Cut) map[string]string {
+	vars := details.vars()
+	vars["cut_title"] = cut.Title
+	vars["hook"] = ""
+	if cut.Hook != nil {
+		vars["hook"] = cut.Hook.Text
+	}
+	vars["channel"] = channel.Name
+	vars["video_id"] = videoID
+	return vars
+}
+
+// coverText returns the text drawn on the cover of cut: the cover text of its
+// editing sidecar or the cover_text of the channel with its placeholders
+// expanded, or the cut title. Text without line breaks of its own is broken
+// every words_per_line words. Separators left around empty placeholders are
+// dropped, and text that ends up empty falls back to the cut title.
+func coverText(channel config.Channel, cut Cut, vars map[string]string) string {
+	template := channel.CoverText
+	if cut.CoverText != "" {
+		template = cut.CoverText
+	}
+
+	text := vars["cut_title"]
+	if template != "" {
+		var lines []string
+		for _, line := range strings.Split(expandTemplate(template, vars), "\n") {
+			line = strings.Trim(strings.Join(strings.Fields(line), " "), " |-–—#:,")
+			if line != "" {
+				lines = append(lines, line)
+			}
+		}
+		if len(lines) > 1 {
+			return strings.Join(lines, "\n")
+		}
+		if len(lines) == 1 {
+			text = lines[0]
+		}
+	}
+
+	perLine := defaultWordsPerLine
+	if channel.Cover.WordsPerLine > 0 {
+		perLine = channel.Cover.WordsPerLine
+	}
+	return wrapTitle(text, perLine)
+}
+
+// expandTemplate replaces every {name} placeholder in text with vars[name].
+// Unknown placeholders are left untouched.
+func expandTemplate(text string, vars map[string]string) string {
+	pairs := make([]string, 0, len(vars)*2)
+	for name, value := range vars {
+		pairs = append(pairs, "{"+name+"}", value)
+	}
+	return strings.NewReplacer(pairs...).Replace(text)
+}
+
+// coverLayers converts the layers of a cover template into renderer layers,
+// expanding placeholders and inheriting the channel text style.
+func coverLayers(channel config.Channel, template *config.CoverTemplate, vars map[string]string) []Layer {
+	var layers []Layer
+	for _, layer := range template.Layers {
+		switch layer.Type {
+		case "image":
+			layers = append(layers, Layer{
+				Image: layer.Source,
+				Width: layer.Width,
+				X:     layer.X,
+				Y:     layer.Y,
+			})
+		case "text":
+			text := strings.TrimSpace(expandTemplate(layer.Text, vars))
+			if text == "" {
+				continue
+			}
+
+			style := coverStyle(channel)
+			if layer.Font != "" {
+				style.Font = layer.Font
+			}
+			if layer.FontSize != "" {
+				style.FontSize = layer.FontSize
+			}
+			if layer.FontColor != "" {
+				style.FontColor = layer.FontColor
+			}
+			style.X = layer.X
+			style.Y = layer.Y
+
+			layers = append(layers, Layer{Text: text, Style: style})
+		}
+	}
+	return layers
+}
@@ -0,0 +1,332 @@
+package videos
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/rogersilvasouza/godeogoker/internal/config"
+	"github.com/rogersilvasouza/godeogoker/internal/events"
+	"github.com/rogersilvasouza/godeogoker/internal/schedule"
+)
+
+// DefaultDaemonInterval is how often the daemon polls the feed of a channel
+// without a schedule.
+const DefaultDaemonInterval = 15 * time.Minute
+
+// watchedChannel is a channel polled by the daemon with its schedule.
+type watchedChannel struct {
+	channel  config.Channel
+	schedule schedule.Schedule
+	next     time.Time // Time of the next poll
+}
+
+// channelSchedule returns the schedule of the daemon polls of channel: its
+// cron expression in the channel timezone, or every interval without one.
+func channelSchedule(channel config.Channel, interval time.Duration) (schedule.Schedule, error) {
+	if channel.Schedule == "" {
+		return schedule.Interval(interval), nil
+	}
+	s, err := schedule.Parse(channel.Schedule)
+	if err != nil {
+		return nil, err
+	}
+	loc, err := channelLocation(channel)
+	if err != nil {
+		return nil, err
+	}
+	return schedule.In(s, loc), nil
+}
+
+// Watch runs the daemon: it polls the feed of each channel on its schedule
+// and processes the videos it has not seen yet, until ctx is cancelled. The
+// videos found by the polls are queued per channel and handed to the daemon
+// workers round-robin, so a channel publishing many videos does not hold back
+// the others, each channel having at most its max_in_flight videos processed
+// at once. The videos processed and the time of the last poll of each channel
+// are kept in the state store, so a restart neither processes a video again
+// nor polls before the next scheduled time. A video interrupted by the
+// cancellation is left resumable for the next poll. Invalid schedules are
+// returned before anything is polled.
+func Watch(ctx context.Context, client *Client, channels []config.Channel, interval time.Duration) error {
+	if client.State == nil {
+		return fmt.Errorf("the daemon needs a state store")
+	}
+
+	now := time.Now()
+	watched := make([]*watchedChannel, 0, len(channels))
+	for _, channel := range channels {
+		s, err := channelSchedule(channel, interval)
+		if err != nil {
+			return fmt.Errorf("schedule of channel %s: %v", channel.ID, err)
+		}
+		if s.Next(now).IsZero() {
+			return fmt.Errorf("schedule of channel %s never runs", channel.ID)
+		}
+		w := &watchedChannel{channel: channel, schedule: s, next: now}
+		if last := client.State.LastPoll(channel.ID); !last.IsZero() {
+			if next := s.Next(last); next.After(now) {
+				w.next = next
+			}
+		}
+		watched = append(watched, w)
+	}
+	if len(watched) == 0 {
+		return fmt.Errorf("no channels to watch")
+	}
+
+	queue := newDaemonQueue(client, channels)
+	ctx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	defer cancel()
+	for range max(client.Config.Daemon.Workers, 1) {
+		wg.Add(1)
+		go func() {
+			defer RemoveTempFilesOnPanic()
+			defer wg.Done()
+			queue.work(ctx)
+		}()
+	}
+
+	for {
+		due := watched[0]
+		for _, w := range watched[1:] {
+			if w.next.Before(due.next) {
+				due = w
+			}
+		}
+
+		if wait := time.Until(due.next); wait > 0 {
+			fmt.Println(subtitleStyle.Render(fmt.Sprintf("Next poll: %s at %s", due.channel.Name, due.next.Format("2006-01-02 15:04"))))
+			timer := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return nil
+			case <-timer.C:
+			}
+		}
+
+		polled := time.Now()
+		queue.poll(ctx, due.channel)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err := client.State.MarkPoll(due.channel.ID, polled); err != nil {
+			fmt.Println(errorStyle.Render("Error recording poll: " + err.Error()))
+		}
+		if due.next = due.schedule.Next(polled); due.next.IsZero() {
+			return fmt.Errorf("schedule of channel %s never runs again", due.channel.ID)
+		}
+	}
+}
+
+// daemonQueue holds the videos found by the polls of the daemon until a
+// worker processes them, serving the channels in turn.
+type daemonQueue struct {
+	client *Client
+	ready  chan struct{} // Wakes a waiting worker when a video can be taken
+
+	mu       sync.Mutex
+	channels []*channelQueue // Queues of the watched channels, served in this order
+	next     int             // Index of the channel served first by the next take
+}
+
+// channelQueue is the queue of the videos of one channel of the daemon.
+type channelQueue struct {
+	channel  config.Channel
+	limit    int             // Videos of the channel processed at once
+	pending  []Video         // Videos waiting for a worker, oldest poll first
+	queued   map[string]bool // IDs of the videos pending or being processed
+	inFlight int             // Videos being processed
+
+	client      *Client      // Client of the last poll, with the language model budget of the channel; tells its pipelines apart
+	errorBudget *ErrorBudget // Error budget of the last poll
+	idle        []*pipeline  // Pipelines of the last poll not processing a video
+}
+
+// newDaemonQueue returns an empty queue of the videos of channels.
+func newDaemonQueue(client *Client, channels []config.Channel) *daemonQueue {
+	q := &daemonQueue{client: client, ready: make(chan struct{}, 1)}
+	for _, channel := range channels {
+		q.channels = append(q.channels, &channelQueue{
+			channel: channel,
+			limit:   max(channel.MaxInFlight, 1),
+			queued:  make(map[string]bool),
+		})
+	}
+	return q
+}
+
+// signal wakes a waiting worker, if any.
+func (q *daemonQueue) signal() {
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+}
+
+// poll queues the videos in the feed of channel the daemon has not seen yet
+// and is not processing already. Each poll has its own error budget, so
+// failures of an earlier poll do not stop the later ones. A feed that cannot
+// be fetched is reported and polled again on schedule.
+func (q *daemonQueue) poll(ctx context.Context, channel config.Channel) {
+	fmt.Println(titleStyle.Render("Polling channel: " + channel.Name))
+
+	p, err := newPipeline(ctx, q.client, channel)
+	if err != nil {
+		fmt.Println(errorStyle.Render("Error configuring " + err.Error()))
+		return
+	}
+	p.errorBudget = NewErrorBudget(q.client.Config.ErrorBudget)
+
+	videos, err := q.client.GetLastVideos(ctx, channel)
+	if err != nil {
+		q.client.reportError(err)
+		q.client.Events.Emit(events.Event{Type: events.Failed, Channel: channel.ID, Code: CodeOf(err), Error: err.Error()})
+		return
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	cq := q.channels[slices.IndexFunc(q.channels, func(cq *channelQueue) bool { return cq.channel.ID == channel.ID })]
+	cq.client, cq.errorBudget, cq.idle = p.client, p.errorBudget, []*pipeline{p}
+
+	added := 0
+	for _, video := range videos {
+		if cq.queued[video.ID] || q.client.State.Seen(channel.ID, video.ID) {
+			continue
+		}
+		cq.pending = append(cq.pending, video)
+		cq.queued[video.ID] = true
+		added++
+	}
+	if added == 0 {
+		fmt.Println(subtitleStyle.Render("No new videos"))
+		return
+	}
+	fmt.Println(subtitleStyle.Render(fmt.Sprintf("%d new videos queued", added)))
+	q.signal()
+}
+
+// take returns the next video to process and the queue of its channel,
+// waiting until one is queued. The channels are served round-robin, skipping
+// those processing their max_in_flight videos already. It returns false once
+// ctx is cancelled.
+func (q *daemonQueue) take(ctx context.Context) (*channelQueue, Video, bool) {
+	for {
+		q.mu.Lock()
+		for i := range q.channels {
+			cq := q.channels[(q.next+i)%len(q.channels)]
+			if len(cq.pending) == 0 || cq.inFlight >= cq.limit {
+				continue
+			}
+			video := cq.pending[0]
+			cq.pending = cq.pending[1:]
+			cq.inFlight++
+			q.next = (q.next + i + 1) % len(q.channels)
+			q.mu.Unlock()
+			// Other videos may be waiting for another worker.
+			q.signal()
+			return cq, video, true
+		}
+		q.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return nil, Video{}, false
+		case <-q.ready:
+		}
+	}
+}
+
+// pipeline returns a pipeline of the last poll of cq not processing a video,
+// configuring another one sharing its budgets when all are busy.
+func (q *daemonQueue) pipeline(ctx context.Context, cq *channelQueue) (*pipeline, error) {
+	q.mu.Lock()
+	if n := len(cq.idle); n > 0 {
+		p := cq.idle[n-1]
+		cq.idle = cq.idle[:n-1]
+		q.mu.Unlock()
+		return p, nil
+	}
+	client, errorBudget := cq.client, cq.errorBudget
+	q.mu.Unlock()
+
+	p, err := newPipeline(ctx, q.client, cq.channel)
+	if err != nil {
+		return nil, err
+	}
+	p.client, p.errorBudget = client, errorBudget
+	return p, nil
+}
+
+// work processes the queued videos until ctx is cancelled.
+func (q *daemonQueue) work(ctx context.Context) {
+	for {
+		cq, video, ok := q.take(ctx)
+		if !ok {
+			return
+		}
+		q.process(ctx, cq, video)
+	}
+}
+
+// process processes video of the channel of cq and marks it seen once done.
+// A video still in progress, such as one whose download failed, is tried
+// again at the next poll. When the pipeline asks for the remaining videos to
+// be skipped, as once the error budget is spent, the videos of the channel
+// still queued wait for the next poll. The feeds of the channel are written
+// once its queue is empty.
+func (q *daemonQueue) process(ctx context.Context, cq *channelQueue, video Video) {
+	channel := cq.channel
+	p, err := q.pipeline(ctx, cq)
+	if err != nil {
+		fmt.Println(errorStyle.Render("Error configuring " + err.Error()))
+		q.finish(cq, video, nil, false)
+		return
+	}
+
+	fmt.Println(titleStyle.Render(fmt.Sprintf("Processing new video of %s (ID: %s)", channel.Name, video.ID)))
+	proceed := p.process(ctx, video, StageOptions{})
+	if ctx.Err() != nil {
+		q.finish(cq, video, nil, true)
+		return
+	}
+	if _, done := p.progress(filepath.Join(channel.Folder, video.ID), video.ID); done && proceed {
+		if err := q.client.State.MarkSeen(channel.ID, video.ID); err != nil {
+			fmt.Println(errorStyle.Render("Error recording seen video: " + err.Error()))
+		}
+	}
+
+	if q.finish(cq, video, p, proceed) {
+		p.writeFeeds(ctx)
+	}
+}
+
+// finish releases the slot of video in cq and returns p, when given, to the
+// pipelines of the channel unless a poll replaced them meanwhile. Unless
+// proceed is set, the videos of the channel still queued are dropped until
+// the next poll. It reports whether the queue of the channel is now empty.
+func (q *daemonQueue) finish(cq *channelQueue, video Video, p *pipeline, proceed bool) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	defer q.signal()
+
+	cq.inFlight--
+	delete(cq.queued, video.ID)
+	if !proceed {
+		for _, skipped := range cq.pending {
+			delete(cq.queued, skipped.ID)
+		}
+		cq.pending = nil
+	}
+	if p != nil && p.client == cq.client {
+		cq.idle = append(cq.idle, p)
+	}
+	return p != nil && len(cq.pending) == 0 && cq.inFlight == 0
+}
@@ -0,0 +1,161 @@
+package videos
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// debugLogFile is the name of the file inside a video folder keeping the full
+// output of every yt-dlp, ffmpeg and ffprobe call made for the video, and the
+// failed language model attempts.
+const debugLogFile = "debug.log"
+
+// debugLogKey is the context key of the debug log of the video being processed.
+type debugLogKey struct{}
+
+// debugLog appends the calls made for a video to its debug log. Calls may run
+// concurrently, so each is buffered and written whole once it exits.
+type debugLog struct {
+	mu   sync.Mutex
+	path string
+	file *os.File
+}
+
+// withDebugLog returns a context whose commands are recorded in the debug log
+// inside outputDir, and a function closing the log. The context is returned
+// unchanged when the log cannot be opened.
+func withDebugLog(ctx context.Context, outputDir string) (context.Context, func()) {
+	path := filepath.Join(outputDir, debugLogFile)
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		fmt.Println(errorStyle.Render("Error opening debug log: " + err.Error()))
+		return ctx, func() {}
+	}
+	return context.WithValue(ctx, debugLogKey{}, &debugLog{path: path, file: file}), func() { file.Close() }
+}
+
+// debugLogOf returns the debug log of ctx, nil when commands are not recorded.
+func debugLogOf(ctx context.Context) *debugLog {
+	log, _ := ctx.Value(debugLogKey{}).(*debugLog)
+	return log
+}
+
+// record appends the command line of cmd, its output and how it exited.
+func (l *debugLog) record(cmd *exec.Cmd, started time.Time, output []byte, err error) {
+	status := "exit status 0"
+	if err != nil {
+		status = err.Error()
+	}
+
+	var entry bytes.Buffer
+	fmt.Fprintf(&entry, "=== %s $ %s\n", started.Format(time.RFC3339), strings.Join(cmd.Args, " "))
+	entry.Write(output)
+	if len(output) > 0 && output[len(output)-1] != '\n' {
+		entry.WriteByte('\n')
+	}
+	fmt.Fprintf(&entry, "=== %s after %s\n\n", status, time.Since(started).Round(time.Millisecond))
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.file.Write(entry.Bytes())
+}
+
+// note appends a line about the processing of the video, such as a failed
+// language model request, to the log. A nil log records nothing.
+func (l *debugLog) note(text string) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	fmt.Fprintf(l.file, "=== %s %s\n\n", time.Now().Format(time.RFC3339), text)
+}
+
+// command is an external command whose output is copied into the debug log
+// of the video it runs for, if any.
+type command struct {
+	*exec.Cmd
+	log *debugLog
+}
+
+// Run starts the command and waits for it to exit.
+func (c *command) Run() error {
+	_, err := c.capture(false, false)
+	return err
+}
+
+// Output runs the command and returns its standard output.
+func (c *command) Output() ([]byte, error) {
+	return c.capture(true, false)
+}
+
+// CombinedOutput runs the command and returns its standard output and
+// standard error combined.
+func (c *command) CombinedOutput() ([]byte, error) {
+	return c.capture(true, true)
+}
+
+// capture runs the command, returning its standard output when stdout is set
+// and its standard error too when stderr is set. Without a debug log the
+// exec.Cmd methods are used as they are; with one, the whole output is
+// recorded and a failure names the log.
+func (c *command) capture(stdout bool, stderr bool) ([]byte, error) {
+	if c.log == nil {
+		switch {
+		case stderr:
+			return c.Cmd.CombinedOutput()
+		case stdout:
+			return c.Cmd.Output()
+		default:
+			return nil, c.Cmd.Run()
+		}
+	}
+
+	var mu sync.Mutex
+	var output, transcript bytes.Buffer
+	out, errOut := &lockedWriter{mu: &mu, w: &transcript}, &lockedWriter{mu: &mu, w: &transcript}
+	c.Stdout, c.Stderr = out, errOut
+	if stdout {
+		out.also = &output
+	}
+	if stderr {
+		errOut.also = &output
+	}
+
+	started := time.Now()
+	err := c.Cmd.Run()
+	c.log.record(c.Cmd, started, transcript.Bytes(), err)
+	if err != nil {
+		err = fmt.Errorf("%w (output in %s)", err, c.log.path)
+	}
+	if !stdout {
+		return nil, err
+	}
+	return output.Bytes(), err
+}
+
+// lockedWriter writes into a transcript shared by the standard output and
+// standard error of a command, and into also when set.
+type lockedWriter struct {
+	mu   *sync.Mutex
+	w    *bytes.Buffer
+	also *bytes.Buffer
+}
+
+// Write appends p to the transcript and to also.
+func (w *lockedWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.w.Write(p)
+	if w.also != nil {
+		w.also.Write(p)
+	}
+	return len(p), nil
+}
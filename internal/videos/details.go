@@ -0,0 +1,89 @@
+package videos
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// detailsFile is the name of the file caching the extracted details inside a video folder.
+const detailsFile = "details.json"
+
+// VideoDetails holds structured fields extracted from the source title and
+// description, available to title templates, cover templates and metadata.
+type VideoDetails struct {
+	Guest   string `json:"guest,omitempty"`   // Guest or main speaker names
+	Episode string `json:"episode,omitempty"` // Episode number
+	Date    string `json:"date,omitempty"`    // Recording or publication date (YYYY-MM-DD)
+}
+
+// vars returns the template placeholders provided by the details.
+func (d *VideoDetails) vars() map[string]string {
+	if d == nil {
+		return map[string]string{"guest": "", "episode": "", "date": ""}
+	}
+	return map[string]string{"guest": d.Guest, "episode": d.Episode, "date": d.Date}
+}
+
+// ExtractDetails asks the language model for the guest, episode number and date
+// mentioned in the source title and description of video.
+// Failures are returned as *Error values of kind ErrLLMRequest or ErrLLMParse.
+func (c *Client) ExtractDetails(ctx context.Context, video Video) (*VideoDetails, error) {
+	systemPrompt := `You extract structured information from the title and description of podcast and interview videos.
+	Return only a JSON object in the format: {"guest": "Guest or speaker names, comma separated", "episode": "Episode number digits only", "date": "Recording date as YYYY-MM-DD"}.
+	Use an empty string for every field that is not explicitly mentioned. Never guess.`
+
+	userPrompt := fmt.Sprintf("Title: %s\n\nDescription:\n%s", video.Title, video.Description)
+
+	var details VideoDetails
+	err := c.chatCompletion(ctx, purposeDetails, 60*time.Second, systemPrompt, userPrompt, func(content string) error {
+		return json.Unmarshal([]byte(content), &details)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &details, nil
+}
+
+// loadDetails returns the details of video, extracting them on the first run and
+// reading them from outputDir afterwards. The publication date of the feed is
+// used when no date is mentioned, and the only detail without a language model.
+// It returns nil when nothing is known about the video.
+func (p *pipeline) loadDetails(ctx context.Context, outputDir string, video Video) *VideoDetails {
+	path := filepath.Join(outputDir, detailsFile)
+
+	if content, err := os.ReadFile(path); err == nil {
+		var details VideoDetails
+		if err := json.Unmarshal(content, &details); err == nil {
+			return &details
+		}
+	}
+
+	if video.Title == "" {
+		return nil
+	}
+
+	details := &VideoDetails{}
+	if p.client.hasLanguageModel() {
+		fmt.Println(commandStyle.Render("Extracting guest and episode details..."))
+		extracted, err := p.client.ExtractDetails(ctx, video)
+		if err != nil {
+			p.fail(withContext(err, ErrLLMRequest, video.ID, ""))
+			return nil
+		}
+		details = extracted
+	}
+
+	if details.Date == "" && len(video.Published) >= len("2006-01-02") {
+		details.Date = video.Published[:len("2006-01-02")]
+	}
+
+	if content, err := json.MarshalIndent(details, "", "  "); err == nil {
+		writeFileAtomic(path, content, 0644)
+	}
+	return details
+}
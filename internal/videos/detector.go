@@ -0,0 +1,354 @@
+package videos
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/rogersilvasouza/godeogoker/internal/config"
+)
+
+// minDetectedCut is the shortest cut, in seconds, kept by the detectors that
+// do not ask the language model.
+const minDetectedCut = 20
+
+// defaultKeywordBefore is how many seconds before the first mention of a
+// keyword its cut starts when the channel does not set it.
+const defaultKeywordBefore = 5
+
+// titleWords is how many words of the transcript name a cut found without
+// the language model.
+const titleWords = 8
+
+// CutSource is what a CutDetector looks for cuts in.
+type CutSource struct {
+	Captions string          // Path to the WEBVTT captions, as in Media.Captions
+	Entries  []SubtitleEntry // Parsed captions, nil when they could not be read
+	Video    string          // Downloaded video file, empty when only the captions were downloaded
+	Duration float64         // Length of the video in seconds
+	Hints    *CutHints       // Signals about the source video
+}
+
+// CutDetector finds the cuts of a video. Cut times are absolute seconds of
+// the source video. Implementations must honor ctx.
+type CutDetector interface {
+	Detect(ctx context.Context, source CutSource) ([]Cut, error)
+}
+
+// NewCutDetectors returns the detectors configured for the channel, in order.
+// Supported values for channel.CutDetectors are "llm" (default), "chapters",
+// "interval", "keywords" and "energy"; only "llm" needs a language model.
+func NewCutDetectors(client *Client, channel config.Channel, renderer Renderer) ([]CutDetector, error) {
+	names := channel.CutDetectors
+	if len(names) == 0 {
+		names = []string{"llm"}
+	}
+
+	length := targetLength(channel)
+	var detectors []CutDetector
+	for _, name := range names {
+		switch name {
+		case "llm":
+			detectors = append(detectors, &LLMDetector{Client: client, Channel: channel})
+		case "chapters":
+			detectors = append(detectors, &ChapterDetector{MaxLength: 3 * length})
+		case "interval":
+			detectors = append(detectors, &IntervalDetector{Length: length})
+		case "keywords":
+			detectors = append(detectors, newKeywordDetector(channel, length))
+		case "energy":
+			detectors = append(detectors, &EnergyDetector{Renderer: renderer, Length: length, Count: max(channel.Excerpts, 1)})
+		default:
+			return nil, fmt.Errorf("unknown cut detector: %s", name)
+		}
+	}
+	return detectors, nil
+}
+
+// targetLength returns the target length of a cut in seconds, from the
+// stretch_time of the channel in minutes.
+func targetLength(channel config.Channel) int {
+	return max(channel.StretchTime, 1) * 60
+}
+
+// splitTopics returns the comma-separated topics of a channel.
+func splitTopics(topics string) []string {
+	var result []string
+	for _, topic := range strings.Split(topics, ",") {
+		if topic = strings.TrimSpace(topic); topic != "" {
+			result = append(result, topic)
+		}
+	}
+	return result
+}
+
+// detectCuts runs the detectors of the channel over source and combines their
+// cuts: a cut mostly overlapping one found by an earlier detector is dropped,
+// and repeated titles are numbered so every cut gets its own files.
+func (p *pipeline) detectCuts(ctx context.Context, source CutSource) ([]Cut, error) {
+	var cuts []Cut
+	for _, detector := range p.detectors {
+		found, err := detector.Detect(ctx, source)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, cut := range found {
+			if !overlapsMostly(cut, cuts) {
+				cuts = append(cuts, cut)
+			}
+		}
+	}
+	return uniqueTitles(cuts), nil
+}
+
+// needsMedia reports whether a detector of the pipeline works on the video
+// itself, so the cuts cannot be found from the captions alone.
+func (p *pipeline) needsMedia() bool {
+	for _, detector := range p.detectors {
+		if _, ok := detector.(*EnergyDetector); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// overlapsMostly reports whether more than half of cut, or of one of cuts,
+// is shared between them.
+func overlapsMostly(cut Cut, cuts []Cut) bool {
+	for _, other := range cuts {
+		shared := min(cut.End, other.End) - max(cut.Begin, other.Begin)
+		if shared > 0 && 2*shared > min(cut.End-cut.Begin, other.End-other.Begin) {
+			return true
+		}
+	}
+	return false
+}
+
+// uniqueTitles numbers the repeated titles of cuts.
+func uniqueTitles(cuts []Cut) []Cut {
+	seen := make(map[string]int)
+	for i, cut := range cuts {
+		key := strings.ToLower(safeFileName(cut.Title))
+		seen[key]++
+		if n := seen[key]; n > 1 {
+			cuts[i].Title = fmt.Sprintf("%s (%d)", cut.Title, n)
+		}
+	}
+	return cuts
+}
+
+// snapToCaptions widens [begin, end] to the start of the caption spoken at
+// begin and the end of the caption spoken at end, so cuts found by time do
+// not start or stop mid-sentence.
+func snapToCaptions(entries []SubtitleEntry, begin, end int) (int, int) {
+	for _, entry := range entries {
+		start, stop := entry.StartTime.Seconds(), entry.EndTime.Seconds()
+		if start <= float64(begin) && stop > float64(begin) {
+			begin = min(begin, int(start))
+		}
+		if start < float64(end) && stop >= float64(end) {
+			end = max(end, int(math.Ceil(stop)))
+		}
+	}
+	return begin, end
+}
+
+// excerptTitle names a cut found without the language model after the first
+// words spoken in it, followed by its start time.
+func excerptTitle(entries []SubtitleEntry, begin, end int) string {
+	var words []string
+	for _, entry := range entries {
+		if entry.StartTime < time.Duration(begin)*time.Second || entry.StartTime >= time.Duration(end)*time.Second {
+			continue
+		}
+		for _, word := range strings.Fields(cleanSubtitleText(entry.Text)) {
+			if len(words) == titleWords {
+				break
+			}
+			if !isMusicMarker(word) {
+				words = append(words, word)
+			}
+		}
+	}
+
+	if len(words) == 0 {
+		return "Excerpt " + titleClock(begin)
+	}
+	return fmt.Sprintf("%s… (%s)", strings.Join(words, " "), titleClock(begin))
+}
+
+// titleClock formats a time in seconds for a cut title, e.g. 12m05s. Colons
+// are avoided since titles become file names.
+func titleClock(seconds int) string {
+	return fmt.Sprintf("%dm%02ds", seconds/60, seconds%60)
+}
+
+// captionsDuration returns the end of the last caption in seconds.
+func captionsDuration(entries []SubtitleEntry) float64 {
+	var end time.Duration
+	for _, entry := range entries {
+		end = max(end, entry.EndTime)
+	}
+	return end.Seconds()
+}
+
+// LLMDetector asks the language model for the cuts about the channel topics.
+type LLMDetector struct {
+	Client  *Client        // Client calling the language model
+	Channel config.Channel // Channel providing the topics, excerpts, length and language
+}
+
+// Detect sends the captions and hints to the language model.
+func (d *LLMDetector) Detect(ctx context.Context, source CutSource) ([]Cut, error) {
+	channel := d.Channel
+	return d.Client.GetCuts(ctx, source.Captions, channel.Topics, channel.PromptExtra, channel.Excerpts, channel.StretchTime, channel.Language, source.Hints)
+}
+
+// ChapterDetector turns each chapter of the video description into a cut
+// named after the chapter.
+type ChapterDetector struct {
+	MaxLength int // Chapters longer than this many seconds are cut at this length
+}
+
+// Detect returns a cut per chapter, skipping the chapters shorter than
+// minDetectedCut and music chapters.
+func (d *ChapterDetector) Detect(ctx context.Context, source CutSource) ([]Cut, error) {
+	if source.Hints == nil {
+		return nil, nil
+	}
+
+	chapters := source.Hints.Chapters
+	var cuts []Cut
+	for i, chapter := range chapters {
+		end := int(source.Duration)
+		if i+1 < len(chapters) {
+			end = chapters[i+1].Start
+		}
+		if d.MaxLength > 0 && end-chapter.Start > d.MaxLength {
+			_, end = snapToCaptions(source.Entries, chapter.Start, chapter.Start+d.MaxLength)
+		}
+
+		if end-chapter.Start < minDetectedCut || musicChapter.MatchString(chapter.Title) {
+			continue
+		}
+		cuts = append(cuts, Cut{Title: chapter.Title, Begin: chapter.Start, End: end})
+	}
+	return cuts, nil
+}
+
+// IntervalDetector splits the whole video into consecutive cuts of the same length.
+type IntervalDetector struct {
+	Length int // Length of each cut in seconds
+}
+
+// Detect returns consecutive cuts of Length seconds, ending on the end of a
+// caption. A last cut shorter than minDetectedCut is dropped.
+func (d *IntervalDetector) Detect(ctx context.Context, source CutSource) ([]Cut, error) {
+	duration := int(source.Duration)
+	if d.Length <= 0 {
+		return nil, fmt.Errorf("interval length must be positive")
+	}
+
+	var cuts []Cut
+	for begin := 0; begin < duration; {
+		_, end := snapToCaptions(source.Entries, begin, min(begin+d.Length, duration))
+		end = min(end, duration)
+		if end-begin >= minDetectedCut {
+			cuts = append(cuts, Cut{Title: excerptTitle(source.Entries, begin, end), Begin: begin, End: end})
+		}
+		begin = end
+	}
+	return cuts, nil
+}
+
+// KeywordDetector finds the passages of the transcript mentioning configured
+// phrases, such as product names or the recurring segments of a show.
+type KeywordDetector struct {
+	Phrases   []string // Words or phrases to look for, matched case-insensitively
+	Before    int      // Seconds kept before the first mention of a passage
+	After     int      // Seconds kept after the last mention of a passage
+	MaxLength int      // Longest cut in seconds
+}
+
+// newKeywordDetector returns the keywords detector of the channel, looking
+// for the channel topics when no phrases are set. length is the target
+// length of a cut in seconds.
+func newKeywordDetector(channel config.Channel, length int) *KeywordDetector {
+	d := &KeywordDetector{Before: defaultKeywordBefore, After: length, MaxLength: 3 * length}
+	if keywords := channel.Keywords; keywords != nil {
+		d.Phrases = keywords.Phrases
+		if keywords.Before > 0 {
+			d.Before = keywords.Before
+		}
+		if keywords.After > 0 {
+			d.After = keywords.After
+		}
+	}
+	if len(d.Phrases) == 0 {
+		d.Phrases = splitTopics(channel.Topics)
+	}
+	return d
+}
+
+// Detect returns a cut per passage mentioning a phrase, from Before seconds
+// ahead of its first mention to After seconds past its last one. Mentions
+// closer than After seconds belong to the same passage. Phrases split across
+// two captions are found too.
+func (d *KeywordDetector) Detect(ctx context.Context, source CutSource) ([]Cut, error) {
+	entries := source.Entries
+	duration := int(source.Duration)
+
+	var cuts []Cut
+	var phrase string
+	first, last := -1, -1
+	flush := func() {
+		if first < 0 {
+			return
+		}
+
+		begin, end := snapToCaptions(entries, max(first-d.Before, 0), min(last+d.After, duration))
+		end = min(end, duration, begin+d.MaxLength)
+		if end-begin >= minDetectedCut {
+			cuts = append(cuts, Cut{Title: fmt.Sprintf("%s (%s)", phrase, titleClock(first)), Begin: begin, End: end})
+		}
+		first, last = -1, -1
+	}
+
+	for i, entry := range entries {
+		text := cleanSubtitleText(entry.Text)
+		if i+1 < len(entries) {
+			text += " " + cleanSubtitleText(entries[i+1].Text)
+		}
+		found := matchKeyword(text, d.Phrases)
+		if found == "" {
+			continue
+		}
+
+		start, stop := int(entry.StartTime.Seconds()), int(math.Ceil(entry.EndTime.Seconds()))
+		if first >= 0 && (start > last+d.After || stop-first > d.MaxLength) {
+			flush()
+		}
+		if first < 0 {
+			phrase, first = found, start
+		}
+		last = max(last, stop)
+	}
+	flush()
+
+	return cuts, nil
+}
+
+// matchKeyword returns the first of keywords found in text, ignoring case,
+// or an empty string when none is.
+func matchKeyword(text string, keywords []string) string {
+	text = strings.ToLower(text)
+	for _, keyword := range keywords {
+		if strings.Contains(text, strings.ToLower(keyword)) {
+			return keyword
+		}
+	}
+	return ""
+}
@@ -0,0 +1,243 @@
+package videos
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	htmltemplate "html/template"
+	"os"
+	"path/filepath"
+	"text/template"
+	"time"
+
+	"github.com/rogersilvasouza/godeogoker/internal/config"
+	"github.com/rogersilvasouza/godeogoker/internal/notify"
+	"github.com/rogersilvasouza/godeogoker/internal/state"
+)
+
+// DefaultDigestPeriod is the period covered by the first digest of a channel.
+const DefaultDigestPeriod = 24 * time.Hour
+
+// defaultMaxThumbnails is the number of clip covers embedded in a digest
+// when the configuration does not set one.
+const defaultMaxThumbnails = 12
+
+// digestHTML is the HTML body of a digest email.
+var digestHTML = htmltemplate.Must(htmltemplate.New("digest").Parse(`<!DOCTYPE html>
+<html>
+<body style="font-family:system-ui,sans-serif;color:#222;max-width:700px">
+<h1>{{.Channel}}</h1>
+<p>From {{.From}} to {{.To}}</p>
+{{with .Videos}}<h2>New videos ({{len .}})</h2>
+<ul>{{range .}}<li><a href="{{.PageURL}}">{{if .Title}}{{.Title}}{{else}}{{.ID}}{{end}}</a></li>{{end}}</ul>{{end}}
+{{with .Clips}}<h2>Clips produced ({{len .}})</h2>
+{{range .}}<div style="margin-bottom:1rem">{{with .Thumbnail}}<img src="cid:{{.}}" width="320" alt=""><br>{{end}}<strong>{{.Title}}</strong>{{with .Link}}<br><a href="{{.}}">{{.}}</a>{{end}}</div>
+{{end}}{{end}}
+{{with .Uploads}}<h2>Uploaded to YouTube ({{len .}})</h2>
+<ul>{{range .}}<li>{{.}}</li>{{end}}</ul>{{end}}
+{{with .Failures}}<h2>Failures ({{len .}})</h2>
+<ul>{{range .}}<li>{{.VideoID}}{{with .Cut}} / {{.}}{{end}}: {{.Stage}} [{{.Code}}] {{.Error}}</li>{{end}}</ul>{{end}}
+<h2>Costs</h2>
+<p>Language model spend in {{.Month}}: ${{printf "%.2f" .Spend}}</p>
+</body>
+</html>
+`))
+
+// digestText is the plain text body of a digest email.
+var digestText = template.Must(template.New("digest").Parse(`{{.Channel}}
+From {{.From}} to {{.To}}
+{{with .Videos}}
+New videos ({{len .}}):
+{{range .}}- {{if .Title}}{{.Title}}{{else}}{{.ID}}{{end}} {{.PageURL}}
+{{end}}{{end}}{{with .Clips}}
+Clips produced ({{len .}}):
+{{range .}}- {{.Title}}{{with .Link}} {{.}}{{end}}
+{{end}}{{end}}{{with .Uploads}}
+Uploaded to YouTube ({{len .}}):
+{{range .}}- {{.}}
+{{end}}{{end}}{{with .Failures}}
+Failures ({{len .}}):
+{{range .}}- {{.VideoID}}{{with .Cut}} / {{.}}{{end}}: {{.Stage}} [{{.Code}}] {{.Error}}
+{{end}}{{end}}
+Language model spend in {{.Month}}: ${{printf "%.2f" .Spend}}
+`))
+
+// digest is what happened in a channel during the period of a digest email.
+type digest struct {
+	Channel  string
+	From     string
+	To       string
+	Videos   []Video
+	Clips    []digestClip
+	Uploads  []string
+	Failures []state.Failure
+	Month    string
+	Spend    float64
+}
+
+// digestClip is a clip listed in a digest, with its public link when the feed
+// base URL of the channel is set and the content ID of its embedded cover.
+type digestClip struct {
+	Title     string
+	Link      string
+	Thumbnail string
+}
+
+// empty reports whether nothing happened during the period.
+func (d *digest) empty() bool {
+	return len(d.Videos) == 0 && len(d.Clips) == 0 && len(d.Uploads) == 0 && len(d.Failures) == 0
+}
+
+// SendDigests emails a digest per channel listing the new videos, the clips
+// produced with their covers, the uploads, the failures and the spend since
+// the previous digest of the channel, or during period when it is set or no
+// digest was sent yet. Channels with nothing to report are skipped. With a
+// nil mailer the plain text digests are printed instead of sent and the
+// covered time is not recorded.
+func SendDigests(ctx context.Context, channels []config.Channel, settings config.Digest, store *state.Store, mailer notify.Mailer, period time.Duration) error {
+	failed := 0
+	for _, channel := range channels {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err := sendDigest(ctx, channel, settings, store, mailer, period); err != nil {
+			fmt.Println(errorStyle.Render(fmt.Sprintf("Error sending digest of %s: %v", channel.Name, err)))
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d digests could not be sent", failed, len(channels))
+	}
+	return nil
+}
+
+// sendDigest builds and sends the digest of a single channel.
+func sendDigest(ctx context.Context, channel config.Channel, settings config.Digest, store *state.Store, mailer notify.Mailer, period time.Duration) error {
+	recipients := channel.DigestTo
+	if len(recipients) == 0 {
+		recipients = settings.To
+	}
+	if len(recipients) == 0 && mailer != nil {
+		return fmt.Errorf("no recipients: set digest.to or digest_to of the channel")
+	}
+
+	now := time.Now()
+	since := store.LastDigest(channel.ID)
+	if period > 0 || since.IsZero() {
+		if period <= 0 {
+			period = DefaultDigestPeriod
+		}
+		since = now.Add(-period)
+	}
+
+	d, inline, err := buildDigest(channel, settings, store, since, now)
+	if err != nil {
+		return err
+	}
+
+	if d.empty() {
+		fmt.Println(subtitleStyle.Render("Nothing new for channel: " + channel.Name))
+		if mailer == nil {
+			return nil
+		}
+		return store.MarkDigest(channel.ID, now)
+	}
+
+	var text bytes.Buffer
+	if err := digestText.Execute(&text, d); err != nil {
+		return fmt.Errorf("error rendering digest: %v", err)
+	}
+
+	if mailer == nil {
+		fmt.Println(text.String())
+		return nil
+	}
+
+	var html bytes.Buffer
+	if err := digestHTML.Execute(&html, d); err != nil {
+		return fmt.Errorf("error rendering digest: %v", err)
+	}
+
+	msg := notify.Message{
+		To:      recipients,
+		Subject: fmt.Sprintf("%s: %d new videos, %d clips, %d failures", channel.Name, len(d.Videos), len(d.Clips), len(d.Failures)),
+		Text:    text.String(),
+		HTML:    html.String(),
+		Inline:  inline,
+	}
+	if err := mailer.Send(ctx, msg); err != nil {
+		return err
+	}
+
+	fmt.Println(successStyle.Render("Digest sent for channel: " + channel.Name))
+	return store.MarkDigest(channel.ID, now)
+}
+
+// buildDigest collects what happened in channel between since and now, with
+// the covers of the newest clips to embed.
+func buildDigest(channel config.Channel, settings config.Digest, store *state.Store, since time.Time, now time.Time) (*digest, []notify.Attachment, error) {
+	d := &digest{
+		Channel: channel.Name,
+		From:    since.Format("2006-01-02 15:04"),
+		To:      now.Format("2006-01-02 15:04"),
+		Uploads: store.UploadsSince(channel.ID, since),
+		Month:   now.Format("2006-01"),
+		Spend:   store.MonthSpend(channel.ID, now.Format("2006-01")),
+	}
+
+	videoFiles, err := filepath.Glob(filepath.Join(channel.Folder, "*", videoFile))
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, file := range videoFiles {
+		info, err := os.Stat(file)
+		if err != nil || !info.ModTime().After(since) {
+			continue
+		}
+		videoDir := filepath.Dir(file)
+		d.Videos = append(d.Videos, loadVideo(videoDir, filepath.Base(videoDir)))
+	}
+
+	for _, failure := range store.Failures(channel.ID) {
+		if failure.Time.After(since) {
+			d.Failures = append(d.Failures, failure)
+		}
+	}
+
+	clips, err := ListClips(channel.Folder)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error listing clips: %v", err)
+	}
+
+	maxThumbnails := settings.MaxThumbnails
+	if maxThumbnails == 0 {
+		maxThumbnails = defaultMaxThumbnails
+	}
+
+	var inline []notify.Attachment
+	for _, clip := range clips {
+		if !clip.Rendered.After(since) {
+			continue
+		}
+
+		entry := digestClip{Title: clip.Metadata.Title}
+		if channel.Feed.BaseURL != "" {
+			entry.Link = PublicLink(channel, clip.Clip)
+		}
+		if clip.Cover != "" && len(inline) < maxThumbnails {
+			if data, err := os.ReadFile(clip.Cover); err == nil {
+				entry.Thumbnail = fmt.Sprintf("cover-%d@godeogoker", len(inline)+1)
+				inline = append(inline, notify.Attachment{
+					ContentID:   entry.Thumbnail,
+					Name:        filepath.Base(clip.Cover),
+					ContentType: "image/jpeg",
+					Data:        data,
+				})
+			}
+		}
+		d.Clips = append(d.Clips, entry)
+	}
+
+	return d, inline, nil
+}
@@ -0,0 +1,550 @@
+package videos
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rogersilvasouza/godeogoker/internal/config"
+	"google.golang.org/api/youtube/v3"
+)
+
+// Media holds the local files produced by a Downloader for a single video.
+// SubtitleFile is the base subtitle name; the WEBVTT captions live next to it
+// as SubtitleFile + "." + language + ".vtt", the naming used by yt-dlp, and
+// the captions made by the transcriber as SubtitleFile + ".transcribed.vtt".
+type Media struct {
+	VideoFile    string // Path to the downloaded video
+	SubtitleFile string // Base path of the downloaded captions
+	Captions     string // Path to the WEBVTT captions used, empty when the video has none
+	Language     string // Language of the captions used, transcribed for the ones made by the transcriber
+}
+
+// transcribedCaptions is the language suffix of the captions made by the transcriber.
+const transcribedCaptions = "transcribed"
+
+// captionsPath returns the path of the captions in language of the video
+// whose base subtitle name is subtitleFile.
+func captionsPath(subtitleFile string, language string) string {
+	return subtitleFile + "." + language + ".vtt"
+}
+
+// locateCaptions sets the captions of m to the first of languages, in order,
+// with a captions file next to its SubtitleFile, then to the transcribed
+// captions, and reports whether any were found. Captions in other languages,
+// left by runs with other caption languages, are not used.
+func (m *Media) locateCaptions(languages []string) bool {
+	for _, language := range append(slices.Clone(languages), transcribedCaptions) {
+		if _, err := os.Stat(captionsPath(m.SubtitleFile, language)); err == nil {
+			m.Captions, m.Language = captionsPath(m.SubtitleFile, language), language
+			return true
+		}
+	}
+	m.Captions, m.Language = "", ""
+	return false
+}
+
+// Downloader fetches the media and captions of a video into a local folder.
+// Implementations must honor ctx and leave existing files untouched so that
+// interrupted runs can be resumed.
+type Downloader interface {
+	Fetch(ctx context.Context, video Video, outputDir string) (*Media, error)
+}
+
+// CaptionsDownloader is implemented by downloaders able to fetch the captions
+// of a video without its media, used to analyze a video before downloading it.
+type CaptionsDownloader interface {
+	FetchCaptions(ctx context.Context, video Video, outputDir string) (*Media, error)
+}
+
+// NewDownloader returns the Downloader configured for the channel.
+// Supported values for channel.Downloader are "ytdlp" (default), "local"
+// and "youtube-api".
+func NewDownloader(client *Client, channel config.Channel) (Downloader, error) {
+	ytdlp := &YtDlpDownloader{
+		Path:      client.Config.YtDlp,
+		Format:    channel.YtdlpFormat,
+		Args:      ytdlpArgs(channel.YtdlpOptions),
+		Languages: captionLanguages(channel),
+		Timeout:   downloadTimeout(client.Config.Timeouts),
+	}
+	if channel.AgeRestricted {
+		ytdlp.Auth = ytdlpAuthArgs(client.Config)
+	}
+
+	switch channel.Downloader {
+	case "", "ytdlp":
+		return ytdlp, nil
+	case "local":
+		if channel.SourceDir == "" {
+			return nil, fmt.Errorf("downloader 'local' requires source_dir to be set")
+		}
+		return &LocalDownloader{Dir: channel.SourceDir, Languages: ytdlp.Languages}, nil
+	case "youtube-api":
+		return &YouTubeCaptionsDownloader{Media: ytdlp, Client: client, Languages: ytdlp.Languages}, nil
+	default:
+		return nil, fmt.Errorf("unknown downloader: %s", channel.Downloader)
+	}
+}
+
+// captionLanguages returns the caption languages of the channel in the order
+// they are tried, Portuguese when it sets none.
+func captionLanguages(channel config.Channel) []string {
+	var languages []string
+	for _, language := range channel.CaptionLanguages {
+		if language = strings.TrimSpace(language); language != "" && !slices.Contains(languages, language) {
+			languages = append(languages, language)
+		}
+	}
+	if len(languages) == 0 {
+		return []string{"pt"}
+	}
+	return languages
+}
+
+// ytdlpArgs returns the yt-dlp arguments of the channel options.
+func ytdlpArgs(options config.YtdlpOptions) []string {
+	var args []string
+	for _, value := range options.ExtractorArgs {
+		args = append(args, "--extractor-args", value)
+	}
+	if options.POToken != "" {
+		args = append(args, "--extractor-args", "youtube:po_token="+options.POToken)
+	}
+	if options.Impersonate != "" {
+		args = append(args, "--impersonate", options.Impersonate)
+	}
+	if options.UserAgent != "" {
+		args = append(args, "--user-agent", options.UserAgent)
+	}
+	return append(args, options.Args...)
+}
+
+// ytdlpAuthArgs returns the yt-dlp arguments signing in with the account of
+// ytdlp_auth.
+func ytdlpAuthArgs(cfg *config.Config) []string {
+	auth := cfg.YtdlpAuth
+	var args []string
+	if path := cfg.CookiesPath(); path != "" {
+		args = append(args, "--cookies", path)
+	}
+	if auth.CookiesFromBrowser != "" {
+		args = append(args, "--cookies-from-browser", auth.CookiesFromBrowser)
+	}
+	if auth.OAuth {
+		args = append(args, "--username", "oauth2", "--password", "")
+	}
+	return args
+}
+
+// ageRestrictedMarkers are phrases of the yt-dlp errors of videos only
+// signed-in accounts old enough may watch.
+var ageRestrictedMarkers = []string{"confirm your age", "age-restricted", "inappropriate for some users"}
+
+// isAgeRestricted reports whether the yt-dlp output tells the video is age-restricted.
+func isAgeRestricted(output []byte) bool {
+	text := strings.ToLower(string(output))
+	return slices.ContainsFunc(ageRestrictedMarkers, func(marker string) bool {
+		return strings.Contains(text, marker)
+	})
+}
+
+// mediaFiles returns the standard file names used for a video inside outputDir.
+func mediaFiles(videoID string, outputDir string) *Media {
+	return &Media{
+		VideoFile:    filepath.Join(outputDir, videoID+".mp4"),
+		SubtitleFile: filepath.Join(outputDir, videoID+".srt"),
+	}
+}
+
+// YtDlpDownloader downloads videos and auto-generated captions with yt-dlp.
+// Any fork accepting the same flags (such as youtube-dl) can be used through Path.
+type YtDlpDownloader struct {
+	Path      string        // Path to the yt-dlp executable
+	Format    string        // Format selector passed to --format
+	Args      []string      // Extra arguments of the channel, added before the URL
+	Auth      []string      // Arguments signing in to YouTube, only added for age-restricted videos
+	Languages []string      // Caption languages tried in order, Portuguese when empty
+	Timeout   time.Duration // Time limit of each yt-dlp call, 0 for none
+}
+
+// Fetch downloads the video and its auto-captions concurrently,
+// skipping files already present. When one download fails the other is
+// cancelled. Failures are reported as ErrDownloadFailed or ErrNoCaptions.
+func (d *YtDlpDownloader) Fetch(ctx context.Context, video Video, outputDir string) (*Media, error) {
+	media := mediaFiles(video.ID, outputDir)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Keep the error that caused the cancellation, not the one it caused.
+	var once sync.Once
+	var firstErr error
+	fail := func(err error) {
+		once.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer RemoveTempFilesOnPanic()
+		defer wg.Done()
+		if err := d.fetchVideo(ctx, video, media.VideoFile); err != nil {
+			fail(err)
+		}
+	}()
+	go func() {
+		defer RemoveTempFilesOnPanic()
+		defer wg.Done()
+		if err := d.fetchSubtitles(ctx, video, media); err != nil {
+			fail(err)
+		}
+	}()
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	return media, nil
+}
+
+// FetchCaptions downloads only the auto-captions, skipping them when already present.
+// Failures are reported as ErrNoCaptions.
+func (d *YtDlpDownloader) FetchCaptions(ctx context.Context, video Video, outputDir string) (*Media, error) {
+	media := mediaFiles(video.ID, outputDir)
+
+	if err := d.fetchSubtitles(ctx, video, media); err != nil {
+		return nil, err
+	}
+
+	return media, nil
+}
+
+// fetchVideo downloads the video file unless it already exists.
+func (d *YtDlpDownloader) fetchVideo(ctx context.Context, video Video, videoFileName string) error {
+	if _, err := os.Stat(videoFileName); err == nil {
+		fmt.Println(subtitleStyle.Render("Video file already exists. Skipping download."))
+		return nil
+	}
+
+	fmt.Println(commandStyle.Render("Downloading video..."))
+	args := []string{
+		"--ignore-errors",
+		"--merge-output-format", "mp4",
+		"--geo-bypass",
+		"--no-check-certificate",
+	}
+	args = append(args,
+		"--format", d.Format,
+		"--concurrent-fragments", "8",
+		"-o",
+		videoFileName,
+	)
+	if err := d.run(ctx, video, "video download", args); err != nil {
+		return newError(ErrDownloadFailed, video.ID, "", err)
+	}
+	fmt.Println(successStyle.Render("Video downloaded successfully"))
+	return nil
+}
+
+// fetchSubtitles downloads the auto-generated captions of media unless
+// captions in one of the languages of the downloader already exist.
+// Other sites than YouTube rarely generate captions, so their uploaded subtitles are accepted too.
+// The captions of every language are requested at once and the first of
+// them, in order, with any subtitles is kept as the captions of media; a
+// video with none is left without captions, to be transcribed.
+func (d *YtDlpDownloader) fetchSubtitles(ctx context.Context, video Video, media *Media) error {
+	languages := d.Languages
+	if len(languages) == 0 {
+		languages = []string{"pt"}
+	}
+
+	if media.locateCaptions(languages) {
+		fmt.Println(subtitleStyle.Render("Subtitle file already exists. Skipping download."))
+		return nil
+	}
+	subtitleFileName := media.SubtitleFile
+
+	fmt.Println(commandStyle.Render("Downloading subtitles..."))
+	args := []string{"--write-auto-sub"}
+	if video.URL != "" {
+		args = append(args, "--write-subs", "--sub-format", "vtt")
+	}
+	args = append(args,
+		"--sub-lang", strings.Join(languages, ","),
+		"--skip-download",
+		"--output", subtitleFileName,
+	)
+	if err := d.run(ctx, video, "captions download", args); err != nil {
+		return newError(ErrNoCaptions, video.ID, "", err)
+	}
+	pickCaptions(subtitleFileName, languages)
+	media.locateCaptions(languages)
+	return nil
+}
+
+// pickCaptions keeps the captions downloaded next to subtitleFileName in the
+// first of languages whose file has any subtitles and removes the captions
+// of the other languages.
+func pickCaptions(subtitleFileName string, languages []string) {
+	chosen := ""
+	for _, language := range languages {
+		if entries, err := parseVTTFile(captionsPath(subtitleFileName, language)); err == nil && len(entries) > 0 {
+			chosen = language
+			break
+		}
+	}
+	for _, language := range languages {
+		if language != chosen {
+			os.Remove(captionsPath(subtitleFileName, language))
+		}
+	}
+
+	switch {
+	case chosen == "":
+		fmt.Println(subtitleStyle.Render(fmt.Sprintf("No captions found in %s", strings.Join(languages, ", "))))
+	case chosen != languages[0]:
+		fmt.Println(successStyle.Render(fmt.Sprintf("Subtitles downloaded successfully, in %s", chosen)))
+	default:
+		fmt.Println(successStyle.Render("Subtitles downloaded successfully"))
+	}
+}
+
+// run calls yt-dlp with args, the arguments of the channel and the page of
+// video, within the time limit of the downloads; what names the call in the
+// timeout error. An age-restricted video is downloaded again signed in when
+// the channel opted in, and reported with how to opt in otherwise.
+func (d *YtDlpDownloader) run(ctx context.Context, video Video, what string, args []string) error {
+	ctx, cancel := withTimeout(ctx, d.Timeout, what)
+	defer cancel()
+
+	output, err := newCommand(ctx, d.Path, slices.Concat(args, d.Args, []string{video.PageURL()})...).CombinedOutput()
+	if err == nil || ctx.Err() != nil || !isAgeRestricted(output) {
+		return timeoutCause(ctx, err)
+	}
+	if len(d.Auth) == 0 {
+		return fmt.Errorf("age-restricted video, set age_restricted on the channel and ytdlp_auth to download it signed in: %w", err)
+	}
+
+	fmt.Println(subtitleStyle.Render("Age-restricted video, downloading it signed in..."))
+	_, err = newCommand(ctx, d.Path, slices.Concat(args, d.Args, d.Auth, []string{video.PageURL()})...).CombinedOutput()
+	return timeoutCause(ctx, err)
+}
+
+// LocalDownloader provides videos that were downloaded beforehand.
+// It expects {Dir}/{videoID}.mp4 and optionally {Dir}/{videoID}.vtt, taken
+// to be in the first of Languages, or {Dir}/{videoID}.{language}.vtt.
+type LocalDownloader struct {
+	Dir       string   // Folder containing the pre-downloaded files
+	Languages []string // Caption languages tried in order, Portuguese when empty
+}
+
+// Fetch copies the pre-downloaded video and captions into outputDir.
+func (d *LocalDownloader) Fetch(ctx context.Context, video Video, outputDir string) (*Media, error) {
+	videoID := video.ID
+	media := mediaFiles(videoID, outputDir)
+
+	if err := copyIfMissing(ctx, filepath.Join(d.Dir, videoID+".mp4"), media.VideoFile); err != nil {
+		return nil, newError(ErrDownloadFailed, videoID, "", err)
+	}
+
+	languages := d.Languages
+	if len(languages) == 0 {
+		languages = []string{"pt"}
+	}
+	if err := copyCaptions(ctx, filepath.Join(d.Dir, videoID), media, languages); err != nil {
+		return nil, newError(ErrNoCaptions, videoID, "", err)
+	}
+
+	fmt.Println(successStyle.Render("Local files ready"))
+	return media, nil
+}
+
+// YouTubeCaptionsDownloader fetches captions through the YouTube Data API,
+// delegating the video download to Media. The API only serves caption tracks
+// of videos owned by the authenticated account.
+type YouTubeCaptionsDownloader struct {
+	Media     Downloader // Downloader used for the video file
+	Client    *Client    // Client providing the YouTube credentials
+	Languages []string   // Caption languages tried in order, Portuguese when empty
+}
+
+// Fetch downloads the video with the wrapped downloader and the caption track
+// in WEBVTT format through the Data API, in the first of the languages of the
+// downloader the video has a track in.
+func (d *YouTubeCaptionsDownloader) Fetch(ctx context.Context, video Video, outputDir string) (*Media, error) {
+	videoID := video.ID
+	media, err := d.Media.Fetch(ctx, video, outputDir)
+	if err != nil {
+		return nil, err
+	}
+
+	languages := d.Languages
+	if len(languages) == 0 {
+		languages = []string{"pt"}
+	}
+	if media.locateCaptions(languages) {
+		return media, nil
+	}
+
+	fmt.Println(commandStyle.Render("Downloading captions from YouTube Data API..."))
+	service, err := d.Client.youtubeService(ctx)
+	if err != nil {
+		return nil, withContext(err, ErrAuth, videoID, "")
+	}
+
+	list, err := service.Captions.List([]string{"snippet"}, videoID).Context(ctx).Do()
+	if err != nil {
+		return nil, newError(ErrNoCaptions, videoID, "", err)
+	}
+
+	for _, language := range languages {
+		i := slices.IndexFunc(list.Items, func(caption *youtube.Caption) bool {
+			return caption.Snippet != nil && strings.EqualFold(caption.Snippet.Language, language)
+		})
+		if i == -1 {
+			continue
+		}
+		caption := list.Items[i]
+
+		resp, err := service.Captions.Download(caption.Id).Tfmt("vtt").Context(ctx).Download()
+		if err != nil {
+			return nil, newError(ErrNoCaptions, videoID, "", err)
+		}
+		defer resp.Body.Close()
+
+		err = writeAtomically(captionsPath(media.SubtitleFile, language), func(tmp string) error {
+			file, err := os.Create(tmp)
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+
+			if _, err := io.Copy(file, resp.Body); err != nil {
+				return err
+			}
+			return file.Close()
+		})
+		if err != nil {
+			return nil, newError(ErrNoCaptions, videoID, "", err)
+		}
+
+		if language != languages[0] {
+			fmt.Println(successStyle.Render(fmt.Sprintf("Captions downloaded successfully, in %s", language)))
+		} else {
+			fmt.Println(successStyle.Render("Captions downloaded successfully"))
+		}
+		media.locateCaptions(languages)
+		return media, nil
+	}
+
+	return nil, newError(ErrNoCaptions, videoID, "", fmt.Errorf("no caption track in %s", strings.Join(languages, ", ")))
+}
+
+// fetchFile copies the local file of video into outputDir, with the WEBVTT
+// captions next to it named after it, such as talk.vtt or talk.en.vtt for
+// talk.mp4, looked up in that order for each of languages. A file without
+// captions fails with ErrNoCaptions unless transcribe is set, the pipeline
+// then transcribing it. Cancelling ctx stops the copy.
+func fetchFile(ctx context.Context, video Video, outputDir string, languages []string, transcribe bool) (*Media, error) {
+	media := mediaFiles(video.ID, outputDir)
+	if err := copyIfMissing(ctx, video.File, media.VideoFile); err != nil {
+		return nil, newError(ErrDownloadFailed, video.ID, "", timeoutCause(ctx, err))
+	}
+
+	stem := strings.TrimSuffix(video.File, filepath.Ext(video.File))
+	if err := copyCaptions(ctx, stem, media, languages); err != nil {
+		return nil, newError(ErrNoCaptions, video.ID, "", err)
+	}
+	if media.Captions == "" && !transcribe {
+		return nil, newError(ErrNoCaptions, video.ID, "", fmt.Errorf("no captions next to %s, such as %s, and no transcription configured", video.File, filepath.Base(stem+".vtt")))
+	}
+
+	fmt.Println(successStyle.Render("Local files ready"))
+	return media, nil
+}
+
+// copyCaptions copies the WEBVTT captions named after stem, stem.vtt taken
+// to be in the first of languages or stem.{language}.vtt for each of them,
+// as the captions of media, unless media already has captions in one of
+// languages.
+func copyCaptions(ctx context.Context, stem string, media *Media, languages []string) error {
+	if media.locateCaptions(languages) {
+		return nil
+	}
+
+	candidates := map[string]string{stem + ".vtt": languages[0]}
+	names := []string{stem + ".vtt"}
+	for _, language := range languages {
+		candidates[stem+"."+language+".vtt"] = language
+		names = append(names, stem+"."+language+".vtt")
+	}
+	for _, name := range names {
+		if _, err := os.Stat(name); err != nil {
+			continue
+		}
+		if err := copyIfMissing(ctx, name, captionsPath(media.SubtitleFile, candidates[name])); err != nil {
+			return err
+		}
+		media.locateCaptions(languages)
+		return nil
+	}
+	return nil
+}
+
+// copyIfMissing copies src to dst unless dst already exists, stopping when
+// ctx is cancelled.
+func copyIfMissing(ctx context.Context, src string, dst string) error {
+	if _, err := os.Stat(dst); err == nil {
+		return nil
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	return writeAtomically(dst, func(tmp string) error {
+		out, err := os.Create(tmp)
+		if err != nil {
+			return err
+		}
+		defer out.Close()
+
+		if _, err := io.Copy(out, contextReader{ctx: ctx, r: in}); err != nil {
+			return err
+		}
+		return out.Close()
+	})
+}
+
+// contextReader is a reader that fails with the error of ctx once ctx is
+// cancelled, so long copies can be stopped.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+// Read reads from the underlying reader unless ctx is done.
+func (r contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
+
+// watchURL returns the YouTube watch page for a video.
+func watchURL(videoID string) string {
+	return fmt.Sprintf("https://www.youtube.com/watch?v=%s", videoID)
+}
@@ -0,0 +1,89 @@
+package videos
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFetchFile(t *testing.T) {
+	source := t.TempDir()
+	file := filepath.Join(source, "talk.mp4")
+	if err := os.WriteFile(file, []byte("video"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	video := Video{ID: fileVideoID(file), File: file}
+
+	t.Run("without captions", func(t *testing.T) {
+		_, err := fetchFile(context.Background(), video, t.TempDir(), []string{"pt"}, false)
+		if !errors.Is(err, ErrNoCaptions) {
+			t.Errorf("error = %v, want %v", err, ErrNoCaptions)
+		}
+
+		media, err := fetchFile(context.Background(), video, t.TempDir(), []string{"pt"}, true)
+		if err != nil {
+			t.Fatalf("with a transcriber: %v", err)
+		}
+		if content, err := os.ReadFile(media.VideoFile); err != nil || string(content) != "video" {
+			t.Errorf("video = %q, %v", content, err)
+		}
+	})
+
+	t.Run("with captions", func(t *testing.T) {
+		if err := os.WriteFile(filepath.Join(source, "talk.en.vtt"), []byte("WEBVTT\n\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		media, err := fetchFile(context.Background(), video, t.TempDir(), []string{"pt", "en"}, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if media.Language != "en" || media.Captions != captionsPath(media.SubtitleFile, "en") {
+			t.Errorf("captions = %s in %q", media.Captions, media.Language)
+		}
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		outputDir := t.TempDir()
+		_, err := fetchFile(ctx, video, outputDir, []string{"pt"}, true)
+		if !errors.Is(err, ErrDownloadFailed) {
+			t.Errorf("error = %v, want %v", err, ErrDownloadFailed)
+		}
+		assertMissing(t, mediaFiles(video.ID, outputDir).VideoFile)
+	})
+}
+
+func TestCaptionsFollowTheCaptionLanguages(t *testing.T) {
+	writeCaptions := func(path string, text string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte("WEBVTT\n\n"+text), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	media := mediaFiles("video", t.TempDir())
+
+	// yt-dlp found no Portuguese subtitles, only English ones.
+	writeCaptions(captionsPath(media.SubtitleFile, "pt"), "")
+	writeCaptions(captionsPath(media.SubtitleFile, "en"), "00:00:01.000 --> 00:00:02.000\nHello\n\n")
+	pickCaptions(media.SubtitleFile, []string{"pt", "en"})
+	if !media.locateCaptions([]string{"pt", "en"}) || media.Language != "en" {
+		t.Fatalf("captions = %s in %q, want the English ones", media.Captions, media.Language)
+	}
+	assertMissing(t, captionsPath(media.SubtitleFile, "pt"))
+
+	// The channel now only takes Spanish captions.
+	if media.locateCaptions([]string{"es"}) {
+		t.Errorf("captions = %s in %q, want none in Spanish", media.Captions, media.Language)
+	}
+
+	writeCaptions(captionsPath(media.SubtitleFile, transcribedCaptions), "00:00:01.000 --> 00:00:02.000\nHola\n\n")
+	if !media.locateCaptions([]string{"es"}) || media.Language != transcribedCaptions {
+		t.Errorf("captions = %s in %q, want the transcribed ones", media.Captions, media.Language)
+	}
+	if !media.locateCaptions([]string{"en"}) || media.Language != "en" {
+		t.Errorf("captions = %s in %q, want the English ones before the transcribed ones", media.Captions, media.Language)
+	}
+}
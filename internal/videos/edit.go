@@ -0,0 +1,235 @@
+package videos
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/rogersilvasouza/godeogoker/internal/config"
+	"google.golang.org/api/youtube/v3"
+)
+
+// editSuffix ends the name of the editing sidecar of a clip, next to its
+// metadata in horizontal/, e.g. horizontal/The_Big_Reveal.edit.json.
+const editSuffix = ".edit.json"
+
+// CutEdit is the editing sidecar of a clip, written by hand to replace the
+// generated metadata before upload. Empty fields keep the generated values.
+type CutEdit struct {
+	Title       string   `json:"title,omitempty"`       // Upload title
+	Description string   `json:"description,omitempty"` // Upload description
+	Tags        []string `json:"tags,omitempty"`        // Search tags without the # symbol
+	Hashtags    []string `json:"hashtags,omitempty"`    // Hashtags with the # symbol
+	CoverText   string   `json:"cover_text,omitempty"`  // Cover text, with the placeholders of cover_text
+}
+
+// loadEdit returns the editing sidecar of the clip name in outputDir, nil
+// when there is none.
+func loadEdit(outputDir string, name string) (*CutEdit, error) {
+	content, err := os.ReadFile(filepath.Join(outputDir, "horizontal", name+editSuffix))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var edit CutEdit
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&edit); err != nil {
+		return nil, fmt.Errorf("%s%s: %v", name, editSuffix, err)
+	}
+	return &edit, nil
+}
+
+// apply replaces the values of metadata set in the edit, and reports whether
+// anything changed. Edited hashtags replace those of every platform too.
+func (e *CutEdit) apply(metadata *VideoMetadata) bool {
+	changed := false
+	if e.Title != "" && e.Title != metadata.Title {
+		metadata.Title, changed = e.Title, true
+	}
+	if e.Description != "" && e.Description != metadata.Description {
+		metadata.Description, changed = e.Description, true
+	}
+	if len(e.Tags) > 0 && !slices.Equal(e.Tags, metadata.Tags) {
+		metadata.Tags, changed = e.Tags, true
+	}
+	if len(e.Hashtags) > 0 {
+		if !slices.Equal(e.Hashtags, metadata.Hashtags) {
+			metadata.Hashtags, changed = e.Hashtags, true
+		}
+		for platform, hashtags := range metadata.PlatformHashtags {
+			if !slices.Equal(e.Hashtags, hashtags) {
+				metadata.PlatformHashtags[platform], changed = e.Hashtags, true
+			}
+		}
+	}
+	return changed
+}
+
+// applyEdit replaces the generated metadata of the clip name with the values
+// of its editing sidecar, writing them to its metadata file.
+func (p *pipeline) applyEdit(ctx context.Context, outputDir string, name string, edit *CutEdit, metadata *VideoMetadata) {
+	if !edit.apply(metadata) {
+		return
+	}
+
+	metadataFile := filepath.Join(outputDir, "horizontal", name+".json")
+	metadataJSON, _ := json.MarshalIndent(metadata, "", "  ")
+	if err := writeFileAtomic(metadataFile, metadataJSON, 0644); err != nil {
+		fmt.Println(errorStyle.Render("Error writing edited metadata: " + err.Error()))
+		return
+	}
+	p.publish(ctx, metadataFile)
+	fmt.Println(successStyle.Render("Edits applied to the metadata of " + name))
+}
+
+// ApplyEdits applies the editing sidecars of the processed clips of channel
+// that changed since they were last applied: the metadata of each edited clip
+// is rewritten and its cover drawn again, its spooled uploads are sent with
+// the edited details, and the title, description and tags of its YouTube
+// uploads are updated. Clips not uploaded yet get the edits when they are.
+// It returns the number of clips edited, and an error when the backends of
+// the channel cannot be configured.
+func ApplyEdits(ctx context.Context, client *Client, channel config.Channel) (int, error) {
+	ctx = withPriorities(ctx, client.Config)
+	files, err := filepath.Glob(filepath.Join(channel.Folder, "*", "horizontal", "*"+editSuffix))
+	if err != nil || len(files) == 0 {
+		return 0, err
+	}
+
+	p, err := newPipeline(ctx, client, channel)
+	if err != nil {
+		return 0, fmt.Errorf("error configuring %v", err)
+	}
+	cached, _ := CachedCuts(channel.Folder)
+
+	edited := 0
+	for _, file := range files {
+		if ctx.Err() != nil {
+			break
+		}
+
+		outputDir := filepath.Dir(filepath.Dir(file))
+		videoID := filepath.Base(outputDir)
+		name := strings.TrimSuffix(filepath.Base(file), editSuffix)
+		if p.applyEditFile(ctx, outputDir, videoID, name, cached[videoID]) {
+			edited++
+		}
+	}
+	return edited, nil
+}
+
+// applyEditFile applies the editing sidecar of the clip name of videoID,
+// found among cuts, unless it was applied as it is. It reports whether the
+// edit was applied.
+func (p *pipeline) applyEditFile(ctx context.Context, outputDir string, videoID string, name string, cuts []Cut) bool {
+	editFile := filepath.Join(outputDir, "horizontal", name+editSuffix)
+	edit, err := loadEdit(outputDir, name)
+	if err != nil {
+		p.fail(newError(ErrInvalidEdit, videoID, name, err))
+		return false
+	}
+	hash := settingsHash("edit", edit)
+	if loadSettings(outputDir)[settingsName(outputDir, editFile)] == hash {
+		return false
+	}
+
+	metadataFile := filepath.Join(outputDir, "horizontal", name+".json")
+	content, err := os.ReadFile(metadataFile)
+	if err != nil {
+		fmt.Println(errorStyle.Render(fmt.Sprintf("No metadata for the edited clip %s of video %s, run exec first", name, videoID)))
+		return false
+	}
+	var metadata VideoMetadata
+	if err := json.Unmarshal(content, &metadata); err != nil {
+		fmt.Println(errorStyle.Render(fmt.Sprintf("Error reading metadata of %s: %v", name, err)))
+		return false
+	}
+
+	fmt.Println(titleStyle.Render(fmt.Sprintf("Applying edits to %s of video %s", name, videoID)))
+	p.applyEdit(ctx, outputDir, name, edit, &metadata)
+
+	for _, cut := range cuts {
+		if safeFileName(cut.Title) != name {
+			continue
+		}
+		clip := filepath.Join(outputDir, "horizontal", name+Format(p.channel.Outputs.Clip).ext())
+		clipHash := loadSettings(outputDir)[settingsName(outputDir, clip)]
+		details := p.loadDetails(ctx, outputDir, loadVideo(outputDir, videoID))
+		cut.CoverText = edit.CoverText
+		p.cover(ctx, videoID, details, outputDir, name, clip, cut, clipHash)
+		break
+	}
+
+	failed := false
+	for _, version := range []struct{ dir, suffix string }{{"horizontal-yt", ""}, {"vertical", " (Vertical)"}} {
+		output := findOutput(filepath.Join(outputDir, version.dir), name)
+		if output == "" {
+			continue
+		}
+		if !p.updateUpload(ctx, videoID, name, output, p.detailsFor(&metadata, version.suffix, "")) {
+			failed = true
+		}
+	}
+	if failed {
+		return false
+	}
+
+	recordSettings(outputDir, settingsName(outputDir, editFile), hash)
+	return true
+}
+
+// updateUpload replaces the details of the upload of output: the title,
+// description and tags of the YouTube video it was uploaded as, or the
+// details it is sent with when it waits in the spool. It returns false when
+// the YouTube video could not be updated.
+func (p *pipeline) updateUpload(ctx context.Context, videoID string, name string, output string, details uploadDetails) bool {
+	store := p.client.State
+	if store == nil {
+		return true
+	}
+	key := p.outputKey(output)
+
+	if _, youtubeID, ok := store.Upload(p.channel.ID, key); ok {
+		if youtubeID == "" {
+			fmt.Println(subtitleStyle.Render("The YouTube ID of " + key + " is unknown, leaving its upload as is"))
+			return true
+		}
+		fmt.Println(commandStyle.Render("Updating the YouTube video of " + key + "..."))
+		err := p.client.updateVideoSnippet(ctx, youtubeID, func(snippet *youtube.VideoSnippet) {
+			snippet.Title = details.Title
+			snippet.Description = details.Description
+			snippet.Tags = details.Tags
+		})
+		if err != nil {
+			p.fail(withContext(err, ErrUploadFailed, videoID, name))
+			return false
+		}
+		fmt.Println(successStyle.Render("YouTube video updated: https://youtu.be/" + youtubeID))
+		return true
+	}
+
+	for _, entry := range store.Spooled(p.channel.ID) {
+		if entry.Key != key {
+			continue
+		}
+		content, err := json.MarshalIndent(details, "", "  ")
+		if err == nil {
+			err = writeFileAtomic(entry.Metadata, content, 0644)
+		}
+		if err != nil {
+			fmt.Println(errorStyle.Render("Error updating spooled upload: " + err.Error()))
+			return true
+		}
+		fmt.Println(successStyle.Render("Spooled upload updated: " + key))
+	}
+	return true
+}
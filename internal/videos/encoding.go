@@ -0,0 +1,143 @@
+package videos
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/rogersilvasouza/godeogoker/internal/config"
+)
+
+// encodingProfiles are the built-in encoding profiles. Their bitrate
+// ceilings and levels follow the upload recommendations of each platform, so
+// the platforms do not have to re-encode the uploads.
+var encodingProfiles = map[string]config.EncodingProfile{
+	"youtube": {
+		Codec: "h264", Preset: "fast", CRF: 20, MaxRate: "16M",
+		CodecProfile: "high", Level: "4.2", AudioBitrate: "192k",
+	},
+	"yt-shorts": {
+		Codec: "h264", Preset: "fast", CRF: 21, MaxRate: "12M",
+		CodecProfile: "high", Level: "4.2", AudioBitrate: "192k",
+	},
+	"tiktok": {
+		Codec: "h264", Preset: "fast", CRF: 23, MaxRate: "6M",
+		CodecProfile: "high", Level: "4.1", AudioBitrate: "128k",
+	},
+	"instagram": {
+		Codec: "h264", Preset: "fast", CRF: 23, MaxRate: "5M",
+		CodecProfile: "high", Level: "4.1", AudioBitrate: "128k",
+	},
+	"archive": {
+		Codec: "av1", Preset: "6", CRF: 30, AudioBitrate: "160k",
+	},
+}
+
+// videoEncoders are the ffmpeg encoders of the supported video codecs.
+var videoEncoders = map[string]string{"h264": "libx264", "hevc": "libx265", "av1": "libsvtav1"}
+
+// profilesOf returns the built-in encoding profiles with the ones configured
+// in cfg added or replacing them.
+func profilesOf(cfg *config.Config) map[string]config.EncodingProfile {
+	profiles := make(map[string]config.EncodingProfile, len(encodingProfiles)+len(cfg.EncodingProfiles))
+	for name, profile := range encodingProfiles {
+		profiles[name] = profile
+	}
+	for name, profile := range cfg.EncodingProfiles {
+		profiles[name] = profile
+	}
+	return profiles
+}
+
+// withProfile returns format with the codec of its encoding profile, checking
+// that the profile exists, is valid and agrees with the codec of format.
+func withProfile(format Format, profiles map[string]config.EncodingProfile) (Format, error) {
+	if format.Profile == "" {
+		return format, nil
+	}
+
+	profile, ok := profiles[format.Profile]
+	if !ok {
+		return format, fmt.Errorf("unknown encoding profile: %s", format.Profile)
+	}
+	if _, ok := videoEncoders[profile.Codec]; !ok {
+		return format, fmt.Errorf("encoding profile %s: unknown codec: %s", format.Profile, profile.Codec)
+	}
+	if profile.Codec == "av1" && (profile.CodecProfile != "" || profile.Level != "") {
+		return format, fmt.Errorf("encoding profile %s: codec_profile and level are not supported by av1", format.Profile)
+	}
+	if profile.BufSize == "" && profile.MaxRate != "" {
+		if _, err := doubleRate(profile.MaxRate); err != nil {
+			return format, fmt.Errorf("encoding profile %s: invalid max_rate: %s", format.Profile, profile.MaxRate)
+		}
+	}
+
+	if format.Codec != "" && format.Codec != profile.Codec {
+		return format, fmt.Errorf("codec %s does not match the %s codec of encoding profile %s", format.Codec, profile.Codec, format.Profile)
+	}
+	format.Codec = profile.Codec
+	return format, nil
+}
+
+// profileArgs returns the codec options of profile for an output in
+// container: a constant rate factor capped by the bitrate ceiling, the codec
+// profile and level, and the audio bitrate.
+func profileArgs(profile config.EncodingProfile, container string) []string {
+	args := []string{"-c:v", videoEncoders[profile.Codec]}
+	if profile.Preset != "" {
+		args = append(args, "-preset", profile.Preset)
+	}
+	if profile.CRF > 0 {
+		args = append(args, "-crf", strconv.Itoa(profile.CRF))
+	}
+	if profile.MaxRate != "" {
+		bufSize := profile.BufSize
+		if bufSize == "" {
+			bufSize, _ = doubleRate(profile.MaxRate)
+		}
+		args = append(args, "-maxrate", profile.MaxRate, "-bufsize", bufSize)
+	}
+	if profile.CodecProfile != "" {
+		args = append(args, "-profile:v", profile.CodecProfile)
+	}
+	switch {
+	case profile.Level == "":
+	case profile.Codec == "hevc":
+		args = append(args, "-x265-params", "level-idc="+profile.Level)
+	default:
+		args = append(args, "-level:v", profile.Level)
+	}
+	if profile.Codec == "hevc" {
+		args = append(args, "-tag:v", "hvc1")
+	}
+	args = append(args, "-pix_fmt", "yuv420p")
+
+	if container == "webm" {
+		args = append(args, "-c:a", "libopus")
+	} else {
+		args = append(args, "-c:a", "aac", "-movflags", "+faststart")
+	}
+	if profile.AudioBitrate != "" {
+		args = append(args, "-b:a", profile.AudioBitrate)
+	}
+	return append(args, "-threads", "0")
+}
+
+// doubleRate returns twice a bitrate such as 8M or 2500k.
+func doubleRate(rate string) (string, error) {
+	number := strings.TrimRight(rate, "kKmMgG")
+	value, err := strconv.ParseFloat(number, 64)
+	if err != nil || value <= 0 {
+		return "", fmt.Errorf("invalid bitrate: %s", rate)
+	}
+	return strconv.FormatFloat(value*2, 'f', -1, 64) + rate[len(number):], nil
+}
+
+// encode returns the codec options of format, from its encoding profile when
+// it has one.
+func (r *FFmpegRenderer) encode(format Format) []string {
+	if profile, ok := r.Profiles[format.Profile]; ok && format.Profile != "" {
+		return profileArgs(profile, format.Container)
+	}
+	return format.encode()
+}
@@ -0,0 +1,142 @@
+package videos
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// silentLoudness is the loudness, in LUFS, given to silent seconds.
+const silentLoudness = -70.0
+
+// loudnessPattern matches the momentary loudness lines logged by ebur128.
+var loudnessPattern = regexp.MustCompile(`t:\s*([\d.]+)\s+TARGET:.*?M:\s*(-?[\d.]+|-inf)`)
+
+// Loudness runs ebur128 over input and returns its momentary loudness in
+// LUFS for each second.
+func (r *FFmpegRenderer) Loudness(ctx context.Context, input string) ([]float64, error) {
+	ctx, cancel := withTimeout(ctx, r.Timeout, "ffmpeg")
+	defer cancel()
+
+	output, err := newCommand(
+		ctx,
+		r.FFmpeg,
+		"-nostats",
+		"-i", input,
+		"-vn",
+		"-af", "ebur128",
+		"-f", "null",
+		"-",
+	).CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("error measuring loudness: %v", timeoutCause(ctx, err))
+	}
+
+	var sums, counts []float64
+	for _, line := range strings.Split(string(output), "\n") {
+		m := loudnessPattern.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+
+		t, _ := strconv.ParseFloat(m[1], 64)
+		value, err := strconv.ParseFloat(m[2], 64)
+		if err != nil || value < silentLoudness {
+			value = silentLoudness
+		}
+
+		second := int(t)
+		for len(sums) <= second {
+			sums = append(sums, 0)
+			counts = append(counts, 0)
+		}
+		sums[second] += value
+		counts[second]++
+	}
+
+	loudness := make([]float64, len(sums))
+	for i := range sums {
+		loudness[i] = silentLoudness
+		if counts[i] > 0 {
+			loudness[i] = sums[i] / counts[i]
+		}
+	}
+	return loudness, nil
+}
+
+// EnergyDetector finds the loudest passages of the video, where the audience
+// laughs, the speakers get excited or the music drops.
+type EnergyDetector struct {
+	Renderer Renderer // Renderer measuring the loudness
+	Length   int      // Length of each cut in seconds
+	Count    int      // Number of cuts to find
+}
+
+// Detect returns the Count windows of Length seconds with the highest mean
+// loudness that do not overlap, in order of the video. It needs the video.
+func (d *EnergyDetector) Detect(ctx context.Context, source CutSource) ([]Cut, error) {
+	if source.Video == "" {
+		return nil, fmt.Errorf("energy detection needs the video")
+	}
+
+	fmt.Println(commandStyle.Render("Measuring loudness to find the liveliest passages..."))
+	loudness, err := d.Renderer.Loudness(ctx, source.Video)
+	if err != nil {
+		return nil, newError(ErrRenderFailed, "", "", err)
+	}
+
+	var cuts []Cut
+	for _, begin := range loudestWindows(loudness, d.Length, d.Count) {
+		begin, end := snapToCaptions(source.Entries, begin, min(begin+d.Length, len(loudness)))
+		cuts = append(cuts, Cut{Title: excerptTitle(source.Entries, begin, end), Begin: begin, End: end})
+	}
+	sort.Slice(cuts, func(i, j int) bool { return cuts[i].Begin < cuts[j].Begin })
+	return cuts, nil
+}
+
+// loudestWindows returns the start of up to count windows of length seconds
+// with the highest mean loudness that do not overlap, loudest first.
+func loudestWindows(loudness []float64, length int, count int) []int {
+	if length <= 0 || len(loudness) < length {
+		return nil
+	}
+
+	// Loudness is averaged as power, so a few loud seconds are not drowned
+	// out by the quieter ones around them.
+	power := make([]float64, len(loudness)+1)
+	for i, value := range loudness {
+		power[i+1] = power[i] + math.Pow(10, value/10)
+	}
+
+	starts := make([]int, len(loudness)-length+1)
+	for i := range starts {
+		starts[i] = i
+	}
+	sort.SliceStable(starts, func(i, j int) bool {
+		a, b := starts[i], starts[j]
+		return power[a+length]-power[a] > power[b+length]-power[b]
+	})
+
+	var picked []int
+	for _, start := range starts {
+		if len(picked) == count {
+			break
+		}
+
+		overlaps := false
+		for _, other := range picked {
+			if start < other+length && other < start+length {
+				overlaps = true
+				break
+			}
+		}
+		if !overlaps {
+			picked = append(picked, start)
+		}
+	}
+	return picked
+}
@@ -0,0 +1,185 @@
+package videos
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"google.golang.org/api/youtube/v3"
+)
+
+// indexFile is the file the episode index is written to inside the video folder.
+const indexFile = "index.txt"
+
+// defaultIndexHeader is the first line of the episode index unless the
+// channel sets its header.
+const defaultIndexHeader = "Clips from this episode:"
+
+// episodeIndex returns the clip index of the source video processed into
+// outputDir: the header, with the placeholders of vars expanded, followed by
+// a line per cut uploaded to YouTube with its start in the source video, its
+// title and its link, in the order of the cuts. It returns an empty index
+// when none of the cuts was uploaded.
+func (p *pipeline) episodeIndex(outputDir string, cuts []Cut, vars map[string]string) string {
+	var lines []string
+	for _, cut := range cuts {
+		name := safeFileName(cut.Title)
+		youtubeID := ""
+		for _, dir := range []string{"horizontal-yt", "vertical"} {
+			output := findOutput(filepath.Join(outputDir, dir), name)
+			if output == "" {
+				continue
+			}
+			if _, id, ok := p.client.State.Upload(p.channel.ID, p.outputKey(output)); ok && id != "" {
+				youtubeID = id
+				break
+			}
+		}
+		if youtubeID == "" {
+			continue
+		}
+
+		title := cut.Title
+		if content, err := os.ReadFile(filepath.Join(outputDir, "horizontal", name+".json")); err == nil {
+			var metadata VideoMetadata
+			if json.Unmarshal(content, &metadata) == nil && metadata.Title != "" {
+				title = metadata.Title
+			}
+		}
+		lines = append(lines, fmt.Sprintf("%s %s: https://youtu.be/%s", chapterClock(cut.Begin), title, youtubeID))
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+
+	header := defaultIndexHeader
+	if p.channel.EpisodeIndex.Header != "" {
+		header = strings.Join(strings.Fields(expandTemplate(p.channel.EpisodeIndex.Header, vars)), " ")
+	}
+	return header + "\n" + strings.Join(lines, "\n")
+}
+
+// writeEpisodeIndex writes the clip index of video to index.txt in outputDir
+// and, when the channel asks for it, puts it in the description of the source
+// video or of a playlist. The description is only updated when the index
+// changed since the last update.
+func (p *pipeline) writeEpisodeIndex(ctx context.Context, outputDir string, video Video, details *VideoDetails, cuts []Cut) {
+	settings := p.channel.EpisodeIndex
+	if settings == nil || p.client.State == nil {
+		return
+	}
+
+	vars := details.vars()
+	vars["title"] = video.Title
+	index := p.episodeIndex(outputDir, cuts, vars)
+	if index == "" {
+		fmt.Println(subtitleStyle.Render("No uploaded clips to index"))
+		return
+	}
+
+	file := filepath.Join(outputDir, indexFile)
+	if err := writeFileAtomic(file, []byte(index+"\n"), 0644); err != nil {
+		p.fail(newError(ErrStorageFailed, video.ID, "", err))
+		return
+	}
+	p.publish(ctx, file)
+	fmt.Println(successStyle.Render("Episode index written: " + indexFile))
+
+	hash := settingsHash("index", index, settings.Update, settings.PlaylistID)
+	if settings.Update == "" || loadSettings(outputDir)[indexFile] == hash {
+		return
+	}
+
+	var err error
+	switch settings.Update {
+	case "source":
+		if !video.OnYouTube() {
+			fmt.Println(subtitleStyle.Render("The source is not a YouTube video, leaving its description as is"))
+			return
+		}
+		fmt.Println(commandStyle.Render("Adding the episode index to the description of the source video..."))
+		err = p.client.updateVideoSnippet(ctx, video.ID, func(snippet *youtube.VideoSnippet) {
+			snippet.Description = withIndex(snippet.Description, index)
+		})
+	case "playlist":
+		fmt.Println(commandStyle.Render("Adding the episode index to the description of the playlist..."))
+		err = p.client.updatePlaylistDescription(ctx, settings.PlaylistID, index)
+	}
+	if err != nil {
+		p.fail(withContext(err, ErrUploadFailed, video.ID, ""))
+		return
+	}
+
+	recordSettings(outputDir, indexFile, hash)
+	fmt.Println(successStyle.Render("Description updated with the episode index"))
+}
+
+// withIndex returns description with index in place of the earlier index
+// starting with the same header line, or appended after a blank line.
+func withIndex(description string, index string) string {
+	header, _, _ := strings.Cut(index, "\n")
+	if start := strings.Index(description, header); start >= 0 {
+		end := len(description)
+		if i := strings.Index(description[start:], "\n\n"); i >= 0 {
+			end = start + i
+		}
+		return description[:start] + index + description[end:]
+	}
+
+	description = strings.TrimRight(description, "\n")
+	if description == "" {
+		return index
+	}
+	return description + "\n\n" + index
+}
+
+// updateVideoSnippet changes the snippet of the YouTube video videoID, which
+// the authenticated account must own, with update.
+func (c *Client) updateVideoSnippet(ctx context.Context, videoID string, update func(snippet *youtube.VideoSnippet)) error {
+	service, err := c.youtubeService(ctx)
+	if err != nil {
+		return err
+	}
+
+	response, err := service.Videos.List([]string{"snippet"}).Id(videoID).Context(ctx).Do()
+	if err != nil {
+		return classifyUploadError(err)
+	}
+	if len(response.Items) == 0 {
+		return fmt.Errorf("video not found: %s", videoID)
+	}
+
+	video := response.Items[0]
+	update(video.Snippet)
+	if _, err := service.Videos.Update([]string{"snippet"}, &youtube.Video{Id: videoID, Snippet: video.Snippet}).Context(ctx).Do(); err != nil {
+		return classifyUploadError(err)
+	}
+	return nil
+}
+
+// updatePlaylistDescription puts index in the description of the YouTube
+// playlist playlistID, which the authenticated account must own.
+func (c *Client) updatePlaylistDescription(ctx context.Context, playlistID string, index string) error {
+	service, err := c.youtubeService(ctx)
+	if err != nil {
+		return err
+	}
+
+	response, err := service.Playlists.List([]string{"snippet"}).Id(playlistID).Context(ctx).Do()
+	if err != nil {
+		return classifyUploadError(err)
+	}
+	if len(response.Items) == 0 {
+		return fmt.Errorf("playlist not found: %s", playlistID)
+	}
+
+	playlist := response.Items[0]
+	playlist.Snippet.Description = withIndex(playlist.Snippet.Description, index)
+	if _, err := service.Playlists.Update([]string{"snippet"}, &youtube.Playlist{Id: playlistID, Snippet: playlist.Snippet}).Context(ctx).Do(); err != nil {
+		return classifyUploadError(err)
+	}
+	return nil
+}
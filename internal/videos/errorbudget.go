@@ -0,0 +1,103 @@
+package videos
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/rogersilvasouza/godeogoker/internal/config"
+)
+
+// ErrorBudget stops processing once videos keep failing, so a systemic
+// failure such as an expired cookie or a broken yt-dlp does not burn hours
+// failing on every video in turn. A nil *ErrorBudget never stops.
+type ErrorBudget struct {
+	MaxConsecutive int // Consecutive failed videos that stop a channel, 0 for no limit
+	MaxRun         int // Failed videos that stop the run, 0 for no limit
+
+	mu          sync.Mutex
+	consecutive map[string]int // Consecutive failed videos by channel
+	failed      int            // Failed videos of the run
+}
+
+// NewErrorBudget returns the error budget configured in budget, or nil when
+// both limits are disabled. Limits that are not set, or negative, are disabled.
+func NewErrorBudget(budget config.ErrorBudget) *ErrorBudget {
+	maxConsecutive := max(budget.Channel, 0)
+	maxRun := max(budget.Run, 0)
+	if maxConsecutive == 0 && maxRun == 0 {
+		return nil
+	}
+	return &ErrorBudget{MaxConsecutive: maxConsecutive, MaxRun: maxRun}
+}
+
+// Record counts a processed video of channel, failed with cause or
+// successful when cause is nil. It returns an error of kind ErrErrorBudget
+// once the channel or the run has spent its budget.
+func (b *ErrorBudget) Record(channel string, cause error) error {
+	if b == nil {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.consecutive == nil {
+		b.consecutive = make(map[string]int)
+	}
+	if cause == nil {
+		b.consecutive[channel] = 0
+		return nil
+	}
+	b.consecutive[channel]++
+	b.failed++
+
+	if b.MaxRun > 0 && b.failed >= b.MaxRun {
+		return newError(ErrErrorBudget, "", "", fmt.Errorf("%d videos failed during the run, stopping the run; last failure: %v", b.failed, cause))
+	}
+	if b.MaxConsecutive > 0 && b.consecutive[channel] >= b.MaxConsecutive {
+		return newError(ErrErrorBudget, "", "", fmt.Errorf("%d videos in a row failed, skipping the remaining videos of the channel; last failure: %v", b.consecutive[channel], cause))
+	}
+	return nil
+}
+
+// RunSpent reports whether so many videos failed that the run must stop.
+func (b *ErrorBudget) RunSpent() bool {
+	if b == nil {
+		return false
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.MaxRun > 0 && b.failed >= b.MaxRun
+}
+
+// Failed returns how many videos failed during the run.
+func (b *ErrorBudget) Failed() int {
+	if b == nil {
+		return 0
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.failed
+}
+
+// countsAgainstBudget reports whether err is a failure of the video itself.
+// Exhausted upload quotas, spending limits and clips held for review are
+// expected to repeat on every video and have their own handling, and invalid
+// edit files are mistakes of whoever edited them.
+func countsAgainstBudget(err error) bool {
+	return !errors.Is(err, ErrUploadQuota) &&
+		!errors.Is(err, ErrBudgetExceeded) &&
+		!errors.Is(err, ErrQualityGate) &&
+		!errors.Is(err, ErrInvalidEdit) &&
+		!errors.Is(err, ErrErrorBudget)
+}
+
+// retryable reports whether a video that failed with err is worth processing
+// again right away. Timeouts would double the time spent on the video and
+// authentication failures do not go away by themselves.
+func retryable(err error) bool {
+	return !errors.Is(err, ErrTimeout) && !errors.Is(err, ErrAuth)
+}
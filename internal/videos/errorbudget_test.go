@@ -0,0 +1,35 @@
+package videos
+
+import (
+	"testing"
+
+	"github.com/rogersilvasouza/godeogoker/internal/config"
+)
+
+func TestNewErrorBudget(t *testing.T) {
+	tests := []struct {
+		name   string
+		budget config.ErrorBudget
+		want   *ErrorBudget
+	}{
+		{"not configured", config.ErrorBudget{}, nil},
+		{"disabled", config.ErrorBudget{Channel: -1, Run: -1}, nil},
+		{"channel only", config.ErrorBudget{Channel: 3}, &ErrorBudget{MaxConsecutive: 3}},
+		{"run only", config.ErrorBudget{Channel: -1, Run: 10}, &ErrorBudget{MaxRun: 10}},
+		{"both", config.ErrorBudget{Channel: 3, Run: 10}, &ErrorBudget{MaxConsecutive: 3, MaxRun: 10}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := NewErrorBudget(test.budget)
+			switch {
+			case got == nil || test.want == nil:
+				if got != test.want {
+					t.Errorf("budget = %+v, want %+v", got, test.want)
+				}
+			case got.MaxConsecutive != test.want.MaxConsecutive || got.MaxRun != test.want.MaxRun:
+				t.Errorf("limits = %d, %d, want %d, %d", got.MaxConsecutive, got.MaxRun, test.want.MaxConsecutive, test.want.MaxRun)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package videos
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Kind is a class of pipeline failure identified by a stable code.
+// The Err* values are sentinels meant to be matched with errors.Is.
+type Kind struct {
+	Code    string // Stable machine-readable identifier
+	Message string // Human-readable description
+}
+
+// Error returns the human-readable description of the kind.
+func (k *Kind) Error() string {
+	return k.Message
+}
+
+// Failure kinds reported by the pipeline.
+var (
+	ErrFeedFailed     = &Kind{Code: "feed_failed", Message: "unable to fetch channel feed"}
+	ErrDownloadFailed = &Kind{Code: "download_failed", Message: "download failed"}
+	ErrNoCaptions     = &Kind{Code: "no_captions", Message: "no captions available"}
+	ErrLLMRequest     = &Kind{Code: "llm_request", Message: "language model request failed"}
+	ErrLLMParse       = &Kind{Code: "llm_parse", Message: "unable to parse language model response"}
+	ErrLLMRateLimit   = &Kind{Code: "llm_rate_limit", Message: "language model rate limit reached"}
+	ErrBudgetExceeded = &Kind{Code: "budget_exceeded", Message: "language model spending limit reached"}
+	ErrRenderFailed   = &Kind{Code: "render_failed", Message: "render failed"}
+	ErrStorageFailed  = &Kind{Code: "storage_failed", Message: "unable to store output"}
+	ErrUploadFailed   = &Kind{Code: "upload_failed", Message: "upload failed"}
+	ErrUploadQuota    = &Kind{Code: "upload_quota", Message: "upload quota exceeded"}
+	ErrAuth           = &Kind{Code: "auth", Message: "authentication failed"}
+	ErrTimeout        = &Kind{Code: "timeout", Message: "time limit exceeded"}
+	ErrQualityGate    = &Kind{Code: "quality_rejected", Message: "clip held back for review"}
+	ErrErrorBudget    = &Kind{Code: "error_budget", Message: "too many failed videos"}
+	ErrInvalidEdit    = &Kind{Code: "invalid_edit", Message: "invalid edit file"}
+)
+
+// Error is a pipeline failure with the video and cut it happened on.
+type Error struct {
+	Kind    *Kind  // Class of the failure
+	VideoID string // Video being processed, if any
+	Cut     string // Title of the cut being processed, if any
+	Err     error  // Underlying cause
+}
+
+// newError wraps err with its kind and processing context.
+func newError(kind *Kind, videoID string, cut string, err error) *Error {
+	return &Error{Kind: kind, VideoID: videoID, Cut: cut, Err: err}
+}
+
+// Error formats the failure with its context, e.g.
+// `video abc123: cut "Intro": render failed: exit status 1`.
+func (e *Error) Error() string {
+	msg := e.Kind.Message
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	if e.Cut != "" {
+		msg = fmt.Sprintf("cut %q: %s", e.Cut, msg)
+	}
+	if e.VideoID != "" {
+		msg = fmt.Sprintf("video %s: %s", e.VideoID, msg)
+	}
+	return msg
+}
+
+// Unwrap exposes both the kind and the cause to errors.Is and errors.As.
+func (e *Error) Unwrap() []error {
+	if e.Err == nil {
+		return []error{e.Kind}
+	}
+	return []error{e.Kind, e.Err}
+}
+
+// Code returns the stable code of the failure.
+func (e *Error) Code() string {
+	return e.Kind.Code
+}
+
+// CodeOf returns the code of the first Kind found in err's chain, or "unknown".
+func CodeOf(err error) string {
+	var kind *Kind
+	if errors.As(err, &kind) {
+		return kind.Code
+	}
+	return "unknown"
+}
+
+// withContext attaches the video and cut being processed to err. Errors that
+// are not pipeline errors yet are wrapped with the fallback kind.
+func withContext(err error, fallback *Kind, videoID string, cut string) error {
+	var pipelineErr *Error
+	if errors.As(err, &pipelineErr) {
+		if pipelineErr.VideoID == "" {
+			pipelineErr.VideoID = videoID
+		}
+		if pipelineErr.Cut == "" {
+			pipelineErr.Cut = cut
+		}
+		return err
+	}
+	return newError(fallback, videoID, cut, err)
+}
+
+// stageOf returns the pipeline stage a failure belongs to, used to group the
+// failures waiting to be retried. Model failures with a cut happened while
+// generating its metadata, the others while looking for cuts.
+func stageOf(err error) string {
+	var pipelineErr *Error
+	hasCut := errors.As(err, &pipelineErr) && pipelineErr.Cut != ""
+
+	switch {
+	case errors.Is(err, ErrFeedFailed):
+		return "feed"
+	case errors.Is(err, ErrDownloadFailed), errors.Is(err, ErrNoCaptions):
+		return "download"
+	case errors.Is(err, ErrInvalidEdit):
+		return "metadata"
+	case errors.Is(err, ErrLLMRequest), errors.Is(err, ErrLLMParse), errors.Is(err, ErrLLMRateLimit), errors.Is(err, ErrBudgetExceeded):
+		if hasCut {
+			return "metadata"
+		}
+		return "cuts"
+	case errors.Is(err, ErrRenderFailed):
+		return "render"
+	case errors.Is(err, ErrStorageFailed):
+		return "storage"
+	case errors.Is(err, ErrUploadFailed), errors.Is(err, ErrUploadQuota), errors.Is(err, ErrQualityGate):
+		return "upload"
+	case errors.Is(err, ErrAuth):
+		return "auth"
+	case errors.Is(err, ErrTimeout):
+		return "timeout"
+	default:
+		return "unknown"
+	}
+}
+
+// reportError prints a failure prefixed with its code, without the
+// credentials of the configuration.
+func (c *Client) reportError(err error) {
+	fmt.Println(errorStyle.Render(fmt.Sprintf("[%s] %v", CodeOf(err), c.Config.Redact(err.Error()))))
+}
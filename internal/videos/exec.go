@@ -0,0 +1,94 @@
+package videos
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// commandWaitDelay is how long an interrupted subprocess is given to exit
+// after receiving SIGINT before it is forcibly killed.
+const commandWaitDelay = 10 * time.Second
+
+// resumableMarker is the file written inside a video folder while it is being
+// processed. A folder that still contains it was interrupted and can be resumed.
+const resumableMarker = ".resumable"
+
+// newCommand builds an external command bound to ctx.
+// When ctx is cancelled the process receives SIGINT so ffmpeg and yt-dlp can
+// finalize or clean up their own files, and is killed if it does not exit in time.
+// Windows cannot deliver SIGINT to a child process, so it is killed right away.
+// When ctx carries the debug log of a video, the output of the command is
+// recorded in it, and when it carries priorities the command runs with the
+// one of its tool.
+func newCommand(ctx context.Context, name string, args ...string) *command {
+	name, args = prioritized(ctx, name, args)
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Cancel = func() error {
+		if runtime.GOOS == "windows" {
+			return cmd.Process.Kill()
+		}
+		return cmd.Process.Signal(os.Interrupt)
+	}
+	cmd.WaitDelay = commandWaitDelay
+	return &command{Cmd: cmd, log: debugLogOf(ctx)}
+}
+
+// sleepContext pauses for the given duration or until ctx is cancelled.
+// It returns the context error when the wait was interrupted.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// markResumable flags outputDir as a video whose processing has not finished yet.
+func markResumable(outputDir string) error {
+	return os.WriteFile(filepath.Join(outputDir, resumableMarker), []byte(time.Now().Format(time.RFC3339)), 0644)
+}
+
+// clearResumable removes the in-progress marker once a video has been fully processed.
+func clearResumable(outputDir string) {
+	os.Remove(filepath.Join(outputDir, resumableMarker))
+}
+
+// isResumable reports whether outputDir holds an interrupted run.
+func isResumable(outputDir string) bool {
+	_, err := os.Stat(filepath.Join(outputDir, resumableMarker))
+	return err == nil
+}
+
+// removeTempFiles deletes the intermediate files an interrupted run leaves
+// behind: temporary cuts and their subtitles, outputs that were still being
+// written, and the segment parts of older versions.
+func removeTempFiles(outputDir string) {
+	tempOutputs := "*" + tempMarker + ".*"
+	for _, pattern := range []string{"temp_*", "*.part*.mp4", "*.part*.srt", tempOutputs, filepath.Join("*", tempOutputs)} {
+		matches, _ := filepath.Glob(filepath.Join(outputDir, pattern))
+		for _, match := range matches {
+			os.Remove(match)
+		}
+	}
+}
+
+// unsafeFileNameChars replaces the characters that are not allowed in file
+// names on Windows, plus the path separators, when cut titles become file names.
+var unsafeFileNameChars = strings.NewReplacer(
+	"<", "_", ">", "_", ":", "_", "\"", "_", "/", "_",
+	"\\", "_", "|", "_", "?", "_", "*", "_",
+)
+
+// safeFileName turns a cut title into a file name valid on every platform.
+func safeFileName(title string) string {
+	return strings.TrimRight(unsafeFileNameChars.Replace(title), ". ")
+}
@@ -0,0 +1,272 @@
+package videos
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rogersilvasouza/godeogoker/internal/config"
+)
+
+// statsBatch is how many videos a single YouTube statistics request covers.
+const statsBatch = 50
+
+// metadataColumns are the columns of the metadata export, one row per clip.
+var metadataColumns = []string{
+	"channel", "source_id", "source_title", "source_published",
+	"clip", "title", "description", "tags", "hashtags", "hook",
+	"guest", "episode", "date", "rendered", "size_bytes",
+	"vertical", "cover", "link", "uploaded", "youtube_url",
+	"views", "likes", "comments",
+}
+
+// numericColumns are the columns written as numbers in spreadsheets.
+var numericColumns = map[string]bool{"size_bytes": true, "views": true, "likes": true, "comments": true}
+
+// videoStats are the public counters of an uploaded video.
+type videoStats struct {
+	Views    uint64
+	Likes    uint64
+	Comments uint64
+}
+
+// ExportMetadata writes the metadata of every clip of channels, one row per
+// clip, into out as csv or xlsx. Upload times and YouTube links come from the
+// state store of client; with stats, the views, likes and comments of the
+// uploaded clips are read from YouTube too.
+func ExportMetadata(ctx context.Context, client *Client, channels []config.Channel, format string, out string, stats bool) error {
+	if format != "csv" && format != "xlsx" {
+		return fmt.Errorf("unknown export format: %s", format)
+	}
+
+	var rows [][]string
+	var ids []string
+	for _, channel := range channels {
+		fmt.Println(commandStyle.Render("Exporting metadata of channel: " + channel.Name))
+
+		clips, err := ListClips(channel.Folder)
+		if err != nil {
+			return fmt.Errorf("error listing clips of %s: %v", channel.Name, err)
+		}
+		for _, clip := range clips {
+			row, id := metadataRow(client, channel, clip)
+			rows = append(rows, row)
+			ids = append(ids, id)
+		}
+	}
+
+	if stats {
+		counters, err := client.videoStats(ctx, ids)
+		if err != nil {
+			return err
+		}
+		for i, id := range ids {
+			if counter, ok := counters[id]; ok {
+				rows[i][len(rows[i])-3] = strconv.FormatUint(counter.Views, 10)
+				rows[i][len(rows[i])-2] = strconv.FormatUint(counter.Likes, 10)
+				rows[i][len(rows[i])-1] = strconv.FormatUint(counter.Comments, 10)
+			}
+		}
+	}
+
+	// The export replaces the previous one only once it is complete, so a
+	// failed write leaves the previous export in place.
+	err := writeAtomically(out, func(tmp string) error {
+		file, err := os.Create(tmp)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		if format == "xlsx" {
+			err = writeXLSX(file, metadataColumns, rows)
+		} else {
+			err = writeCSV(file, metadataColumns, rows)
+		}
+		if err != nil {
+			return err
+		}
+		return file.Close()
+	})
+	if err != nil {
+		return fmt.Errorf("error writing %s: %v", out, err)
+	}
+
+	fmt.Println(successStyle.Render(fmt.Sprintf("Metadata of %d clips exported to %s", len(rows), out)))
+	return nil
+}
+
+// metadataRow flattens a clip into a row of metadataColumns and returns the
+// YouTube video ID of its upload, empty when it was not uploaded or its ID is
+// unknown. The statistics columns are left empty.
+func metadataRow(client *Client, channel config.Channel, clip LibraryClip) ([]string, string) {
+	metadata := clip.Metadata
+
+	var uploaded, id string
+	if store := client.State; store != nil {
+		for _, path := range []string{clip.Clip, clip.Vertical} {
+			if path == "" {
+				continue
+			}
+			if at, youtubeID, ok := store.Upload(channel.ID, OutputKey(channel.Folder, path)); ok {
+				uploaded = at.UTC().Format(time.RFC3339)
+				id = youtubeID
+				break
+			}
+		}
+	}
+	var youtubeURL string
+	if id != "" {
+		youtubeURL = "https://www.youtube.com/watch?v=" + id
+	}
+	var vertical, cover string
+	if clip.Vertical != "" {
+		vertical = OutputKey(channel.Folder, clip.Vertical)
+	}
+	if clip.Cover != "" {
+		cover = OutputKey(channel.Folder, clip.Cover)
+	}
+
+	return []string{
+		channel.ID,
+		clip.Source.ID,
+		clip.Source.Title,
+		clip.Source.Published,
+		clip.Name,
+		metadata.Title,
+		metadata.Description,
+		strings.Join(metadata.Tags, ", "),
+		strings.Join(metadata.Hashtags, " "),
+		metadata.Hook,
+		metadata.Guest,
+		metadata.Episode,
+		metadata.Date,
+		clip.Rendered.Format(time.RFC3339),
+		strconv.FormatInt(clip.Size, 10),
+		vertical,
+		cover,
+		PublicLink(channel, clip.Clip),
+		uploaded,
+		youtubeURL,
+		"", "", "",
+	}, id
+}
+
+// videoStats reads the public counters of the YouTube videos ids, skipping
+// the empty ones, in batches of statsBatch.
+func (c *Client) videoStats(ctx context.Context, ids []string) (map[string]videoStats, error) {
+	var pending []string
+	seen := make(map[string]bool)
+	for _, id := range ids {
+		if id != "" && !seen[id] {
+			seen[id] = true
+			pending = append(pending, id)
+		}
+	}
+
+	stats := make(map[string]videoStats)
+	if len(pending) == 0 {
+		return stats, nil
+	}
+
+	fmt.Println(commandStyle.Render(fmt.Sprintf("Reading the statistics of %d videos from YouTube...", len(pending))))
+	service, err := c.youtubeService(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for start := 0; start < len(pending); start += statsBatch {
+		batch := pending[start:min(start+statsBatch, len(pending))]
+		response, err := service.Videos.List([]string{"statistics"}).Id(batch...).Context(ctx).Do()
+		if err != nil {
+			return nil, fmt.Errorf("error reading video statistics: %v", err)
+		}
+		for _, video := range response.Items {
+			if video.Statistics == nil {
+				continue
+			}
+			stats[video.Id] = videoStats{
+				Views:    video.Statistics.ViewCount,
+				Likes:    video.Statistics.LikeCount,
+				Comments: video.Statistics.CommentCount,
+			}
+		}
+	}
+	return stats, nil
+}
+
+// writeCSV writes columns as the header row followed by rows.
+func writeCSV(w io.Writer, columns []string, rows [][]string) error {
+	writer := csv.NewWriter(w)
+	writer.Write(columns)
+	writer.WriteAll(rows)
+	return writer.Error()
+}
+
+// writeXLSX writes columns as the header row followed by rows into a single
+// sheet workbook. Cells of numericColumns are written as numbers and every
+// other cell as text.
+func writeXLSX(w io.Writer, columns []string, rows [][]string) error {
+	archive := zip.NewWriter(w)
+
+	files := []struct {
+		name    string
+		content string
+	}{
+		{"[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/></Types>`},
+		{"_rels/.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`},
+		{"xl/workbook.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="Metadata" sheetId="1" r:id="rId1"/></sheets></workbook>`},
+		{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/></Relationships>`},
+		{"xl/worksheets/sheet1.xml", sheetXML(columns, rows)},
+	}
+
+	for _, file := range files {
+		part, err := archive.Create(file.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(part, file.content); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+// sheetXML returns the worksheet holding columns and rows.
+func sheetXML(columns []string, rows [][]string) string {
+	var sheet strings.Builder
+	sheet.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+
+	writeRow := func(row []string, header bool) {
+		sheet.WriteString("<row>")
+		for i, value := range row {
+			if !header && numericColumns[columns[i]] && value != "" {
+				sheet.WriteString("<c><v>" + value + "</v></c>")
+				continue
+			}
+			sheet.WriteString(`<c t="inlineStr"><is><t xml:space="preserve">`)
+			xml.EscapeText(&sheet, []byte(value))
+			sheet.WriteString("</t></is></c>")
+		}
+		sheet.WriteString("</row>")
+	}
+
+	writeRow(columns, true)
+	for _, row := range rows {
+		writeRow(row, false)
+	}
+	sheet.WriteString("</sheetData></worksheet>")
+	return sheet.String()
+}
@@ -0,0 +1,47 @@
+package videos
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rogersilvasouza/godeogoker/internal/config"
+)
+
+func TestExportMetadataReplacesThePreviousExport(t *testing.T) {
+	channels := []config.Channel{{ID: "channel", Name: "Channel", Folder: t.TempDir()}}
+	out := filepath.Join(t.TempDir(), "clips.csv")
+	if err := os.WriteFile(out, []byte("previous export"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ExportMetadata(context.Background(), &Client{}, channels, "csv", out, false); err != nil {
+		t.Fatal(err)
+	}
+	content, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(content), strings.Join(metadataColumns, ",")+"\n") {
+		t.Errorf("export = %q, want the header row", content)
+	}
+	assertMissing(t, tempName(out))
+}
+
+func TestExportMetadataReportsFailedWrites(t *testing.T) {
+	channels := []config.Channel{{ID: "channel", Name: "Channel", Folder: t.TempDir()}}
+
+	// A folder in the way of the export cannot be replaced by it.
+	out := filepath.Join(t.TempDir(), "clips.xlsx")
+	if err := os.MkdirAll(filepath.Join(out, "taken"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	err := ExportMetadata(context.Background(), &Client{}, channels, "xlsx", out, false)
+	if err == nil || !strings.Contains(err.Error(), out) {
+		t.Errorf("error = %v, want one naming %s", err, out)
+	}
+	assertMissing(t, tempName(out))
+}
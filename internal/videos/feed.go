@@ -0,0 +1,285 @@
+package videos
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// defaultFeedLimit is the number of clips listed when the channel sets no feed limit.
+const defaultFeedLimit = 50
+
+// feedFiles maps each supported feed format to the file written inside the channel folder.
+var feedFiles = map[string]string{
+	"rss":  "feed.xml",
+	"atom": "atom.xml",
+	"json": "feed.json",
+}
+
+// writeFeeds lists the rendered clips of the channel in the configured feed
+// formats, writing them to the channel folder and publishing them to the storage.
+func (p *pipeline) writeFeeds(ctx context.Context) {
+	settings := p.channel.Feed
+	if len(settings.Formats) == 0 {
+		return
+	}
+
+	items, err := ListClips(p.channel.Folder)
+	if err != nil {
+		fmt.Println(errorStyle.Render("Error listing clips for the feeds: " + err.Error()))
+		return
+	}
+
+	limit := settings.Limit
+	if limit <= 0 {
+		limit = defaultFeedLimit
+	}
+	if len(items) > limit {
+		items = items[:limit]
+	}
+
+	for _, format := range settings.Formats {
+		file, ok := feedFiles[format]
+		if !ok {
+			fmt.Println(errorStyle.Render("Unknown feed format: " + format))
+			continue
+		}
+
+		var content []byte
+		switch format {
+		case "rss":
+			content, err = p.rssFeed(items)
+		case "atom":
+			content, err = p.atomFeed(items)
+		case "json":
+			content, err = p.jsonFeed(items)
+		}
+		if err != nil {
+			fmt.Println(errorStyle.Render(fmt.Sprintf("Error building %s feed: %v", format, err)))
+			continue
+		}
+
+		path := filepath.Join(p.channel.Folder, file)
+		if err := writeFileAtomic(path, content, 0644); err != nil {
+			fmt.Println(errorStyle.Render(fmt.Sprintf("Error writing %s feed: %v", format, err)))
+			continue
+		}
+		if err := p.storage.Put(ctx, file, path); err != nil {
+			fmt.Println(errorStyle.Render(fmt.Sprintf("Error storing %s feed: %v", format, err)))
+			continue
+		}
+	}
+
+	fmt.Println(successStyle.Render(fmt.Sprintf("Feeds updated with %d clips", len(items))))
+}
+
+// feedUpdated returns the time of the newest item, or now for an empty feed.
+func feedUpdated(items []LibraryClip) time.Time {
+	if len(items) == 0 {
+		return time.Now().UTC()
+	}
+	return items[0].Rendered
+}
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string       `xml:"title"`
+	Link        string       `xml:"link"`
+	Description string       `xml:"description"`
+	GUID        rssGUID      `xml:"guid"`
+	PubDate     string       `xml:"pubDate"`
+	Categories  []string     `xml:"category"`
+	Enclosure   rssEnclosure `xml:"enclosure"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type rssEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Length int64  `xml:"length,attr"`
+	Type   string `xml:"type,attr"`
+}
+
+// rssFeed renders items as an RSS 2.0 feed with the clips as enclosures.
+func (p *pipeline) rssFeed(items []LibraryClip) ([]byte, error) {
+	feed := rssFeed{
+		Version: "2.0",
+		Channel: rssChannel{
+			Title:         p.channel.Name,
+			Link:          p.channel.Feed.BaseURL,
+			Description:   p.channel.Description,
+			LastBuildDate: feedUpdated(items).Format(time.RFC1123Z),
+		},
+	}
+
+	for _, item := range items {
+		link := PublicLink(p.channel, item.Clip)
+		feed.Channel.Items = append(feed.Channel.Items, rssItem{
+			Title:       item.Metadata.Title,
+			Link:        link,
+			Description: item.Metadata.Description,
+			GUID:        rssGUID{Value: p.outputKey(item.Clip)},
+			PubDate:     item.Rendered.Format(time.RFC1123Z),
+			Categories:  item.Metadata.Tags,
+			Enclosure:   rssEnclosure{URL: link, Length: item.Size, Type: videoType(item.Clip)},
+		})
+	}
+
+	content, err := xml.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), content...), nil
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomEntry struct {
+	Title      string         `xml:"title"`
+	ID         string         `xml:"id"`
+	Updated    string         `xml:"updated"`
+	Summary    string         `xml:"summary,omitempty"`
+	Links      []atomLink     `xml:"link"`
+	Categories []atomCategory `xml:"category"`
+}
+
+type atomLink struct {
+	Href   string `xml:"href,attr"`
+	Rel    string `xml:"rel,attr,omitempty"`
+	Type   string `xml:"type,attr,omitempty"`
+	Length int64  `xml:"length,attr,omitempty"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+// atomFeed renders items as an Atom feed, linking the clip, its cover and its source video.
+func (p *pipeline) atomFeed(items []LibraryClip) ([]byte, error) {
+	feed := atomFeed{
+		Title:   p.channel.Name,
+		ID:      "urn:godeogoker:" + p.channel.ID,
+		Updated: feedUpdated(items).Format(time.RFC3339),
+	}
+	if p.channel.Feed.BaseURL != "" {
+		feed.Links = []atomLink{{Href: p.channel.Feed.BaseURL}}
+	}
+
+	for _, item := range items {
+		link := PublicLink(p.channel, item.Clip)
+		entry := atomEntry{
+			Title:   item.Metadata.Title,
+			ID:      "urn:godeogoker:" + p.channel.ID + ":" + p.outputKey(item.Clip),
+			Updated: item.Rendered.Format(time.RFC3339),
+			Summary: item.Metadata.Description,
+			Links: []atomLink{
+				{Href: link},
+				{Href: link, Rel: "enclosure", Type: videoType(item.Clip), Length: item.Size},
+			},
+		}
+		if source := item.Source.PageURL(); source != "" {
+			entry.Links = append(entry.Links, atomLink{Href: source, Rel: "via"})
+		}
+		if item.Cover != "" {
+			entry.Links = append(entry.Links, atomLink{Href: PublicLink(p.channel, item.Cover), Rel: "enclosure", Type: "image/jpeg"})
+		}
+		for _, tag := range item.Metadata.Tags {
+			entry.Categories = append(entry.Categories, atomCategory{Term: tag})
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+
+	content, err := xml.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), content...), nil
+}
+
+type jsonFeed struct {
+	Version     string         `json:"version"`
+	Title       string         `json:"title"`
+	HomePageURL string         `json:"home_page_url,omitempty"`
+	FeedURL     string         `json:"feed_url,omitempty"`
+	Description string         `json:"description,omitempty"`
+	Items       []jsonFeedItem `json:"items"`
+}
+
+type jsonFeedItem struct {
+	ID            string               `json:"id"`
+	URL           string               `json:"url"`
+	ExternalURL   string               `json:"external_url,omitempty"`
+	Title         string               `json:"title"`
+	ContentText   string               `json:"content_text"`
+	Image         string               `json:"image,omitempty"`
+	DatePublished string               `json:"date_published"`
+	Tags          []string             `json:"tags,omitempty"`
+	Attachments   []jsonFeedAttachment `json:"attachments"`
+}
+
+type jsonFeedAttachment struct {
+	URL         string `json:"url"`
+	MimeType    string `json:"mime_type"`
+	SizeInBytes int64  `json:"size_in_bytes"`
+}
+
+// jsonFeed renders items as a JSON Feed 1.1 document.
+func (p *pipeline) jsonFeed(items []LibraryClip) ([]byte, error) {
+	feed := jsonFeed{
+		Version:     "https://jsonfeed.org/version/1.1",
+		Title:       p.channel.Name,
+		HomePageURL: p.channel.Feed.BaseURL,
+		Description: p.channel.Description,
+		Items:       []jsonFeedItem{},
+	}
+	if p.channel.Feed.BaseURL != "" {
+		feed.FeedURL = strings.TrimRight(p.channel.Feed.BaseURL, "/") + "/" + feedFiles["json"]
+	}
+
+	for _, item := range items {
+		link := PublicLink(p.channel, item.Clip)
+		entry := jsonFeedItem{
+			ID:            p.outputKey(item.Clip),
+			URL:           link,
+			ExternalURL:   item.Source.PageURL(),
+			Title:         item.Metadata.Title,
+			ContentText:   item.Metadata.Description,
+			DatePublished: item.Rendered.Format(time.RFC3339),
+			Tags:          item.Metadata.Tags,
+			Attachments:   []jsonFeedAttachment{{URL: link, MimeType: videoType(item.Clip), SizeInBytes: item.Size}},
+		}
+		if item.Cover != "" {
+			entry.Image = PublicLink(p.channel, item.Cover)
+		}
+		feed.Items = append(feed.Items, entry)
+	}
+
+	return json.MarshalIndent(feed, "", "  ")
+}
@@ -0,0 +1,97 @@
+package videos
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strings"
+)
+
+// defaultHashtagsPerClip is how many hashtags of the channel pool are added to
+// each clip when the channel does not set it.
+const defaultHashtagsPerClip = 3
+
+// youtubeHashtagLimit is the most hashtags YouTube accepts; past it, YouTube
+// ignores all the hashtags of a video.
+const youtubeHashtagLimit = 15
+
+// applyHashtags merges the next hashtags of the channel pool with the
+// generated hashtags of metadata, pool hashtags first, and fills the
+// hashtags of each platform up to its limit. The rotation through the pool is
+// kept in the state store, or derived from the title without one.
+func (p *pipeline) applyHashtags(metadata *VideoMetadata) {
+	hashtags := p.channel.Hashtags
+	pool := normalizeHashtags(hashtags.Pool)
+
+	count := hashtags.PerClip
+	if count <= 0 {
+		count = defaultHashtagsPerClip
+	}
+	count = min(count, len(pool))
+
+	start := 0
+	if count > 0 {
+		if p.client.State != nil {
+			var err error
+			if start, err = p.client.State.RotateHashtags(p.channel.ID, count, len(pool)); err != nil {
+				fmt.Println(errorStyle.Render("Error recording hashtag rotation: " + err.Error()))
+			}
+		} else {
+			h := fnv.New32a()
+			h.Write([]byte(metadata.Title))
+			start = int(h.Sum32() % uint32(len(pool)))
+		}
+	}
+
+	var picked []string
+	for i := range count {
+		picked = append(picked, pool[(start+i)%len(pool)])
+	}
+	metadata.Hashtags = normalizeHashtags(append(picked, metadata.Hashtags...))
+	metadata.PlatformHashtags = platformHashtags(metadata.Hashtags, hashtags.Limits)
+}
+
+// platformHashtags returns hashtags cut to the limit of each platform in
+// limits. YouTube is always included, limited to youtubeHashtagLimit unless
+// set lower.
+func platformHashtags(hashtags []string, configured map[string]int) map[string][]string {
+	limits := map[string]int{"youtube": youtubeHashtagLimit}
+	for platform, limit := range configured {
+		platform = strings.ToLower(platform)
+		if platform == "youtube" {
+			limit = min(limit, youtubeHashtagLimit)
+		}
+		limits[platform] = limit
+	}
+
+	result := make(map[string][]string, len(limits))
+	for platform, limit := range limits {
+		result[platform] = hashtags[:min(max(limit, 0), len(hashtags))]
+	}
+	return result
+}
+
+// normalizeHashtags writes each hashtag with a single # symbol and no spaces,
+// dropping the empty ones and the repeated ones, ignoring case.
+func normalizeHashtags(hashtags []string) []string {
+	var result []string
+	seen := make(map[string]bool)
+	for _, hashtag := range hashtags {
+		hashtag = strings.Join(strings.Fields(strings.TrimLeft(strings.TrimSpace(hashtag), "#")), "")
+		if hashtag == "" || seen[strings.ToLower(hashtag)] {
+			continue
+		}
+		seen[strings.ToLower(hashtag)] = true
+		result = append(result, "#"+hashtag)
+	}
+	return result
+}
+
+// withHashtags appends the YouTube hashtags of metadata to description when
+// the channel has a hashtag pool.
+func (p *pipeline) withHashtags(description string, metadata *VideoMetadata) string {
+	hashtags := metadata.PlatformHashtags["youtube"]
+	if p.channel.Hashtags == nil || len(hashtags) == 0 {
+		return description
+	}
+	return description + "\n\n" + strings.Join(hashtags, " ")
+}
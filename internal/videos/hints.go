@@ -0,0 +1,376 @@
+package videos
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/rogersilvasouza/godeogoker/internal/config"
+)
+
+// Files caching the fetched signals inside a video folder.
+const (
+	heatmapFile  = "heatmap.json"
+	commentsFile = "comments.json"
+)
+
+// Chapter is a creator-defined chapter of the source video.
+type Chapter struct {
+	Start int    // Start time in seconds
+	Title string // Chapter title
+}
+
+// CutHints carries signals about the source video that help the model choose
+// cut boundaries. All times are in seconds from the start of the source video.
+type CutHints struct {
+	Chapters  []Chapter   // Chapters listed in the video description
+	Peaks     []HeatPoint // Most replayed moments, strongest first
+	Comments  []Comment   // Viewer comments mentioning timestamps, most liked first
+	Reactions []Reaction  // Laughter, applause and shouting, in order of time
+	Music     []Range     // Sections with music that cuts must not include
+}
+
+// Range is a time range in seconds of the source video.
+type Range struct {
+	Start int // Start time in seconds
+	End   int // End time in seconds
+}
+
+// overlaps reports whether the cut shares any second with r.
+func (r Range) overlaps(cut Cut) bool {
+	return cut.Begin < r.End && cut.End > r.Start
+}
+
+// musicChapter matches chapter titles announcing a music section.
+var musicChapter = regexp.MustCompile(`(?i)\b(music|música|musica|song|canção|cancion|canción|performance|karaoke|videoclipe|clipe)\b`)
+
+// musicFromChapters returns the chapters whose title announces music. The
+// last chapter runs until the end of the video.
+func musicFromChapters(chapters []Chapter) []Range {
+	var ranges []Range
+	for i, chapter := range chapters {
+		if !musicChapter.MatchString(chapter.Title) {
+			continue
+		}
+
+		end := math.MaxInt32
+		if i+1 < len(chapters) {
+			end = chapters[i+1].Start
+		}
+		ranges = append(ranges, Range{Start: chapter.Start, End: end})
+	}
+	return ranges
+}
+
+// isMusicMarker reports whether caption text is a music marker of YouTube
+// auto-captions: "[Music]", "[Música]" or "♪".
+func isMusicMarker(text string) bool {
+	text = strings.ToLower(text)
+	return strings.Contains(text, "[music") || strings.Contains(text, "[música") || strings.Contains(text, "♪")
+}
+
+// musicFromCaptions returns the sections YouTube auto-captions mark as music,
+// merging markers less than 10 seconds apart.
+func musicFromCaptions(entries []SubtitleEntry) []Range {
+	var ranges []Range
+	for _, entry := range entries {
+		if !isMusicMarker(entry.Text) {
+			continue
+		}
+
+		start, end := int(entry.StartTime.Seconds()), int(math.Ceil(entry.EndTime.Seconds()))
+		if n := len(ranges); n > 0 && start-ranges[n-1].End < 10 {
+			ranges[n-1].End = end
+			continue
+		}
+		ranges = append(ranges, Range{Start: start, End: end})
+	}
+	return ranges
+}
+
+// withoutMusic drops the cuts overlapping a music section.
+func withoutMusic(cuts []Cut, music []Range) []Cut {
+	var kept []Cut
+	for _, cut := range cuts {
+		excluded := false
+		for _, r := range music {
+			if r.overlaps(cut) {
+				excluded = true
+				break
+			}
+		}
+
+		if excluded {
+			fmt.Println(subtitleStyle.Render(fmt.Sprintf("Skipping cut with music: %s", cut.Title)))
+			continue
+		}
+		kept = append(kept, cut)
+	}
+	return kept
+}
+
+// Comment is a viewer comment pointing at a moment of the source video.
+type Comment struct {
+	Time  int    `json:"time"`  // Mentioned timestamp in seconds
+	Text  string `json:"text"`  // Comment text
+	Likes int64  `json:"likes"` // Like count of the comment
+}
+
+// timestamp matches clock timestamps such as "32:10" or "1:02:03" in free text.
+var timestamp = regexp.MustCompile(`\b((?:\d{1,2}:)?\d{1,2}:\d{2})\b`)
+
+// FetchComments returns the most relevant top-level comments of a video that
+// mention a timestamp, most liked first, keeping at most limit of them.
+func (c *Client) FetchComments(ctx context.Context, videoID string, limit int) ([]Comment, error) {
+	service, err := c.youtubeService(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := service.CommentThreads.List([]string{"snippet"}).
+		VideoId(videoID).
+		Order("relevance").
+		TextFormat("plainText").
+		MaxResults(100).
+		Context(ctx).
+		Do()
+	if err != nil {
+		return nil, fmt.Errorf("error fetching comments: %v", err)
+	}
+
+	var comments []Comment
+	for _, thread := range response.Items {
+		if thread.Snippet == nil || thread.Snippet.TopLevelComment == nil || thread.Snippet.TopLevelComment.Snippet == nil {
+			continue
+		}
+
+		snippet := thread.Snippet.TopLevelComment.Snippet
+		match := timestamp.FindStringSubmatch(snippet.TextDisplay)
+		if match == nil {
+			continue
+		}
+
+		text := strings.Join(strings.Fields(snippet.TextDisplay), " ")
+		if runes := []rune(text); len(runes) > 200 {
+			text = string(runes[:200])
+		}
+		comments = append(comments, Comment{Time: parseClock(match[1]), Text: text, Likes: snippet.LikeCount})
+	}
+
+	sort.SliceStable(comments, func(i, j int) bool {
+		return comments[i].Likes > comments[j].Likes
+	})
+	if len(comments) > limit {
+		comments = comments[:limit]
+	}
+	return comments, nil
+}
+
+// loadComments returns the timestamped comments of videoID, fetching them on the
+// first run and reading them from outputDir afterwards.
+func (p *pipeline) loadComments(ctx context.Context, outputDir string, videoID string) []Comment {
+	path := filepath.Join(outputDir, commentsFile)
+
+	var comments []Comment
+	if content, err := os.ReadFile(path); err == nil {
+		if err := json.Unmarshal(content, &comments); err == nil {
+			return comments
+		}
+	}
+
+	fmt.Println(commandStyle.Render("Mining viewer comments for timestamps..."))
+	comments, err := p.client.FetchComments(ctx, videoID, 20)
+	if err != nil {
+		fmt.Println(subtitleStyle.Render("Comments unavailable: " + err.Error()))
+		return nil
+	}
+	if comments == nil {
+		comments = []Comment{}
+	}
+
+	if content, err := json.MarshalIndent(comments, "", "  "); err == nil {
+		writeFileAtomic(path, content, 0644)
+	}
+	return comments
+}
+
+// HeatPoint is a range of the "most replayed" heatmap of a video.
+// Value is the relative replay intensity between 0 and 1.
+type HeatPoint struct {
+	Start float64 `json:"start_time"`
+	End   float64 `json:"end_time"`
+	Value float64 `json:"value"`
+}
+
+// FetchHeatmap returns the "most replayed" heatmap of a video as reported by
+// yt-dlp, or nil when YouTube does not show one (new or low-traffic videos).
+func (c *Client) FetchHeatmap(ctx context.Context, videoID string, options config.YtdlpOptions) ([]HeatPoint, error) {
+	ctx, cancel := withTimeout(ctx, downloadTimeout(c.Config.Timeouts), "heatmap download")
+	defer cancel()
+
+	args := append([]string{"--skip-download", "--print", "%(heatmap)j"}, ytdlpArgs(options)...)
+	output, err := newCommand(ctx, c.Config.YtDlp, append(args, watchURL(videoID))...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("error fetching heatmap: %v", timeoutCause(ctx, err))
+	}
+
+	// yt-dlp prints NA when the field is missing.
+	if trimmed := strings.TrimSpace(string(output)); trimmed == "NA" || trimmed == "null" {
+		return nil, nil
+	}
+
+	var heatmap []HeatPoint
+	if err := json.Unmarshal(output, &heatmap); err != nil {
+		return nil, fmt.Errorf("error parsing heatmap: %v", err)
+	}
+	return heatmap, nil
+}
+
+// heatPeaks merges adjacent heatmap ranges at or above threshold and returns
+// the strongest limit of them, strongest first.
+func heatPeaks(heatmap []HeatPoint, threshold float64, limit int) []HeatPoint {
+	var peaks []HeatPoint
+	for _, point := range heatmap {
+		if point.Value < threshold {
+			continue
+		}
+
+		if n := len(peaks); n > 0 && peaks[n-1].End >= point.Start {
+			peaks[n-1].End = point.End
+			if point.Value > peaks[n-1].Value {
+				peaks[n-1].Value = point.Value
+			}
+			continue
+		}
+		peaks = append(peaks, point)
+	}
+
+	sort.SliceStable(peaks, func(i, j int) bool {
+		return peaks[i].Value > peaks[j].Value
+	})
+	if len(peaks) > limit {
+		peaks = peaks[:limit]
+	}
+	return peaks
+}
+
+// loadHeatmap returns the heatmap of videoID, fetching it on the first run and
+// reading it from outputDir afterwards.
+func (p *pipeline) loadHeatmap(ctx context.Context, outputDir string, videoID string) []HeatPoint {
+	path := filepath.Join(outputDir, heatmapFile)
+
+	var heatmap []HeatPoint
+	if content, err := os.ReadFile(path); err == nil {
+		if err := json.Unmarshal(content, &heatmap); err == nil {
+			return heatmap
+		}
+	}
+
+	fmt.Println(commandStyle.Render("Fetching most replayed heatmap..."))
+	heatmap, err := p.client.FetchHeatmap(ctx, videoID, p.channel.YtdlpOptions)
+	if err != nil {
+		fmt.Println(subtitleStyle.Render("Heatmap unavailable: " + err.Error()))
+		return nil
+	}
+
+	if len(heatmap) == 0 {
+		fmt.Println(subtitleStyle.Render("No heatmap available for this video"))
+		heatmap = []HeatPoint{}
+	}
+
+	if content, err := json.Marshal(heatmap); err == nil {
+		writeFileAtomic(path, content, 0644)
+	}
+	return heatmap
+}
+
+// chapterLine matches description lines such as "12:34 Topic" or "1:02:03 - Topic".
+var chapterLine = regexp.MustCompile(`^\s*\(?((?:\d{1,2}:)?\d{1,2}:\d{2})\)?\s*[-–—:|]?\s*(.+?)\s*$`)
+
+// parseChapters extracts the chapters of a YouTube description. Like YouTube,
+// it only accepts lists starting at 0:00 with increasing timestamps.
+func parseChapters(description string) []Chapter {
+	var chapters []Chapter
+	for _, line := range strings.Split(description, "\n") {
+		match := chapterLine.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+
+		start := parseClock(match[1])
+		if len(chapters) == 0 && start != 0 {
+			continue
+		}
+		if len(chapters) > 0 && start <= chapters[len(chapters)-1].Start {
+			continue
+		}
+		chapters = append(chapters, Chapter{Start: start, Title: match[2]})
+	}
+
+	if len(chapters) < 2 {
+		return nil
+	}
+	return chapters
+}
+
+// parseClock converts "H:MM:SS" or "M:SS" into seconds.
+func parseClock(clock string) int {
+	seconds := 0
+	for _, part := range strings.Split(clock, ":") {
+		n, _ := strconv.Atoi(part)
+		seconds = seconds*60 + n
+	}
+	return seconds
+}
+
+// prompt describes the hints for the cut prompt, or returns an empty string
+// when there are none.
+func (h *CutHints) prompt() string {
+	if h == nil {
+		return ""
+	}
+
+	var b strings.Builder
+	if len(h.Chapters) > 0 {
+		b.WriteString("\n\nThe creator split the video into these chapters (start time in seconds - title). Chapter starts are strong candidates for cut boundaries; prefer cuts that begin at a chapter start and do not cross into unrelated chapters:\n")
+		for _, chapter := range h.Chapters {
+			fmt.Fprintf(&b, "%d - %s\n", chapter.Start, chapter.Title)
+		}
+	}
+	if len(h.Peaks) > 0 {
+		b.WriteString("\n\nViewers replay these moments the most (start-end in seconds, replay intensity from 0 to 1, strongest first). Among segments that match the topics, favor the ones containing these peaks, without cutting a peak in half:\n")
+		for _, peak := range h.Peaks {
+			fmt.Fprintf(&b, "%d-%d (%.2f)\n", int(peak.Start), int(peak.End), peak.Value)
+		}
+	}
+	if len(h.Comments) > 0 {
+		b.WriteString("\n\nViewers pointed at these moments in their comments (time in seconds, likes, comment). Treat them as candidate cuts when they match the topics:\n")
+		for _, comment := range h.Comments {
+			fmt.Fprintf(&b, "%d (%d likes): %s\n", comment.Time, comment.Likes, comment.Text)
+		}
+	}
+	if len(h.Reactions) > 0 {
+		b.WriteString("\n\nThe audience or the speakers react at these moments (start-end in seconds, reaction; loud means laughter, applause or shouting heard in the audio). They often mark the funniest or most intense moments, which are not obvious from the text alone; favor segments containing them and end cuts after a reaction, not during it:\n")
+		for _, reaction := range h.Reactions {
+			fmt.Fprintf(&b, "%d-%d (%s)\n", reaction.Start, reaction.End, reaction.Kind)
+		}
+	}
+	if len(h.Music) > 0 {
+		b.WriteString("\n\nThese sections contain music and must NOT be part of any cut (start-end in seconds):\n")
+		for _, r := range h.Music {
+			if r.End == math.MaxInt32 {
+				fmt.Fprintf(&b, "%d-end\n", r.Start)
+				continue
+			}
+			fmt.Fprintf(&b, "%d-%d\n", r.Start, r.End)
+		}
+	}
+	return b.String()
+}
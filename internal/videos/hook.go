@@ -0,0 +1,181 @@
+package videos
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// hooksFile is the name of the file caching the hook of each cut inside a video folder.
+const hooksFile = "hooks.json"
+
+// maxHookLength is the longest hook, in seconds, accepted from the model.
+const maxHookLength = 8
+
+// Hook is the most attention-grabbing moment of a cut, in absolute seconds of the source video.
+type Hook struct {
+	Begin int    `json:"begin"` // Start of the hook
+	End   int    `json:"end"`   // End of the hook
+	Text  string `json:"text"`  // Sentence spoken during the hook
+}
+
+// FindHook asks the language model for the most attention-grabbing sentence of
+// a cut of length seconds. subtitles is the SRT text of the cut with times
+// relative to its start, and so are the times of the returned hook.
+// Failures are returned as *Error values of kind ErrLLMRequest or ErrLLMParse.
+func (c *Client) FindHook(ctx context.Context, subtitles string, length int, language string) (*Hook, error) {
+	systemPrompt := fmt.Sprintf(`You are a professional short-form video editor. Find the single most attention-grabbing sentence of a %d second clip:
+	a bold claim, a surprising fact, a strong emotion or a question that makes viewers stay to hear the rest.
+	The sentence must be understandable on its own and last between 2 and %d seconds.
+	Return only a JSON object in the format: {"begin": start time in seconds from the start of the clip (integer), "end": end time in seconds (integer), "text": "The sentence"}
+
+	%s`, length, maxHookLength, languageInstruction(language))
+
+	userPrompt := fmt.Sprintf("Here are the subtitles of the clip in SRT format:\n\n%s", subtitles)
+
+	var hook Hook
+	err := c.chatCompletion(ctx, purposeHook, 60*time.Second, systemPrompt, userPrompt, func(content string) error {
+		return json.Unmarshal([]byte(content), &hook)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &hook, nil
+}
+
+// findHook returns the hook of cut, asking the model on the first run and
+// reading it from outputDir when it was found with the same settings. It
+// returns nil when no usable hook was found.
+func (p *pipeline) findHook(ctx context.Context, outputDir string, videoID string, subtitleEntries []SubtitleEntry, cut Cut) *Hook {
+	path := filepath.Join(outputDir, hooksFile)
+	cached := make(map[string]*Hook) // Hooks keyed by cut title
+	if content, err := os.ReadFile(path); err == nil {
+		json.Unmarshal(content, &cached)
+	}
+
+	name := hooksFile + ":" + cut.Title
+	hash := p.hookSettings(cut)
+	if hook, ok := cached[cut.Title]; ok && p.upToDate(outputDir, name, hash) {
+		return hook
+	}
+
+	fmt.Println(commandStyle.Render("Finding the hook of the clip..."))
+	length := cut.End - cut.Begin
+	hook, err := p.client.FindHook(withPromptCut(ctx, cut.Title), getSubtitlesForTimeRange(subtitleEntries, cut.Begin, cut.End), length, p.channel.Language)
+	if err != nil {
+		p.fail(withContext(err, ErrLLMRequest, videoID, cut.Title))
+		return nil
+	}
+
+	if hook.Begin < 0 || hook.End <= hook.Begin || hook.End > length || hook.End-hook.Begin > maxHookLength {
+		fmt.Println(subtitleStyle.Render("No usable hook found"))
+		hook = nil
+	} else {
+		hook.Begin += cut.Begin
+		hook.End += cut.Begin
+		hook.Text = strings.TrimSpace(hook.Text)
+		fmt.Println(successStyle.Render("Hook: " + hook.Text))
+	}
+
+	cached[cut.Title] = hook
+	if content, err := json.MarshalIndent(cached, "", "  "); err == nil {
+		if err := writeFileAtomic(path, content, 0644); err != nil {
+			fmt.Println(errorStyle.Render("Error caching hook: " + err.Error()))
+		} else {
+			recordSettings(outputDir, name, hash)
+		}
+	}
+
+	return hook
+}
+
+// coldOpen reports whether the clip of cut starts with its hook before
+// jumping back to the beginning. Hooks already at the start are left in place.
+func (p *pipeline) coldOpen(cut Cut) bool {
+	return p.channel.Hook == "cold_open" && cut.Hook != nil && cut.Hook.Begin > cut.Begin
+}
+
+// cutClip extracts the clip of cut from the source video into output, with
+// its quiet passages sped up when pacing is enabled and prefixed by its hook
+// in cold-open mode.
+func (p *pipeline) cutClip(ctx context.Context, videoFileName string, cut Cut, output string) error {
+	coldOpen := p.coldOpen(cut)
+	if !coldOpen && len(cut.Pace) == 0 {
+		return p.renderer.Cut(ctx, videoFileName, cut.Begin, cut.End, output)
+	}
+
+	base := strings.TrimSuffix(output, filepath.Ext(output))
+	bodyFile := base + "_body" + filepath.Ext(output)
+	defer os.Remove(bodyFile)
+
+	if err := p.renderer.Cut(ctx, videoFileName, cut.Begin, cut.End, bodyFile); err != nil {
+		return err
+	}
+	if len(cut.Pace) > 0 {
+		if err := p.pace(ctx, bodyFile, cut); err != nil {
+			return err
+		}
+	}
+	if !coldOpen {
+		return os.Rename(bodyFile, output)
+	}
+
+	hookFile := base + "_hook" + filepath.Ext(output)
+	defer os.Remove(hookFile)
+
+	if err := p.renderer.Cut(ctx, videoFileName, cut.Hook.Begin, cut.Hook.End, hookFile); err != nil {
+		return err
+	}
+
+	return p.renderer.Concat(ctx, []string{hookFile, bodyFile}, output)
+}
+
+// clipSubtitles returns the SRT subtitles of the clip of cut, following the
+// timing of the sped up passages and with the subtitles of the hook first in
+// cold-open mode.
+func (p *pipeline) clipSubtitles(subtitleEntries []SubtitleEntry, cut Cut) string {
+	coldOpen := p.coldOpen(cut)
+	if !coldOpen && len(cut.Pace) == 0 {
+		return getSubtitlesForTimeRange(subtitleEntries, cut.Begin, cut.End)
+	}
+
+	// shift moves the entries within [begin, end] to the clip timeline, where
+	// that range starts at offset, mapping the times through timing.
+	shift := func(begin, end int, offset time.Duration, timing func(time.Duration) time.Duration) []SubtitleEntry {
+		var shifted []SubtitleEntry
+		from, to := time.Duration(begin)*time.Second, time.Duration(end)*time.Second
+		for _, entry := range subtitleEntries {
+			if entry.EndTime <= from || entry.StartTime >= to {
+				continue
+			}
+			entry.StartTime = timing(max(entry.StartTime, from)-from) + offset
+			entry.EndTime = timing(min(entry.EndTime, to)-from) + offset
+			shifted = append(shifted, entry)
+		}
+		return shifted
+	}
+
+	paced := func(t time.Duration) time.Duration {
+		return pacedTime(t, cut.Pace, p.channel.Pacing)
+	}
+	unchanged := func(t time.Duration) time.Duration {
+		return t
+	}
+
+	var entries []SubtitleEntry
+	var offset time.Duration
+	length := cut.End - cut.Begin
+	if coldOpen {
+		entries = shift(cut.Hook.Begin, cut.Hook.End, 0, unchanged)
+		offset = time.Duration(cut.Hook.End-cut.Hook.Begin) * time.Second
+		length += cut.Hook.End - cut.Hook.Begin
+	}
+	entries = append(entries, shift(cut.Begin, cut.End, offset, paced)...)
+
+	return getSubtitlesForTimeRange(entries, 0, length)
+}
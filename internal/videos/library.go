@@ -0,0 +1,137 @@
+package videos
+
+import (
+	"encoding/json"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rogersilvasouza/godeogoker/internal/config"
+)
+
+// LibraryClip is a rendered clip found in a channel folder, as listed by the
+// output feeds, the static site and the server API.
+type LibraryClip struct {
+	Source   Video         // Source video the clip was cut from
+	Name     string        // File name of the clip without extension
+	Metadata VideoMetadata // Generated title, description and tags
+	Clip     string        // Composed horizontal version, or the plain clip when there is none
+	Vertical string        // Vertical version, empty when there is none
+	Cover    string        // Cover image, empty when there is none
+	Size     int64         // Size of Clip in bytes
+	Rendered time.Time     // When Clip was rendered
+}
+
+// ListClips returns the clips with generated metadata inside the channel folder, newest first.
+func ListClips(folder string) ([]LibraryClip, error) {
+	metadataFiles, err := filepath.Glob(filepath.Join(folder, "*", "horizontal", "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var clips []LibraryClip
+	for _, metadataFile := range metadataFiles {
+		if strings.HasSuffix(metadataFile, editSuffix) {
+			continue
+		}
+		content, err := os.ReadFile(metadataFile)
+		if err != nil {
+			continue
+		}
+		var metadata VideoMetadata
+		if err := json.Unmarshal(content, &metadata); err != nil {
+			continue
+		}
+
+		videoDir := filepath.Dir(filepath.Dir(metadataFile))
+		name := strings.TrimSuffix(filepath.Base(metadataFile), ".json")
+
+		clip := findOutput(filepath.Join(videoDir, "horizontal-yt"), name)
+		if clip == "" {
+			clip = findOutput(filepath.Join(videoDir, "horizontal"), name)
+		}
+		info, err := os.Stat(clip)
+		if clip == "" || err != nil {
+			continue
+		}
+
+		entry := LibraryClip{
+			Source:   loadVideo(videoDir, filepath.Base(videoDir)),
+			Name:     name,
+			Metadata: metadata,
+			Clip:     clip,
+			Size:     info.Size(),
+			Rendered: info.ModTime().UTC(),
+		}
+		if vertical := findOutput(filepath.Join(videoDir, "vertical"), name); vertical != "" {
+			entry.Vertical = vertical
+		}
+		if cover := filepath.Join(videoDir, "covers", name+".jpg"); fileExists(cover) {
+			entry.Cover = cover
+		}
+		clips = append(clips, entry)
+	}
+
+	sort.SliceStable(clips, func(i, j int) bool { return clips[i].Rendered.After(clips[j].Rendered) })
+	return clips, nil
+}
+
+// fileExists reports whether path exists.
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// OutputKey returns the slash-separated path of an output relative to the channel folder,
+// the key it is published under.
+func OutputKey(folder string, localPath string) string {
+	key, err := filepath.Rel(folder, localPath)
+	if err != nil {
+		return filepath.ToSlash(localPath)
+	}
+	return filepath.ToSlash(key)
+}
+
+// PublicLink returns the link of a stored output below the feed base URL of
+// the channel, with each segment of its key escaped, or its key relative to
+// the channel folder when no base URL is set.
+func PublicLink(channel config.Channel, localPath string) string {
+	key := OutputKey(channel.Folder, localPath)
+	if channel.Feed.BaseURL == "" {
+		return key
+	}
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.TrimRight(channel.Feed.BaseURL, "/") + "/" + strings.Join(segments, "/")
+}
+
+// videoExtensions are the extensions of the containers outputs are written in.
+var videoExtensions = []string{".mp4", ".webm"}
+
+// findOutput returns the video named name inside dir, in any container, or
+// the newest one when the container was changed since it was first rendered.
+// It returns an empty path when there is none.
+func findOutput(dir string, name string) string {
+	var found string
+	var newest time.Time
+	for _, ext := range videoExtensions {
+		path := filepath.Join(dir, name+ext)
+		if info, err := os.Stat(path); err == nil && (found == "" || info.ModTime().After(newest)) {
+			found, newest = path, info.ModTime()
+		}
+	}
+	return found
+}
+
+// videoType returns the MIME type of the video at path.
+func videoType(path string) string {
+	if strings.EqualFold(filepath.Ext(path), ".webm") {
+		return "video/webm"
+	}
+	return "video/mp4"
+}
@@ -0,0 +1,32 @@
+package videos
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/rogersilvasouza/godeogoker/internal/config"
+)
+
+func TestPublicLinkEscapesTheKey(t *testing.T) {
+	folder := filepath.Join("clips", "channel")
+	tests := []struct {
+		name    string
+		baseURL string
+		path    string
+		want    string
+	}{
+		{"plain", "https://cdn.example.com/clips/", filepath.Join(folder, "abc", "clip_1.mp4"), "https://cdn.example.com/clips/abc/clip_1.mp4"},
+		{"spaces and accents", "https://cdn.example.com", filepath.Join(folder, "abc", "Olá mundo.mp4"), "https://cdn.example.com/abc/Ol%C3%A1%20mundo.mp4"},
+		{"reserved characters", "https://cdn.example.com", filepath.Join(folder, "abc", "50% off #1?.mp4"), "https://cdn.example.com/abc/50%25%20off%20%231%3F.mp4"},
+		{"no base URL", "", filepath.Join(folder, "abc", "Olá mundo.mp4"), "abc/Olá mundo.mp4"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			channel := config.Channel{Folder: folder, Feed: config.Feed{BaseURL: test.baseURL}}
+			if got := PublicLink(channel, test.path); got != test.want {
+				t.Errorf("got  %s\nwant %s", got, test.want)
+			}
+		})
+	}
+}
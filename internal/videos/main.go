@@ -1,28 +1,32 @@
 package videos
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"encoding/xml"
+	"errors"
 	"fmt"
-	"io"
 	"io/ioutil"
 	"log"
 	"math"
 	"net/http"
 	"os"
-	"os/exec"
+	"path/filepath"
 	"regexp"
-	"strconv"
+	"slices"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/charmbracelet/lipgloss"
-	"github.com/mowshon/moviego"
+	"github.com/rogersilvasouza/godeogoker/internal/ai"
 	"github.com/rogersilvasouza/godeogoker/internal/auth"
 	"github.com/rogersilvasouza/godeogoker/internal/config"
+	"github.com/rogersilvasouza/godeogoker/internal/events"
+	"github.com/rogersilvasouza/godeogoker/internal/state"
+	"github.com/rogersilvasouza/godeogoker/internal/storage"
 	"golang.org/x/oauth2"
+	"google.golang.org/api/googleapi"
 	"google.golang.org/api/option"
 	"google.golang.org/api/youtube/v3"
 )
@@ -64,532 +68,1538 @@ var (
 type Feed struct {
 	XMLName xml.Name `xml:"feed"`
 	Entries []struct {
-		ID    string `xml:"id"`
-		Title string `xml:"title"`
+		ID        string `xml:"id"`
+		Title     string `xml:"title"`
+		Published string `xml:"published"`
+		Group     struct {
+			Description string `xml:"description"`
+		} `xml:"group"`
 	} `xml:"entry"`
 }
 
-// GetLastVideos retrieves video IDs from a YouTube channel using its RSS feed.
+// Video is a source video listed by a channel feed.
+type Video struct {
+	ID          string `json:"id"`                    // YouTube video ID, or the yt-dlp ID for other sites
+	Title       string `json:"title,omitempty"`       // Source title, empty when unknown
+	Description string `json:"description,omitempty"` // Source description, empty when unknown
+	Published   string `json:"published,omitempty"`   // RFC 3339 publication time, empty when unknown
+	URL         string `json:"url,omitempty"`         // Page of the video on other sites than YouTube, or its podcast episode file, empty for YouTube videos
+	File        string `json:"file,omitempty"`        // Local file of the video listed from a folder, copied instead of downloaded
+}
+
+// PageURL returns the page yt-dlp downloads the video from, empty for a local
+// file.
+func (v Video) PageURL() string {
+	switch {
+	case v.File != "":
+		return ""
+	case v.URL != "":
+		return v.URL
+	default:
+		return watchURL(v.ID)
+	}
+}
+
+// OnYouTube reports whether the video is a YouTube video, rather than one of
+// another site, a podcast episode or a local file.
+func (v Video) OnYouTube() bool {
+	return v.URL == "" && v.File == ""
+}
+
+// GetLastVideos retrieves the latest videos of a YouTube channel using its RSS feed at c.FeedURL.
+// The videos of the extra sources of the channel are merged in, deduplicated by
+// video ID and sorted from the newest; see channelSources for how each kind of
+// source is listed. Channels given by handle or URL are resolved to their
+// channel ID.
 // If channel.ChannelID starts with "v=", it processes a specific video instead.
 // It respects the video limit set in the channel configuration.
-func GetLastVideos(channel config.Channel) []string {
+// A source that cannot be listed is reported and skipped when other sources
+// list videos; otherwise its error, of kind ErrFeedFailed, is returned.
+func (c *Client) GetLastVideos(ctx context.Context, channel config.Channel) ([]Video, error) {
 	fmt.Println(titleStyle.Render("Getting videos from channel: " + channel.Name))
 
-	if strings.HasPrefix(channel.ChannelID, "v=") {
-		videoID := strings.TrimPrefix(channel.ChannelID, "v=")
-		fmt.Println(subtitleStyle.Render("Processing specific video: " + videoID))
-		return []string{videoID}
+	if strings.HasPrefix(channel.ChannelID, "v=") {
+		videoID := strings.TrimPrefix(channel.ChannelID, "v=")
+		fmt.Println(subtitleStyle.Render("Processing specific video: " + videoID))
+		return []Video{{ID: videoID}}, nil
+	}
+
+	sources, feedErr := c.channelSources(ctx, channel)
+
+	var entries []Video
+	seen := make(map[string]bool)
+	merge := func(videos []Video) {
+		for _, video := range videos {
+			if !seen[video.ID] {
+				seen[video.ID] = true
+				entries = append(entries, video)
+			}
+		}
+	}
+	for _, source := range sources {
+		videos, err := source.List(ctx, channel)
+		if err != nil {
+			fmt.Println(errorStyle.Render(err.Error()))
+			feedErr = err
+			continue
+		}
+		merge(videos)
+	}
+
+	if len(entries) == 0 {
+		if feedErr != nil {
+			return nil, feedErr
+		}
+		fmt.Println(subtitleStyle.Render("No videos found for channel: " + channel.Name))
+		return nil, nil
+	}
+
+	if len(sources) > 1 {
+		sort.SliceStable(entries, func(i, j int) bool { return entries[i].Published > entries[j].Published })
+	}
+
+	fmt.Println(subtitleStyle.Render(fmt.Sprintf("Total videos found: %d", len(entries))))
+
+	videoLimit := channel.VideoLimit
+	if videoLimit == 0 || videoLimit > len(entries) {
+		videoLimit = len(entries)
+	}
+
+	fmt.Println(subtitleStyle.Render(fmt.Sprintf("Processing %d videos", videoLimit)))
+
+	videos := entries[:videoLimit]
+	for i, video := range videos {
+		fmt.Println(optionStyle.Render(fmt.Sprintf("Video %d: %s (ID: %s)", i+1, video.Title, video.ID)))
+	}
+
+	return videos, nil
+}
+
+// feedRequest returns a GET request of address carrying the User-Agent and
+// headers of the feed_fetch settings, with the transport to send it with.
+func (c *Client) feedRequest(ctx context.Context, address string) (*http.Request, Doer, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, address, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	settings := c.Config.FeedFetch
+	req.Header.Set("User-Agent", DefaultFeedUserAgent)
+	if settings.UserAgent != "" {
+		req.Header.Set("User-Agent", settings.UserAgent)
+	}
+	for name, value := range settings.Headers {
+		req.Header.Set(name, value)
+	}
+
+	if c.FeedHTTP != nil {
+		return req, c.FeedHTTP, nil
+	}
+	return req, c.HTTP, nil
+}
+
+// fetchFeed returns the videos of the RSS feed selected by query, such as
+// channel_id=... or playlist_id=..., in feed order. The request carries the
+// User-Agent and headers of the feed_fetch settings and is bounded by their
+// timeout.
+// Failures are returned as *Error values of kind ErrFeedFailed.
+func (c *Client) fetchFeed(ctx context.Context, query string) ([]Video, error) {
+	feedURL := fmt.Sprintf("%s?%s", c.FeedURL, query)
+	fmt.Println(descriptionStyle.Render("Fetching RSS feed: " + feedURL))
+
+	settings := c.Config.FeedFetch
+	ctx, cancel := withTimeout(ctx, feedTimeout(settings), "RSS feed request")
+	defer cancel()
+
+	req, doer, err := c.feedRequest(ctx, feedURL)
+	if err != nil {
+		return nil, newError(ErrFeedFailed, "", "", fmt.Errorf("error requesting RSS feed: %v", err))
+	}
+	resp, err := doer.Do(req)
+	if err != nil {
+		err = timeoutCause(ctx, err)
+		return nil, newError(ErrFeedFailed, "", "", fmt.Errorf("error requesting RSS feed: %v", err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newError(ErrFeedFailed, "", "", fmt.Errorf("RSS feed %s answered %s", feedURL, resp.Status))
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, newError(ErrFeedFailed, "", "", fmt.Errorf("error reading RSS feed: %v", err))
+	}
+
+	var feed Feed
+	if err := xml.Unmarshal(body, &feed); err != nil {
+		return nil, newError(ErrFeedFailed, "", "", fmt.Errorf("error parsing RSS feed: %v", err))
+	}
+
+	var videos []Video
+	for _, entry := range feed.Entries {
+		videos = append(videos, Video{
+			ID:          extractVideoID(entry.ID),
+			Title:       entry.Title,
+			Description: entry.Group.Description,
+			Published:   entry.Published,
+		})
+	}
+
+	return videos, nil
+}
+
+// playlist is the part of the yt-dlp --flat-playlist -J output used to list a source.
+type playlist struct {
+	Entries []struct {
+		ID          string  `json:"id"`
+		URL         string  `json:"url"`
+		WebpageURL  string  `json:"webpage_url"`
+		Title       string  `json:"title"`
+		Description string  `json:"description"`
+		Timestamp   float64 `json:"timestamp"`
+		UploadDate  string  `json:"upload_date"`
+		LiveStatus  string  `json:"live_status"`
+	} `json:"entries"`
+}
+
+// fetchPlaylist returns the videos yt-dlp lists for url, in playlist order,
+// passing it the extra arguments. Streams that are live or upcoming are left
+// out, since they cannot be downloaded yet.
+// Errors are reported and yield no videos, so one broken source does not stop the channel.
+func (c *Client) fetchPlaylist(ctx context.Context, url string, options config.YtdlpOptions, extra ...string) []Video {
+	fmt.Println(descriptionStyle.Render("Listing source with yt-dlp: " + url))
+
+	ctx, cancel := withTimeout(ctx, downloadTimeout(c.Config.Timeouts), "source listing")
+	defer cancel()
+
+	args := slices.Concat([]string{"--flat-playlist", "-J"}, ytdlpArgs(options), extra)
+	output, err := newCommand(ctx, c.Config.YtDlp, append(args, url)...).Output()
+	if err != nil {
+		fmt.Println(errorStyle.Render(fmt.Sprintf("Error listing source %s: %v", url, timeoutCause(ctx, err))))
+		return nil
+	}
+
+	var list playlist
+	if err := json.Unmarshal(output, &list); err != nil {
+		fmt.Println(errorStyle.Render(fmt.Sprintf("Error parsing source %s: %v", url, err)))
+		return nil
+	}
+
+	var videos []Video
+	for _, entry := range list.Entries {
+		page := entry.WebpageURL
+		if page == "" {
+			page = entry.URL
+		}
+		if entry.ID == "" || page == "" || entry.LiveStatus == "is_live" || entry.LiveStatus == "is_upcoming" {
+			continue
+		}
+
+		var published string
+		switch {
+		case entry.Timestamp > 0:
+			published = time.Unix(int64(entry.Timestamp), 0).UTC().Format(time.RFC3339)
+		case entry.UploadDate != "":
+			if day, err := time.Parse("20060102", entry.UploadDate); err == nil {
+				published = day.Format(time.RFC3339)
+			}
+		}
+
+		videos = append(videos, Video{
+			ID:          safeFileName(entry.ID),
+			Title:       entry.Title,
+			Description: entry.Description,
+			Published:   published,
+			URL:         page,
+		})
+	}
+
+	return videos
+}
+
+func extractVideoID(rssID string) string {
+	videoID := rssID[strings.LastIndex(rssID, ":")+1:]
+	return videoID
+}
+
+// DownloadVideo runs the full pipeline for the latest videos of a channel.
+// It stops as soon as ctx is cancelled, removing temporary files and leaving the
+// interrupted video marked as resumable so the next run picks it up again.
+// It returns an error when the backends of the channel cannot be configured,
+// when its feed cannot be fetched (of kind ErrFeedFailed) and when the error
+// budget stopped the channel (of kind ErrErrorBudget); failures of single
+// videos are recorded instead.
+func DownloadVideo(ctx context.Context, client *Client, channel config.Channel, options StageOptions) error {
+	fmt.Println(titleStyle.Render("Processing channel: " + channel.Name))
+
+	p, err := newPipeline(ctx, client, channel)
+	if err != nil {
+		return fmt.Errorf("error configuring %v", err)
+	}
+
+	p.errorBudget = client.Errors
+	videos, err := client.GetLastVideos(ctx, channel)
+	if err != nil {
+		return err
+	}
+
+	for i, video := range videos {
+		if ctx.Err() != nil {
+			break
+		}
+
+		fmt.Println(titleStyle.Render(fmt.Sprintf("Processing video %d/%d (ID: %s)", i+1, len(videos), video.ID)))
+		if !p.process(ctx, video, options) {
+			break
+		}
+	}
+
+	if ctx.Err() == nil {
+		p.writeFeeds(ctx)
+	}
+
+	fmt.Println(titleStyle.Render("Processing completed for channel: " + channel.Name))
+	return p.budgetSpent
+}
+
+// ProcessVideo runs the full pipeline for a single video of channel, such as
+// a job claimed from a distributed queue. It returns false when processing was
+// interrupted or paused by a spending limit, leaving the video resumable, and
+// an error when the backends of the channel cannot be configured.
+func ProcessVideo(ctx context.Context, client *Client, channel config.Channel, video Video, options StageOptions) (bool, error) {
+	fmt.Println(titleStyle.Render(fmt.Sprintf("Processing video %s of channel: %s", video.ID, channel.Name)))
+
+	p, err := newPipeline(ctx, client, channel)
+	if err != nil {
+		return false, fmt.Errorf("error configuring %v", err)
+	}
+
+	if !p.process(ctx, video, options) {
+		return false, nil
+	}
+
+	if ctx.Err() == nil {
+		p.writeFeeds(ctx)
+	}
+	return true, nil
+}
+
+// process runs the pipeline for video unless it was already processed, and
+// resumes it when an earlier run did not finish. With options.Force, earlier
+// outputs are removed first; with options.Repeat, only the outputs of those
+// stages, also when the video was left resumable. With options.Until, the
+// stages after it are left for the next run. A video that fails is processed
+// once more, reusing the outputs that succeeded, and then skipped; with an
+// error budget, the remaining videos are skipped once too many failed. It
+// returns false when the remaining videos should be skipped.
+func (p *pipeline) process(ctx context.Context, video Video, options StageOptions) bool {
+	p.emit(events.Event{Type: events.VideoDetected, VideoID: video.ID})
+
+	outputDir := filepath.Join(p.channel.Folder, video.ID)
+	p.until = options.Until
+
+	if !options.Force {
+		started, done := p.progress(outputDir, video.ID)
+		switch {
+		case done && len(options.Repeat) == 0:
+			fmt.Println(subtitleStyle.Render("Video already processed. Skipping. Use --force to reprocess."))
+			return true
+		case started && len(options.Repeat) > 0:
+			fmt.Println(subtitleStyle.Render("Repeating stages: " + strings.Join(options.Repeat, ", ")))
+			p.resetStages(outputDir, video.ID, options.Repeat)
+			removeTempFiles(outputDir)
+		case started:
+			fmt.Println(subtitleStyle.Render("Resuming interrupted processing..."))
+			removeTempFiles(outputDir)
+		}
+	} else {
+		if _, err := os.Stat(outputDir); err == nil {
+			fmt.Println(subtitleStyle.Render("Removing existing processed files..."))
+			prompts, _ := os.ReadFile(filepath.Join(outputDir, promptsFile))
+			if err := os.RemoveAll(outputDir); err != nil {
+				fmt.Println(errorStyle.Render("Error removing directory: " + err.Error()))
+				return true
+			}
+			// The prompt log outlives reprocessing, so earlier prompts can still be compared.
+			if prompts != nil {
+				if err := os.MkdirAll(outputDir, 0755); err == nil {
+					writeFileAtomic(filepath.Join(outputDir, promptsFile), prompts, 0644)
+				}
+			}
+		}
+	}
+
+	p.failed, p.lastFailure = 0, nil
+	if !p.run(ctx, outputDir, video) {
+		return false
+	}
+
+	if p.failed > 0 && retryable(p.lastFailure) {
+		fmt.Println(subtitleStyle.Render("Video failed, retrying it once: " + video.ID))
+		retrying := p.retrying
+		p.retrying = true
+		p.failed, p.lastFailure = 0, nil
+		ok := p.run(ctx, outputDir, video)
+		p.retrying = retrying
+		if !ok {
+			return false
+		}
+		if p.failed > 0 {
+			fmt.Println(errorStyle.Render("Video failed again, skipping it: " + video.ID))
+		}
+	}
+
+	if err := p.errorBudget.Record(p.channel.ID, p.lastFailure); err != nil {
+		p.fail(err)
+		p.budgetSpent = err
+		return false
+	}
+	return true
+}
+
+// newPipeline configures the backends of channel. Errors are prefixed with
+// the backend that could not be configured.
+func newPipeline(ctx context.Context, client *Client, channel config.Channel) (*pipeline, error) {
+	downloader, err := NewDownloader(client, channel)
+	if err != nil {
+		return nil, fmt.Errorf("downloader: %v", err)
+	}
+
+	renderer, err := NewRenderer(client.Config, channel)
+	if err != nil {
+		return nil, fmt.Errorf("renderer: %v", err)
+	}
+
+	store, err := storage.New(ctx, channel)
+	if err != nil {
+		return nil, fmt.Errorf("storage: %v", err)
+	}
+
+	if _, err := SafeZoneFor(channel.SafeZone); err != nil {
+		return nil, fmt.Errorf("safe zone: %v", err)
+	}
+
+	switch channel.Hook {
+	case "", "detect", "cold_open":
+	default:
+		return nil, fmt.Errorf("hook: unknown mode: %s", channel.Hook)
+	}
+
+	if _, err := channelLocation(channel); err != nil {
+		return nil, fmt.Errorf("timezone: %v", err)
+	}
+	if err := validatePublish(channel.Publish); err != nil {
+		return nil, fmt.Errorf("publish: %v", err)
+	}
+
+	switch channel.StillImage {
+	case "", "never", "auto", "always":
+	default:
+		return nil, fmt.Errorf("still image: unknown mode: %s", channel.StillImage)
+	}
+
+	switch channel.VerticalLayout {
+	case "", "background":
+	case "split":
+		if channel.Split == nil || channel.Split.Video == "" {
+			return nil, fmt.Errorf("vertical layout: split.video is required by the split layout")
+		}
+	default:
+		return nil, fmt.Errorf("vertical layout: unknown layout: %s", channel.VerticalLayout)
+	}
+
+	if index := channel.EpisodeIndex; index != nil {
+		switch index.Update {
+		case "", "source":
+		case "playlist":
+			if index.PlaylistID == "" {
+				return nil, fmt.Errorf("episode index: playlist_id is required to update a playlist")
+			}
+		default:
+			return nil, fmt.Errorf("episode index: unknown update: %s", index.Update)
+		}
+	}
+
+	if rules := channel.TitleRules; rules != nil {
+		switch rules.Case {
+		case "", "sentence", "title", "upper", "lower":
+		default:
+			return nil, fmt.Errorf("title rules: unknown case: %s", rules.Case)
+		}
+	}
+
+	if translations := channel.Translations; translations != nil {
+		switch translations.Mode {
+		case "", "localizations":
+			if translations.Source == "" && len(translations.Languages) > 0 {
+				return nil, fmt.Errorf("translations: source language code is required by localizations")
+			}
+		case "uploads":
+		default:
+			return nil, fmt.Errorf("translations: unknown mode: %s", translations.Mode)
+		}
+	}
+
+	client = client.WithBudget(NewBudget(client.Config, channel, client.State))
+	if channel.AI != nil {
+		settings := *channel.AI
+		if settings.Provider == "openai" {
+			if settings.Key == "" {
+				settings.Key = client.Config.OpenAI.Key
+			}
+			if settings.Model == "" {
+				settings.Model = client.Config.OpenAI.Model
+			}
+		}
+		if client.AI, err = ai.New(settings, client.HTTP); err != nil {
+			return nil, fmt.Errorf("ai: %v", err)
+		}
+		if settings.LongModel != "" {
+			settings.Model = settings.LongModel
+			if client.LongAI, err = ai.New(settings, client.HTTP); err != nil {
+				return nil, fmt.Errorf("ai: %v", err)
+			}
+		}
+		client.ContextWindow = settings.ContextWindow
+	}
+	detectors, err := NewCutDetectors(client, channel, renderer)
+	if err != nil {
+		return nil, fmt.Errorf("cut detectors: %v", err)
+	}
+
+	transcriber, err := NewTranscriber(client)
+	if err != nil {
+		return nil, fmt.Errorf("transcription: %v", err)
+	}
+
+	return &pipeline{
+		client:      client,
+		channel:     channel,
+		downloader:  downloader,
+		renderer:    renderer,
+		storage:     store,
+		detectors:   detectors,
+		transcriber: transcriber,
+	}, nil
+}
+
+// run processes a single video into outputDir, keeping it marked as resumable,
+// in its folder and in the state store, until every step has run. Failures
+// previously recorded for the video are cleared first. A video exceeding its
+// time limit is left resumable with a timeout failure and the next videos are
+// processed, and so is a video whose run stops before the last stage. The
+// manifest of the video is written once the run ends, unless interrupted. It
+// returns false when the remaining videos must be skipped.
+func (p *pipeline) run(ctx context.Context, outputDir string, video Video) bool {
+	if p.client.State != nil {
+		if err := p.client.State.StartVideo(p.channel.ID, video.ID); err != nil {
+			fmt.Println(errorStyle.Render("Error recording progress: " + err.Error()))
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Join(outputDir, "horizontal"), 0755); err != nil {
+		fmt.Println(errorStyle.Render("Error creating output directory: " + err.Error()))
+		return true
+	}
+
+	if err := markResumable(outputDir); err != nil {
+		fmt.Println(errorStyle.Render("Error marking video as in progress: " + err.Error()))
+		return true
+	}
+	defer func() {
+		if ctx.Err() == nil {
+			p.client.writeManifest(p.channel, outputDir, video.ID)
+		}
+	}()
+
+	if !p.retrying {
+		if err := saveVideo(outputDir, video); err != nil {
+			fmt.Println(errorStyle.Render("Error saving video details: " + err.Error()))
+		}
+	}
+
+	if p.client.State != nil {
+		if err := p.client.State.ClearFailures(p.channel.ID, video.ID); err != nil {
+			fmt.Println(errorStyle.Render("Error clearing recorded failures: " + err.Error()))
+		}
+	}
+
+	logCtx, closeLog := withDebugLog(ctx, outputDir)
+	defer closeLog()
+	logCtx = withPromptLog(logCtx, p.client.State, p.channel.ID, video.ID, outputDir)
+	logCtx = withPriorities(logCtx, p.client.Config)
+
+	videoCtx, cancel := withTimeout(logCtx, timeoutOf(p.client.Config.Timeouts.Video, DefaultVideoTimeout), "video processing")
+	defer cancel()
+	p.processVideo(videoCtx, outputDir, video)
+
+	if ctx.Err() != nil {
+		removeTempFiles(outputDir)
+		fmt.Println(errorStyle.Render("Processing interrupted. Video marked as resumable: " + video.ID))
+		return false
+	}
+
+	if videoCtx.Err() != nil {
+		removeTempFiles(outputDir)
+		p.fail(newError(ErrTimeout, video.ID, "", context.Cause(videoCtx)))
+		fmt.Println(errorStyle.Render("Time limit reached. Video marked as resumable: " + video.ID))
+		return true
+	}
+
+	if p.aiPaused {
+		removeTempFiles(outputDir)
+		fmt.Println(errorStyle.Render("Spending limit reached. Video marked as resumable and remaining videos skipped: " + video.ID))
+		return false
+	}
+
+	if p.stopsEarly() {
+		removeTempFiles(outputDir)
+		fmt.Println(subtitleStyle.Render(fmt.Sprintf("Stopped after the %s stage. Video marked as resumable: %s", p.until, video.ID)))
+		return true
+	}
+
+	clearResumable(outputDir)
+	p.emit(events.Event{Type: events.VideoCompleted, VideoID: video.ID})
+	if p.client.State != nil {
+		if err := p.client.State.CompleteVideo(p.channel.ID, video.ID); err != nil {
+			fmt.Println(errorStyle.Render("Error recording progress: " + err.Error()))
+		}
+		if !hasFailures(p.client.State, p.channel.ID, video.ID) {
+			if err := p.client.State.MarkRun(p.channel.ID); err != nil {
+				fmt.Println(errorStyle.Render("Error recording successful run: " + err.Error()))
+			}
+		}
+	}
+	return true
+}
+
+// coverStyle returns the cover text style of the channel with defaults applied.
+func coverStyle(channel config.Channel) CoverStyle {
+	cover := channel.Cover
+	style := CoverStyle{
+		Font:         channel.Font,
+		FontSize:     "36",
+		FontColor:    "white",
+		FontEffect:   channel.FontEffect,
+		BorderWidth:  cover.BorderWidth,
+		BorderColor:  "black",
+		ShadowOffset: cover.ShadowOffset,
+		ShadowColor:  "black@0.6",
+		Box:          cover.Box,
+		BoxColor:     "black@0.5",
+		BoxPadding:   20,
+		Position:     cover.Position,
+		SafeMargin:   0.1,
+	}
+	if channel.FontSize != "" {
+		style.FontSize = channel.FontSize
+	}
+	if channel.FontColor != "" {
+		style.FontColor = channel.FontColor
+	}
+	if cover.BorderColor != "" {
+		style.BorderColor = cover.BorderColor
+	}
+	if cover.ShadowColor != "" {
+		style.ShadowColor = cover.ShadowColor
+	}
+	if cover.BoxColor != "" {
+		style.BoxColor = cover.BoxColor
+	}
+	if cover.BoxPadding > 0 {
+		style.BoxPadding = cover.BoxPadding
+	}
+	if cover.SafeMargin > 0 {
+		style.SafeMargin = cover.SafeMargin
+	}
+	return style
+}
+
+// pipeline bundles the channel settings with the backends used to process its videos.
+type pipeline struct {
+	client      *Client
+	channel     config.Channel
+	downloader  Downloader
+	renderer    Renderer
+	storage     storage.Storage
+	detectors   []CutDetector
+	transcriber Transcriber // Generates the captions of videos without any, nil when not configured
+
+	uploadsBlocked bool   // Set once the upload quota is exhausted for this run
+	aiPaused       bool   // Set once the language model spending limit of the channel is reached
+	retrying       bool   // Reuse existing outputs and cached cuts so only failed steps run again
+	still          bool   // Set while processing a source that is audio over a still image
+	refreshing     bool   // Regenerate only the outputs whose settings changed since they were produced
+	until          string // Last stage run for the video being processed, empty to run them all
+
+	series map[string]*series // Cuts split into parts, keyed by the title of the whole cut
+	bases  baseCache          // Stills of the base videos prepared during the run
+
+	errorBudget *ErrorBudget // Stops the channel once videos keep failing, nil to never stop
+	failed      int          // Failures of the video being processed counted against the error budget
+	lastFailure error        // Last of those failures
+	budgetSpent error        // Error of kind ErrErrorBudget once the budget stopped the channel
+}
+
+// emit publishes a lifecycle event for the channel being processed.
+func (p *pipeline) emit(e events.Event) {
+	e.Channel = p.channel.ID
+	p.client.Events.Emit(e)
+}
+
+// fail prints a failure and publishes it as a failed event.
+func (p *pipeline) fail(err error) {
+	p.client.reportError(err)
+
+	e := events.Event{Type: events.Failed, Code: CodeOf(err), Error: err.Error()}
+	var pipelineErr *Error
+	if errors.As(err, &pipelineErr) {
+		e.VideoID = pipelineErr.VideoID
+		e.Cut = pipelineErr.Cut
+	}
+	p.emit(e)
+
+	if p.client.State != nil && e.VideoID != "" {
+		failure := state.Failure{
+			VideoID: e.VideoID,
+			Cut:     e.Cut,
+			Stage:   stageOf(err),
+			Code:    e.Code,
+			Error:   e.Error,
+			Time:    time.Now(),
+		}
+		if err := p.client.State.AddFailure(p.channel.ID, failure); err != nil {
+			fmt.Println(errorStyle.Render("Error recording failure: " + err.Error()))
+		}
+	}
+
+	if countsAgainstBudget(err) {
+		p.failed++
+		p.lastFailure = err
+	}
+
+	if errors.Is(err, ErrBudgetExceeded) && !p.aiPaused {
+		p.aiPaused = true
+		p.emit(events.Event{Type: events.BudgetExceeded, VideoID: e.VideoID, Error: err.Error()})
+	}
+}
+
+// fetch downloads video into outputDir with the downloader of the channel, or
+// copies it when its source listed a local file.
+func (p *pipeline) fetch(ctx context.Context, video Video, outputDir string) (*Media, error) {
+	if video.File != "" {
+		return fetchFile(ctx, video, outputDir, captionLanguages(p.channel), p.transcriber != nil)
+	}
+	media, err := p.downloader.Fetch(ctx, video, outputDir)
+	if err != nil {
+		return nil, err
+	}
+	if media.Captions == "" {
+		media.locateCaptions(captionLanguages(p.channel))
+	}
+	return media, nil
+}
+
+// media returns the files of videoID in outputDir with the captions of the
+// video in the caption languages of the channel, for the steps that run
+// without downloading it.
+func (p *pipeline) media(videoID string, outputDir string) *Media {
+	media := mediaFiles(videoID, outputDir)
+	media.locateCaptions(captionLanguages(p.channel))
+	return media
+}
+
+// reportUploadError prints an upload failure and stops further uploads for
+// the rest of the run when the YouTube quota has been exhausted.
+func (p *pipeline) reportUploadError(err error) {
+	p.fail(err)
+	if errors.Is(err, ErrUploadQuota) {
+		p.uploadsBlocked = true
+		fmt.Println(subtitleStyle.Render("Upload quota exhausted. Remaining uploads are skipped for this run."))
+	}
+}
+
+// processVideo downloads a single video with its subtitles, finds the cuts and
+// renders, enhances and optionally uploads every clip into outputDir.
+func (p *pipeline) processVideo(ctx context.Context, outputDir string, video Video) {
+	videoID := video.ID
+
+	var hints *CutHints
+	if p.channel.CaptionsFirst {
+		var found bool
+		if hints, found = p.analyzeCaptions(ctx, outputDir, video); !found {
+			return
+		}
+	}
+
+	// The details only need the feed entry, so they are extracted while the
+	// media downloads.
+	detailsDone := make(chan *VideoDetails, 1)
+	go func() {
+		defer RemoveTempFilesOnPanic()
+		detailsDone <- p.loadDetails(ctx, outputDir, video)
+	}()
+
+	release := p.client.Stages.download(ctx)
+	p.emit(events.Event{Type: events.DownloadStarted, VideoID: videoID})
+	media, err := p.fetch(ctx, video, outputDir)
+	release()
+	details := <-detailsDone
+	if err != nil {
+		p.fail(withContext(err, ErrDownloadFailed, videoID, ""))
+		return
+	}
+	p.emit(events.Event{Type: events.DownloadFinished, VideoID: videoID, Path: media.VideoFile})
+	p.completeStage(videoID, StageDownload)
+	if !p.runs(StageTranscription) {
+		return
+	}
+
+	if err := p.transcribe(ctx, videoID, media); err != nil {
+		p.fail(err)
+		return
+	}
+	if !p.runs(StageCuts) {
+		return
+	}
+
+	videoFileName := media.VideoFile
+	if p.lacksSpeech(media.Captions) {
+		return
+	}
+
+	// Cuts found by the captions-first analysis are reused.
+	analyzed := hints != nil
+	if !analyzed {
+		hints = p.cutHints(ctx, outputDir, video, media.Captions, videoFileName)
+	}
+
+	// Cuts are searched in the full transcript, so their timestamps are absolute
+	// and the clips are cut straight from the source video.
+	fmt.Println(commandStyle.Render("Finding interesting cuts..."))
+	cuts, err := p.findCuts(ctx, outputDir, media, videoFileName, hints, p.retrying || analyzed)
+	if err != nil {
+		p.fail(withContext(err, ErrLLMRequest, videoID, ""))
+		return
+	}
+	p.completeStage(videoID, StageCuts)
+	if !p.runs(StageMetadata) {
+		return
+	}
+	cuts = withoutMusic(cuts, hints.Music)
+
+	if len(cuts) == 0 {
+		fmt.Println(subtitleStyle.Render("No interesting cuts found"))
+		return
+	}
+
+	if subtitleEntries, err := parseVTTFile(media.Captions); err == nil {
+		cuts = p.splitSeries(ctx, outputDir, videoID, subtitleEntries, cuts)
+	}
+
+	fmt.Println(successStyle.Render(fmt.Sprintf("Found %d interesting cuts", len(cuts))))
+	p.emit(events.Event{Type: events.CutsFound, VideoID: videoID, Count: len(cuts)})
+
+	videoDuration, err := p.renderer.Duration(ctx, videoFileName)
+	if err != nil {
+		p.fail(newError(ErrRenderFailed, videoID, "", err))
+		return
+	}
+	p.still = p.stillImage(ctx, videoFileName, videoDuration)
+
+	for j, cut := range cuts {
+		if ctx.Err() != nil || p.aiPaused {
+			return
+		}
+
+		fmt.Println(optionStyle.Render(fmt.Sprintf("Processing cut %d/%d: %s", j+1, len(cuts), cut.Title)))
+		p.processCut(ctx, videoID, details, outputDir, media.Captions, videoFileName, videoDuration, cut)
+	}
+
+	if ctx.Err() == nil && !p.aiPaused {
+		p.completeStage(videoID, StageMetadata)
+		if p.runs(StageRender) {
+			p.completeStage(videoID, StageRender)
+		}
+		if p.runs(StageUpload) && p.channel.UploadToYouTube {
+			p.completeStage(videoID, StageUpload)
+		}
+		p.writeEpisodeIndex(ctx, outputDir, video, details, cuts)
+	}
+}
+
+// analyzeCaptions downloads only the captions of video and looks for cuts in
+// them, so the media is downloaded only when there is something to cut.
+// It returns the hints used for the analysis and whether processing should go on.
+func (p *pipeline) analyzeCaptions(ctx context.Context, outputDir string, video Video) (*CutHints, bool) {
+	if video.File != "" {
+		fmt.Println(subtitleStyle.Render("The video is a local file, there is no download to save. Copying it."))
+		return nil, true
+	}
+	captions, ok := p.downloader.(CaptionsDownloader)
+	if !ok {
+		fmt.Println(subtitleStyle.Render("The configured downloader cannot fetch captions alone. Downloading the full video."))
+		return nil, true
+	}
+	if p.needsMedia() {
+		fmt.Println(subtitleStyle.Render("The configured cut detectors need the video. Downloading the full video."))
+		return nil, true
+	}
+
+	fmt.Println(commandStyle.Render("Analyzing captions before downloading the video..."))
+	media, err := captions.FetchCaptions(ctx, video, outputDir)
+	if err != nil {
+		p.fail(withContext(err, ErrNoCaptions, video.ID, ""))
+		return nil, false
+	}
+
+	if media.Captions == "" && p.transcriber != nil {
+		fmt.Println(subtitleStyle.Render("No captions found. Downloading the full video to transcribe it."))
+		return nil, true
+	}
+
+	if p.lacksSpeech(media.Captions) {
+		return nil, false
+	}
+
+	hints := p.cutHints(ctx, outputDir, video, media.Captions, "")
+	cuts, err := p.findCuts(ctx, outputDir, media, "", hints, p.retrying)
+	if err != nil {
+		p.fail(withContext(err, ErrLLMRequest, video.ID, ""))
+		return nil, false
+	}
+
+	if len(withoutMusic(cuts, hints.Music)) == 0 {
+		fmt.Println(subtitleStyle.Render("No interesting cuts found in the captions. Skipping video download."))
+		return nil, false
+	}
+
+	return hints, true
+}
+
+// cutHints gathers the signals about video passed to the cut prompt.
+// captions is the WEBVTT file of the video, used to detect music sections and
+// reactions, and videoFile the downloaded video, empty when only the captions
+// were downloaded, used to hear reactions.
+func (p *pipeline) cutHints(ctx context.Context, outputDir string, video Video, captions string, videoFile string) *CutHints {
+	hints := &CutHints{}
+
+	if !p.channel.IgnoreChapters {
+		hints.Chapters = parseChapters(video.Description)
+		if len(hints.Chapters) > 0 {
+			fmt.Println(subtitleStyle.Render(fmt.Sprintf("Using %d chapters from the description as cut hints", len(hints.Chapters))))
+		}
+	}
+
+	// Heatmaps and comments are only available for YouTube videos.
+	if p.channel.UseHeatmap && video.OnYouTube() {
+		hints.Peaks = heatPeaks(p.loadHeatmap(ctx, outputDir, video.ID), 0.5, 8)
+		if len(hints.Peaks) > 0 {
+			fmt.Println(subtitleStyle.Render(fmt.Sprintf("Using %d most replayed moments as cut hints", len(hints.Peaks))))
+		}
+	}
+
+	if p.channel.MineComments && video.OnYouTube() {
+		hints.Comments = p.loadComments(ctx, outputDir, video.ID)
+		if len(hints.Comments) > 0 {
+			fmt.Println(subtitleStyle.Render(fmt.Sprintf("Using %d timestamped comments as cut hints", len(hints.Comments))))
+		}
+	}
+
+	if p.channel.DetectReactions {
+		hints.Reactions = p.loadReactions(ctx, outputDir, captions, videoFile)
+		if len(hints.Reactions) > 0 {
+			fmt.Println(subtitleStyle.Render(fmt.Sprintf("Using %d audience reactions as cut hints", len(hints.Reactions))))
+		}
+	}
+
+	if p.channel.ExcludeMusic {
+		hints.Music = musicFromChapters(parseChapters(video.Description))
+		if entries, err := parseVTTFile(captions); err == nil {
+			hints.Music = append(hints.Music, musicFromCaptions(entries)...)
+		}
+		if len(hints.Music) > 0 {
+			fmt.Println(subtitleStyle.Render(fmt.Sprintf("Excluding %d music sections from cuts", len(hints.Music))))
+		}
+	}
+
+	return hints
+}
+
+// publish hands a finished output to the channel storage, keyed by its path
+// relative to the channel folder.
+func (p *pipeline) publish(ctx context.Context, localPath string) {
+	key := p.outputKey(localPath)
+	videoID, _, _ := strings.Cut(key, "/")
+
+	if err := p.storage.Put(ctx, key, localPath); err != nil {
+		p.fail(newError(ErrStorageFailed, videoID, "", err))
+	}
+}
+
+// publishMissing publishes an output reused from a previous run unless it is
+// already present in the storage.
+func (p *pipeline) publishMissing(ctx context.Context, localPath string) {
+	if exists, err := p.storage.Exists(ctx, p.outputKey(localPath)); err == nil && exists {
+		return
 	}
+	p.publish(ctx, localPath)
+}
 
-	feedURL := fmt.Sprintf("https://www.youtube.com/feeds/videos.xml?channel_id=%s", channel.ChannelID)
-	fmt.Println(descriptionStyle.Render("Fetching RSS feed: " + feedURL))
+// outputKey returns the slash-separated path of an output relative to the channel folder.
+func (p *pipeline) outputKey(localPath string) string {
+	return OutputKey(p.channel.Folder, localPath)
+}
 
-	resp, err := http.Get(feedURL)
-	if err != nil {
-		fmt.Println(errorStyle.Render("Error requesting RSS feed: " + err.Error()))
-		log.Fatalf("Error requesting RSS feed: %v", err)
+// processCut renders a single cut into its horizontal clip, then adds subtitles,
+// metadata, cover and composed versions, uploading them when enabled. Stages
+// after the last one of the run are skipped.
+// Outputs that already exist are reused when the settings they were produced
+// with did not change, so only the steps that failed before or whose settings
+// changed run again.
+func (p *pipeline) processCut(ctx context.Context, videoID string, details *VideoDetails, outputDir string, captions string, videoFileName string, videoDuration float64, cut Cut) {
+	channel := p.channel
+
+	name := safeFileName(cut.Title)
+	outputs := channel.Outputs
+	outputFileName := filepath.Join(outputDir, "horizontal", name+Format(outputs.Clip).ext())
+
+	if videoDuration < float64(cut.Begin) || videoDuration < float64(cut.End) {
+		fmt.Println(errorStyle.Render("Cut time exceeds video duration. Skipping."))
+		return
 	}
-	defer resp.Body.Close()
 
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		fmt.Println(errorStyle.Render("Error reading RSS feed: " + err.Error()))
-		log.Fatalf("Error reading RSS feed response: %v", err)
+	subtitleEntries, subtitleErr := parseVTTFile(captions)
+
+	if subtitleErr == nil && channel.Hook != "" {
+		cut.Hook = p.findHook(ctx, outputDir, videoID, subtitleEntries, cut)
 	}
+	cut.Pace = p.lowEnergy(ctx, videoID, videoFileName, cut)
 
-	var feed Feed
-	if err := xml.Unmarshal(body, &feed); err != nil {
-		fmt.Println(errorStyle.Render("Error parsing RSS feed: " + err.Error()))
-		log.Fatalf("Error unmarshalling RSS: %v", err)
+	clipHash := p.clipSettings(cut, subtitleErr == nil)
+	if p.runs(StageRender) && !p.reuse(ctx, outputDir, outputFileName, clipHash) {
+		if !p.renderClip(ctx, videoID, outputDir, name, videoFileName, subtitleEntries, cut, clipHash) {
+			return
+		}
 	}
 
-	if len(feed.Entries) == 0 {
-		fmt.Println(errorStyle.Render("No videos found for channel: " + channel.Name))
-		log.Fatalf("No videos found for channel: %s", channel.Name)
+	if subtitleErr != nil {
+		return
 	}
 
-	fmt.Println(subtitleStyle.Render(fmt.Sprintf("Total videos found: %d", len(feed.Entries))))
+	// The values of the editing sidecar replace the generated ones. A clip
+	// whose sidecar cannot be read is not uploaded with the generated ones.
+	edit, editErr := loadEdit(outputDir, name)
+	if editErr != nil {
+		p.fail(newError(ErrInvalidEdit, videoID, cut.Title, editErr))
+	} else if edit != nil {
+		cut.CoverText = edit.CoverText
+	}
 
-	videoLimit := channel.VideoLimit
-	if videoLimit == 0 || videoLimit > len(feed.Entries) {
-		videoLimit = len(feed.Entries)
+	metadata := p.cutMetadata(ctx, videoID, details, outputDir, name, subtitleEntries, cut)
+	switch {
+	case editErr != nil:
+		metadata = nil
+	case edit != nil && metadata != nil:
+		p.applyEdit(ctx, outputDir, name, edit, metadata)
 	}
 
-	fmt.Println(subtitleStyle.Render(fmt.Sprintf("Processing %d videos", videoLimit)))
+	if !p.runs(StageRender) {
+		return
+	}
+
+	p.cover(ctx, videoID, details, outputDir, name, outputFileName, cut, clipHash)
+
+	if p.vertical() {
+		verticalOutputDir := filepath.Join(outputDir, "vertical")
+		if _, err := os.Stat(verticalOutputDir); os.IsNotExist(err) {
+			os.Mkdir(verticalOutputDir, 0755)
+		}
+
+		verticalOutputFileName := filepath.Join(verticalOutputDir, name+Format(outputs.Vertical).ext())
+		verticalHash := p.verticalSettings(clipHash)
+		if !p.reuse(ctx, outputDir, verticalOutputFileName, verticalHash) {
+			fmt.Println(commandStyle.Render("Creating vertical version..."))
+			err := p.renderVertical(ctx, outputDir, name, videoFileName, subtitleEntries, cut, verticalOutputFileName)
+			if err == nil {
+				err = p.checkRender(ctx, verticalOutputFileName, cut)
+			}
+			if err != nil {
+				os.Remove(verticalOutputFileName)
+				p.fail(newError(ErrRenderFailed, videoID, cut.Title, err))
+			} else {
+				fmt.Println(successStyle.Render("Vertical version created successfully"))
+				recordSettings(outputDir, settingsName(outputDir, verticalOutputFileName), verticalHash)
+				p.emit(events.Event{Type: events.ClipRendered, VideoID: videoID, Cut: cut.Title, Path: verticalOutputFileName})
+				p.publish(ctx, verticalOutputFileName)
+			}
+		}
+	}
+
+	if channel.HorizontalVideoBase != "" {
+		horizontalOutputDir := filepath.Join(outputDir, "horizontal-yt")
+		if _, err := os.Stat(horizontalOutputDir); os.IsNotExist(err) {
+			os.Mkdir(horizontalOutputDir, 0755)
+		}
 
-	var videoIDs []string
-	for i := 0; i < videoLimit; i++ {
-		videoID := extractVideoID(feed.Entries[i].ID)
-		fmt.Println(optionStyle.Render(fmt.Sprintf("Video %d: %s (ID: %s)", i+1, feed.Entries[i].Title, videoID)))
-		videoIDs = append(videoIDs, videoID)
+		horizontalOutputFileName := filepath.Join(horizontalOutputDir, name+Format(outputs.Horizontal).ext())
+		horizontalHash := p.horizontalSettings(clipHash)
+		if !p.reuse(ctx, outputDir, horizontalOutputFileName, horizontalHash) {
+			fmt.Println(commandStyle.Render("Creating horizontal version..."))
+			release := p.client.Stages.render(ctx)
+			err := p.renderer.Overlay(ctx, p.base(ctx, channel.HorizontalVideoBase), outputFileName, horizontalOutputFileName)
+			release()
+			if err == nil {
+				err = p.checkRender(ctx, horizontalOutputFileName, cut)
+			}
+			if err != nil {
+				os.Remove(horizontalOutputFileName)
+				p.fail(newError(ErrRenderFailed, videoID, cut.Title, err))
+			} else {
+				fmt.Println(successStyle.Render("horizontal version created successfully"))
+				recordSettings(outputDir, settingsName(outputDir, horizontalOutputFileName), horizontalHash)
+				p.emit(events.Event{Type: events.ClipRendered, VideoID: videoID, Cut: cut.Title, Path: horizontalOutputFileName})
+				p.publish(ctx, horizontalOutputFileName)
+			}
+		}
 	}
 
-	return videoIDs
+	// After processing the video, upload it to YouTube
+	if p.runs(StageUpload) && channel.UploadToYouTube && metadata != nil {
+		// Upload horizontal video
+		languages := []string{""}
+		if p.localizedUploads() {
+			for _, language := range sortedLanguages(p.channel.Translations.Languages) {
+				if _, ok := metadata.Translations[language]; ok {
+					languages = append(languages, language)
+				}
+			}
+		}
+
+		for _, language := range languages {
+			p.upload(ctx, videoID, cut, filepath.Join(outputDir, "horizontal-yt", name+Format(outputs.Horizontal).ext()), p.detailsFor(metadata, "", language), language)
+
+			// Upload vertical video if it exists
+			verticalFileName := filepath.Join(outputDir, "vertical", name+Format(outputs.Vertical).ext())
+			if _, err := os.Stat(verticalFileName); err == nil {
+				p.upload(ctx, videoID, cut, verticalFileName, p.detailsFor(metadata, " (Vertical)", language), language)
+			}
+		}
+	}
 }
 
-func extractVideoID(rssID string) string {
-	videoID := rssID[strings.LastIndex(rssID, ":")+1:]
-	return videoID
+// cover draws the cover of the cut named name from clip into covers/, unless
+// the channel has no covers or the cover is up to date with the settings of
+// the clip, whose hash is clipHash.
+func (p *pipeline) cover(ctx context.Context, videoID string, details *VideoDetails, outputDir string, name string, clip string, cut Cut, clipHash string) {
+	if p.channel.CoverVideoBase == "" && p.channel.CoverTemplate == nil {
+		return
+	}
+
+	coverOutputDir := filepath.Join(outputDir, "covers")
+	if _, err := os.Stat(coverOutputDir); os.IsNotExist(err) {
+		os.Mkdir(coverOutputDir, 0755)
+	}
+
+	coverOutputFileName := filepath.Join(coverOutputDir, name+".jpg")
+	coverHash := p.coverSettings(videoID, details, cut, clipHash)
+	if !p.reuse(ctx, outputDir, coverOutputFileName, coverHash) {
+		if p.renderCover(ctx, videoID, details, clip, cut, coverOutputFileName) {
+			recordSettings(outputDir, settingsName(outputDir, coverOutputFileName), coverHash)
+		}
+	}
 }
 
-func splitLongVideo(videoFileName string, subtitleFileName string) ([]string, []string, error) {
-	const segmentDuration = 1200
-	var videoSegments []string
-	var subtitleSegments []string
+// reuse reports whether a step can be skipped because its output was already
+// produced by a previous run with the settings of hash, publishing it again in
+// case storing it failed.
+func (p *pipeline) reuse(ctx context.Context, outputDir string, output string, hash string) bool {
+	if _, err := os.Stat(output); err != nil {
+		return false
+	}
+	if !p.upToDate(outputDir, settingsName(outputDir, output), hash) {
+		fmt.Println(subtitleStyle.Render("Settings changed, rendering again: " + filepath.Base(output)))
+		return false
+	}
+
+	fmt.Println(subtitleStyle.Render("Already rendered, reusing: " + filepath.Base(output)))
+	p.publishMissing(ctx, output)
+	return true
+}
 
-	ffprobePath := config.GetFFprobe()
-	cmd := exec.Command(ffprobePath, "-v", "quiet", "-show_entries", "format=duration", "-of", "default=noprint_wrappers=1:nokey=1", videoFileName)
-	output, err := cmd.Output()
+// renderClip cuts the clip from the source video and burns its subtitles into
+// horizontal/{name}.mp4, or .webm in the webm container. Without subtitles, or when the subtitled render is
+// broken, the plain clip is kept. It returns false when the clip could not be
+// cut at all or its cut is broken. hash is recorded as the settings of the
+// clip once it is complete.
+func (p *pipeline) renderClip(ctx context.Context, videoID string, outputDir string, name string, videoFileName string, subtitleEntries []SubtitleEntry, cut Cut, hash string) bool {
+	ext := Format(p.channel.Outputs.Clip).ext()
+	tempOutputFileName := filepath.Join(outputDir, "temp_"+name+ext)
+	outputFileName := filepath.Join(outputDir, "horizontal", name+ext)
+	defer p.client.Stages.render(ctx)()
+
+	fmt.Println(descriptionStyle.Render(fmt.Sprintf("Creating clip from %d to %d seconds", cut.Begin, cut.End)))
+	err := p.cutClip(ctx, videoFileName, cut, tempOutputFileName)
+	if err == nil {
+		err = p.checkRender(ctx, tempOutputFileName, cut)
+	}
 	if err != nil {
-		return nil, nil, fmt.Errorf("error getting video duration: %v", err)
+		os.Remove(tempOutputFileName)
+		p.fail(newError(ErrRenderFailed, videoID, cut.Title, err))
+		return false
+	}
+
+	if subtitleEntries == nil {
+		fmt.Println(subtitleStyle.Render("Creating clip without subtitles"))
+		os.Rename(tempOutputFileName, outputFileName)
+		recordSettings(outputDir, settingsName(outputDir, outputFileName), hash)
+		p.publish(ctx, outputFileName)
+		return true
 	}
 
-	duration, err := strconv.ParseFloat(strings.TrimSpace(string(output)), 64)
+	cutSubtitleFileName := filepath.Join(outputDir, "temp_"+name+".srt")
+	subtitleText := p.clipSubtitles(subtitleEntries, cut)
+
+	if err := writeFileAtomic(cutSubtitleFileName, []byte(subtitleText), 0644); err != nil {
+		p.fail(newError(ErrRenderFailed, videoID, cut.Title, err))
+		os.Rename(tempOutputFileName, outputFileName)
+		p.publish(ctx, outputFileName)
+		return true
+	}
+
+	fmt.Println(commandStyle.Render("Adding subtitles to video..."))
+	err = p.renderer.BurnSubtitles(ctx, tempOutputFileName, cutSubtitleFileName, outputFileName)
+	if err == nil {
+		err = p.checkRender(ctx, outputFileName, cut)
+	}
 	if err != nil {
-		return nil, nil, fmt.Errorf("error converting video duration: %v", err)
+		p.fail(newError(ErrRenderFailed, videoID, cut.Title, err))
+		os.Rename(tempOutputFileName, outputFileName)
+	} else {
+		fmt.Println(successStyle.Render("Subtitles added successfully"))
+		recordSettings(outputDir, settingsName(outputDir, outputFileName), hash)
+		p.emit(events.Event{Type: events.ClipRendered, VideoID: videoID, Cut: cut.Title, Path: outputFileName})
 	}
 
-	if duration <= float64(segmentDuration) {
-		return []string{videoFileName}, []string{subtitleFileName}, nil
+	// The clip without subtitles is kept for the vertical version, which
+	// burns its own captions.
+	if !p.vertical() {
+		os.Remove(tempOutputFileName)
 	}
+	os.Remove(cutSubtitleFileName)
+	p.publish(ctx, outputFileName)
+	return true
+}
+
+// vertical reports whether the cuts get a vertical version: with a vertical
+// background or the split layout, or as audiograms of still-image sources.
+func (p *pipeline) vertical() bool {
+	return p.channel.VerticalVideoBase != "" || p.still || splitLayout(p.channel) != nil
+}
 
-	numSegments := int(math.Ceil(duration / float64(segmentDuration)))
-	ffmpegPath := config.GetFFmpeg()
+// renderVertical composes the clip without subtitles over the vertical
+// background, next to the secondary video of the split layout, or into an
+// audiogram for still-image sources, and burns the
+// captions sized for the 1080x1920 frame, so they are not shrunk along with
+// the clip. The clip left by renderClip is used when present, otherwise it
+// is cut again from the source video.
+func (p *pipeline) renderVertical(ctx context.Context, outputDir string, name string, videoFileName string, subtitleEntries []SubtitleEntry, cut Cut, output string) error {
+	clip := filepath.Join(outputDir, "temp_"+name+Format(p.channel.Outputs.Clip).ext())
+	defer os.Remove(clip)
+	defer p.client.Stages.render(ctx)()
+
+	if _, err := os.Stat(clip); err != nil {
+		if err := p.cutClip(ctx, videoFileName, cut, clip); err != nil {
+			return err
+		}
+	}
 
-	for i := 0; i < numSegments; i++ {
-		startTime := i * segmentDuration
-		segmentVideoFile := fmt.Sprintf("%s.part%d.mp4", videoFileName[:len(videoFileName)-4], i+1)
-		segmentSubtitleFile := fmt.Sprintf("%s.part%d.srt", subtitleFileName[:len(subtitleFileName)-4], i+1)
+	subtitles := filepath.Join(outputDir, "temp_"+name+".srt")
+	defer os.Remove(subtitles)
 
-		cmd = exec.Command(ffmpegPath,
-			"-i", videoFileName,
-			"-ss", fmt.Sprintf("%d", startTime),
-			"-t", fmt.Sprintf("%d", segmentDuration),
-			"-c", "copy",
-			"-y",
-			segmentVideoFile)
+	if err := writeFileAtomic(subtitles, []byte(p.clipSubtitles(subtitleEntries, cut)), 0644); err != nil {
+		return err
+	}
 
-		if err := cmd.Run(); err != nil {
-			return nil, nil, fmt.Errorf("error splitting video segment %d: %v", i+1, err)
+	// The preset was validated by newPipeline.
+	zone, _ := SafeZoneFor(p.channel.SafeZone)
+	if p.still {
+		background := p.channel.VerticalVideoBase
+		if audiogram := p.channel.Audiogram; audiogram != nil && audiogram.Background != "" {
+			background = audiogram.Background
 		}
+		return p.renderer.Audiogram(ctx, p.base(ctx, background), clip, subtitles, verticalCaptionStyle(p.channel), zone, audiogramLayout(p.channel), output)
+	}
+	if layout := splitLayout(p.channel); layout != nil {
+		return p.renderer.Split(ctx, clip, subtitles, splitCaptionStyle(p.channel, verticalCaptionStyle(p.channel)), zone, *layout, output)
+	}
+	return p.renderer.OverlaySubtitles(ctx, p.base(ctx, p.channel.VerticalVideoBase), clip, subtitles, verticalCaptionStyle(p.channel), zone, output)
+}
 
-		if subtitleEntries, err := parseVTTFile(subtitleFileName + ".pt.vtt"); err == nil {
-			subtitleText := getSubtitlesForTimeRange(subtitleEntries, startTime, startTime+segmentDuration)
-			if err := ioutil.WriteFile(segmentSubtitleFile, []byte(subtitleText), 0644); err != nil {
-				log.Printf("Error creating subtitle file for segment %d: %v", i+1, err)
-			} else {
-				subtitleSegments = append(subtitleSegments, segmentSubtitleFile)
-			}
+// verticalCaptionStyle returns the caption style of vertical videos, filling in the defaults.
+func verticalCaptionStyle(channel config.Channel) SubtitleStyle {
+	captions := channel.VerticalCaptions
+	style := SubtitleStyle{
+		Font:     captions.Font,
+		FontSize: 12,
+		MarginV:  60,
+		Outline:  1,
+	}
+	if captions.FontSize > 0 {
+		style.FontSize = captions.FontSize
+	}
+	if captions.MarginV > 0 {
+		style.MarginV = captions.MarginV
+	}
+	if captions.Outline > 0 {
+		style.Outline = captions.Outline
+	}
+	return style
+}
+
+// cutMetadata generates the SEO metadata of a cut into horizontal/{name}.json,
+// or reads it back when it was generated with the same settings. The title is
+// corrected to follow the title rules of the channel, the hashtags of its
+// pool are merged in and it is translated into the languages of the channel.
+// It returns nil when generation or translation failed.
+func (p *pipeline) cutMetadata(ctx context.Context, videoID string, details *VideoDetails, outputDir string, name string, subtitleEntries []SubtitleEntry, cut Cut) *VideoMetadata {
+	metadataFile := filepath.Join(outputDir, "horizontal", name+".json")
+	hash := p.metadataSettings(cut)
+
+	if content, err := os.ReadFile(metadataFile); err == nil && p.upToDate(outputDir, settingsName(outputDir, metadataFile), hash) {
+		var metadata VideoMetadata
+		if err := json.Unmarshal(content, &metadata); err == nil {
+			p.publishMissing(ctx, metadataFile)
+			return &metadata
 		}
+	}
 
-		videoSegments = append(videoSegments, segmentVideoFile)
+	var metadata *VideoMetadata
+	if cut.Parts > 0 {
+		metadata = p.seriesMetadata(ctx, videoID, details, subtitleEntries, cut)
+	} else {
+		metadata = p.generateMetadata(ctx, videoID, details, subtitleEntries, cut)
+	}
+	if metadata == nil {
+		return nil
+	}
+	if cut.Hook != nil {
+		metadata.Hook = cut.Hook.Text
+	}
+	metadata.Score = cut.Score
+	if rules := p.channel.TitleRules; rules != nil {
+		title, corrections := applyTitleRules(metadata.Title, *rules, details.vars())
+		if len(corrections) > 0 {
+			fmt.Println(subtitleStyle.Render(fmt.Sprintf("Title corrected (%s): %s", strings.Join(corrections, ", "), title)))
+			metadata.Title = title
+		}
+	}
+	if p.channel.Hashtags != nil {
+		p.applyHashtags(metadata)
+	}
+	if p.channel.Translations != nil && !p.translateMetadata(ctx, videoID, cut, metadata) {
+		return nil
 	}
 
-	return videoSegments, subtitleSegments, nil
+	metadataJSON, _ := json.MarshalIndent(metadata, "", "  ")
+	if err := writeFileAtomic(metadataFile, metadataJSON, 0644); err == nil {
+		recordSettings(outputDir, settingsName(outputDir, metadataFile), hash)
+	}
+	p.publish(ctx, metadataFile)
+	fmt.Println(successStyle.Render("Metadata generated successfully"))
+	return metadata
 }
 
-func DownloadVideo(channel config.Channel, force bool) {
-	fmt.Println(titleStyle.Render("Processing channel: " + channel.Name))
+// generateMetadata asks the language model for the SEO metadata of cut.
+// Without a language model, the metadata is made of the cut title, the start of
+// its transcript and the channel topics instead. It returns nil when
+// generation failed.
+func (p *pipeline) generateMetadata(ctx context.Context, videoID string, details *VideoDetails, subtitleEntries []SubtitleEntry, cut Cut) *VideoMetadata {
+	channel := p.channel
 
-	videoIDs := GetLastVideos(channel)
+	// Extract clean text from subtitles for metadata generation
+	var subtitleContent string
+	for _, entry := range subtitleEntries {
+		if (entry.StartTime >= time.Duration(cut.Begin)*time.Second) &&
+			(entry.EndTime <= time.Duration(cut.End)*time.Second) {
+			subtitleContent += " " + cleanSubtitleText(entry.Text)
+		}
+	}
+	subtitleContent = strings.TrimSpace(subtitleContent)
 
-	for i, videoID := range videoIDs {
-		fmt.Println(titleStyle.Render(fmt.Sprintf("Processing video %d/%d (ID: %s)", i+1, len(videoIDs), videoID)))
+	if !p.client.hasLanguageModel() {
+		fmt.Println(subtitleStyle.Render("No language model configured, using the cut title as metadata"))
+		metadata := &VideoMetadata{Title: cut.Title, Description: plainDescription(subtitleContent), Tags: splitTopics(channel.Topics)}
+		metadata.applyDetails(details, channel.TitleTemplate)
+		return metadata
+	}
 
-		outputDir := channel.Folder + "/" + videoID
+	// Generate SEO-optimized metadata
+	fmt.Println(commandStyle.Render("Generating metadata..."))
+	metadata, err := p.client.GenerateMetadata(withPromptCut(ctx, cut.Title), cut.Title, subtitleContent, channel.Topics, channel.Language)
+	if err != nil || metadata == nil {
+		p.fail(withContext(err, ErrLLMRequest, videoID, cut.Title))
+		return nil
+	}
 
-		if !force {
-			if _, err := os.Stat(outputDir); err == nil {
-				fmt.Println(subtitleStyle.Render("Video already processed. Skipping. Use force=true to reprocess."))
-				continue
-			}
-		} else {
-			if _, err := os.Stat(outputDir); err == nil {
-				fmt.Println(subtitleStyle.Render("Removing existing processed files..."))
-				if err := os.RemoveAll(outputDir); err != nil {
-					fmt.Println(errorStyle.Render("Error removing directory: " + err.Error()))
-					continue
-				}
-			}
-		}
+	metadata.applyDetails(details, channel.TitleTemplate)
+	return metadata
+}
 
-		videoFileName := outputDir + "/" + fmt.Sprintf("%s.mp4", videoID)
-		subtitleFileName := outputDir + "/" + fmt.Sprintf("%s.srt", videoID)
-		videoURL := fmt.Sprintf("https://www.youtube.com/watch?v=%s", videoID)
-		ytDlpPath := config.GetYtDlp()
+// plainDescription shortens a transcript to a description of at most 250
+// characters, ending on a whole word.
+func plainDescription(text string) string {
+	if len([]rune(text)) <= 250 {
+		return text
+	}
 
-		if err := os.MkdirAll(outputDir+"/horizontal", 0755); err != nil {
-			fmt.Println(errorStyle.Render("Error creating output directory: " + err.Error()))
-			continue
-		}
+	text = string([]rune(text)[:249])
+	if i := strings.LastIndex(text, " "); i > 0 {
+		text = text[:i]
+	}
+	return text + "…"
+}
 
-		if _, err := os.Stat(videoFileName); os.IsNotExist(err) {
-			fmt.Println(commandStyle.Render("Downloading video..."))
-			cmd := exec.Command(
-				ytDlpPath,
-				"--ignore-errors",
-				"--merge-output-format", "mp4",
-				"--geo-bypass",
-				"--no-check-certificate",
-				"--force-generic-extractor",
-				"--format", channel.YtdlpFormat,
-				"--concurrent-fragments", "8",
-				"-o",
-				videoFileName,
-				videoURL,
-			)
-
-			if _, err := cmd.CombinedOutput(); err != nil {
-				fmt.Println(errorStyle.Render(fmt.Sprintf("Error downloading video: %v", err)))
-				continue
-			}
-			fmt.Println(successStyle.Render("Video downloaded successfully"))
-		} else {
-			fmt.Println(subtitleStyle.Render("Video file already exists. Skipping download."))
-		}
-
-		if _, err := os.Stat(subtitleFileName); os.IsNotExist(err) {
-			fmt.Println(commandStyle.Render("Downloading subtitles..."))
-			cmd := exec.Command(
-				ytDlpPath,
-				"--write-auto-sub",
-				"--sub-lang", "pt",
-				"--skip-download",
-				"--output", subtitleFileName,
-				videoURL,
-			)
-			if _, err := cmd.CombinedOutput(); err != nil {
-				fmt.Println(errorStyle.Render(fmt.Sprintf("Error downloading subtitles: %v", err)))
-				continue
-			}
-			fmt.Println(successStyle.Render("Subtitles downloaded successfully"))
-		} else {
-			fmt.Println(subtitleStyle.Render("Subtitle file already exists. Skipping download."))
+// renderCover draws the cover image of a cut, either from the channel cover
+// template or as a single title over the cover base. It returns false when
+// the cover could not be drawn.
+func (p *pipeline) renderCover(ctx context.Context, videoID string, details *VideoDetails, clip string, cut Cut, output string) bool {
+	channel := p.channel
+
+	fmt.Println(commandStyle.Render("Generating cover image..."))
+	defer p.client.Stages.render(ctx)()
+	vars := coverVars(channel, videoID, details, cut)
+	text := coverText(channel, cut, vars)
+
+	var err error
+	if template := channel.CoverTemplate; template != nil {
+		background := channel.CoverVideoBase
+		switch template.Background {
+		case "":
+			background = p.base(ctx, background)
+		case "clip":
+			background = clip
+		default:
+			background = p.base(ctx, template.Background)
 		}
 
-		fmt.Println(commandStyle.Render("Processing video segments..."))
-		videoSegments, subtitleSegments, err := splitLongVideo(videoFileName, subtitleFileName)
-		if err != nil {
-			fmt.Println(errorStyle.Render("Error splitting video: " + err.Error()))
-			continue
-		}
+		vars["title"] = text
+		err = p.renderer.Compose(ctx, background, coverLayers(channel, template, vars), output)
+	} else {
+		err = p.renderer.Cover(ctx, p.base(ctx, channel.CoverVideoBase), text, coverStyle(channel), output)
+	}
 
-		for i, segmentVideoFile := range videoSegments {
-			fmt.Println(subtitleStyle.Render(fmt.Sprintf("Processing segment %d/%d", i+1, len(videoSegments))))
+	if err != nil {
+		os.Remove(output)
+		p.fail(newError(ErrRenderFailed, videoID, cut.Title, err))
+		return false
+	}
 
-			segmentSubtitleFile := subtitleSegments[i]
-			fmt.Println(commandStyle.Render("Finding interesting cuts in this segment..."))
-			cuts := GetCuts(segmentSubtitleFile, channel.Topics, channel.Excerpts, channel.StretchTime)
+	fmt.Println(successStyle.Render("Cover image generated successfully"))
+	p.emit(events.Event{Type: events.ClipRendered, VideoID: videoID, Cut: cut.Title, Path: output})
+	p.publish(ctx, output)
+	return true
+}
 
-			if len(cuts) > 0 {
-				fmt.Println(successStyle.Render(fmt.Sprintf("Found %d interesting cuts", len(cuts))))
+// upload sends a rendered clip to YouTube as unlisted with details, unless
+// it was already uploaded by a previous run or the quality gate holds it
+// back. language is set for the copies uploaded per translated language,
+// recorded apart from the original upload. Uploads that still fail after
+// their attempts, and those skipped once the upload quota is exhausted, are
+// spooled for a later drain.
+func (p *pipeline) upload(ctx context.Context, videoID string, cut Cut, path string, details uploadDetails, language string) {
+	key := languageKey(p.outputKey(path), language)
+	if p.client.State != nil && p.client.State.Uploaded(p.channel.ID, key) {
+		fmt.Println(subtitleStyle.Render("Already uploaded, skipping: " + filepath.Base(path)))
+		p.communityPost(ctx, videoID, cut, path, details, key)
+		return
+	}
 
-				video, err := moviego.Load(segmentVideoFile)
-				if err != nil {
-					fmt.Println(errorStyle.Render("Error loading video segment: " + err.Error()))
-					continue
-				}
+	if !p.passesQuality(ctx, videoID, cut, path) {
+		return
+	}
 
-				videoDuration := video.Duration()
-
-				for j, cut := range cuts {
-					fmt.Println(optionStyle.Render(fmt.Sprintf("Processing cut %d/%d: %s", j+1, len(cuts), cut.Title)))
-
-					tempOutputFileName := fmt.Sprintf("%s/temp_%s.mp4", outputDir, cut.Title)
-					outputFileName := fmt.Sprintf("%s/horizontal/%s.mp4", outputDir, cut.Title)
-
-					if videoDuration < float64(cut.Begin) || videoDuration < float64(cut.End) {
-						fmt.Println(errorStyle.Render("Cut time exceeds video duration. Skipping."))
-						continue
-					}
-
-					fmt.Println(descriptionStyle.Render(fmt.Sprintf("Creating clip from %d to %d seconds", cut.Begin, cut.End)))
-					if err := video.SubClip(float64(cut.Begin), float64(cut.End)).Output(tempOutputFileName).Run(); err != nil {
-						fmt.Println(errorStyle.Render("Error creating clip: " + err.Error()))
-						continue
-					}
-
-					subtitleEntries, err := parseVTTFile(subtitleFileName + ".pt.vtt")
-					if err != nil {
-						fmt.Println(subtitleStyle.Render("Creating clip without subtitles"))
-						os.Rename(tempOutputFileName, outputFileName)
-						continue
-					}
-
-					cutSubtitleFileName := fmt.Sprintf("%s/temp_%s.srt", outputDir, cut.Title)
-					subtitleText := getSubtitlesForTimeRange(subtitleEntries, cut.Begin, cut.End)
-
-					if err := ioutil.WriteFile(cutSubtitleFileName, []byte(subtitleText), 0644); err != nil {
-						fmt.Println(errorStyle.Render("Error writing subtitle file: " + err.Error()))
-						os.Rename(tempOutputFileName, outputFileName)
-						continue
-					}
-
-					// Extract clean text from subtitles for metadata generation
-					var subtitleContent string
-					for _, entry := range subtitleEntries {
-						if (entry.StartTime >= time.Duration(cut.Begin)*time.Second) &&
-							(entry.EndTime <= time.Duration(cut.End)*time.Second) {
-							subtitleContent += " " + cleanSubtitleText(entry.Text)
-						}
-					}
-					subtitleContent = strings.TrimSpace(subtitleContent)
-
-					// Generate SEO-optimized metadata
-					fmt.Println(commandStyle.Render("Generating metadata..."))
-					metadata, err := GenerateMetadata(cut.Title, subtitleContent, channel.Topics)
-					if err == nil && metadata != nil {
-						metadataFile := fmt.Sprintf("%s/horizontal/%s.json", outputDir, cut.Title)
-						metadataJSON, _ := json.MarshalIndent(metadata, "", "  ")
-						ioutil.WriteFile(metadataFile, metadataJSON, 0644)
-						fmt.Println(successStyle.Render("Metadata generated successfully"))
-					} else {
-						fmt.Println(errorStyle.Render(fmt.Sprintf("Error generating metadata: %v", err)))
-					}
-
-					fmt.Println(commandStyle.Render("Adding subtitles to video..."))
-					ffmpegPath := config.GetFFmpeg()
-					cmd := exec.Command(
-						ffmpegPath,
-						"-i", tempOutputFileName,
-						"-vf", "subtitles="+cutSubtitleFileName+":force_style='FontSize=22,Alignment=2'",
-						"-c:a", "aac",
-						"-c:v", "libx264",
-						"-preset", "ultrafast",
-						"-tune", "fastdecode",
-						"-crf", "28",
-						"-threads", "0",
-						"-y",
-						outputFileName,
-					)
-
-					if err := cmd.Run(); err != nil {
-						fmt.Println(errorStyle.Render("Error adding subtitles: " + err.Error()))
-						os.Rename(tempOutputFileName, outputFileName)
-					} else {
-						fmt.Println(successStyle.Render("Subtitles added successfully"))
-					}
-
-					os.Remove(tempOutputFileName)
-					os.Remove(cutSubtitleFileName)
-
-					if channel.CoverVideoBase != "" {
-						fmt.Println(commandStyle.Render("Generating cover image..."))
-						coverOutputDir := outputDir + "/covers"
-						if _, err := os.Stat(coverOutputDir); os.IsNotExist(err) {
-							os.Mkdir(coverOutputDir, 0755)
-						}
-
-						coverOutputFileName := fmt.Sprintf("%s/%s.jpg", coverOutputDir, cut.Title)
-
-						words := strings.Fields(cut.Title)
-						formattedTitle := cut.Title
-						if len(words) > 3 {
-							var lines []string
-							for i := 0; i < len(words); i += 3 {
-								end := i + 3
-								if end > len(words) {
-									end = len(words)
-								}
-								lines = append(lines, strings.Join(words[i:end], " "))
-							}
-							formattedTitle = strings.Join(lines, "\n")
-						}
-
-						fontSize := "36"
-						if channel.FontSize != "" {
-							fontSize = channel.FontSize
-						}
-
-						fontColor := "white"
-						if channel.FontColor != "" {
-							fontColor = channel.FontColor
-						}
-
-						fontName := ""
-						fontParam := ""
-						if channel.Font != "" {
-							fontName = channel.Font
-							fontParam = ":fontfile=" + fontName
-						}
-
-						fontEffect := ""
-						if channel.FontEffect != "" {
-							fontEffect = channel.FontEffect
-						}
-
-						cmd := exec.Command(
-							ffmpegPath,
-							"-i", channel.CoverVideoBase,
-							"-vf", fmt.Sprintf("drawtext=text='%s':fontsize=%s:fontcolor=%s%s:x=(w-text_w)/2:y=(h-text_h)/2%s",
-								formattedTitle, fontSize, fontColor, fontParam, fontEffect),
-							"-frames:v", "1",
-							"-y",
-							coverOutputFileName,
-						)
-
-						if err := cmd.Run(); err != nil {
-							fmt.Println(errorStyle.Render("Error generating cover image: " + err.Error()))
-						} else {
-							fmt.Println(successStyle.Render("Cover image generated successfully"))
-						}
-					}
-
-					if channel.VerticalVideoBase != "" {
-						fmt.Println(commandStyle.Render("Creating vertical version..."))
-						verticalOutputDir := outputDir + "/vertical"
-						if _, err := os.Stat(verticalOutputDir); os.IsNotExist(err) {
-							os.Mkdir(verticalOutputDir, 0755)
-						}
-
-						verticalOutputFileName := fmt.Sprintf("%s/%s.mp4", verticalOutputDir, cut.Title)
-						cmd := exec.Command(
-							ffmpegPath,
-							"-i", channel.VerticalVideoBase,
-							"-i", outputFileName,
-							"-filter_complex", "[0:v]loop=loop=-1:size=1:start=0[loopbg];[1:v]scale=1080:-1[scaled];[loopbg][scaled]overlay=(W-w)/2:(H-h)/2:shortest=1[outv]",
-							"-map", "[outv]",
-							"-map", "1:a",
-							"-c:a", "aac",
-							"-c:v", "libx264",
-							"-preset", "ultrafast",
-							"-tune", "fastdecode",
-							"-crf", "28",
-							"-threads", "0",
-							"-shortest",
-							"-y",
-							verticalOutputFileName,
-						)
-						if err := cmd.Run(); err != nil {
-							fmt.Println(errorStyle.Render("Error creating vertical version: " + err.Error()))
-						} else {
-							fmt.Println(successStyle.Render("Vertical version created successfully"))
-						}
-					}
-
-					if channel.HorizontalVideoBase != "" {
-						fmt.Println(commandStyle.Render("Creating horizontal version..."))
-						horizontalOutputDir := outputDir + "/horizontal-yt"
-						if _, err := os.Stat(horizontalOutputDir); os.IsNotExist(err) {
-							os.Mkdir(horizontalOutputDir, 0755)
-						}
-
-						horizontalOutputFileName := fmt.Sprintf("%s/%s.mp4", horizontalOutputDir, cut.Title)
-						cmd := exec.Command(
-							ffmpegPath,
-							"-i", channel.HorizontalVideoBase,
-							"-i", outputFileName,
-							"-filter_complex", "[0:v]loop=loop=-1:size=1:start=0[loopbg];[1:v]scale=1080:-1[scaled];[loopbg][scaled]overlay=(W-w)/2:(H-h)/2:shortest=1[outv]",
-							"-map", "[outv]",
-							"-map", "1:a",
-							"-c:a", "aac",
-							"-c:v", "libx264",
-							"-preset", "ultrafast",
-							"-tune", "fastdecode",
-							"-crf", "28",
-							"-threads", "0",
-							"-shortest",
-							"-y",
-							horizontalOutputFileName,
-						)
-						if err := cmd.Run(); err != nil {
-							fmt.Println(errorStyle.Render("Error creating horizontal version: " + err.Error()))
-						} else {
-							fmt.Println(successStyle.Render("horizontal version created successfully"))
-						}
-					}
-
-					// After processing the video, upload it to YouTube
-					if channel.UploadToYouTube && metadata != nil {
-						// Upload horizontal video
-						fmt.Println(commandStyle.Render("Uploading horizontal video to YouTube..."))
-						outputFileName := fmt.Sprintf("%s/horizontal-yt/%s.mp4", outputDir, cut.Title)
-						err := UploadToYouTube(
-							outputFileName,
-							metadata.Title,
-							metadata.Description,
-							metadata.Tags,
-							"unlisted",
-						)
-
-						if err != nil {
-							fmt.Println(errorStyle.Render(fmt.Sprintf("YouTube upload failed: %v", err)))
-						} else {
-							fmt.Println(successStyle.Render("Video uploaded to YouTube successfully"))
-						}
-
-						// Upload vertical video if it exists
-						verticalFileName := fmt.Sprintf("%s/vertical/%s.mp4", outputDir, cut.Title)
-						if _, err := os.Stat(verticalFileName); err == nil {
-							fmt.Println(commandStyle.Render("Uploading vertical video to YouTube..."))
-							err := UploadToYouTube(
-								verticalFileName,
-								metadata.Title+" (Vertical)",
-								metadata.Description,
-								metadata.Tags,
-								"unlisted",
-							)
-
-							if err != nil {
-								fmt.Println(errorStyle.Render(fmt.Sprintf("Vertical video upload failed: %v", err)))
-							} else {
-								fmt.Println(successStyle.Render("Vertical video uploaded to YouTube successfully"))
-							}
-						}
-					}
-				}
-			} else {
-				fmt.Println(subtitleStyle.Render("No interesting cuts found in this segment"))
-			}
+	if p.uploadsBlocked {
+		p.spool(videoID, cut, path, key, details, ErrUploadQuota)
+		return
+	}
+
+	p.client.schedulePublish(ctx, p.channel, &details)
+	fmt.Println(commandStyle.Render("Uploading " + filepath.Base(filepath.Dir(path)) + " video to YouTube..."))
+	id, err := p.client.uploadClip(ctx, path, details)
+	if err != nil {
+		p.reportUploadError(withContext(err, ErrUploadFailed, videoID, cut.Title))
+		if ctx.Err() == nil {
+			p.spool(videoID, cut, path, key, details, err)
 		}
+		return
+	}
 
-		if len(videoSegments) > 1 {
-			fmt.Println(commandStyle.Render("Cleaning up temporary files..."))
-			for _, file := range append(videoSegments, subtitleSegments...) {
-				if file != videoFileName && file != subtitleFileName {
-					os.Remove(file)
-				}
+	fmt.Println(successStyle.Render("Video uploaded to YouTube successfully"))
+	p.emit(events.Event{Type: events.Uploaded, VideoID: videoID, Cut: cut.Title, Path: path})
+	if p.client.State != nil {
+		if err := p.client.State.MarkUploaded(p.channel.ID, key, id); err != nil {
+			fmt.Println(errorStyle.Render("Error recording upload: " + err.Error()))
+		}
+		for _, spooled := range p.client.State.Spooled(p.channel.ID) {
+			if spooled.Key == key {
+				unspool(p.client.State, p.channel.ID, spooled)
 			}
-			fmt.Println(successStyle.Render("Cleanup completed"))
+		}
+		if err := p.client.State.RemoveReview(p.channel.ID, key); err != nil {
+			fmt.Println(errorStyle.Render("Error removing review: " + err.Error()))
 		}
 	}
-
-	fmt.Println(titleStyle.Render("Processing completed for channel: " + channel.Name))
+	p.communityPost(ctx, videoID, cut, path, details, key)
 }
 
 type Cut struct {
-	Title string `json:"title"`
-	Begin int    `json:"begin"`
-	End   int    `json:"end"`
+	Title  string  `json:"title"`
+	Begin  int     `json:"begin"`
+	End    int     `json:"end"`
+	Series string  `json:"series,omitempty"` // Title of the whole cut when this is a part of a series
+	Part   int     `json:"part,omitempty"`   // Part number within the series, starting at 1
+	Parts  int     `json:"parts,omitempty"`  // Number of parts in the series
+	Hook   *Hook   `json:"hook,omitempty"`   // Most attention-grabbing moment of the cut, when detected
+	Pace   []Span  `json:"pace,omitempty"`   // Quiet passages sped up, relative to Begin
+	Score  float64 `json:"score,omitempty"`  // How engaging the model rated the cut, from 1 to 10
+
+	CoverText string `json:"-"` // Cover text template from the editing sidecar, replacing the one of the channel
 }
 
 type CutsResponse struct {
 	Cuts []Cut `json:"cuts"`
 }
 
-func GetCuts(subtleFileName string, topics string, excerpts int, stretchTime int) []Cut {
-	isSegment := strings.Contains(subtleFileName, ".part")
-
-	var vttPath string
-	if isSegment {
-		basePath := strings.Split(subtleFileName, ".part")[0]
-		vttPath = basePath + ".srt.pt.vtt"
-	} else {
-		vttPath = subtleFileName + ".pt.vtt"
+// languageInstruction tells the model which language to write in. An empty
+// language keeps the language of the subtitles, which can produce mixed-language
+// output for bilingual videos.
+func languageInstruction(language string) string {
+	if language == "" {
+		return `IMPORTANT: Keep the language of your output THE SAME as the language used in the subtitle excerpt.
+	DO NOT translate to English - maintain the original language of the subtitles.`
 	}
+	return fmt.Sprintf(`IMPORTANT: Write ALL of your output in %s, even when the subtitles are in another language
+	or mix several languages. Translate whatever is needed - never mix languages in a single field.`, language)
+}
 
-	subtleContent, err := ioutil.ReadFile(vttPath)
+// GetCuts asks the language model for interesting cuts in captions, the WEBVTT captions of a video.
+// Cut titles are written in language, or in the language of the subtitles when empty.
+// Hints about the source video, such as its chapters, and the extra
+// instructions are added to the prompt. Subtitles too long for the context
+// window of the language model and of the long model are split into chunks
+// asked for cuts one at a time.
+// Failures are returned as *Error values of kind ErrNoCaptions, ErrLLMRequest or ErrLLMParse.
+func (c *Client) GetCuts(ctx context.Context, captions string, topics string, extra string, excerpts int, stretchTime int, language string, hints *CutHints) ([]Cut, error) {
+	subtleContent, err := ioutil.ReadFile(captions)
 	if err != nil {
-		log.Printf("Error reading subtitle file: %v", err)
-		return nil
+		return nil, newError(ErrNoCaptions, "", "", err)
 	}
 
 	subtleContentString := string(subtleContent)
 
-	url := "https://api.openai.com/v1/chat/completions"
-	method := "POST"
-
-	systemPrompt := fmt.Sprintf(`You are a professional video editor specialized in analyzing video subtitles and identifying compelling segments about the topics "%s".
+	systemPrompt := func(excerpts int) string {
+		return fmt.Sprintf(`You are a professional video editor specialized in analyzing video subtitles and identifying compelling segments about the topics "%s".
 	Your task is to locate multiple excerpts (at least %d, if possible) that contain relevant discussions about these topics.
 
 	While each excerpt should target around %d minute(s) in length, you should prioritize natural cutting points where conversations
@@ -597,103 +1607,76 @@ func GetCuts(subtleFileName string, topics string, excerpts int, stretchTime int
 	if that produces a better quality clip with complete thoughts and discussions.
 
 	Focus on segments that are self-contained, meaningful, and engaging. Cut at natural conversational breaks, not mid-sentence.
+	Rate how engaging each excerpt would be as a standalone clip with a score from 1 to 10.
 
-	Return only a JSON object in the format: {"cuts": [{"title": "Descriptive title of the cut", "begin": start time in seconds (integer), "end": end time in seconds (integer)}]}`, topics, excerpts, stretchTime)
-
-	userPrompt := fmt.Sprintf("Here is the subtitle file in WEBVTT format:\n\n%s\n\nIdentify multiple interesting segments related to the topics \"%s\". Target approximately %d minute(s) per segment, but prioritize natural cut points for complete thoughts. Return only the JSON object with the identified cuts.", subtleContentString, topics, stretchTime)
-
-	requestBody := map[string]interface{}{
-		"model": config.GetOpenAIModel(),
-		"messages": []map[string]string{
-			{
-				"role":    "system",
-				"content": systemPrompt,
-			},
-			{
-				"role":    "user",
-				"content": userPrompt,
-			},
-		},
-		"response_format": map[string]string{
-			"type": "json_object",
-		},
-	}
+	Return only a JSON object in the format: {"cuts": [{"title": "Descriptive title of the cut", "begin": start time in seconds (integer), "end": end time in seconds (integer), "score": engagement score from 1 to 10}]}
 
-	jsonData, err := json.Marshal(requestBody)
-	if err != nil {
-		log.Printf("Error creating request JSON: %v", err)
-		return nil
+	%s`, topics, excerpts, stretchTime, languageInstruction(language))
 	}
 
-	payload := bytes.NewBuffer(jsonData)
-
-	maxRetries := 3
-	var apiResponse OpenAIResponse
-	var respBody []byte
-	var statusCode int
-
-	for attempt := 0; attempt < maxRetries; attempt++ {
-		if attempt > 0 {
-			backoffDuration := time.Duration(2<<uint(attempt-1)) * time.Second
-			time.Sleep(backoffDuration)
+	userPrompt := func(subtitles string) string {
+		prompt := fmt.Sprintf("Here is the subtitle file in WEBVTT format:\n\n%s\n\nIdentify multiple interesting segments related to the topics \"%s\". Target approximately %d minute(s) per segment, but prioritize natural cut points for complete thoughts. Return only the JSON object with the identified cuts.%s", subtitles, topics, stretchTime, hints.prompt())
+		if extra != "" {
+			prompt += "\n\nAdditional instructions: " + extra
 		}
+		return prompt
+	}
 
-		client := &http.Client{
-			Timeout: 120 * time.Second,
-		}
-		req, err := http.NewRequest(method, url, payload)
+	chunks := []string{subtleContentString}
+	promptTokens := ai.EstimateTokens(systemPrompt(excerpts)) + ai.EstimateTokens(userPrompt(subtleContentString))
+	if c.routeModel(promptTokens) == nil {
+		overhead := ai.EstimateTokens(systemPrompt(excerpts)) + ai.EstimateTokens(userPrompt(""))
+		chunks, err = chunkSubtitles(parseVTT(subtleContentString), c.contextWindow()-ai.DefaultMaxTokens-overhead)
 		if err != nil {
-			continue
+			return nil, newError(ErrLLMRequest, "", "", err)
 		}
-		req.Header.Add("Content-Type", "application/json")
-		req.Header.Add("Authorization", "Bearer "+config.GetOpenAIKey())
+		fmt.Println(subtitleStyle.Render(fmt.Sprintf("Subtitles of about %d tokens exceed the %d token context window of %s, splitting them into %d chunks", promptTokens, c.contextWindow(), c.languageModel().Model(), len(chunks))))
+	}
 
-		res, err := client.Do(req)
-		if err != nil {
-			continue
+	var cuts []Cut
+	for i, chunk := range chunks {
+		if len(chunks) > 1 {
+			fmt.Println(descriptionStyle.Render(fmt.Sprintf("Finding cuts in chunk %d/%d...", i+1, len(chunks))))
 		}
-
-		statusCode = res.StatusCode
-
-		respBody, err = io.ReadAll(res.Body)
-		res.Body.Close()
+		var cutsResponse CutsResponse
+		err = c.chatCompletion(ctx, purposeCuts, 120*time.Second, systemPrompt((excerpts+len(chunks)-1)/len(chunks)), userPrompt(chunk), func(content string) error {
+			return json.Unmarshal([]byte(content), &cutsResponse)
+		})
 		if err != nil {
-			continue
-		}
-
-		if statusCode != http.StatusOK {
-			continue
+			return nil, err
 		}
+		cuts = append(cuts, cutsResponse.Cuts...)
+	}
 
-		if err := json.Unmarshal(respBody, &apiResponse); err != nil {
-			continue
-		}
+	return cuts, nil
+}
 
-		break
-	}
+// minChunkTokens is the smallest chunk of subtitles worth asking for cuts.
+const minChunkTokens = 1000
 
-	if statusCode != http.StatusOK {
-		return nil
+// chunkSubtitles splits entries into WEBVTT documents of at most tokens
+// estimated tokens each, keeping their timestamps absolute. Cuts cannot span
+// two chunks, so chunks are as long as the limit allows.
+func chunkSubtitles(entries []SubtitleEntry, tokens int) ([]string, error) {
+	if tokens < minChunkTokens {
+		return nil, fmt.Errorf("the context window leaves %d tokens for the subtitles, at least %d are needed", tokens, minChunkTokens)
 	}
-
-	if len(apiResponse.Choices) == 0 {
-		return nil
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("no subtitles to split")
 	}
 
-	var cutsResponse CutsResponse
-	if err := json.Unmarshal([]byte(apiResponse.Choices[0].Message.Content), &cutsResponse); err != nil {
-		return nil
+	header := ai.EstimateTokens(string(formatVTT(nil)))
+	var chunks []string
+	start, size := 0, header
+	for i := range entries {
+		entrySize := ai.EstimateTokens(string(formatVTT(entries[i:i+1]))) - header
+		if i > start && size+entrySize > tokens {
+			chunks = append(chunks, string(formatVTT(entries[start:i])))
+			start, size = i, header
+		}
+		size += entrySize
 	}
-
-	return cutsResponse.Cuts
-}
-
-type OpenAIResponse struct {
-	Choices []struct {
-		Message struct {
-			Content string `json:"content"`
-		} `json:"message"`
-	} `json:"choices"`
+	return append(chunks, string(formatVTT(entries[start:]))), nil
 }
 
 type SubtitleEntry struct {
@@ -709,7 +1692,12 @@ func parseVTTFile(filePath string) ([]SubtitleEntry, error) {
 		return nil, err
 	}
 
-	lines := strings.Split(string(content), "\n")
+	return parseVTT(string(content)), nil
+}
+
+// parseVTT parses the cues of WEBVTT captions.
+func parseVTT(content string) []SubtitleEntry {
+	lines := strings.Split(content, "\n")
 	var entries []SubtitleEntry
 	var currentEntry SubtitleEntry
 	var inEntry bool = false
@@ -748,7 +1736,7 @@ func parseVTTFile(filePath string) ([]SubtitleEntry, error) {
 		entries = append(entries, currentEntry)
 	}
 
-	return entries, nil
+	return entries
 }
 
 func parseTimestamp(timestamp string) time.Duration {
@@ -833,10 +1821,38 @@ func formatSRTTimestamp(seconds int) string {
 // VideoMetadata represents SEO metadata for a video cut
 // Contains optimized information for publishing videos across multiple platforms
 type VideoMetadata struct {
-	Title       string   `json:"title"`       // SEO-optimized title for the video
-	Description string   `json:"description"` // Short engaging description (max 250 chars)
-	Tags        []string `json:"tags"`        // Relevant search tags without # symbol
-	Hashtags    []string `json:"hashtags"`    // Popular hashtags with # symbol included
+	Title            string                  `json:"title"`                       // SEO-optimized title for the video
+	Description      string                  `json:"description"`                 // Short engaging description (max 250 chars)
+	Tags             []string                `json:"tags"`                        // Relevant search tags without # symbol
+	Hashtags         []string                `json:"hashtags"`                    // Popular hashtags with # symbol included
+	PlatformHashtags map[string][]string     `json:"platform_hashtags,omitempty"` // Hashtags within the limit of each platform, when the channel has a hashtag pool
+	Translations     map[string]Localization `json:"translations,omitempty"`      // Title, description and tags by language code, when the channel translates its metadata
+	Guest            string                  `json:"guest,omitempty"`             // Guest extracted from the source video
+	Episode          string                  `json:"episode,omitempty"`           // Episode number extracted from the source video
+	Date             string                  `json:"date,omitempty"`              // Date extracted from the source video
+	Hook             string                  `json:"hook,omitempty"`              // Most attention-grabbing sentence of the cut
+	Score            float64                 `json:"score,omitempty"`             // How engaging the model rated the cut, from 1 to 10
+}
+
+// applyDetails copies the extracted details into the metadata and, when
+// titleTemplate is set, rebuilds the title from it. The template accepts the
+// {title}, {guest}, {episode} and {date} placeholders; separators left around
+// empty placeholders are trimmed.
+func (m *VideoMetadata) applyDetails(details *VideoDetails, titleTemplate string) {
+	if details != nil {
+		m.Guest = details.Guest
+		m.Episode = details.Episode
+		m.Date = details.Date
+	}
+
+	if titleTemplate == "" {
+		return
+	}
+
+	vars := details.vars()
+	vars["title"] = m.Title
+	title := strings.Join(strings.Fields(expandTemplate(titleTemplate, vars)), " ")
+	m.Title = strings.Trim(title, " |-–—#:,")
 }
 
 // GenerateMetadata generates optimized SEO metadata using AI based on video content
@@ -844,18 +1860,20 @@ type VideoMetadata struct {
 //   - videoTitle: The original title of the video clip
 //   - subtitleContent: The transcript text from the video
 //   - topics: The main topics or themes to focus on
+//   - language: The output language, empty to keep the language of the subtitles
 //
 // Returns SEO-optimized metadata or an error if generation fails
-func GenerateMetadata(videoTitle string, subtitleContent string, topics string) (*VideoMetadata, error) {
-	url := "https://api.openai.com/v1/chat/completions"
-	method := "POST"
+func (c *Client) GenerateMetadata(ctx context.Context, videoTitle string, subtitleContent string, topics string, language string) (*VideoMetadata, error) {
+	outputLanguage := "in the SAME LANGUAGE as the subtitle"
+	if language != "" {
+		outputLanguage = "in " + language
+	}
 
 	systemPrompt := fmt.Sprintf(`You are an expert in SEO for YouTube, TikTok, and Instagram videos.
 	Your task is to create optimized metadata for a video clip about "%s".
 	Generate an attractive title, an engaging description limited to 250 characters, up to 10 relevant tags, and 5 popular hashtags.
 
-	IMPORTANT: Keep the language of your output THE SAME as the language used in the subtitle excerpt.
-	DO NOT translate to English - maintain the original language of the subtitles.`, topics)
+	%s`, topics, languageInstruction(language))
 
 	userPrompt := fmt.Sprintf(`Based on this subtitle excerpt:
 	"%s"
@@ -863,125 +1881,44 @@ func GenerateMetadata(videoTitle string, subtitleContent string, topics string)
 	And with this original title: "%s"
 
 	Create SEO-optimized metadata in JSON format with the following fields:
-	1. title: An attractive SEO-optimized title (keep in the SAME LANGUAGE as the subtitle)
-	2. description: An engaging description up to 250 characters (keep in the SAME LANGUAGE as the subtitle)
-	3. tags: List of up to 10 relevant tags (without the # symbol, keep in the SAME LANGUAGE as the subtitle)
-	4. hashtags: List of 5 popular hashtags (including the # symbol, keep in the SAME LANGUAGE as the subtitle)`, subtitleContent, videoTitle)
-
-	requestBody := map[string]interface{}{
-		"model": config.GetOpenAIModel(),
-		"messages": []map[string]string{
-			{
-				"role":    "system",
-				"content": systemPrompt,
-			},
-			{
-				"role":    "user",
-				"content": userPrompt,
-			},
-		},
-		"response_format": map[string]string{
-			"type": "json_object",
-		},
-	}
-
-	jsonData, err := json.Marshal(requestBody)
-	if err != nil {
-		return nil, fmt.Errorf("error creating request JSON: %v", err)
-	}
-
-	payload := bytes.NewBuffer(jsonData)
+	1. title: An attractive SEO-optimized title (written %[3]s)
+	2. description: An engaging description up to 250 characters (written %[3]s)
+	3. tags: List of up to 10 relevant tags (without the # symbol, written %[3]s)
+	4. hashtags: List of 5 popular hashtags (including the # symbol, written %[3]s)`, subtitleContent, videoTitle, outputLanguage)
 
-	maxRetries := 3
 	var metadata VideoMetadata
-	var respBody []byte
-	var statusCode int
-
-	for attempt := 0; attempt < maxRetries; attempt++ {
-		if attempt > 0 {
-			backoffDuration := time.Duration(2<<uint(attempt-1)) * time.Second
-			time.Sleep(backoffDuration)
-		}
-
-		client := &http.Client{
-			Timeout: 60 * time.Second,
-		}
-		req, err := http.NewRequest(method, url, payload)
-		if err != nil {
-			continue
-		}
-		req.Header.Add("Content-Type", "application/json")
-		req.Header.Add("Authorization", "Bearer "+config.GetOpenAIKey())
-
-		res, err := client.Do(req)
-		if err != nil {
-			continue
-		}
-
-		statusCode = res.StatusCode
-
-		respBody, err = io.ReadAll(res.Body)
-		res.Body.Close()
-		if err != nil {
-			continue
-		}
-
-		if statusCode != http.StatusOK {
-			continue
-		}
-
-		var apiResponse struct {
-			Choices []struct {
-				Message struct {
-					Content string `json:"content"`
-				} `json:"message"`
-			} `json:"choices"`
-		}
-
-		if err := json.Unmarshal(respBody, &apiResponse); err != nil {
-			continue
-		}
-
-		if len(apiResponse.Choices) == 0 {
-			continue
+	err := c.chatCompletion(ctx, purposeMetadata, 60*time.Second, systemPrompt, userPrompt, func(content string) error {
+		if err := json.Unmarshal([]byte(content), &metadata); err != nil {
+			return err
 		}
-
-		if err := json.Unmarshal([]byte(apiResponse.Choices[0].Message.Content), &metadata); err != nil {
-			continue
+		if metadata.Title == "" {
+			return fmt.Errorf("metadata has no title")
 		}
-
-		break
-	}
-
-	if statusCode != http.StatusOK {
-		return nil, fmt.Errorf("API error: status code %d", statusCode)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return &metadata, nil
 }
 
-// UploadToYouTube uploads a video to YouTube using saved credentials
-func UploadToYouTube(videoPath, title, description string, tags []string, privacy string) error {
-	// Get authentication token
-	token, err := auth.GetClient()
-	if err != nil {
-		return fmt.Errorf("error getting authentication token: %v", err)
-	}
-
-	ctx := context.Background()
-	tokenSource := oauth2.StaticTokenSource(token)
-	client := oauth2.NewClient(ctx, tokenSource)
-
-	// Create YouTube service
-	service, err := youtube.NewService(ctx, option.WithHTTPClient(client))
+// UploadToYouTube uploads a video to YouTube using saved credentials and
+// returns its YouTube video ID. language is the language code of the title
+// and description, and localizations their translations by language code;
+// both may be empty. With publishAt, an RFC 3339 time, the video is uploaded
+// as private and goes public at that time. Quota and rate limit rejections
+// are reported as ErrUploadQuota.
+func (c *Client) UploadToYouTube(ctx context.Context, videoPath, title, description string, tags []string, privacy string, language string, localizations map[string]Localization, publishAt string) (string, error) {
+	service, err := c.youtubeService(ctx)
 	if err != nil {
-		return fmt.Errorf("error creating YouTube service: %v", err)
+		return "", err
 	}
 
 	// Open video file
 	file, err := os.Open(videoPath)
 	if err != nil {
-		return fmt.Errorf("error opening video file: %v", err)
+		return "", newError(ErrUploadFailed, "", "", err)
 	}
 	defer file.Close()
 
@@ -989,6 +1926,10 @@ func UploadToYouTube(videoPath, title, description string, tags []string, privac
 	if privacy == "" {
 		privacy = "unlisted"
 	}
+	// Scheduled videos must stay private until they are published
+	if publishAt != "" {
+		privacy = "private"
+	}
 
 	// Configure video metadata
 	upload := &youtube.Video{
@@ -1000,17 +1941,65 @@ func UploadToYouTube(videoPath, title, description string, tags []string, privac
 		},
 		Status: &youtube.VideoStatus{
 			PrivacyStatus: privacy,
+			PublishAt:     publishAt,
 		},
 	}
 
+	parts := []string{"snippet", "status"}
+	if language != "" {
+		upload.Snippet.DefaultLanguage = language
+	}
+	if language != "" && len(localizations) > 0 {
+		upload.Localizations = make(map[string]youtube.VideoLocalization, len(localizations))
+		for code, localization := range localizations {
+			upload.Localizations[code] = youtube.VideoLocalization{Title: localization.Title, Description: localization.Description}
+		}
+		parts = append(parts, "localizations")
+	}
+
 	// Execute upload
-	call := service.Videos.Insert([]string{"snippet", "status"}, upload)
+	call := service.Videos.Insert(parts, upload)
 	call = call.Media(file)
-	_, err = call.Do()
+	video, err := call.Context(ctx).Do()
 	if err != nil {
-		return fmt.Errorf("error uploading video: %v", err)
+		return "", classifyUploadError(err)
 	}
 
 	log.Printf("Video '%s' successfully uploaded to YouTube", title)
-	return nil
+	return video.Id, nil
+}
+
+// youtubeService creates a YouTube Data API client authenticated with the saved token.
+func (c *Client) youtubeService(ctx context.Context) (*youtube.Service, error) {
+	token, err := auth.GetClient(c.Config)
+	if err != nil {
+		return nil, newError(ErrAuth, "", "", err)
+	}
+
+	tokenSource := oauth2.StaticTokenSource(token)
+	client := oauth2.NewClient(ctx, tokenSource)
+
+	service, err := youtube.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("error creating YouTube service: %v", err)
+	}
+
+	return service, nil
+}
+
+// classifyUploadError maps YouTube API errors to the pipeline failure kinds.
+func classifyUploadError(err error) error {
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) {
+		for _, item := range apiErr.Errors {
+			switch item.Reason {
+			case "quotaExceeded", "uploadLimitExceeded", "rateLimitExceeded", "userRateLimitExceeded":
+				return newError(ErrUploadQuota, "", "", err)
+			}
+		}
+		if apiErr.Code == http.StatusUnauthorized {
+			return newError(ErrAuth, "", "", err)
+		}
+	}
+	return newError(ErrUploadFailed, "", "", err)
 }
@@ -0,0 +1,215 @@
+package videos
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rogersilvasouza/godeogoker/internal/config"
+)
+
+// manifestFile is the name of the file listing the artifacts of a video, for
+// the tools cleaning up or archiving the outputs.
+const manifestFile = "manifest.json"
+
+// Types of the artifacts listed in a manifest.
+const (
+	ArtifactSource     = "source"     // Downloaded video
+	ArtifactCaptions   = "captions"   // Captions of the video or subtitles of a clip
+	ArtifactClip       = "clip"       // Horizontal clip of a cut
+	ArtifactHorizontal = "horizontal" // Clip composed over the horizontal base video
+	ArtifactVertical   = "vertical"   // Vertical version of a clip
+	ArtifactCover      = "cover"      // Cover image of a clip
+	ArtifactMetadata   = "metadata"   // Generated title, description and tags of a clip
+	ArtifactEdit       = "edit"       // Manual edits of the metadata of a clip
+	ArtifactCommunity  = "community"  // Community post teaser of an uploaded clip
+	ArtifactLog        = "log"        // Debug log of the runs
+	ArtifactData       = "data"       // Details, cuts and the other files the pipeline reuses
+	ArtifactOther      = "other"      // Any other file of the video folder
+)
+
+// Upload statuses of the artifacts listed in a manifest.
+const (
+	UploadUploaded = "uploaded" // Uploaded to YouTube
+	UploadSpooled  = "spooled"  // Waiting in the upload spool for another attempt
+	UploadReview   = "review"   // Held back by the quality gate until approved
+)
+
+// manifestMu serializes the updates of the manifests.
+var manifestMu sync.Mutex
+
+// Manifest lists the artifacts produced for a video, written to manifest.json
+// in its folder after every run.
+type Manifest struct {
+	Channel   string     `json:"channel"`   // Configured channel ID
+	VideoID   string     `json:"video_id"`  // Source video ID
+	Completed bool       `json:"completed"` // Whether the video went through every stage
+	Updated   time.Time  `json:"updated"`   // When the manifest was written
+	Artifacts []Artifact `json:"artifacts"` // Files of the video folder, by path
+}
+
+// Artifact is a file produced for a video.
+type Artifact struct {
+	Type      string    `json:"type"`                 // One of the Artifact types
+	Path      string    `json:"path"`                 // Slash-separated path relative to the video folder
+	Size      int64     `json:"size"`                 // Size in bytes
+	Modified  time.Time `json:"modified"`             // Last modification of the file
+	Checksum  string    `json:"checksum"`             // SHA-256 of the content as sha256:{hex}
+	Settings  string    `json:"settings,omitempty"`   // Hash of the settings it was produced with, when recorded
+	Upload    string    `json:"upload,omitempty"`     // Upload status of a clip, empty when not uploaded
+	YouTubeID string    `json:"youtube_id,omitempty"` // YouTube video ID once uploaded
+}
+
+// LoadManifest returns the manifest of the video in outputDir.
+func LoadManifest(outputDir string) (*Manifest, error) {
+	content, err := os.ReadFile(filepath.Join(outputDir, manifestFile))
+	if err != nil {
+		return nil, err
+	}
+	var manifest Manifest
+	if err := json.Unmarshal(content, &manifest); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %v", filepath.Join(outputDir, manifestFile), err)
+	}
+	return &manifest, nil
+}
+
+// writeManifest lists the artifacts of videoID of channel in the manifest of
+// outputDir. The checksums of the files unchanged since the last manifest are
+// reused, so the source video is only read once.
+func (c *Client) writeManifest(channel config.Channel, outputDir string, videoID string) {
+	manifestMu.Lock()
+	defer manifestMu.Unlock()
+
+	previous := make(map[string]Artifact)
+	if manifest, err := LoadManifest(outputDir); err == nil {
+		for _, artifact := range manifest.Artifacts {
+			previous[artifact.Path] = artifact
+		}
+	}
+
+	var spooled, reviews []string
+	if c.State != nil {
+		for _, entry := range c.State.Spooled(channel.ID) {
+			spooled = append(spooled, entry.Key)
+		}
+		for _, review := range c.State.Reviews(channel.ID) {
+			if !review.Approved {
+				reviews = append(reviews, review.Key)
+			}
+		}
+	}
+
+	settings := loadSettings(outputDir)
+	manifest := Manifest{Channel: channel.ID, VideoID: videoID, Completed: !isResumable(outputDir), Updated: time.Now()}
+	err := filepath.WalkDir(outputDir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		name := settingsName(outputDir, path)
+		if name == manifestFile || name == resumableMarker || strings.Contains(entry.Name(), tempMarker+".") {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		artifact := Artifact{
+			Type:     artifactType(name, videoID),
+			Path:     name,
+			Size:     info.Size(),
+			Modified: info.ModTime().UTC(),
+			Settings: settings[name],
+		}
+		if last, ok := previous[name]; ok && last.Size == artifact.Size && last.Modified.Equal(artifact.Modified) {
+			artifact.Checksum = last.Checksum
+		} else if artifact.Checksum, err = fileChecksum(path); err != nil {
+			return err
+		}
+
+		key := OutputKey(channel.Folder, path)
+		switch {
+		case artifact.Type != ArtifactHorizontal && artifact.Type != ArtifactVertical && artifact.Type != ArtifactClip:
+		case slices.Contains(spooled, key):
+			artifact.Upload = UploadSpooled
+		case slices.Contains(reviews, key):
+			artifact.Upload = UploadReview
+		case c.State != nil:
+			if _, youtubeID, ok := c.State.Upload(channel.ID, key); ok {
+				artifact.Upload, artifact.YouTubeID = UploadUploaded, youtubeID
+			}
+		}
+		manifest.Artifacts = append(manifest.Artifacts, artifact)
+		return nil
+	})
+	if err != nil {
+		fmt.Println(errorStyle.Render("Error listing artifacts: " + err.Error()))
+		return
+	}
+
+	content, _ := json.MarshalIndent(manifest, "", "  ")
+	if err := writeFileAtomic(filepath.Join(outputDir, manifestFile), content, 0644); err != nil {
+		fmt.Println(errorStyle.Render("Error writing manifest: " + err.Error()))
+	}
+}
+
+// artifactType returns the type of the artifact name of the folder of videoID.
+func artifactType(name string, videoID string) string {
+	folder, file := "", name
+	if i := strings.LastIndex(name, "/"); i != -1 {
+		folder, file = name[:i], name[i+1:]
+	}
+	ext := strings.ToLower(filepath.Ext(file))
+	isVideo := slices.Contains(videoExtensions, ext)
+
+	switch {
+	case strings.HasSuffix(file, communitySuffix):
+		return ArtifactCommunity
+	case strings.HasSuffix(file, editSuffix):
+		return ArtifactEdit
+	case ext == ".srt" || ext == ".vtt" || ext == ".ass":
+		return ArtifactCaptions
+	case folder == "horizontal" && ext == ".json":
+		return ArtifactMetadata
+	case folder == "horizontal" && isVideo:
+		return ArtifactClip
+	case folder == "horizontal-yt" && isVideo:
+		return ArtifactHorizontal
+	case folder == "vertical" && isVideo:
+		return ArtifactVertical
+	case folder == "covers":
+		return ArtifactCover
+	case folder == "" && isVideo && strings.HasPrefix(file, videoID):
+		return ArtifactSource
+	case folder == "" && file == debugLogFile:
+		return ArtifactLog
+	case folder == "" && (ext == ".json" || ext == ".txt"):
+		return ArtifactData
+	default:
+		return ArtifactOther
+	}
+}
+
+// fileChecksum returns the SHA-256 of the content of path as sha256:{hex}.
+func fileChecksum(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return "sha256:" + hex.EncodeToString(hash.Sum(nil)), nil
+}
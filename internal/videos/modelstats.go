@@ -0,0 +1,76 @@
+package videos
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// ModelStats counts the language model requests of a run and their failed
+// attempts by error code, for the summary printed once the run ends. The
+// zero value is ready to use and a nil *ModelStats counts nothing.
+type ModelStats struct {
+	mu       sync.Mutex
+	requests int            // Requests sent, each made of one or more attempts
+	failed   int            // Requests whose every attempt failed
+	attempts map[string]int // Failed attempts by error code
+}
+
+// request counts a request about to be sent.
+func (s *ModelStats) request() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests++
+}
+
+// attemptFailed counts an attempt that failed with the error code.
+func (s *ModelStats) attemptFailed(code string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.attempts == nil {
+		s.attempts = make(map[string]int)
+	}
+	s.attempts[code]++
+}
+
+// requestFailed counts a request given up after its last attempt.
+func (s *ModelStats) requestFailed() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failed++
+}
+
+// Summary describes the requests of the run in a line, e.g. `12 language
+// model requests, 1 failed; failed attempts: llm_parse 1, llm_rate_limit 2`.
+// It is empty when no request was sent.
+func (s *ModelStats) Summary() string {
+	if s == nil {
+		return ""
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.requests == 0 {
+		return ""
+	}
+
+	summary := fmt.Sprintf("%d language model requests, %d failed", s.requests, s.failed)
+	if len(s.attempts) > 0 {
+		var counts []string
+		for _, code := range slices.Sorted(maps.Keys(s.attempts)) {
+			counts = append(counts, fmt.Sprintf("%s %d", code, s.attempts[code]))
+		}
+		summary += "; failed attempts: " + strings.Join(counts, ", ")
+	}
+	return summary
+}
@@ -0,0 +1,173 @@
+package videos
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Low-energy passages are stretches quieter than silenceNoise lasting at
+// least silenceMinDuration seconds.
+const (
+	silenceNoise       = "-30dB"
+	silenceMinDuration = 0.6
+)
+
+// Span is a time range in seconds with sub-second precision.
+type Span struct {
+	Start float64 `json:"start"` // Start time in seconds
+	End   float64 `json:"end"`   // End time in seconds
+}
+
+var (
+	silenceStartPattern = regexp.MustCompile(`silence_start: (-?[\d.]+)`)
+	silenceEndPattern   = regexp.MustCompile(`silence_end: ([\d.]+)`)
+)
+
+// Silences runs silencedetect over the [begin, end] seconds range of input and
+// returns the quiet passages relative to begin.
+func (r *FFmpegRenderer) Silences(ctx context.Context, input string, begin, end int) ([]Span, error) {
+	ctx, cancel := withTimeout(ctx, r.Timeout, "ffmpeg")
+	defer cancel()
+
+	output, err := newCommand(
+		ctx,
+		r.FFmpeg,
+		"-ss", strconv.Itoa(begin),
+		"-t", strconv.Itoa(end-begin),
+		"-i", input,
+		"-vn",
+		"-af", fmt.Sprintf("silencedetect=noise=%s:d=%g", silenceNoise, silenceMinDuration),
+		"-f", "null",
+		"-",
+	).CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("error detecting silences: %v", timeoutCause(ctx, err))
+	}
+
+	var spans []Span
+	start := -1.0
+	for _, line := range strings.Split(string(output), "\n") {
+		if m := silenceStartPattern.FindStringSubmatch(line); m != nil {
+			start, _ = strconv.ParseFloat(m[1], 64)
+			start = max(start, 0)
+		} else if m := silenceEndPattern.FindStringSubmatch(line); m != nil && start >= 0 {
+			stop, _ := strconv.ParseFloat(m[1], 64)
+			spans = append(spans, Span{Start: start, End: stop})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		spans = append(spans, Span{Start: start, End: float64(end - begin)})
+	}
+
+	return spans, nil
+}
+
+// atempo returns the audio filter changing the tempo by factor without
+// changing the pitch. atempo accepts at most 2, so larger factors are chained.
+func atempo(factor float64) string {
+	var filters []string
+	for factor > 2 {
+		filters = append(filters, "atempo=2")
+		factor /= 2
+	}
+	filters = append(filters, fmt.Sprintf("atempo=%.4f", factor))
+	return strings.Join(filters, ",")
+}
+
+// Pace speeds up the spans of input by factor, keeping the pitch of the audio,
+// and plays the rest at normal speed.
+func (r *FFmpegRenderer) Pace(ctx context.Context, input string, spans []Span, factor float64, output string) error {
+	duration, err := r.Duration(ctx, input)
+	if err != nil {
+		return err
+	}
+
+	var filters, streams []string
+	add := func(start, end float64, fast bool) {
+		if end-start < 0.05 {
+			return
+		}
+		i := len(streams)
+		video := fmt.Sprintf("[0:v]trim=start=%.3f:end=%.3f,setpts=PTS-STARTPTS", start, end)
+		audio := fmt.Sprintf("[0:a]atrim=start=%.3f:end=%.3f,asetpts=PTS-STARTPTS", start, end)
+		if fast {
+			video += fmt.Sprintf(",setpts=PTS/%.4f", factor)
+			audio += "," + atempo(factor)
+		}
+		filters = append(filters, fmt.Sprintf("%s[v%d]", video, i), fmt.Sprintf("%s[a%d]", audio, i))
+		streams = append(streams, fmt.Sprintf("[v%d][a%d]", i, i))
+	}
+
+	position := 0.0
+	for _, span := range spans {
+		add(position, span.Start, false)
+		add(span.Start, min(span.End, duration), true)
+		position = min(span.End, duration)
+	}
+	add(position, duration, false)
+
+	filters = append(filters, fmt.Sprintf("%sconcat=n=%d:v=1:a=1[outv][outa]", strings.Join(streams, ""), len(streams)))
+
+	args := []string{
+		"-i", input,
+		"-filter_complex", strings.Join(filters, ";"),
+		"-map", "[outv]",
+		"-map", "[outa]",
+	}
+	args = append(args, r.encode(r.Clip)...)
+	args = append(args, r.Clip.args()...)
+
+	return r.render(ctx, output, args...)
+}
+
+// lowEnergy returns the quiet passages of cut, relative to its start, that
+// the pacing option of the channel speeds up. It returns nil when pacing is
+// disabled or detection failed, leaving the clip at normal speed.
+func (p *pipeline) lowEnergy(ctx context.Context, videoID string, videoFileName string, cut Cut) []Span {
+	if p.channel.Pacing <= 1 {
+		return nil
+	}
+
+	spans, err := p.renderer.Silences(ctx, videoFileName, cut.Begin, cut.End)
+	if err != nil {
+		p.fail(newError(ErrRenderFailed, videoID, cut.Title, err))
+		return nil
+	}
+
+	if len(spans) > 0 {
+		fmt.Println(subtitleStyle.Render(fmt.Sprintf("Speeding up %d quiet passages", len(spans))))
+	}
+	return spans
+}
+
+// pace speeds up the quiet passages of cut in clip, replacing the file.
+func (p *pipeline) pace(ctx context.Context, clip string, cut Cut) error {
+	ext := filepath.Ext(clip)
+	paced := strings.TrimSuffix(clip, ext) + "_paced" + ext
+	if err := p.renderer.Pace(ctx, clip, cut.Pace, p.channel.Pacing, paced); err != nil {
+		os.Remove(paced)
+		return err
+	}
+	return os.Rename(paced, clip)
+}
+
+// pacedTime maps t, relative to the start of cut, to the time it is played at
+// once the quiet passages of cut are sped up by factor.
+func pacedTime(t time.Duration, spans []Span, factor float64) time.Duration {
+	seconds := t.Seconds()
+	saved := 0.0
+	for _, span := range spans {
+		if span.Start >= seconds {
+			break
+		}
+		saved += (min(span.End, seconds) - span.Start) * (1 - 1/factor)
+	}
+	return t - time.Duration(saved*float64(time.Second))
+}
@@ -0,0 +1,263 @@
+package videos
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rogersilvasouza/godeogoker/internal/config"
+	"github.com/rogersilvasouza/godeogoker/internal/state"
+)
+
+// fakeDownloader writes a placeholder video with Portuguese captions, after
+// failing the first fetches of the videos listed in failures.
+type fakeDownloader struct {
+	failures map[string]int     // Fetches of each video that fail before one succeeds, -1 for all
+	err      error              // Error of the failed fetches
+	cancel   context.CancelFunc // Called by every fetch when set, to interrupt the run
+	fetches  map[string]int     // Fetches of each video
+}
+
+func (d *fakeDownloader) Fetch(ctx context.Context, video Video, outputDir string) (*Media, error) {
+	if d.fetches == nil {
+		d.fetches = make(map[string]int)
+	}
+	d.fetches[video.ID]++
+
+	if d.cancel != nil {
+		d.cancel()
+		return nil, newError(ErrDownloadFailed, video.ID, "", ctx.Err())
+	}
+	if failures := d.failures[video.ID]; failures < 0 || d.fetches[video.ID] <= failures {
+		return nil, newError(ErrDownloadFailed, video.ID, "", d.err)
+	}
+
+	media := mediaFiles(video.ID, outputDir)
+	if err := os.WriteFile(media.VideoFile, []byte("video"), 0644); err != nil {
+		return nil, err
+	}
+	captions := "WEBVTT\n\n00:00:01.000 --> 00:00:30.000\nBom dia\n\n"
+	if err := os.WriteFile(captionsPath(media.SubtitleFile, "pt"), []byte(captions), 0644); err != nil {
+		return nil, err
+	}
+	return media, nil
+}
+
+// fakeRenderer fails to measure videos. Its other methods are left to the
+// nil Renderer it embeds, so a test reaching them panics.
+type fakeRenderer struct {
+	Renderer
+	durations int // Calls of Duration
+}
+
+func (r *fakeRenderer) Duration(ctx context.Context, input string) (float64, error) {
+	r.durations++
+	return 0, errors.New("moov atom not found")
+}
+
+// fakeDetector finds the same cuts in every video.
+type fakeDetector struct {
+	cuts []Cut
+}
+
+func (d *fakeDetector) Detect(ctx context.Context, source CutSource) ([]Cut, error) {
+	return d.cuts, nil
+}
+
+// testPipeline returns a pipeline of a channel in a temporary folder, with
+// its state in another, downloading with downloader, rendering with renderer
+// and finding no cuts.
+func testPipeline(t *testing.T, downloader Downloader, renderer Renderer) *pipeline {
+	t.Helper()
+	store, err := state.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := NewClient(&config.Config{}, &fakeDoer{})
+	client.State = store
+
+	return &pipeline{
+		client:     client,
+		channel:    config.Channel{ID: "channel", Name: "Channel", Folder: t.TempDir()},
+		downloader: downloader,
+		renderer:   renderer,
+		detectors:  []CutDetector{&fakeDetector{}},
+	}
+}
+
+func TestProcessRetriesAFailedVideoOnce(t *testing.T) {
+	tests := []struct {
+		name     string
+		failures int
+		err      error
+		fetches  int
+		recovers bool
+	}{
+		{"succeeds on the retry", 1, errors.New("HTTP Error 503"), 2, true},
+		{"fails twice", -1, errors.New("HTTP Error 503"), 2, false},
+		{"authentication failure", -1, newError(ErrAuth, "", "", errors.New("sign in to confirm your age")), 1, false},
+		{"timeout", -1, newError(ErrTimeout, "", "", context.DeadlineExceeded), 1, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			downloader := &fakeDownloader{failures: map[string]int{"video": test.failures}, err: test.err}
+			p := testPipeline(t, downloader, &fakeRenderer{})
+
+			if !p.process(context.Background(), Video{ID: "video"}, StageOptions{}) {
+				t.Fatal("process asked to skip the remaining videos")
+			}
+			if downloader.fetches["video"] != test.fetches {
+				t.Errorf("fetches = %d, want %d", downloader.fetches["video"], test.fetches)
+			}
+
+			// A failed video is done with its failure recorded for the retry
+			// command, not left resumable.
+			if isResumable(filepath.Join(p.channel.Folder, "video")) {
+				t.Error("the video is still resumable")
+			}
+			failures := p.client.State.Failures("channel")
+			if test.recovers && len(failures) != 0 {
+				t.Errorf("failures = %+v, want those of the first attempt cleared", failures)
+			}
+			if !test.recovers && (len(failures) != 1 || failures[0].Stage != "download") {
+				t.Errorf("failures = %+v, want a failed download", failures)
+			}
+		})
+	}
+}
+
+func TestProcessRetriesFailedRenders(t *testing.T) {
+	renderer := &fakeRenderer{}
+	p := testPipeline(t, &fakeDownloader{}, renderer)
+	p.detectors = []CutDetector{&fakeDetector{cuts: []Cut{{Title: "Highlight", Begin: 1, End: 30}}}}
+
+	if !p.process(context.Background(), Video{ID: "video"}, StageOptions{}) {
+		t.Fatal("process asked to skip the remaining videos")
+	}
+
+	// The first run measures the video to find the cuts and to render them,
+	// the retry reuses the cuts it found.
+	if renderer.durations != 3 {
+		t.Errorf("durations = %d, want 3", renderer.durations)
+	}
+	failures := p.client.State.Failures("channel")
+	if len(failures) != 1 || failures[0].Stage != "render" || failures[0].Code != ErrRenderFailed.Code {
+		t.Errorf("failures = %+v, want a failed render", failures)
+	}
+	if p.lastFailure == nil || !errors.Is(p.lastFailure, ErrRenderFailed) {
+		t.Errorf("last failure = %v", p.lastFailure)
+	}
+}
+
+func TestErrorBudgetStopsTheChannel(t *testing.T) {
+	downloader := &fakeDownloader{failures: map[string]int{"a": -1, "b": -1, "d": -1, "e": -1}, err: errors.New("HTTP Error 403")}
+	p := testPipeline(t, downloader, &fakeRenderer{})
+	p.errorBudget = &ErrorBudget{MaxConsecutive: 2}
+
+	// The success of c starts the count of consecutive failures over.
+	for _, videoID := range []string{"a", "b", "c", "d", "e"} {
+		wantSkip := videoID == "b" || videoID == "e"
+		if p.process(context.Background(), Video{ID: videoID}, StageOptions{}) == wantSkip {
+			t.Fatalf("video %s: process returned %v", videoID, !wantSkip)
+		}
+		if wantSkip {
+			if !errors.Is(p.budgetSpent, ErrErrorBudget) {
+				t.Fatalf("video %s: budget error = %v", videoID, p.budgetSpent)
+			}
+			p.budgetSpent = nil
+		}
+	}
+
+	if failed := p.errorBudget.Failed(); failed != 4 {
+		t.Errorf("failed videos = %d, want 4", failed)
+	}
+	if downloader.fetches["a"] != 2 || downloader.fetches["c"] != 1 {
+		t.Errorf("fetches = %v, want failed videos fetched twice", downloader.fetches)
+	}
+}
+
+func TestErrorBudgetStopsTheRun(t *testing.T) {
+	budget := &ErrorBudget{MaxRun: 3}
+	downloader := &fakeDownloader{failures: map[string]int{"a": -1, "b": -1, "c": -1}, err: errors.New("HTTP Error 403")}
+
+	// Two channels of the same run share the budget.
+	first := testPipeline(t, downloader, &fakeRenderer{})
+	second := testPipeline(t, downloader, &fakeRenderer{})
+	second.channel.ID = "other"
+	first.errorBudget, second.errorBudget = budget, budget
+
+	if !first.process(context.Background(), Video{ID: "a"}, StageOptions{}) || !first.process(context.Background(), Video{ID: "b"}, StageOptions{}) {
+		t.Fatal("the first channel stopped before spending the budget")
+	}
+	if budget.RunSpent() {
+		t.Fatal("the run stopped after 2 failed videos")
+	}
+	if second.process(context.Background(), Video{ID: "c"}, StageOptions{}) {
+		t.Fatal("the second channel went on after the run spent its budget")
+	}
+	if !budget.RunSpent() || !errors.Is(second.budgetSpent, ErrErrorBudget) {
+		t.Errorf("run spent = %v, budget error = %v", budget.RunSpent(), second.budgetSpent)
+	}
+}
+
+func TestInterruptedVideoResumes(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	downloader := &fakeDownloader{cancel: cancel}
+	p := testPipeline(t, downloader, &fakeRenderer{})
+	outputDir := filepath.Join(p.channel.Folder, "video")
+
+	if p.process(ctx, Video{ID: "video"}, StageOptions{}) {
+		t.Fatal("an interrupted run asked to go on with the remaining videos")
+	}
+	if downloader.fetches["video"] != 1 {
+		t.Errorf("fetches = %d, want no retry of an interrupted video", downloader.fetches["video"])
+	}
+	if !isResumable(outputDir) {
+		t.Fatal("the interrupted video is not resumable")
+	}
+	leftover := filepath.Join(outputDir, "temp_cut.mp4")
+	if err := os.WriteFile(leftover, []byte("half a clip"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	downloader.cancel = nil
+	if !p.process(context.Background(), Video{ID: "video"}, StageOptions{}) {
+		t.Fatal("the resumed run asked to skip the remaining videos")
+	}
+	if isResumable(outputDir) {
+		t.Error("the resumed video is still resumable")
+	}
+	assertMissing(t, leftover)
+
+	if !p.process(context.Background(), Video{ID: "video"}, StageOptions{}) || downloader.fetches["video"] != 2 {
+		t.Errorf("fetches = %d, want the completed video skipped", downloader.fetches["video"])
+	}
+}
+
+func TestVideoStoppedBeforeTheLastStageStaysResumable(t *testing.T) {
+	downloader := &fakeDownloader{}
+	p := testPipeline(t, downloader, &fakeRenderer{})
+	outputDir := filepath.Join(p.channel.Folder, "video")
+
+	if !p.process(context.Background(), Video{ID: "video"}, StageOptions{Until: StageDownload}) {
+		t.Fatal("process asked to skip the remaining videos")
+	}
+	if !isResumable(outputDir) {
+		t.Error("the video stopped after the download is not resumable")
+	}
+	progress, _ := p.client.State.Progress("channel", "video")
+	if _, ok := progress.Stages[StageDownload]; !ok || !progress.Completed.IsZero() {
+		t.Errorf("progress = %+v, want only the download completed", progress)
+	}
+
+	if !p.process(context.Background(), Video{ID: "video"}, StageOptions{}) {
+		t.Fatal("the resumed run asked to skip the remaining videos")
+	}
+	if isResumable(outputDir) {
+		t.Error("the finished video is still resumable")
+	}
+}
@@ -0,0 +1,214 @@
+package videos
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/rogersilvasouza/godeogoker/internal/ai"
+	"github.com/rogersilvasouza/godeogoker/internal/config"
+)
+
+// Assumptions of the language model estimates of a dry run. Videos whose
+// captions were not downloaded yet are assumed to last planMinutes.
+const (
+	planMinutes         = 30  // Assumed length of a video without captions, in minutes
+	planTokensPerMinute = 400 // Caption tokens per minute of speech, timestamps included
+	planPromptTokens    = 700 // Tokens of the instructions of a prompt
+	planReplyTokens     = 250 // Tokens of the reply to a prompt
+	planCutReplyTokens  = 60  // Tokens of each cut in the reply of the cut prompt
+)
+
+// planEstimate is the estimated language model use of a dry run.
+type planEstimate struct {
+	Requests         int // Language model requests
+	PromptTokens     int // Tokens sent
+	CompletionTokens int // Tokens received
+}
+
+// add counts requests of prompt and reply tokens each.
+func (e *planEstimate) add(requests int, prompt int, reply int) {
+	e.Requests += requests
+	e.PromptTokens += requests * prompt
+	e.CompletionTokens += requests * reply
+}
+
+// PlanChannel prints what a run of channel with options would do, without
+// downloading, running external commands or calling the language model: the
+// latest videos of its feed, the stages each of them would go through and an
+// estimate of the language model requests, tokens and cost. Sources listed
+// with yt-dlp are not listed. It returns an error when the backends of the
+// channel cannot be configured or its feed cannot be fetched.
+func PlanChannel(ctx context.Context, client *Client, channel config.Channel, options StageOptions) error {
+	fmt.Println(titleStyle.Render("Planning channel: " + channel.Name))
+
+	p, err := newPipeline(ctx, client, channel)
+	if err != nil {
+		return fmt.Errorf("error configuring %v", err)
+	}
+	p.until = options.Until
+
+	listed := channel
+	listed.Sources = nil
+	for _, source := range channel.Sources {
+		if source.URL != "" {
+			fmt.Println(subtitleStyle.Render("Not listed by a dry run, yt-dlp lists it: " + source.URL))
+			continue
+		}
+		listed.Sources = append(listed.Sources, source)
+	}
+	latest, err := client.GetLastVideos(ctx, listed)
+	if err != nil {
+		return err
+	}
+
+	var total planEstimate
+	processed := 0
+	for _, video := range latest {
+		outputDir := filepath.Join(channel.Folder, video.ID)
+		stages, action := p.planStages(outputDir, video.ID, options)
+		fmt.Println(commandStyle.Render(fmt.Sprintf("%s: %s", video.ID, action)))
+		if len(stages) == 0 {
+			continue
+		}
+		processed++
+
+		fmt.Println(descriptionStyle.Render("  Stages: " + strings.Join(stages, ", ")))
+		estimate, assumed := p.planModel(outputDir, video, stages)
+		if estimate.Requests > 0 {
+			note := ""
+			if assumed {
+				note = fmt.Sprintf(", assuming a %d-minute video", planMinutes)
+			}
+			fmt.Println(descriptionStyle.Render(fmt.Sprintf("  About %d language model requests, %d prompt and %d completion tokens%s", estimate.Requests, estimate.PromptTokens, estimate.CompletionTokens, note)))
+		}
+		total.Requests += estimate.Requests
+		total.PromptTokens += estimate.PromptTokens
+		total.CompletionTokens += estimate.CompletionTokens
+	}
+
+	input, output := modelPrices(client.Config, channel)
+	cost := (float64(total.PromptTokens)*input + float64(total.CompletionTokens)*output) / 1e6
+	fmt.Println(successStyle.Render(fmt.Sprintf("Dry run of channel %s: %d of %d videos would be processed", channel.Name, processed, len(latest))))
+	if p.client.hasLanguageModel() {
+		fmt.Println(subtitleStyle.Render(fmt.Sprintf("About %d requests to %s, %d prompt and %d completion tokens, $%.4f", total.Requests, p.client.languageModel().Model(), total.PromptTokens, total.CompletionTokens, cost)))
+	} else {
+		fmt.Println(subtitleStyle.Render("No language model configured, no requests would be sent"))
+	}
+	return nil
+}
+
+// planStages returns the stages a run with options would go through for the
+// video in outputDir, in pipeline order, and what would happen to the video.
+// It returns no stages when the video would be skipped.
+func (p *pipeline) planStages(outputDir string, videoID string, options StageOptions) ([]string, string) {
+	stages := slices.Clone(PipelineStages)
+	action := "would be processed"
+
+	if options.Force {
+		if _, err := os.Stat(outputDir); err == nil {
+			action = "would be processed again from scratch"
+		}
+	} else {
+		started, done := p.progress(outputDir, videoID)
+		switch {
+		case done && len(options.Repeat) == 0:
+			return nil, "already processed, would be skipped"
+		case started:
+			action = "would be resumed"
+			if len(options.Repeat) > 0 {
+				action = "would repeat " + strings.Join(options.Repeat, ", ")
+			}
+			if p.client.State == nil {
+				break
+			}
+			if progress, ok := p.client.State.Progress(p.channel.ID, videoID); ok {
+				stages = slices.DeleteFunc(stages, func(stage string) bool {
+					_, completed := progress.Stages[stage]
+					return completed && !slices.Contains(options.Repeat, stage)
+				})
+			}
+		}
+	}
+
+	stages = slices.DeleteFunc(stages, func(stage string) bool {
+		switch {
+		case !p.runs(stage):
+			return true
+		case stage == StageTranscription:
+			return p.client.Config.Transcription.Provider == ""
+		case stage == StageUpload:
+			return !p.channel.UploadToYouTube
+		}
+		return false
+	})
+	if p.stopsEarly() {
+		action += ", stopping after the " + p.until + " stage"
+	}
+	return stages, action
+}
+
+// planModel estimates the language model requests of the video in outputDir
+// going through stages. It reports whether the length of the video was
+// assumed, its captions not being downloaded yet.
+func (p *pipeline) planModel(outputDir string, video Video, stages []string) (planEstimate, bool) {
+	var estimate planEstimate
+	if !p.client.hasLanguageModel() {
+		return estimate, false
+	}
+
+	if slices.Contains(stages, StageDownload) {
+		if _, err := os.Stat(filepath.Join(outputDir, detailsFile)); err != nil {
+			estimate.add(1, planPromptTokens/2+ai.EstimateTokens(video.Title+video.Description), planReplyTokens/4)
+		}
+	}
+
+	captionTokens, assumed := planMinutes*planTokensPerMinute, true
+	if content, err := os.ReadFile(p.media(video.ID, outputDir).Captions); err == nil {
+		captionTokens, assumed = ai.EstimateTokens(string(content)), false
+	}
+
+	cuts := max(p.channel.Excerpts, 1)
+	if slices.Contains(stages, StageCuts) && slices.ContainsFunc(p.detectors, func(detector CutDetector) bool {
+		_, ok := detector.(*LLMDetector)
+		return ok
+	}) {
+		requests := 1
+		if p.client.routeModel(captionTokens+planPromptTokens) == nil {
+			window := max(p.client.contextWindow()-ai.DefaultMaxTokens-planPromptTokens, minChunkTokens)
+			requests = (captionTokens + window - 1) / window
+		}
+		estimate.add(requests, planPromptTokens+captionTokens/requests, cuts*planCutReplyTokens/requests)
+	} else if content, err := os.ReadFile(filepath.Join(outputDir, cutsFile)); err == nil {
+		cached := make(map[string][]Cut) // Cuts keyed by captions file name, as cached by findCuts
+		if json.Unmarshal(content, &cached) == nil && len(cached) > 0 {
+			cuts = 0
+			for _, found := range cached {
+				cuts = max(cuts, len(found))
+			}
+		}
+	}
+
+	cutTokens := max(p.channel.StretchTime, 1) * planTokensPerMinute
+	if slices.Contains(stages, StageMetadata) {
+		estimate.add(cuts, planPromptTokens+cutTokens, planReplyTokens)
+		if p.channel.Hook != "" {
+			estimate.add(cuts, planPromptTokens+cutTokens, planReplyTokens/2)
+		}
+		if p.channel.PartLength > 0 {
+			estimate.add(cuts, planPromptTokens+cutTokens, planReplyTokens)
+		}
+		if translations := p.channel.Translations; translations != nil && len(translations.Languages) > 0 {
+			estimate.add(cuts, planPromptTokens+planReplyTokens, planReplyTokens*len(translations.Languages))
+		}
+	}
+	if slices.Contains(stages, StageUpload) && p.channel.Community != nil {
+		estimate.add(cuts, planPromptTokens+planReplyTokens, planReplyTokens/2)
+	}
+
+	return estimate, assumed
+}
@@ -0,0 +1,70 @@
+package videos
+
+import (
+	"cmp"
+	"context"
+	"os/exec"
+	"runtime"
+	"slices"
+	"strconv"
+
+	"github.com/rogersilvasouza/godeogoker/internal/config"
+)
+
+// ioClasses are the ionice numbers of the I/O scheduling classes.
+var ioClasses = map[string]string{
+	"realtime":    "1",
+	"best-effort": "2",
+	"idle":        "3",
+}
+
+// priorityKey is the context key of the priorities of the external tools.
+type priorityKey struct{}
+
+// withPriorities returns a context whose commands run with the priorities of
+// cfg: yt-dlp with the download one, ffmpeg and ffprobe with the render one
+// and whisper.cpp with the transcription one.
+func withPriorities(ctx context.Context, cfg *config.Config) context.Context {
+	priorities := cfg.Priorities
+	if priorities == (config.Priorities{}) {
+		return ctx
+	}
+	tools := map[string]config.Priority{
+		cfg.FFmpeg:  priorities.Render,
+		cfg.FFprobe: priorities.Render,
+		cmp.Or(cfg.Transcription.Binary, DefaultWhisperCpp): priorities.Transcription,
+		cfg.YtDlp: priorities.Download,
+	}
+	return context.WithValue(ctx, priorityKey{}, tools)
+}
+
+// prioritized returns the command line running name with args at the
+// priority ctx sets for name: prefixed with nice and, on Linux, ionice, which
+// both replace themselves with the command so signals still reach it. Tools
+// without a priority, and systems without nice or ionice, run as they are.
+func prioritized(ctx context.Context, name string, args []string) (string, []string) {
+	tools, _ := ctx.Value(priorityKey{}).(map[string]config.Priority)
+	priority, ok := tools[name]
+	if !ok || runtime.GOOS == "windows" {
+		return name, args
+	}
+
+	var prefix []string
+	if priority.Nice != 0 {
+		if nice, err := exec.LookPath("nice"); err == nil {
+			prefix = append(prefix, nice, "-n", strconv.Itoa(priority.Nice))
+		}
+	}
+	if class, ok := ioClasses[priority.IOClass]; ok && runtime.GOOS == "linux" {
+		if ionice, err := exec.LookPath("ionice"); err == nil {
+			prefix = append(prefix, ionice, "-c", class)
+			if priority.IOLevel > 0 && priority.IOClass != "idle" {
+				prefix = append(prefix, "-n", strconv.Itoa(priority.IOLevel))
+			}
+		}
+	}
+	if len(prefix) == 0 {
+		return name, args
+	}
+	return prefix[0], slices.Concat(prefix[1:], []string{name}, args)
+}
@@ -0,0 +1,193 @@
+package videos
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Stages of the pipeline recorded in the state store as a video goes through
+// them. A run can repeat some of them and stop after any of them.
+const (
+	StageDownload      = "download"
+	StageTranscription = "transcription"
+	StageCuts          = "cuts"
+	StageMetadata      = "metadata"
+	StageRender        = "render"
+	StageUpload        = "upload"
+)
+
+// PipelineStages lists the stages of the pipeline in the order they run.
+var PipelineStages = []string{StageDownload, StageTranscription, StageCuts, StageMetadata, StageRender, StageUpload}
+
+// renderFolders are the folders of a video holding rendered outputs.
+var renderFolders = []string{"horizontal", "vertical", "horizontal-yt", "covers"}
+
+// StageOptions selects the stages a run goes through. The zero value skips
+// the videos already processed and resumes the interrupted ones up to the
+// last stage.
+type StageOptions struct {
+	Force  bool     // Remove every output of processed videos first
+	Repeat []string // Repeat only these stages of processed videos, reusing the other outputs
+	Until  string   // Last stage run, leaving the video resumable for the later ones; empty to run them all
+}
+
+// ParseStages parses a comma-separated list of the stages of PipelineStages.
+func ParseStages(list string) ([]string, error) {
+	var stages []string
+	for _, stage := range strings.Split(list, ",") {
+		stage = strings.TrimSpace(stage)
+		if stage == "" {
+			continue
+		}
+		if !slices.Contains(PipelineStages, stage) {
+			return nil, fmt.Errorf("unknown stage %q, expected one of %s", stage, strings.Join(PipelineStages, ", "))
+		}
+		stages = appendUnique(stages, stage)
+	}
+	if len(stages) == 0 {
+		return nil, fmt.Errorf("no stage given, expected one of %s", strings.Join(PipelineStages, ", "))
+	}
+	return stages, nil
+}
+
+// RepeatStages returns the stages a run from the stage from to the stage
+// until repeats, both included and in pipeline order. Empty bounds stand for
+// the first and the last stage. The upload stage is only repeated when the
+// run starts at it: clips not uploaded yet are uploaded anyway, and repeating
+// it uploads the others again.
+func RepeatStages(from string, until string) ([]string, error) {
+	first, last := 0, len(PipelineStages)-1
+	if from != "" {
+		if first = slices.Index(PipelineStages, from); first == -1 {
+			return nil, fmt.Errorf("unknown stage %q, expected one of %s", from, strings.Join(PipelineStages, ", "))
+		}
+	}
+	if until != "" {
+		if last = slices.Index(PipelineStages, until); last == -1 {
+			return nil, fmt.Errorf("unknown stage %q, expected one of %s", until, strings.Join(PipelineStages, ", "))
+		}
+	}
+	if first > last {
+		return nil, fmt.Errorf("stage %s runs after %s", from, until)
+	}
+	stages := slices.Clone(PipelineStages[first : last+1])
+	if from != StageUpload {
+		stages = slices.DeleteFunc(stages, func(stage string) bool { return stage == StageUpload })
+	}
+	return stages, nil
+}
+
+// runs reports whether stage runs in this run, which stops after p.until.
+func (p *pipeline) runs(stage string) bool {
+	return p.until == "" || slices.Index(PipelineStages, stage) <= slices.Index(PipelineStages, p.until)
+}
+
+// stopsEarly reports whether this run stops before the last stage.
+func (p *pipeline) stopsEarly() bool {
+	return p.until != "" && p.until != PipelineStages[len(PipelineStages)-1]
+}
+
+// progress reports whether a run of videoID into outputDir started and
+// whether it finished. The state store knows, unless the video was processed
+// before it recorded progress; its output folder tells then. A video whose
+// folder was removed counts as never processed.
+func (p *pipeline) progress(outputDir string, videoID string) (started bool, done bool) {
+	if _, err := os.Stat(outputDir); err != nil {
+		return false, false
+	}
+	if p.client.State != nil {
+		if progress, ok := p.client.State.Progress(p.channel.ID, videoID); ok {
+			return true, !progress.Completed.IsZero()
+		}
+	}
+	return true, !isResumable(outputDir)
+}
+
+// completeStage records that stage of videoID completed, unless a failure of
+// the stage was recorded for the video.
+func (p *pipeline) completeStage(videoID string, stage string) {
+	store := p.client.State
+	if store == nil {
+		return
+	}
+	for _, failure := range store.Failures(p.channel.ID) {
+		if failure.VideoID == videoID && failure.Stage == stage {
+			return
+		}
+	}
+	if err := store.CompleteStage(p.channel.ID, videoID, stage); err != nil {
+		fmt.Println(errorStyle.Render("Error recording progress: " + err.Error()))
+	}
+}
+
+// resetStages removes the outputs of stages of videoID from outputDir, so
+// the next run produces them again while reusing the others. Captions are
+// only removed by the download stage when they were downloaded, and by the
+// transcription stage when they were transcribed. Repeating the upload stage
+// forgets the uploads of the video, uploading its clips again.
+func (p *pipeline) resetStages(outputDir string, videoID string, stages []string) {
+	transcribed := false
+	if p.client.State != nil {
+		progress, _ := p.client.State.Progress(p.channel.ID, videoID)
+		_, transcribed = progress.Stages[StageTranscription]
+	}
+
+	media := p.media(videoID, outputDir)
+	transcribed = transcribed || media.Language == transcribedCaptions
+	captions := media.Captions
+	var remove []string
+	for _, stage := range stages {
+		switch stage {
+		case StageDownload:
+			remove = append(remove, media.VideoFile)
+			if !transcribed {
+				remove = append(remove, captions)
+			}
+		case StageTranscription:
+			if transcribed {
+				remove = append(remove, captions)
+			} else {
+				fmt.Println(subtitleStyle.Render("The captions of the video were not transcribed, keeping them"))
+			}
+		case StageCuts:
+			remove = append(remove, filepath.Join(outputDir, cutsFile))
+		case StageMetadata:
+			matches, _ := filepath.Glob(filepath.Join(outputDir, "horizontal", "*.json"))
+			for _, match := range matches {
+				if !strings.HasSuffix(match, editSuffix) {
+					remove = append(remove, match)
+				}
+			}
+		case StageRender:
+			for _, folder := range renderFolders {
+				matches, _ := filepath.Glob(filepath.Join(outputDir, folder, "*"))
+				for _, match := range matches {
+					if filepath.Ext(match) != ".json" {
+						remove = append(remove, match)
+					}
+				}
+			}
+		case StageUpload:
+			if p.client.State != nil {
+				if err := p.client.State.ClearUploads(p.channel.ID, videoID+"/"); err != nil {
+					fmt.Println(errorStyle.Render("Error forgetting uploads: " + err.Error()))
+				}
+			}
+		}
+	}
+
+	for _, file := range remove {
+		if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+			fmt.Println(errorStyle.Render("Error removing output: " + err.Error()))
+		}
+	}
+
+	if p.client.State != nil {
+		if err := p.client.State.ResetStages(p.channel.ID, videoID, stages); err != nil {
+			fmt.Println(errorStyle.Render("Error recording progress: " + err.Error()))
+		}
+	}
+}
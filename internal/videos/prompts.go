@@ -0,0 +1,167 @@
+package videos
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/rogersilvasouza/godeogoker/internal/state"
+)
+
+// Purposes of the language model requests, recorded with their prompts.
+const (
+	purposeCuts         = "cuts"
+	purposeDetails      = "details"
+	purposeMetadata     = "metadata"
+	purposeHook         = "hook"
+	purposeCliffhangers = "cliffhangers"
+	purposeTranslations = "translations"
+	purposeCommunity    = "community"
+)
+
+// promptsFile is the file of a video folder its language model requests are
+// logged in.
+const promptsFile = "prompts.json"
+
+// Prompt is a language model request made for a video, kept so its result
+// can be reproduced or audited after the prompt templates change.
+type Prompt struct {
+	Cut        string            `json:"cut,omitempty"`        // Title of the cut, empty for video-level requests
+	Purpose    string            `json:"purpose"`              // What the request was for: cuts, details, metadata, hook, cliffhangers, translations or community
+	Model      string            `json:"model"`                // Model the request was sent to
+	Parameters map[string]string `json:"parameters,omitempty"` // Request parameters besides the model and the messages
+	System     string            `json:"system"`               // System prompt
+	User       string            `json:"user"`                 // User prompt
+	Response   string            `json:"response"`             // Content of the accepted response
+	Time       time.Time         `json:"time"`                 // When the response was received
+}
+
+// sameRequest reports whether p and other sent the same request for the same
+// step of a video.
+func (p Prompt) sameRequest(other Prompt) bool {
+	return p.Cut == other.Cut && p.Purpose == other.Purpose && p.Model == other.Model &&
+		p.System == other.System && p.User == other.User && maps.Equal(p.Parameters, other.Parameters)
+}
+
+// LoadPrompts returns the language model requests logged in path, in the
+// order they were first made, and none when path does not exist.
+func LoadPrompts(path string) ([]Prompt, error) {
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var prompts []Prompt
+	if err := json.Unmarshal(content, &prompts); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	return prompts, nil
+}
+
+// promptLogKey is the context key of the prompt log of the video being processed.
+type promptLogKey struct{}
+
+// promptLog records the language model requests made for a video, and for
+// one of its cuts when cut is set, in the prompts file of its folder. The
+// state store only keeps where that file is.
+type promptLog struct {
+	mu      *sync.Mutex
+	path    string
+	store   *state.Store
+	channel string
+	videoID string
+	cut     string
+}
+
+// withPromptLog returns a context whose language model requests are logged in
+// the prompts file of outputDir, the folder of videoID of channel. The file
+// is recorded in store when it is set.
+func withPromptLog(ctx context.Context, store *state.Store, channel string, videoID string, outputDir string) context.Context {
+	path, err := filepath.Abs(filepath.Join(outputDir, promptsFile))
+	if err != nil {
+		path = filepath.Join(outputDir, promptsFile)
+	}
+	return context.WithValue(ctx, promptLogKey{}, &promptLog{mu: &sync.Mutex{}, path: path, store: store, channel: channel, videoID: videoID})
+}
+
+// withPromptCut returns a context whose language model requests are recorded
+// for the cut titled cut of the video of ctx.
+func withPromptCut(ctx context.Context, cut string) context.Context {
+	log, _ := ctx.Value(promptLogKey{}).(*promptLog)
+	if log == nil {
+		return ctx
+	}
+	clone := *log
+	clone.cut = cut
+	return context.WithValue(ctx, promptLogKey{}, &clone)
+}
+
+// recordPrompt records the request sent for purpose and its accepted
+// response in the prompt log of ctx, if any. A request identical to one
+// already logged only updates its response and time, so the log keeps one
+// entry per version of the prompt.
+func recordPrompt(ctx context.Context, purpose string, model string, parameters map[string]string, systemPrompt string, userPrompt string, response string) {
+	log, _ := ctx.Value(promptLogKey{}).(*promptLog)
+	if log == nil {
+		return
+	}
+
+	prompt := Prompt{
+		Cut:        log.cut,
+		Purpose:    purpose,
+		Model:      model,
+		Parameters: parameters,
+		System:     systemPrompt,
+		User:       userPrompt,
+		Response:   response,
+		Time:       time.Now(),
+	}
+	if err := log.add(prompt); err != nil {
+		fmt.Println(errorStyle.Render("Error recording prompt: " + err.Error()))
+		return
+	}
+	if log.store != nil {
+		if err := log.store.SavePromptLog(log.channel, log.videoID, log.path); err != nil {
+			fmt.Println(errorStyle.Render("Error recording prompt: " + err.Error()))
+		}
+	}
+}
+
+// add writes prompt to the prompts file of log, replacing the entry of an
+// identical request.
+func (log *promptLog) add(prompt Prompt) error {
+	log.mu.Lock()
+	defer log.mu.Unlock()
+
+	prompts, err := LoadPrompts(log.path)
+	if err != nil {
+		return err
+	}
+
+	replaced := false
+	for i, recorded := range prompts {
+		if recorded.sameRequest(prompt) {
+			prompts[i].Response = prompt.Response
+			prompts[i].Time = prompt.Time
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		prompts = append(prompts, prompt)
+	}
+
+	content, err := json.MarshalIndent(prompts, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(log.path, content, 0644)
+}
@@ -0,0 +1,232 @@
+package videos
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	// The time zone database is embedded so channel timezones resolve on
+	// systems without one, such as Windows or minimal containers.
+	_ "time/tzdata"
+
+	"github.com/rogersilvasouza/godeogoker/internal/config"
+	"github.com/rogersilvasouza/godeogoker/internal/state"
+)
+
+// Defaults of the publish schedule of a channel.
+const (
+	DefaultPublishHour       = 18
+	DefaultPublishMinUploads = 20
+)
+
+const (
+	// publishLead is how long after an upload starts its publish time may
+	// be at the earliest, leaving YouTube the time to process it.
+	publishLead = 30 * time.Minute
+	// publishDays is how many days ahead free publish times are looked for.
+	publishDays = 30
+	// analyticsMaxAge is how long collected analytics are used before they
+	// are read again from YouTube.
+	analyticsMaxAge = 24 * time.Hour
+	// analyticsMinAge is how long an upload must be public before its views
+	// are counted, since the first days do not tell how it performs.
+	analyticsMinAge = 48 * time.Hour
+	// minHourUploads is how many uploads an hour needs before it is ranked,
+	// so a single lucky upload does not make its hour the best.
+	minHourUploads = 3
+)
+
+// channelLocation returns the time zone of channel, the local one when it
+// sets none.
+func channelLocation(channel config.Channel) (*time.Location, error) {
+	if channel.Timezone == "" {
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(channel.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("unknown timezone: %s", channel.Timezone)
+	}
+	return loc, nil
+}
+
+// validatePublish checks the publish hours of channel.
+func validatePublish(publish *config.Publish) error {
+	if publish == nil {
+		return nil
+	}
+	for _, hour := range publish.Hours {
+		if hour < 0 || hour > 23 {
+			return fmt.Errorf("hour %d out of range 0-23", hour)
+		}
+	}
+	if publish.BestHours < 0 || publish.BestHours > 24 {
+		return fmt.Errorf("best_hours must be between 0 and 24")
+	}
+	return nil
+}
+
+// schedulePublish sets the time details go public at when channel publishes
+// its uploads at set hours: the first free publish hour at least publishLead
+// from now. Publish times still ahead are kept, so a spooled upload keeps its
+// slot. With no free hour in the next publishDays days, the upload is left
+// unlisted.
+func (c *Client) schedulePublish(ctx context.Context, channel config.Channel, details *uploadDetails) {
+	if channel.Publish == nil {
+		return
+	}
+	earliest := time.Now().Add(publishLead)
+	if at, err := time.Parse(time.RFC3339, details.PublishAt); err == nil && at.After(earliest) {
+		return
+	}
+	details.PublishAt = ""
+
+	loc, err := channelLocation(channel)
+	if err != nil {
+		fmt.Println(errorStyle.Render("Error scheduling the upload: " + err.Error()))
+		return
+	}
+
+	hours := c.publishHours(ctx, channel, loc)
+	var candidates []time.Time
+	day := earliest.In(loc)
+	for i := 0; i <= publishDays; i++ {
+		for _, hour := range hours {
+			slot := time.Date(day.Year(), day.Month(), day.Day()+i, hour, 0, 0, 0, loc)
+			if slot.After(earliest) {
+				candidates = append(candidates, slot)
+			}
+		}
+	}
+
+	slot := candidates[0]
+	if c.State != nil {
+		slot, err = c.State.ReserveSlot(channel.ID, candidates)
+		if err != nil {
+			fmt.Println(errorStyle.Render("Error recording the publish time: " + err.Error()))
+		}
+		if slot.IsZero() {
+			fmt.Println(subtitleStyle.Render(fmt.Sprintf("No free publish hour in the next %d days, uploading as unlisted", publishDays)))
+			return
+		}
+	}
+
+	details.PublishAt = slot.UTC().Format(time.RFC3339)
+	fmt.Println(subtitleStyle.Render("Scheduled to go public at " + slot.Format("2006-01-02 15:04 MST")))
+}
+
+// publishHours returns the hours of the day, sorted, the uploads of channel
+// are published at: its best hours when it asks for them and enough uploads
+// have statistics, its configured hours otherwise. Analytics older than
+// analyticsMaxAge are collected again first.
+func (c *Client) publishHours(ctx context.Context, channel config.Channel, loc *time.Location) []int {
+	publish := channel.Publish
+	hours := slices.Sorted(slices.Values(publish.Hours))
+	if len(hours) == 0 {
+		hours = []int{DefaultPublishHour}
+	}
+	if publish.BestHours <= 0 || c.State == nil {
+		return slices.Compact(hours)
+	}
+
+	analytics := c.State.Analytics(channel.ID)
+	if time.Since(analytics.Updated) > analyticsMaxAge {
+		collected, err := c.collectAnalytics(ctx, channel, loc)
+		if err != nil {
+			fmt.Println(errorStyle.Render("Error collecting analytics: " + err.Error()))
+		} else {
+			analytics = collected
+			if err := c.State.SaveAnalytics(channel.ID, analytics); err != nil {
+				fmt.Println(errorStyle.Render("Error recording analytics: " + err.Error()))
+			}
+		}
+	}
+
+	minUploads := publish.MinUploads
+	if minUploads <= 0 {
+		minUploads = DefaultPublishMinUploads
+	}
+	best, uploads := bestHours(analytics, publish.BestHours, minUploads)
+	if best == nil {
+		fmt.Println(subtitleStyle.Render(fmt.Sprintf("Not enough public uploads with statistics to find the best hours (%d of %d), publishing at the configured hours", uploads, minUploads)))
+		return slices.Compact(hours)
+	}
+	return best
+}
+
+// bestHours returns the count hours of analytics with the most views per day,
+// among those with minHourUploads uploads, sorted by hour, and the number of
+// uploads the analytics cover. It returns nil hours when they cover fewer
+// than minUploads uploads.
+func bestHours(analytics state.Analytics, count int, minUploads int) ([]int, int) {
+	uploads := 0
+	var hours []int
+	for hour, stats := range analytics.Hours {
+		uploads += stats.Uploads
+		if stats.Uploads >= minHourUploads {
+			hours = append(hours, hour)
+		}
+	}
+	if uploads < minUploads || len(hours) == 0 {
+		return nil, uploads
+	}
+
+	sort.Slice(hours, func(i, j int) bool {
+		a, b := analytics.Hours[hours[i]], analytics.Hours[hours[j]]
+		if a.ViewsPerDay != b.ViewsPerDay {
+			return a.ViewsPerDay > b.ViewsPerDay
+		}
+		return hours[i] < hours[j]
+	})
+	hours = hours[:min(count, len(hours))]
+	sort.Ints(hours)
+	return hours, uploads
+}
+
+// collectAnalytics reads the views of the public uploads of channel from
+// YouTube and groups them by the hour of the day, in loc, they went public.
+// Uploads public for less than analyticsMinAge are left out.
+func (c *Client) collectAnalytics(ctx context.Context, channel config.Channel, loc *time.Location) (state.Analytics, error) {
+	ids := c.State.YouTubeIDs(channel.ID)
+	analytics := state.Analytics{Hours: make(map[int]state.HourStats), Updated: time.Now()}
+	if len(ids) == 0 {
+		return analytics, nil
+	}
+
+	fmt.Println(commandStyle.Render(fmt.Sprintf("Collecting the analytics of %d uploads from YouTube...", len(ids))))
+	service, err := c.youtubeService(ctx)
+	if err != nil {
+		return analytics, err
+	}
+
+	views := make(map[int]float64)
+	for start := 0; start < len(ids); start += statsBatch {
+		batch := ids[start:min(start+statsBatch, len(ids))]
+		response, err := service.Videos.List([]string{"snippet", "statistics", "status"}).Id(batch...).Context(ctx).Do()
+		if err != nil {
+			return analytics, fmt.Errorf("error reading video statistics: %v", err)
+		}
+		for _, video := range response.Items {
+			if video.Snippet == nil || video.Statistics == nil || video.Status == nil || video.Status.PrivacyStatus != "public" {
+				continue
+			}
+			published, err := time.Parse(time.RFC3339, video.Snippet.PublishedAt)
+			if err != nil || time.Since(published) < analyticsMinAge {
+				continue
+			}
+
+			hour := published.In(loc).Hour()
+			stats := analytics.Hours[hour]
+			stats.Uploads++
+			analytics.Hours[hour] = stats
+			views[hour] += float64(video.Statistics.ViewCount) / time.Since(published).Hours() * 24
+		}
+	}
+
+	for hour, stats := range analytics.Hours {
+		stats.ViewsPerDay = views[hour] / float64(stats.Uploads)
+		analytics.Hours[hour] = stats
+	}
+	return analytics, nil
+}
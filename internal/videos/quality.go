@@ -0,0 +1,164 @@
+package videos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rogersilvasouza/godeogoker/internal/config"
+	"github.com/rogersilvasouza/godeogoker/internal/state"
+)
+
+// defaultMaxDefect is the largest share of a clip that may be black or
+// frozen when the quality gate of the channel does not set one.
+const defaultMaxDefect = 0.5
+
+// Detection thresholds: frames darker than blackPixels on average for at
+// least blackMinDuration seconds are black, and frames changing less than
+// freezeNoise for at least freezeMinDuration seconds are frozen.
+const (
+	blackPixels       = "0.10"
+	blackMinDuration  = 0.5
+	freezeNoise       = "-60dB"
+	freezeMinDuration = 2.0
+)
+
+var (
+	blackDurationPattern = regexp.MustCompile(`black_duration:\s*([\d.]+)`)
+	freezeStartPattern   = regexp.MustCompile(`freeze_start:\s*([\d.]+)`)
+	freezeEndPattern     = regexp.MustCompile(`freeze_end:\s*([\d.]+)`)
+)
+
+// Defects is how much of a clip is black or frozen, in seconds.
+type Defects struct {
+	Duration float64 // Length of the clip
+	Black    float64 // Time spent on black frames
+	Frozen   float64 // Time spent on frozen frames
+}
+
+// problem describes why the clip fails gate, or returns an empty string when it passes.
+func (d *Defects) problem(gate config.QualityGate) string {
+	if d.Duration <= 0 {
+		return ""
+	}
+
+	maxBlack := gate.MaxBlack
+	if maxBlack <= 0 {
+		maxBlack = defaultMaxDefect
+	}
+	maxFrozen := gate.MaxFrozen
+	if maxFrozen <= 0 {
+		maxFrozen = defaultMaxDefect
+	}
+
+	if share := d.Black / d.Duration; share > maxBlack {
+		return fmt.Sprintf("%.0f%% of the clip is black", share*100)
+	}
+	if share := d.Frozen / d.Duration; share > maxFrozen {
+		return fmt.Sprintf("%.0f%% of the clip is frozen", share*100)
+	}
+	return ""
+}
+
+// Defects runs blackdetect and freezedetect over input.
+func (r *FFmpegRenderer) Defects(ctx context.Context, input string) (*Defects, error) {
+	duration, err := r.Duration(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := withTimeout(ctx, r.Timeout, "ffmpeg")
+	defer cancel()
+
+	output, err := newCommand(
+		ctx,
+		r.FFmpeg,
+		"-i", input,
+		"-an",
+		"-vf", fmt.Sprintf("blackdetect=d=%g:pix_th=%s,freezedetect=n=%s:d=%g", blackMinDuration, blackPixels, freezeNoise, freezeMinDuration),
+		"-f", "null",
+		"-",
+	).CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("error detecting black and frozen frames: %v", timeoutCause(ctx, err))
+	}
+
+	defects := &Defects{Duration: duration, Frozen: frozenTime(string(output), duration)}
+	for _, line := range strings.Split(string(output), "\n") {
+		if m := blackDurationPattern.FindStringSubmatch(line); m != nil {
+			black, _ := strconv.ParseFloat(m[1], 64)
+			defects.Black += black
+		}
+	}
+
+	return defects, nil
+}
+
+// frozenTime adds up the frozen stretches reported by freezedetect in output
+// for an input lasting duration seconds.
+func frozenTime(output string, duration float64) float64 {
+	var frozen float64
+	freezeStart := -1.0
+	for _, line := range strings.Split(output, "\n") {
+		if m := freezeStartPattern.FindStringSubmatch(line); m != nil {
+			freezeStart, _ = strconv.ParseFloat(m[1], 64)
+		} else if m := freezeEndPattern.FindStringSubmatch(line); m != nil && freezeStart >= 0 {
+			end, _ := strconv.ParseFloat(m[1], 64)
+			frozen += end - freezeStart
+			freezeStart = -1
+		}
+	}
+	// A freeze lasting until the end of the input is never closed.
+	if freezeStart >= 0 {
+		frozen += max(duration-freezeStart, 0)
+	}
+	return frozen
+}
+
+// passesQuality runs the quality gate of the channel on a clip before it is
+// uploaded. Clips mostly black or frozen, a symptom of broken downloads or
+// filters, are held back for manual review and reported as failures, unless
+// a review already approved them.
+func (p *pipeline) passesQuality(ctx context.Context, videoID string, cut Cut, path string) bool {
+	gate := p.channel.QualityGate
+	if gate == nil {
+		return true
+	}
+
+	key := p.outputKey(path)
+	store := p.client.State
+	if store != nil && store.Approved(p.channel.ID, key) {
+		return true
+	}
+
+	fmt.Println(commandStyle.Render("Checking for black and frozen frames..."))
+	defects, err := p.renderer.Defects(ctx, path)
+	if err != nil {
+		p.fail(newError(ErrQualityGate, videoID, cut.Title, err))
+		return false
+	}
+
+	// Still-image sources are frozen by design.
+	if p.still {
+		defects.Frozen = 0
+	}
+	reason := defects.problem(*gate)
+	if reason == "" {
+		return true
+	}
+
+	p.fail(newError(ErrQualityGate, videoID, cut.Title, errors.New(reason)))
+	if store != nil {
+		review := state.Review{Key: key, VideoID: videoID, Cut: cut.Title, Reason: reason, Time: time.Now()}
+		if err := store.AddReview(p.channel.ID, review); err != nil {
+			fmt.Println(errorStyle.Render("Error recording review: " + err.Error()))
+		}
+	}
+	fmt.Println(subtitleStyle.Render("Upload held back for review: " + filepath.Base(path)))
+	return false
+}
@@ -0,0 +1,169 @@
+package videos
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// reactionsFile is the name of the file caching the reactions heard in the
+// audio of a video inside its folder.
+const reactionsFile = "reactions.json"
+
+// Loud reactions are stretches at least loudReactionLevel LU above the median
+// loudness of the video lasting at least loudReactionDuration seconds.
+const (
+	loudReactionLevel    = 10.0
+	loudReactionDuration = 2
+)
+
+// maxReactions is how many reactions are passed to the cut prompt.
+const maxReactions = 30
+
+// Reaction is a moment the audience or the speakers react loudly, in seconds
+// of the source video.
+type Reaction struct {
+	Start int    `json:"start"` // Start time in seconds
+	End   int    `json:"end"`   // End time in seconds
+	Kind  string `json:"kind"`  // laughter, applause, shouting or loud
+}
+
+// reactionMarker matches the sound markers of auto-captions, such as
+// "[Laughter]", "[Risos]" or "[Aplausos]".
+var reactionMarker = regexp.MustCompile(`(?i)\[\s*(laughter|laughs|laughing|risos?|risadas?|applause|aplausos?|cheering|cheers|shouting|gritos?)\s*\]`)
+
+// reactionKinds maps the words of caption markers to reaction kinds.
+var reactionKinds = map[string]string{
+	"laughter": "laughter", "laughs": "laughter", "laughing": "laughter",
+	"riso": "laughter", "risos": "laughter", "risada": "laughter", "risadas": "laughter",
+	"applause": "applause", "aplauso": "applause", "aplausos": "applause",
+	"cheering": "applause", "cheers": "applause",
+	"shouting": "shouting", "grito": "shouting", "gritos": "shouting",
+}
+
+// reactionsFromCaptions returns the reactions marked in the captions.
+func reactionsFromCaptions(entries []SubtitleEntry) []Reaction {
+	var reactions []Reaction
+	for _, entry := range entries {
+		match := reactionMarker.FindStringSubmatch(entry.Text)
+		if match == nil {
+			continue
+		}
+
+		reaction := Reaction{
+			Start: int(entry.StartTime.Seconds()),
+			End:   int(math.Ceil(entry.EndTime.Seconds())),
+			Kind:  reactionKinds[strings.ToLower(match[1])],
+		}
+		// Rolling auto-captions repeat a marker over several entries.
+		if n := len(reactions); n > 0 && reactions[n-1].Kind == reaction.Kind && reaction.Start <= reactions[n-1].End {
+			reactions[n-1].End = max(reactions[n-1].End, reaction.End)
+			continue
+		}
+		reactions = append(reactions, reaction)
+	}
+	return reactions
+}
+
+// reactionsFromLoudness returns the stretches much louder than the rest of
+// the video, where laughter, applause or shouting drown out the speech.
+func reactionsFromLoudness(loudness []float64) []Reaction {
+	if len(loudness) == 0 {
+		return nil
+	}
+
+	sorted := append([]float64(nil), loudness...)
+	sort.Float64s(sorted)
+	threshold := sorted[len(sorted)/2] + loudReactionLevel
+
+	var reactions []Reaction
+	start := -1
+	for second := 0; second <= len(loudness); second++ {
+		if second < len(loudness) && loudness[second] >= threshold {
+			if start < 0 {
+				start = second
+			}
+			continue
+		}
+
+		if start >= 0 && second-start >= loudReactionDuration {
+			reactions = append(reactions, Reaction{Start: start, End: second, Kind: "loud"})
+		}
+		start = -1
+	}
+	return reactions
+}
+
+// loadReactions returns the reactions marked in the captions file and, when
+// videoFile is set, heard in its audio. The reactions heard are measured on
+// the first run and read from outputDir afterwards.
+func (p *pipeline) loadReactions(ctx context.Context, outputDir string, captions string, videoFile string) []Reaction {
+	var reactions []Reaction
+	if entries, err := parseVTTFile(captions); err == nil {
+		reactions = reactionsFromCaptions(entries)
+	}
+	if videoFile == "" {
+		return reactions
+	}
+
+	path := filepath.Join(outputDir, reactionsFile)
+	var heard []Reaction
+	if content, err := os.ReadFile(path); err == nil && json.Unmarshal(content, &heard) == nil {
+		return mergeReactions(reactions, heard)
+	}
+
+	fmt.Println(commandStyle.Render("Listening for laughter, applause and shouting..."))
+	loudness, err := p.renderer.Loudness(ctx, videoFile)
+	if err != nil {
+		fmt.Println(subtitleStyle.Render("Reactions unavailable: " + err.Error()))
+		return reactions
+	}
+
+	heard = reactionsFromLoudness(loudness)
+	if heard == nil {
+		heard = []Reaction{}
+	}
+	if content, err := json.Marshal(heard); err == nil {
+		writeFileAtomic(path, content, 0644)
+	}
+	return mergeReactions(reactions, heard)
+}
+
+// mergeReactions adds the reactions heard that no caption marker covers to
+// those marked, keeping at most maxReactions of them, in order of time.
+func mergeReactions(marked []Reaction, heard []Reaction) []Reaction {
+	reactions := marked
+	for _, reaction := range heard {
+		covered := false
+		for _, other := range marked {
+			if reaction.Start < other.End && other.Start < reaction.End {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			reactions = append(reactions, reaction)
+		}
+	}
+
+	sort.SliceStable(reactions, func(i, j int) bool {
+		return reactions[i].Start < reactions[j].Start
+	})
+	if len(reactions) > maxReactions {
+		// The longest reactions are the strongest ones.
+		sort.SliceStable(reactions, func(i, j int) bool {
+			return reactions[i].End-reactions[i].Start > reactions[j].End-reactions[j].Start
+		})
+		reactions = reactions[:maxReactions]
+		sort.SliceStable(reactions, func(i, j int) bool {
+			return reactions[i].Start < reactions[j].Start
+		})
+	}
+	return reactions
+}
@@ -0,0 +1,626 @@
+package videos
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rogersilvasouza/godeogoker/internal/config"
+)
+
+// overlayFilter loops a still background and places the clip scaled to
+// 1080px wide over it, centered horizontally and at y, followed by the tail
+// filters. Both are converted to rate frames per second when it is set, since
+// the overlay otherwise follows the rate of the background and drops frames
+// of faster clips unevenly.
+func overlayFilter(rate string, y string, tail string) string {
+	var background, clip string
+	if rate != "" {
+		background = ",fps=" + rate
+		clip = "fps=" + rate + ","
+	}
+	return fmt.Sprintf("[0:v]loop=loop=-1:size=1:start=0%s[loopbg];[1:v]%sscale=1080:-1[scaled];[loopbg][scaled]overlay=(W-w)/2:%s:shortest=1%s[outv]", background, clip, y, tail)
+}
+
+// videoCodecArgs are the encoder settings of each supported video codec,
+// tuned for fast encoding.
+var videoCodecArgs = map[string][]string{
+	"h264": {"-c:v", "libx264", "-preset", "ultrafast", "-tune", "fastdecode", "-crf", "28"},
+	"hevc": {"-c:v", "libx265", "-preset", "ultrafast", "-crf", "28", "-tag:v", "hvc1"},
+	"av1":  {"-c:v", "libsvtav1", "-preset", "10", "-crf", "35"},
+}
+
+// Format is the frame rate, audio sample rate, codec and container an output
+// is encoded with.
+type Format struct {
+	FPS        int    // Frames per second, 0 to keep the rate of the input
+	SampleRate int    // Audio sample rate in Hz, 0 to keep the rate of the input
+	Codec      string // Video codec: h264, hevc or av1, empty for h264
+	Container  string // File format: mp4 or webm, empty for mp4
+	Profile    string // Encoding profile, empty for the fast default settings of the codec
+}
+
+// set reports whether the format changes the rates or the encoding of the input.
+func (f Format) set() bool {
+	return f.FPS > 0 || f.SampleRate > 0 || f.Codec != "" || f.Container != "" || f.Profile != ""
+}
+
+// validate checks that the codec and container are supported and go together.
+func (f Format) validate() error {
+	if _, ok := videoCodecArgs[f.codec()]; !ok {
+		return fmt.Errorf("unknown codec: %s", f.Codec)
+	}
+	switch f.Container {
+	case "", "mp4":
+	case "webm":
+		if f.codec() != "av1" {
+			return fmt.Errorf("the webm container needs the av1 codec, not %s", f.codec())
+		}
+	default:
+		return fmt.Errorf("unknown container: %s", f.Container)
+	}
+	return nil
+}
+
+// codec returns the video codec of the format.
+func (f Format) codec() string {
+	if f.Codec == "" {
+		return "h264"
+	}
+	return f.Codec
+}
+
+// ext returns the file extension of the container of the format.
+func (f Format) ext() string {
+	if f.Container == "webm" {
+		return ".webm"
+	}
+	return ".mp4"
+}
+
+// encode returns the codec options of the format: its video codec, with Opus
+// audio in webm files and AAC audio otherwise.
+func (f Format) encode() []string {
+	args := append([]string{}, videoCodecArgs[f.codec()]...)
+	if f.Container == "webm" {
+		args = append(args, "-c:a", "libopus", "-b:a", "128k")
+	} else {
+		args = append(args, "-c:a", "aac")
+	}
+	return append(args, "-threads", "0")
+}
+
+// args returns the output options converting to the format.
+func (f Format) args() []string {
+	var args []string
+	if f.FPS > 0 {
+		args = append(args, "-r", strconv.Itoa(f.FPS))
+	}
+	if f.SampleRate > 0 {
+		args = append(args, "-ar", strconv.Itoa(f.SampleRate))
+	}
+	return args
+}
+
+// optionEscaper and graphEscaper implement the two escaping levels ffmpeg applies
+// to a filter option value: first when parsing the filter options, then when
+// parsing the filtergraph description. textEscaper adds the level drawtext
+// applies to its text, where % starts an expansion such as %{pts}.
+var (
+	textEscaper   = strings.NewReplacer(`\`, `\\`, `%`, `\%`)
+	optionEscaper = strings.NewReplacer(`\`, `\\`, `'`, `\'`, `:`, `\:`)
+	graphEscaper  = strings.NewReplacer(`\`, `\\`, `'`, `\'`, `[`, `\[`, `]`, `\]`, `,`, `\,`, `;`, `\;`)
+)
+
+// filterValue escapes s for use as a filter option value inside a filtergraph.
+func filterValue(s string) string {
+	return graphEscaper.Replace(optionEscaper.Replace(s))
+}
+
+// filterPath escapes a file path for use inside a filtergraph. Separators are
+// converted to forward slashes, which ffmpeg accepts on Windows too, and the
+// colon after a drive letter is escaped so it is not read as an option separator.
+func filterPath(path string) string {
+	return filterValue(filepath.ToSlash(path))
+}
+
+// CoverStyle describes how the title text is drawn on a cover image.
+type CoverStyle struct {
+	Font         string  // Path to the font file, empty for the default font
+	FontSize     string  // Font size in pixels
+	FontColor    string  // Font color name or hex value
+	FontEffect   string  // Extra drawtext options appended to the filter
+	BorderWidth  int     // Outline width in pixels, 0 for no outline
+	BorderColor  string  // Outline color
+	ShadowOffset int     // Drop shadow offset in pixels, 0 for no shadow
+	ShadowColor  string  // Drop shadow color
+	Box          bool    // Draw a filled box behind the text
+	BoxColor     string  // Box color, with optional @opacity
+	BoxPadding   int     // Space between the text and the box edges in pixels
+	Position     string  // Vertical placement: center, top or bottom
+	SafeMargin   float64 // Fraction of the frame height kept clear at the top and bottom
+	X            string  // Horizontal position expression overriding the centering
+	Y            string  // Vertical position expression overriding Position
+}
+
+// drawtext returns the drawtext filter drawing text with the style.
+func (s CoverStyle) drawtext(text string) string {
+	opts := []string{
+		"text=" + filterValue(textEscaper.Replace(text)),
+		"fontsize=" + s.FontSize,
+		"fontcolor=" + s.FontColor,
+	}
+	if s.Font != "" {
+		opts = append(opts, "fontfile="+filterPath(s.Font))
+	}
+	if s.BorderWidth > 0 {
+		opts = append(opts, fmt.Sprintf("borderw=%d", s.BorderWidth), "bordercolor="+s.BorderColor)
+	}
+	if s.ShadowOffset > 0 {
+		opts = append(opts, fmt.Sprintf("shadowx=%d:shadowy=%d", s.ShadowOffset, s.ShadowOffset), "shadowcolor="+s.ShadowColor)
+	}
+	padding := 0
+	if s.Box {
+		padding = s.BoxPadding
+		opts = append(opts, "box=1", "boxcolor="+s.BoxColor, fmt.Sprintf("boxborderw=%d", padding))
+	}
+
+	x := "(w-text_w)/2"
+	if s.X != "" {
+		x = filterValue(s.X)
+	}
+
+	// The margin keeps text, outline and box inside the area that platforms
+	// do not cover with their own overlays.
+	margin := fmt.Sprintf("h*%g", s.SafeMargin)
+	y := "(h-text_h)/2"
+	switch {
+	case s.Y != "":
+		y = filterValue(s.Y)
+	case s.Position == "top":
+		y = fmt.Sprintf("%s+%d", margin, padding)
+	case s.Position == "bottom":
+		y = fmt.Sprintf("h-text_h-%s-%d", margin, padding)
+	}
+	opts = append(opts, "x="+x, "y="+y)
+
+	return "drawtext=" + strings.Join(opts, ":") + s.FontEffect
+}
+
+// SubtitleStyle describes how subtitles are burned by Renderer.OverlaySubtitles.
+// Sizes are relative to a 384x288 frame, as in libass.
+type SubtitleStyle struct {
+	Font     string // Font name, empty for the default font
+	FontSize int    // Font size
+	MarginV  int    // Distance from the bottom edge
+	MarginL  int    // Distance from the left edge, 0 for the default
+	MarginR  int    // Distance from the right edge, 0 for the default
+	Outline  int    // Outline width
+}
+
+// styleEscaper removes the characters that would end a force_style value.
+var styleEscaper = strings.NewReplacer("'", "", ",", "")
+
+// subtitles returns the subtitles filter burning file with the style at the bottom center.
+func (s SubtitleStyle) subtitles(file string) string {
+	opts := []string{
+		fmt.Sprintf("FontSize=%d", s.FontSize),
+		fmt.Sprintf("MarginV=%d", s.MarginV),
+		fmt.Sprintf("Outline=%d", s.Outline),
+		"Alignment=2",
+	}
+	if s.MarginL > 0 {
+		opts = append(opts, fmt.Sprintf("MarginL=%d", s.MarginL))
+	}
+	if s.MarginR > 0 {
+		opts = append(opts, fmt.Sprintf("MarginR=%d", s.MarginR))
+	}
+	if s.Font != "" {
+		opts = append(opts, "FontName="+styleEscaper.Replace(s.Font))
+	}
+	return "subtitles=" + filterPath(file) + ":force_style='" + strings.Join(opts, ",") + "'"
+}
+
+// Renderer cuts clips and composes the derived videos and images.
+// Implementations must honor ctx so renders can be interrupted.
+type Renderer interface {
+	// Duration returns the length of input in seconds.
+	Duration(ctx context.Context, input string) (float64, error)
+	// Cut extracts the [begin, end] seconds range of input into output.
+	Cut(ctx context.Context, input string, begin, end int, output string) error
+	// BurnSubtitles renders the SRT subtitles file onto input.
+	BurnSubtitles(ctx context.Context, input string, subtitles string, output string) error
+	// Overlay centers clip over the looped still background.
+	Overlay(ctx context.Context, background string, clip string, output string) error
+	// Silences returns the quiet passages of the [begin, end] seconds range of
+	// input, relative to begin.
+	Silences(ctx context.Context, input string, begin, end int) ([]Span, error)
+	// Pace speeds up the spans of input by factor, keeping the pitch of the audio.
+	Pace(ctx context.Context, input string, spans []Span, factor float64, output string) error
+	// Concat joins inputs one after the other into output.
+	Concat(ctx context.Context, inputs []string, output string) error
+	// OverlaySubtitles centers clip inside the area of the looped still
+	// background left clear by zone and burns the SRT subtitles file onto the
+	// result with style, also kept out of zone.
+	OverlaySubtitles(ctx context.Context, background string, clip string, subtitles string, style SubtitleStyle, zone SafeZone, output string) error
+	// Cover draws text over the first frame of background and saves it as an image.
+	Cover(ctx context.Context, background string, text string, style CoverStyle, output string) error
+	// Compose draws layers in order over the first frame of background and saves it as an image.
+	Compose(ctx context.Context, background string, layers []Layer, output string) error
+	// Defects measures how much of input is black or frozen.
+	Defects(ctx context.Context, input string) (*Defects, error)
+	// Waveform returns the peak level, from 0 to 1, of each of columns equal
+	// slices of the length seconds of the audio of input from start.
+	Waveform(ctx context.Context, input string, start float64, length float64, columns int) ([]float64, error)
+	// Frozen returns how many of the length seconds of input from start are frozen.
+	Frozen(ctx context.Context, input string, start float64, length float64) (float64, error)
+	// Audiogram composes clip, a still image with audio, over the looped
+	// still background as artwork above a waveform of its audio, and burns
+	// the SRT subtitles file onto the result with style, kept out of zone.
+	Audiogram(ctx context.Context, background string, clip string, subtitles string, style SubtitleStyle, zone SafeZone, layout AudiogramLayout, output string) error
+	// Split stacks clip and the looped secondary video of layout, each
+	// filling half of the vertical frame, and burns the SRT subtitles file
+	// onto the result with style, kept out of zone.
+	Split(ctx context.Context, clip string, subtitles string, style SubtitleStyle, zone SafeZone, layout SplitLayout, output string) error
+	// Probe reports the duration and the streams of input.
+	Probe(ctx context.Context, input string) (*Probe, error)
+	// Loudness returns the loudness of input in LUFS for each second.
+	Loudness(ctx context.Context, input string) ([]float64, error)
+	// Still saves the first frame of input as an image.
+	Still(ctx context.Context, input string, output string) error
+	// Compile joins inputs lasting durations seconds into output with a
+	// transition of fade seconds between them and chapters as markers.
+	Compile(ctx context.Context, inputs []string, durations []float64, transition string, fade float64, chapters []Chapter, output string) error
+}
+
+// Layer is an image or a text drawn by Renderer.Compose.
+type Layer struct {
+	Image string     // Image file to overlay, empty for a text layer
+	Width int        // Width the image is scaled to, 0 to keep its size
+	X, Y  string     // Position expressions of an image, centered when empty
+	Text  string     // Text to draw when Image is empty
+	Style CoverStyle // Style of the text
+}
+
+// NewRenderer returns the Renderer configured for the channel.
+// Supported values for channel.Renderer are "moviego" (default) and "ffmpeg".
+func NewRenderer(cfg *config.Config, channel config.Channel) (Renderer, error) {
+	if channel.LUT != "" {
+		if _, err := os.Stat(channel.LUT); err != nil {
+			return nil, fmt.Errorf("LUT not found: %v", err)
+		}
+	}
+
+	outputs := channel.Outputs
+	profiles := profilesOf(cfg)
+	formats := make([]Format, 3)
+	for i, output := range []struct {
+		name   string
+		format config.OutputFormat
+	}{{"clip", outputs.Clip}, {"horizontal", outputs.Horizontal}, {"vertical", outputs.Vertical}} {
+		format, err := withProfile(Format(output.format), profiles)
+		if err == nil {
+			err = format.validate()
+		}
+		if err != nil {
+			return nil, fmt.Errorf("outputs.%s: %v", output.name, err)
+		}
+		formats[i] = format
+	}
+
+	ffmpegRenderer := &FFmpegRenderer{
+		FFmpeg:      cfg.FFmpeg,
+		FFprobe:     cfg.FFprobe,
+		VideoFilter: videoFilter(channel),
+		AudioFilter: audioFilter(channel),
+		Clip:        formats[0],
+		Horizontal:  formats[1],
+		Vertical:    formats[2],
+		Profiles:    profiles,
+		Timeout:     timeoutOf(cfg.Timeouts.FFmpeg, DefaultFFmpegTimeout),
+	}
+
+	switch channel.Renderer {
+	case "", "moviego":
+		return &MoviegoRenderer{FFmpegRenderer: ffmpegRenderer}, nil
+	case "ffmpeg":
+		return ffmpegRenderer, nil
+	default:
+		return nil, fmt.Errorf("unknown renderer: %s", channel.Renderer)
+	}
+}
+
+// videoFilter returns the video filter chain of the channel: its custom
+// filters followed by the color grading LUT, so the LUT defines the final look.
+func videoFilter(channel config.Channel) string {
+	var filters []string
+	if channel.VideoFilters != "" {
+		filters = append(filters, channel.VideoFilters)
+	}
+	if channel.LUT != "" {
+		filters = append(filters, "lut3d=file="+filterPath(channel.LUT))
+	}
+	return strings.Join(filters, ",")
+}
+
+// audioFilter returns the audio filter chain of the channel: the enabled
+// enhancement filters, in the order a sound engineer would apply them,
+// followed by its custom filters.
+func audioFilter(channel config.Channel) string {
+	audio := channel.Audio
+	var filters []string
+	if audio.HighPass > 0 {
+		filters = append(filters, fmt.Sprintf("highpass=f=%d", audio.HighPass))
+	}
+	if audio.Denoise > 0 {
+		filters = append(filters, fmt.Sprintf("afftdn=nr=%d", audio.Denoise))
+	}
+	if audio.DeEss {
+		filters = append(filters, "deesser")
+	}
+	if audio.Compress {
+		filters = append(filters, "acompressor=threshold=-18dB:ratio=3:attack=5:release=100:makeup=2")
+	}
+	if channel.AudioFilters != "" {
+		filters = append(filters, channel.AudioFilters)
+	}
+	return strings.Join(filters, ",")
+}
+
+// FFmpegRenderer renders everything with plain ffmpeg invocations and filtergraphs.
+type FFmpegRenderer struct {
+	FFmpeg      string                            // Path to the FFmpeg executable
+	FFprobe     string                            // Path to the FFprobe executable
+	VideoFilter string                            // Extra video filter chain applied when cutting, empty for none
+	AudioFilter string                            // Extra audio filter chain applied when cutting, empty for none
+	Clip        Format                            // Format of the clips cut, paced, joined or subtitled
+	Horizontal  Format                            // Format of the clips composed by Overlay
+	Vertical    Format                            // Format of the clips composed by OverlaySubtitles
+	Profiles    map[string]config.EncodingProfile // Encoding profiles the formats may use, by name
+	Timeout     time.Duration                     // Time limit of each ffmpeg and ffprobe call, 0 for none
+}
+
+// filtered reports whether cuts apply extra filters or change the rates.
+func (r *FFmpegRenderer) filtered() bool {
+	return r.VideoFilter != "" || r.AudioFilter != "" || r.Clip.set()
+}
+
+// Duration probes the container duration with ffprobe.
+func (r *FFmpegRenderer) Duration(ctx context.Context, input string) (float64, error) {
+	ctx, cancel := withTimeout(ctx, r.Timeout, "ffprobe")
+	defer cancel()
+
+	cmd := newCommand(ctx, r.FFprobe, "-v", "quiet", "-show_entries", "format=duration", "-of", "default=noprint_wrappers=1:nokey=1", input)
+	output, err := cmd.Output()
+	if err != nil {
+		return 0, fmt.Errorf("error getting video duration: %v", timeoutCause(ctx, err))
+	}
+
+	duration, err := strconv.ParseFloat(strings.TrimSpace(string(output)), 64)
+	if err != nil {
+		return 0, fmt.Errorf("error converting video duration: %v", err)
+	}
+
+	return duration, nil
+}
+
+// frameRate probes the frame rate of the video stream of input with ffprobe,
+// as a fraction such as 30000/1001.
+func (r *FFmpegRenderer) frameRate(ctx context.Context, input string) (string, error) {
+	ctx, cancel := withTimeout(ctx, r.Timeout, "ffprobe")
+	defer cancel()
+
+	output, err := newCommand(ctx, r.FFprobe, "-v", "quiet", "-select_streams", "v:0", "-show_entries", "stream=r_frame_rate", "-of", "default=noprint_wrappers=1:nokey=1", input).Output()
+	if err != nil {
+		return "", fmt.Errorf("error getting frame rate: %v", timeoutCause(ctx, err))
+	}
+
+	rate := strings.TrimSpace(string(output))
+	if rate == "" || strings.HasPrefix(rate, "0/") {
+		return "", fmt.Errorf("no frame rate reported for %s", input)
+	}
+	return rate, nil
+}
+
+// compositeRate returns the frame rate a clip is composed at: the rate of
+// format when set, otherwise the rate of the clip itself, so a background
+// with another rate does not make the clip stutter. It returns an empty
+// string when the rate of the clip is unknown.
+func (r *FFmpegRenderer) compositeRate(ctx context.Context, format Format, clip string) string {
+	if format.FPS > 0 {
+		return strconv.Itoa(format.FPS)
+	}
+	rate, err := r.frameRate(ctx, clip)
+	if err != nil {
+		return ""
+	}
+	return rate
+}
+
+// Cut re-encodes the requested range so the clip starts on an exact frame,
+// applying the extra filters. Every derived output starts from a cut, so the
+// filters are applied exactly once.
+func (r *FFmpegRenderer) Cut(ctx context.Context, input string, begin, end int, output string) error {
+	args := []string{
+		"-ss", strconv.Itoa(begin),
+		"-i", input,
+		"-t", strconv.Itoa(end - begin),
+	}
+	if r.VideoFilter != "" {
+		args = append(args, "-vf", r.VideoFilter)
+	}
+	if r.AudioFilter != "" {
+		args = append(args, "-af", r.AudioFilter)
+	}
+	args = append(args, r.encode(r.Clip)...)
+	args = append(args, r.Clip.args()...)
+
+	return r.render(ctx, output, args...)
+}
+
+// BurnSubtitles hardcodes the subtitles at the bottom center of the video.
+func (r *FFmpegRenderer) BurnSubtitles(ctx context.Context, input string, subtitles string, output string) error {
+	args := []string{
+		"-i", input,
+		"-vf", "subtitles=" + filterPath(subtitles) + ":force_style='FontSize=22,Alignment=2'",
+	}
+	args = append(args, r.encode(r.Clip)...)
+	args = append(args, r.Clip.args()...)
+
+	return r.render(ctx, output, args...)
+}
+
+// Concat joins the inputs with the concat filter, which re-encodes them so
+// clips with different encoding settings can be joined.
+func (r *FFmpegRenderer) Concat(ctx context.Context, inputs []string, output string) error {
+	var args []string
+	var streams strings.Builder
+	for i, input := range inputs {
+		args = append(args, "-i", input)
+		fmt.Fprintf(&streams, "[%d:v][%d:a]", i, i)
+	}
+
+	args = append(args,
+		"-filter_complex", fmt.Sprintf("%sconcat=n=%d:v=1:a=1[outv][outa]", streams.String(), len(inputs)),
+		"-map", "[outv]",
+		"-map", "[outa]",
+	)
+	args = append(args, r.encode(r.Clip)...)
+	args = append(args, r.Clip.args()...)
+
+	return r.render(ctx, output, args...)
+}
+
+// Overlay composes clip over background, keeping the audio of the clip.
+func (r *FFmpegRenderer) Overlay(ctx context.Context, background string, clip string, output string) error {
+	args := []string{
+		"-i", background,
+		"-i", clip,
+		"-filter_complex", overlayFilter(r.compositeRate(ctx, r.Horizontal, clip), "(H-h)/2", ""),
+		"-map", "[outv]",
+		"-map", "1:a",
+	}
+	args = append(args, r.encode(r.Horizontal)...)
+	args = append(args, r.Horizontal.args()...)
+	args = append(args, "-shortest")
+
+	return r.render(ctx, output, args...)
+}
+
+// OverlaySubtitles appends a subtitles filter to the overlay, so the captions
+// are sized for the composed frame instead of the scaled clip.
+func (r *FFmpegRenderer) OverlaySubtitles(ctx context.Context, background string, clip string, subtitles string, style SubtitleStyle, zone SafeZone, output string) error {
+	filter := overlayFilter(r.compositeRate(ctx, r.Vertical, clip), filterValue(zone.clipY()), ","+zone.apply(style).subtitles(subtitles))
+	args := []string{
+		"-i", background,
+		"-i", clip,
+		"-filter_complex", filter,
+		"-map", "[outv]",
+		"-map", "1:a",
+	}
+	args = append(args, r.encode(r.Vertical)...)
+	args = append(args, r.Vertical.args()...)
+	args = append(args, "-shortest")
+
+	return r.render(ctx, output, args...)
+}
+
+// Cover renders a single frame with a drawtext filter built from style.
+func (r *FFmpegRenderer) Cover(ctx context.Context, background string, text string, style CoverStyle, output string) error {
+	return r.render(
+		ctx,
+		output,
+		"-i", background,
+		"-vf", style.drawtext(text),
+		"-frames:v", "1",
+	)
+}
+
+// Compose builds a filtergraph chaining one overlay or drawtext filter per layer.
+func (r *FFmpegRenderer) Compose(ctx context.Context, background string, layers []Layer, output string) error {
+	args := []string{"-i", background}
+	var filters []string
+	last := "0:v"
+	inputs := 1
+
+	for i, layer := range layers {
+		out := fmt.Sprintf("l%d", i)
+
+		if layer.Image == "" {
+			filters = append(filters, fmt.Sprintf("[%s]%s[%s]", last, layer.Style.drawtext(layer.Text), out))
+			last = out
+			continue
+		}
+
+		args = append(args, "-i", layer.Image)
+		image := fmt.Sprintf("%d:v", inputs)
+		inputs++
+
+		if layer.Width > 0 {
+			filters = append(filters, fmt.Sprintf("[%s]scale=%d:-1[s%d]", image, layer.Width, i))
+			image = fmt.Sprintf("s%d", i)
+		}
+
+		x, y := "(W-w)/2", "(H-h)/2"
+		if layer.X != "" {
+			x = filterValue(layer.X)
+		}
+		if layer.Y != "" {
+			y = filterValue(layer.Y)
+		}
+		filters = append(filters, fmt.Sprintf("[%s][%s]overlay=x=%s:y=%s[%s]", last, image, x, y, out))
+		last = out
+	}
+
+	if len(filters) == 0 {
+		filters = append(filters, "[0:v]null[l]")
+		last = "l"
+	}
+
+	args = append(args,
+		"-filter_complex", strings.Join(filters, ";"),
+		"-map", "["+last+"]",
+		"-frames:v", "1",
+	)
+
+	return r.render(ctx, output, args...)
+}
+
+// render runs ffmpeg with args writing to output through a temporary file.
+func (r *FFmpegRenderer) render(ctx context.Context, output string, args ...string) error {
+	ctx, cancel := withTimeout(ctx, r.Timeout, "ffmpeg")
+	defer cancel()
+
+	return writeAtomically(output, func(tmp string) error {
+		return timeoutCause(ctx, newCommand(ctx, r.FFmpeg, append(args, "-y", tmp)...).Run())
+	})
+}
+
+// MoviegoRenderer cuts clips by copying the streams of the source, which is
+// fast but starts each clip on the keyframe before its beginning, the way
+// moviego used to cut them. Durations and compositing are left to
+// FFmpegRenderer.
+type MoviegoRenderer struct {
+	*FFmpegRenderer
+}
+
+// Cut copies the streams of the [begin, end] range into output. Streams
+// cannot be filtered or converted without re-encoding them, so cuts with
+// extra filters or another format are delegated to FFmpegRenderer.
+func (r *MoviegoRenderer) Cut(ctx context.Context, input string, begin, end int, output string) error {
+	if r.filtered() {
+		return r.FFmpegRenderer.Cut(ctx, input, begin, end, output)
+	}
+
+	return r.render(ctx, output,
+		"-ss", strconv.Itoa(begin),
+		"-i", input,
+		"-t", strconv.Itoa(end-begin),
+		"-c", "copy",
+		"-avoid_negative_ts", "make_zero",
+	)
+}
@@ -0,0 +1,179 @@
+package videos
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeTool writes an executable shell script running body and returns its
+// path, to stand in for ffmpeg or ffprobe.
+func fakeTool(t *testing.T, name string, body string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake tools are shell scripts")
+	}
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+body+"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// fakeFFmpeg returns an ffmpeg writing its arguments to the output file, its
+// last argument.
+func fakeFFmpeg(t *testing.T) string {
+	return fakeTool(t, "ffmpeg", `for arg; do last="$arg"; done; echo "$@" > "$last"`)
+}
+
+func TestMoviegoRendererReportsProbeFailures(t *testing.T) {
+	renderer := &MoviegoRenderer{FFmpegRenderer: &FFmpegRenderer{
+		FFmpeg:  fakeFFmpeg(t),
+		FFprobe: fakeTool(t, "ffprobe", `echo "moov atom not found" >&2; exit 1`),
+	}}
+
+	if _, err := renderer.Duration(context.Background(), "truncated.mp4"); err == nil {
+		t.Error("measuring a broken video succeeded")
+	}
+}
+
+func TestMoviegoRendererCopiesStreams(t *testing.T) {
+	renderer := &MoviegoRenderer{FFmpegRenderer: &FFmpegRenderer{FFmpeg: fakeFFmpeg(t)}}
+	output := filepath.Join(t.TempDir(), "clip.mp4")
+
+	if err := renderer.Cut(context.Background(), "source.mp4", 10, 40, output); err != nil {
+		t.Fatal(err)
+	}
+	args, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(args), "-ss 10 -i source.mp4 -t 30 -c copy") {
+		t.Errorf("ffmpeg arguments = %s", args)
+	}
+
+	// Filters need the streams to be encoded again.
+	renderer.VideoFilter = "hflip"
+	if err := renderer.Cut(context.Background(), "source.mp4", 10, 40, output); err != nil {
+		t.Fatal(err)
+	}
+	if args, _ := os.ReadFile(output); strings.Contains(string(args), "-c copy") || !strings.Contains(string(args), "-vf hflip") {
+		t.Errorf("ffmpeg arguments = %s", args)
+	}
+}
+
+// slowFFmpeg returns an ffmpeg that starts writing its output and then runs
+// for a minute unless it is interrupted.
+func slowFFmpeg(t *testing.T) string {
+	return fakeTool(t, "ffmpeg", `for arg; do last="$arg"; done; echo partial > "$last"; echo "encoding frame 1" >&2; trap 'exit 255' INT TERM; sleep 60 & wait`)
+}
+
+func TestMoviegoRendererCutsCanBeInterrupted(t *testing.T) {
+	renderer := &MoviegoRenderer{FFmpegRenderer: &FFmpegRenderer{FFmpeg: slowFFmpeg(t)}}
+	output := filepath.Join(t.TempDir(), "clip.mp4")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+	started := time.Now()
+	if err := renderer.Cut(ctx, "source.mp4", 10, 40, output); err == nil {
+		t.Fatal("the interrupted cut succeeded")
+	}
+	if elapsed := time.Since(started); elapsed > commandWaitDelay {
+		t.Errorf("the cut took %s to stop", elapsed)
+	}
+	assertMissing(t, output, tempName(output))
+}
+
+func TestMoviegoRendererCutsHaveATimeLimit(t *testing.T) {
+	renderer := &MoviegoRenderer{FFmpegRenderer: &FFmpegRenderer{FFmpeg: slowFFmpeg(t), Timeout: 100 * time.Millisecond}}
+	output := filepath.Join(t.TempDir(), "clip.mp4")
+
+	err := renderer.Cut(context.Background(), "source.mp4", 10, 40, output)
+	if err == nil || !strings.Contains(err.Error(), "ffmpeg timed out") {
+		t.Errorf("error = %v, want the ffmpeg time limit", err)
+	}
+	assertMissing(t, output, tempName(output))
+}
+
+func TestMoviegoRendererCallsAreLogged(t *testing.T) {
+	renderer := &MoviegoRenderer{FFmpegRenderer: &FFmpegRenderer{
+		FFmpeg:  fakeTool(t, "ffmpeg", `echo "Invalid data found when processing input" >&2; exit 1`),
+		FFprobe: fakeTool(t, "ffprobe", `echo "moov atom not found" >&2; exit 1`),
+	}}
+	outputDir := t.TempDir()
+	ctx, closeLog := withDebugLog(context.Background(), outputDir)
+
+	_, probeErr := renderer.Duration(ctx, "source.mp4")
+	cutErr := renderer.Cut(ctx, "source.mp4", 10, 40, filepath.Join(outputDir, "clip.mp4"))
+	closeLog()
+
+	for _, err := range []error{probeErr, cutErr} {
+		if err == nil || !strings.Contains(err.Error(), debugLogFile) {
+			t.Errorf("error = %v, want one naming the debug log", err)
+		}
+	}
+	log, err := os.ReadFile(filepath.Join(outputDir, debugLogFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"moov atom not found", "-c copy", "Invalid data found when processing input"} {
+		if !strings.Contains(string(log), want) {
+			t.Errorf("debug log misses %q:\n%s", want, log)
+		}
+	}
+}
+
+func TestDrawtextEscapesTheText(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string // Value of the text option in the filtergraph
+	}{
+		{"plain", "Bom dia", `Bom dia`},
+		{"quote", "it's", `it\\\'s`},
+		{"colon", "10:30", `10\\:30`},
+		{"comma", "a, b", `a\, b`},
+		{"percent", "100%", `100\\\\%`},
+		{"expansion", "%{pts}", `\\\\%{pts}`},
+		{"backslash", `back\slash`, `back\\\\\\\\slash`},
+		{"filtergraph syntax", "[x];y", `\[x\]\;y`},
+	}
+
+	style := CoverStyle{FontSize: "48", FontColor: "white", Font: filepath.FromSlash("C:/Windows/Fonts/arial.ttf")}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := style.drawtext(test.text)
+			want := "drawtext=text=" + test.want + ":fontsize=48:fontcolor=white:fontfile=" + `C\\:/Windows/Fonts/arial.ttf` + ":"
+			if !strings.HasPrefix(got, want) {
+				t.Errorf("got  %s\nwant %s…", got, want)
+			}
+		})
+	}
+}
+
+func TestFilterPath(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{"drive letter", filepath.FromSlash("C:/Windows/Fonts/arial.ttf"), `C\\:/Windows/Fonts/arial.ttf`},
+		{"separators", filepath.Join("fonts", "bold.ttf"), `fonts/bold.ttf`},
+		{"quote", "/fonts/it's.ttf", `/fonts/it\\\'s.ttf`},
+		{"colon", "/fonts/10:30.ttf", `/fonts/10\\:30.ttf`},
+		{"comma", "/fonts/a,b.ttf", `/fonts/a\,b.ttf`},
+		{"percent", "/fonts/100%.ttf", `/fonts/100%.ttf`}, // Only text is expanded
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := filterPath(test.path); got != test.want {
+				t.Errorf("got  %s\nwant %s", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,193 @@
+package videos
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/rogersilvasouza/godeogoker/internal/config"
+	"github.com/rogersilvasouza/godeogoker/internal/state"
+)
+
+// cutsFile is the name of the file caching the cuts found for a video.
+const cutsFile = "cuts.json"
+
+// videoFile is the name of the file keeping the feed entry of a video, so a
+// retry knows where to download it from and what its title and description were.
+const videoFile = "video.json"
+
+// saveVideo writes the feed entry of video into outputDir.
+func saveVideo(outputDir string, video Video) error {
+	content, err := json.MarshalIndent(video, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(outputDir, videoFile), content, 0644)
+}
+
+// loadVideo returns the feed entry saved in outputDir, or a video with only
+// videoID when none was saved.
+func loadVideo(outputDir string, videoID string) Video {
+	video := Video{ID: videoID}
+	if content, err := os.ReadFile(filepath.Join(outputDir, videoFile)); err == nil {
+		json.Unmarshal(content, &video)
+	}
+	return video
+}
+
+// CachedCuts returns the cuts cached for the processed videos inside the
+// channel folder, keyed by video ID.
+func CachedCuts(folder string) (map[string][]Cut, error) {
+	files, err := filepath.Glob(filepath.Join(folder, "*", cutsFile))
+	if err != nil {
+		return nil, err
+	}
+
+	cuts := make(map[string][]Cut)
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		cached := make(map[string][]Cut)
+		if err := json.Unmarshal(content, &cached); err != nil {
+			return nil, fmt.Errorf("error reading %s: %v", file, err)
+		}
+
+		videoID := filepath.Base(filepath.Dir(file))
+		keys := make([]string, 0, len(cached))
+		for key := range cached {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			cuts[videoID] = append(cuts[videoID], cached[key]...)
+		}
+	}
+	return cuts, nil
+}
+
+// findCuts runs the cut detectors over the captions of media and the video at
+// videoFile, empty when only the captions were downloaded, and caches
+// the cuts in outputDir. With reuse, or when the cuts were found with the same
+// settings, cached cuts are returned instead, so a retried render or upload
+// does not pay for a new, probably different, set of cuts.
+func (p *pipeline) findCuts(ctx context.Context, outputDir string, media *Media, videoFile string, hints *CutHints, reuse bool) ([]Cut, error) {
+	path := filepath.Join(outputDir, cutsFile)
+	key := filepath.Base(media.SubtitleFile)
+
+	cached := make(map[string][]Cut) // Cuts keyed by captions file name
+	if content, err := os.ReadFile(path); err == nil {
+		json.Unmarshal(content, &cached)
+	}
+
+	name := cutsFile + ":" + key
+	hash := p.cutsSettings(hints)
+	if cuts, ok := cached[key]; ok && (reuse || p.upToDate(outputDir, name, hash)) {
+		fmt.Println(subtitleStyle.Render("Reusing the cuts found earlier"))
+		return cuts, nil
+	}
+
+	source := CutSource{Captions: media.Captions, Video: videoFile, Hints: hints}
+	source.Entries, _ = parseVTTFile(media.Captions)
+	source.Duration = captionsDuration(source.Entries)
+	if videoFile != "" {
+		if duration, err := p.renderer.Duration(ctx, videoFile); err == nil {
+			source.Duration = duration
+		}
+	}
+
+	cuts, err := p.detectCuts(ctx, source)
+	if err != nil {
+		return nil, err
+	}
+
+	cached[key] = cuts
+	if content, err := json.MarshalIndent(cached, "", "  "); err == nil {
+		if err := writeFileAtomic(path, content, 0644); err != nil {
+			fmt.Println(errorStyle.Render("Error caching cuts: " + err.Error()))
+		} else {
+			recordSettings(outputDir, name, hash)
+		}
+	}
+
+	return cuts, nil
+}
+
+// RetryFailed processes again the videos of channel with failed steps recorded
+// in the state store. Outputs that already exist are reused, so only the failed
+// download, cuts, render, storage and upload steps run again. It returns an
+// error when there is no state store or the backends of the channel cannot be
+// configured.
+func RetryFailed(ctx context.Context, client *Client, channel config.Channel) error {
+	fmt.Println(titleStyle.Render("Retrying failed steps for channel: " + channel.Name))
+
+	if client.State == nil {
+		return fmt.Errorf("no state store available, nothing to retry")
+	}
+
+	stages := make(map[string][]string)
+	var videoIDs []string
+	for _, failure := range client.State.Failures(channel.ID) {
+		if _, ok := stages[failure.VideoID]; !ok {
+			videoIDs = append(videoIDs, failure.VideoID)
+		}
+		stages[failure.VideoID] = appendUnique(stages[failure.VideoID], failure.Stage)
+	}
+
+	if len(videoIDs) == 0 {
+		fmt.Println(successStyle.Render("No failed steps recorded for channel: " + channel.Name))
+		return nil
+	}
+
+	p, err := newPipeline(ctx, client, channel)
+	if err != nil {
+		return fmt.Errorf("error configuring %v", err)
+	}
+	p.retrying = true
+
+	for _, videoID := range videoIDs {
+		if ctx.Err() != nil {
+			break
+		}
+
+		sort.Strings(stages[videoID])
+		fmt.Println(subtitleStyle.Render(fmt.Sprintf("Retrying video %s (failed: %s)", videoID, strings.Join(stages[videoID], ", "))))
+
+		outputDir := filepath.Join(channel.Folder, videoID)
+		if !p.run(ctx, outputDir, loadVideo(outputDir, videoID)) {
+			break
+		}
+	}
+
+	if ctx.Err() == nil {
+		p.writeFeeds(ctx)
+	}
+
+	fmt.Println(titleStyle.Render("Retry completed for channel: " + channel.Name))
+	return nil
+}
+
+// appendUnique appends value to values unless it is already present.
+func appendUnique(values []string, value string) []string {
+	for _, v := range values {
+		if v == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
+
+// hasFailures reports whether failed steps of videoID are recorded for channel.
+func hasFailures(store *state.Store, channel string, videoID string) bool {
+	for _, failure := range store.Failures(channel) {
+		if failure.VideoID == videoID {
+			return true
+		}
+	}
+	return false
+}
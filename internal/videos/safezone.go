@@ -0,0 +1,57 @@
+package videos
+
+import (
+	"fmt"
+	"math"
+)
+
+// SafeZone is the margin, in pixels of a 1080x1920 frame, that a platform
+// covers with its own interface (captions, like and share buttons, progress
+// bar). Text and the clip are kept inside the remaining area.
+type SafeZone struct {
+	Top    int // Height covered at the top
+	Bottom int // Height covered at the bottom
+	Left   int // Width covered on the left
+	Right  int // Width covered on the right
+}
+
+// safeZones holds the presets selected by the safe_zone channel setting.
+// "all" keeps clear of the interface of every supported platform.
+var safeZones = map[string]SafeZone{
+	"shorts": {Top: 180, Bottom: 380, Left: 60, Right: 120},
+	"reels":  {Top: 220, Bottom: 420, Left: 60, Right: 120},
+	"tiktok": {Top: 150, Bottom: 480, Left: 60, Right: 150},
+	"all":    {Top: 220, Bottom: 480, Left: 60, Right: 150},
+}
+
+// SafeZoneFor returns the safe zone preset called name. An empty name returns
+// the zero SafeZone, which leaves the layout unchanged.
+func SafeZoneFor(name string) (SafeZone, error) {
+	if name == "" {
+		return SafeZone{}, nil
+	}
+
+	zone, ok := safeZones[name]
+	if !ok {
+		return SafeZone{}, fmt.Errorf("unknown safe zone: %s", name)
+	}
+	return zone, nil
+}
+
+// clipY returns the overlay expression centering the clip vertically inside
+// the area left clear by the zone, never above the top of the frame.
+func (z SafeZone) clipY() string {
+	if z == (SafeZone{}) {
+		return "(H-h)/2"
+	}
+	return fmt.Sprintf("max(%d+(H-%d-h)/2,0)", z.Top, z.Top+z.Bottom)
+}
+
+// apply keeps the captions of style out of the zone. libass measures SRT
+// styles against a 384x288 frame, so the pixel margins are scaled to it.
+func (z SafeZone) apply(style SubtitleStyle) SubtitleStyle {
+	style.MarginV = max(style.MarginV, int(math.Ceil(float64(z.Bottom)*288/1920)))
+	style.MarginL = max(style.MarginL, int(math.Ceil(float64(z.Left)*384/1080)))
+	style.MarginR = max(style.MarginR, int(math.Ceil(float64(z.Right)*384/1080)))
+	return style
+}
@@ -0,0 +1,120 @@
+package videos
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+// A rendered video may differ from its expected length, and its audio from
+// its video, by durationTolerance seconds or durationToleranceShare of the
+// expected length, whichever is larger.
+const (
+	durationTolerance      = 2.0
+	durationToleranceShare = 0.05
+)
+
+// Probe is what ffprobe reports about the streams of a video.
+type Probe struct {
+	Duration      float64 // Length of the container in seconds
+	Video         bool    // Whether there is a video stream
+	Audio         bool    // Whether there is an audio stream
+	VideoDuration float64 // Length of the video stream, 0 when unknown
+	AudioDuration float64 // Length of the audio stream, 0 when unknown
+}
+
+// Probe reads the container duration and the streams of input with ffprobe.
+func (r *FFmpegRenderer) Probe(ctx context.Context, input string) (*Probe, error) {
+	ctx, cancel := withTimeout(ctx, r.Timeout, "ffprobe")
+	defer cancel()
+
+	output, err := newCommand(
+		ctx,
+		r.FFprobe,
+		"-v", "quiet",
+		"-show_entries", "format=duration:stream=codec_type,duration",
+		"-of", "json",
+		input,
+	).Output()
+	if err != nil {
+		return nil, fmt.Errorf("error probing video: %v", timeoutCause(ctx, err))
+	}
+
+	var result struct {
+		Format struct {
+			Duration string `json:"duration"`
+		} `json:"format"`
+		Streams []struct {
+			CodecType string `json:"codec_type"`
+			Duration  string `json:"duration"`
+		} `json:"streams"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		return nil, fmt.Errorf("error parsing ffprobe output: %v", err)
+	}
+
+	probe := &Probe{}
+	probe.Duration, _ = strconv.ParseFloat(result.Format.Duration, 64)
+	for _, stream := range result.Streams {
+		duration, _ := strconv.ParseFloat(stream.Duration, 64)
+		switch stream.CodecType {
+		case "video":
+			probe.Video = true
+			probe.VideoDuration = duration
+		case "audio":
+			probe.Audio = true
+			probe.AudioDuration = duration
+		}
+	}
+	return probe, nil
+}
+
+// problem describes why a video expected to last expected seconds is broken,
+// or returns an empty string when it looks sane.
+func (probe *Probe) problem(expected float64) string {
+	tolerance := max(durationTolerance, expected*durationToleranceShare)
+
+	switch {
+	case !probe.Video:
+		return "no video stream"
+	case !probe.Audio:
+		return "no audio stream"
+	case math.Abs(probe.Duration-expected) > tolerance:
+		return fmt.Sprintf("lasts %.1fs instead of %.1fs", probe.Duration, expected)
+	case probe.VideoDuration > 0 && probe.AudioDuration > 0 && math.Abs(probe.VideoDuration-probe.AudioDuration) > tolerance:
+		return fmt.Sprintf("audio lasts %.1fs but video lasts %.1fs", probe.AudioDuration, probe.VideoDuration)
+	}
+	return ""
+}
+
+// clipLength returns the expected length in seconds of the clip of cut, once
+// its quiet passages are sped up and its hook is prefixed in cold-open mode.
+func (p *pipeline) clipLength(cut Cut) float64 {
+	length := time.Duration(cut.End-cut.Begin) * time.Second
+	if len(cut.Pace) > 0 {
+		length = pacedTime(length, cut.Pace, p.channel.Pacing)
+	}
+	if p.coldOpen(cut) {
+		length += time.Duration(cut.Hook.End-cut.Hook.Begin) * time.Second
+	}
+	return length.Seconds()
+}
+
+// checkRender probes a rendered video of cut and returns an error when it
+// lacks its audio or video stream or its length is off, so broken renders,
+// such as overlays that lost the audio of the clip, are never published.
+func (p *pipeline) checkRender(ctx context.Context, output string, cut Cut) error {
+	probe, err := p.renderer.Probe(ctx, output)
+	if err != nil {
+		return err
+	}
+
+	if problem := probe.problem(p.clipLength(cut)); problem != "" {
+		return errors.New("broken render: " + problem)
+	}
+	return nil
+}
@@ -0,0 +1,187 @@
+package videos
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// partsFile is the name of the file caching the parts of the cuts split into series.
+const partsFile = "parts.json"
+
+// minPartLength is the shortest part, in seconds, a series split may produce.
+const minPartLength = 10
+
+// series is a long cut published as numbered parts.
+type series struct {
+	cut      Cut            // The whole cut before splitting
+	metadata *VideoMetadata // Metadata shared by every part, generated once
+}
+
+// FindCliffhangers asks the language model where to split a cut of length
+// seconds into parts consecutive parts, each ending on a cliffhanger.
+// subtitles is the SRT text of the cut with times relative to its start.
+// It returns the parts-1 split points in seconds from the start of the cut.
+// Failures are returned as *Error values of kind ErrLLMRequest or ErrLLMParse.
+func (c *Client) FindCliffhangers(ctx context.Context, subtitles string, length int, parts int) ([]int, error) {
+	systemPrompt := fmt.Sprintf(`You are a professional video editor splitting a %d second excerpt into %d consecutive parts for a short-form video series.
+	Each part, except the last, must end on a cliffhanger: right after a question, a setup or a moment of tension that makes viewers want to watch the next part.
+	Never split in the middle of a sentence. Keep the parts of similar length.
+	Return only a JSON object in the format: {"splits": [split times in seconds from the start of the excerpt (integers), in increasing order]} with exactly %d values.`, length, parts, parts-1)
+
+	userPrompt := fmt.Sprintf("Here are the subtitles of the excerpt in SRT format:\n\n%s", subtitles)
+
+	var response struct {
+		Splits []int `json:"splits"`
+	}
+	err := c.chatCompletion(ctx, purposeCliffhangers, 60*time.Second, systemPrompt, userPrompt, func(content string) error {
+		return json.Unmarshal([]byte(content), &response)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return response.Splits, nil
+}
+
+// validSplits reports whether splits divides a cut of length seconds into
+// parts consecutive parts of at least minPartLength seconds.
+func validSplits(splits []int, length int, parts int) bool {
+	if len(splits) != parts-1 {
+		return false
+	}
+
+	previous := 0
+	for _, split := range append(splits, length) {
+		if split-previous < minPartLength {
+			return false
+		}
+		previous = split
+	}
+	return true
+}
+
+// evenSplits divides a cut of length seconds into parts parts of equal length.
+func evenSplits(length int, parts int) []int {
+	splits := make([]int, parts-1)
+	for i := range splits {
+		splits[i] = length * (i + 1) / parts
+	}
+	return splits
+}
+
+// seriesParts turns cut into one cut per part, numbered in their titles.
+func seriesParts(cut Cut, splits []int) []Cut {
+	parts := make([]Cut, 0, len(splits)+1)
+	begin := cut.Begin
+	for i, split := range append(splits, cut.End-cut.Begin) {
+		parts = append(parts, Cut{
+			Title:  fmt.Sprintf("%s (Part %d/%d)", cut.Title, i+1, len(splits)+1),
+			Begin:  begin,
+			End:    cut.Begin + split,
+			Series: cut.Title,
+			Part:   i + 1,
+			Parts:  len(splits) + 1,
+			Score:  cut.Score,
+		})
+		begin = cut.Begin + split
+	}
+	return parts
+}
+
+// splitSeries replaces the cuts longer than the part_length of the channel by
+// numbered parts ending on cliffhangers. The parts are cached in outputDir and
+// reused while the part length and model do not change, so file names and
+// boundaries stay stable.
+func (p *pipeline) splitSeries(ctx context.Context, outputDir string, videoID string, subtitleEntries []SubtitleEntry, cuts []Cut) []Cut {
+	partLength := p.channel.PartLength
+	if partLength <= 0 {
+		return cuts
+	}
+
+	path := filepath.Join(outputDir, partsFile)
+	cached := make(map[string][]Cut) // Parts keyed by the title of the whole cut
+	if content, err := os.ReadFile(path); err == nil {
+		json.Unmarshal(content, &cached)
+	}
+
+	split := make(map[string]string) // Settings hashes of the cuts split now, keyed by title
+
+	var result []Cut
+	for _, cut := range cuts {
+		length := cut.End - cut.Begin
+		if length <= partLength {
+			result = append(result, cut)
+			continue
+		}
+
+		if p.series == nil {
+			p.series = make(map[string]*series)
+		}
+		p.series[cut.Title] = &series{cut: cut}
+
+		hash := p.partsSettings(cut)
+		if parts, ok := cached[cut.Title]; ok && p.upToDate(outputDir, partsFile+":"+cut.Title, hash) {
+			result = append(result, parts...)
+			continue
+		}
+
+		count := int(math.Ceil(float64(length) / float64(partLength)))
+		fmt.Println(commandStyle.Render(fmt.Sprintf("Splitting \"%s\" into %d parts...", cut.Title, count)))
+
+		subtitles := getSubtitlesForTimeRange(subtitleEntries, cut.Begin, cut.End)
+		splits, err := p.client.FindCliffhangers(withPromptCut(ctx, cut.Title), subtitles, length, count)
+		if err != nil {
+			p.fail(withContext(err, ErrLLMRequest, videoID, cut.Title))
+			splits = nil
+		}
+		if !validSplits(splits, length, count) {
+			fmt.Println(subtitleStyle.Render("No usable cliffhangers found, splitting into parts of equal length"))
+			splits = evenSplits(length, count)
+		}
+
+		parts := seriesParts(cut, splits)
+		cached[cut.Title] = parts
+		split[cut.Title] = hash
+		result = append(result, parts...)
+	}
+
+	if content, err := json.MarshalIndent(cached, "", "  "); err == nil && len(cached) > 0 {
+		if err := writeFileAtomic(path, content, 0644); err != nil {
+			fmt.Println(errorStyle.Render("Error caching series parts: " + err.Error()))
+		} else {
+			for title, hash := range split {
+				recordSettings(outputDir, partsFile+":"+title, hash)
+			}
+		}
+	}
+
+	return result
+}
+
+// seriesMetadata returns the metadata of a part: the metadata of the whole
+// cut, generated once per series, with the part number added to the title and
+// description so titles and hashtags stay consistent across the series.
+func (p *pipeline) seriesMetadata(ctx context.Context, videoID string, details *VideoDetails, subtitleEntries []SubtitleEntry, cut Cut) *VideoMetadata {
+	s := p.series[cut.Series]
+	if s == nil {
+		s = &series{cut: Cut{Title: cut.Series, Begin: cut.Begin, End: cut.End}}
+		p.series[cut.Series] = s
+	}
+
+	if s.metadata == nil {
+		s.metadata = p.generateMetadata(ctx, videoID, details, subtitleEntries, s.cut)
+		if s.metadata == nil {
+			return nil
+		}
+	}
+
+	metadata := *s.metadata
+	metadata.Title = fmt.Sprintf("%s (Part %d/%d)", s.metadata.Title, cut.Part, cut.Parts)
+	metadata.Description = fmt.Sprintf("Part %d/%d. %s", cut.Part, cut.Parts, s.metadata.Description)
+	return &metadata
+}
@@ -0,0 +1,233 @@
+package videos
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/rogersilvasouza/godeogoker/internal/config"
+)
+
+// settingsFile is the name of the file recording, for each output of a video,
+// the hash of the settings that produced it.
+const settingsFile = "settings.json"
+
+// settingsRevision is hashed along with the settings of every output. Bump it
+// when the prompts or the render commands change in a way that should
+// regenerate the outputs of earlier runs.
+const settingsRevision = 1
+
+// settingsMu serializes the updates of the settings files.
+var settingsMu sync.Mutex
+
+// settingsHash returns the hash of the settings an output is produced with.
+func settingsHash(values ...any) string {
+	content, _ := json.Marshal(append([]any{settingsRevision}, values...))
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// loadSettings returns the settings hashes recorded in outputDir, keyed by
+// the output they produced.
+func loadSettings(outputDir string) map[string]string {
+	hashes := make(map[string]string)
+	if content, err := os.ReadFile(filepath.Join(outputDir, settingsFile)); err == nil {
+		json.Unmarshal(content, &hashes)
+	}
+	return hashes
+}
+
+// recordSettings saves hash as the settings that produced the output name of outputDir.
+func recordSettings(outputDir string, name string, hash string) {
+	settingsMu.Lock()
+	defer settingsMu.Unlock()
+
+	hashes := loadSettings(outputDir)
+	hashes[name] = hash
+
+	content, _ := json.MarshalIndent(hashes, "", "  ")
+	if err := writeFileAtomic(filepath.Join(outputDir, settingsFile), content, 0644); err != nil {
+		fmt.Println(errorStyle.Render("Error recording output settings: " + err.Error()))
+	}
+}
+
+// settingsName returns the name output is recorded under in the settings file of outputDir.
+func settingsName(outputDir string, output string) string {
+	name, err := filepath.Rel(outputDir, output)
+	if err != nil {
+		return output
+	}
+	return filepath.ToSlash(name)
+}
+
+// upToDate reports whether the output name of outputDir was produced with the
+// settings of hash. Outputs of runs that did not record their settings are
+// adopted as up to date when retrying or refreshing, so upgrading does not
+// regenerate everything.
+func (p *pipeline) upToDate(outputDir string, name string, hash string) bool {
+	recorded, ok := loadSettings(outputDir)[name]
+	if ok {
+		return recorded == hash
+	}
+	if p.retrying || p.refreshing {
+		recordSettings(outputDir, name, hash)
+		return true
+	}
+	return false
+}
+
+// cutsSettings returns the hash of the settings the cuts are found with. The
+// detectors, keywords and extra instructions are only hashed when set, so cuts found before they
+// existed are still up to date.
+func (p *pipeline) cutsSettings(hints *CutHints) string {
+	channel := p.channel
+	values := []any{"cuts", p.client.languageModel().Model(), channel.Topics, channel.Excerpts, channel.StretchTime, channel.Language, hints}
+	if len(channel.CutDetectors) > 0 {
+		values = append(values, channel.CutDetectors)
+	}
+	if channel.Keywords != nil {
+		values = append(values, channel.Keywords)
+	}
+	if channel.PromptExtra != "" {
+		values = append(values, channel.PromptExtra)
+	}
+	return settingsHash(values...)
+}
+
+// hookSettings returns the hash of the settings the hook of cut is found with.
+func (p *pipeline) hookSettings(cut Cut) string {
+	return settingsHash("hook", p.client.languageModel().Model(), p.channel.Language, cut.Title, cut.Begin, cut.End)
+}
+
+// partsSettings returns the hash of the settings cut is split into parts with.
+func (p *pipeline) partsSettings(cut Cut) string {
+	return settingsHash("parts", p.client.languageModel().Model(), p.channel.PartLength, cut)
+}
+
+// clipSettings returns the hash of the settings the horizontal clip of cut is
+// rendered with: the renderer, its filters and the hook and pacing of the cut.
+// The output formats here and below are only hashed when set, so outputs
+// rendered before they existed are still up to date.
+func (p *pipeline) clipSettings(cut Cut, subtitles bool) string {
+	channel := p.channel
+	values := []any{"clip", channel.Renderer, videoFilter(channel), audioFilter(channel), channel.Hook, channel.Pacing, cut, subtitles}
+	if format := channel.Outputs.Clip; format != (config.OutputFormat{}) {
+		values = append(values, p.formatSettings(format)...)
+	}
+	return settingsHash(values...)
+}
+
+// formatSettings returns the settings of an output format to hash: the format
+// and, when it uses a configured encoding profile, the profile.
+func (p *pipeline) formatSettings(format config.OutputFormat) []any {
+	if profile, ok := p.client.Config.EncodingProfiles[format.Profile]; ok && format.Profile != "" {
+		return []any{format, profile}
+	}
+	return []any{format}
+}
+
+// metadataSettings returns the hash of the settings the metadata of cut is
+// generated with. The title rules, the hashtag pool and the translations are
+// only hashed when set, so metadata generated before they existed is still up
+// to date.
+func (p *pipeline) metadataSettings(cut Cut) string {
+	channel := p.channel
+	values := []any{"metadata", p.client.languageModel().Model(), channel.Topics, channel.Language, channel.TitleTemplate, cut}
+	if channel.TitleRules != nil {
+		values = append(values, channel.TitleRules)
+	}
+	if channel.Hashtags != nil {
+		values = append(values, channel.Hashtags)
+	}
+	if channel.Translations != nil {
+		values = append(values, channel.Translations.Languages)
+	}
+	return settingsHash(values...)
+}
+
+// coverSettings returns the hash of the settings the cover of cut is drawn
+// with. clip is the settings hash of the clip it may use as background.
+func (p *pipeline) coverSettings(videoID string, details *VideoDetails, cut Cut, clip string) string {
+	channel := p.channel
+	values := []any{"cover", channel.Name, channel.CoverVideoBase, channel.CoverTemplate, coverStyle(channel), cut.Title, clip}
+	if channel.CoverText != "" || cut.CoverText != "" || channel.Cover.WordsPerLine > 0 {
+		values = append(values, "text", coverText(channel, cut, coverVars(channel, videoID, details, cut)))
+	}
+	return settingsHash(values...)
+}
+
+// verticalSettings returns the hash of the settings the vertical version of a
+// clip is composed with. clip is the settings hash of the clip.
+func (p *pipeline) verticalSettings(clip string) string {
+	channel := p.channel
+	values := []any{"vertical", channel.Renderer, channel.VerticalVideoBase, verticalCaptionStyle(channel), channel.SafeZone, clip}
+	if format := channel.Outputs.Vertical; format != (config.OutputFormat{}) {
+		values = append(values, p.formatSettings(format)...)
+	}
+	if p.still {
+		values = append(values, "audiogram", audiogramLayout(channel), channel.Audiogram)
+	} else if layout := splitLayout(channel); layout != nil {
+		values = append(values, "split", *layout)
+	}
+	return settingsHash(values...)
+}
+
+// horizontalSettings returns the hash of the settings the horizontal version
+// of a clip is composed with. clip is the settings hash of the clip.
+func (p *pipeline) horizontalSettings(clip string) string {
+	values := []any{"horizontal", p.channel.Renderer, p.channel.HorizontalVideoBase, clip}
+	if format := p.channel.Outputs.Horizontal; format != (config.OutputFormat{}) {
+		values = append(values, p.formatSettings(format)...)
+	}
+	return settingsHash(values...)
+}
+
+// RefreshChanged processes again the videos of channel processed by earlier
+// runs, regenerating only the outputs whose settings changed since they were
+// produced. Cuts, hooks and metadata are only requested again from the
+// language model when the settings they depend on changed. It returns an
+// error when the backends of the channel cannot be configured.
+func RefreshChanged(ctx context.Context, client *Client, channel config.Channel) error {
+	fmt.Println(titleStyle.Render("Refreshing changed outputs for channel: " + channel.Name))
+
+	videoFiles, err := filepath.Glob(filepath.Join(channel.Folder, "*", videoFile))
+	if err != nil || len(videoFiles) == 0 {
+		fmt.Println(successStyle.Render("No processed videos found for channel: " + channel.Name))
+		return nil
+	}
+	sort.Strings(videoFiles)
+
+	p, err := newPipeline(ctx, client, channel)
+	if err != nil {
+		return fmt.Errorf("error configuring %v", err)
+	}
+	p.refreshing = true
+
+	for i, file := range videoFiles {
+		if ctx.Err() != nil {
+			break
+		}
+
+		outputDir := filepath.Dir(file)
+		videoID := filepath.Base(outputDir)
+		fmt.Println(titleStyle.Render(fmt.Sprintf("Refreshing video %d/%d (ID: %s)", i+1, len(videoFiles), videoID)))
+
+		removeTempFiles(outputDir)
+		if !p.run(ctx, outputDir, loadVideo(outputDir, videoID)) {
+			break
+		}
+	}
+
+	if ctx.Err() == nil {
+		p.writeFeeds(ctx)
+	}
+
+	fmt.Println(titleStyle.Render("Refresh completed for channel: " + channel.Name))
+	return nil
+}
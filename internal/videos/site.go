@@ -0,0 +1,258 @@
+package videos
+
+import (
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/rogersilvasouza/godeogoker/internal/config"
+	"github.com/rogersilvasouza/godeogoker/internal/state"
+)
+
+// siteStyle is the stylesheet shared by every page of the exported site.
+const siteStyle = `body{font-family:system-ui,sans-serif;margin:2rem auto;max-width:1100px;padding:0 1rem;color:#222}
+a{color:#d6336c}
+.grid{display:grid;grid-template-columns:repeat(auto-fill,minmax(320px,1fr));gap:1.5rem}
+.card{border:1px solid #ddd;border-radius:8px;padding:1rem}
+.card video{width:100%;border-radius:4px;background:#000}
+.card img{width:100%;border-radius:4px}
+.tags{color:#666;font-size:.85rem}
+.badge{background:#2b8a3e;color:#fff;border-radius:4px;padding:0 .4rem;font-size:.8rem}`
+
+// siteTemplates holds the pages of the exported site. The root function is
+// replaced for each page by writePage.
+var siteTemplates = template.Must(template.New("layout").Funcs(template.FuncMap{"root": func(file string) string { return file }}).Parse(`{{define "head"}}<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.}}</title>
+<link rel="stylesheet" href="{{"style.css" | root}}">
+</head>
+<body>
+{{end}}
+
+{{define "index"}}{{template "head" "Clip library"}}
+<h1>Clip library</h1>
+<div class="grid">
+{{range .}}<div class="card">
+<h2><a href="{{.ID}}/index.html">{{.Name}}</a></h2>
+<p>{{len .Videos}} source videos, {{.Clips}} clips</p>
+</div>
+{{end}}</div>
+</body>
+</html>
+{{end}}
+
+{{define "channel"}}{{template "head" .Name}}
+<p><a href="../index.html">Clip library</a></p>
+<h1>{{.Name}}</h1>
+<div class="grid">
+{{range .Videos}}<div class="card">
+<a href="{{.ID}}.html">{{with .Cover}}<img src="{{.}}" alt="">{{end}}<h2>{{.Title}}</h2></a>
+<p>{{len .Clips}} clips{{with .Published}}, published {{.}}{{end}}</p>
+</div>
+{{end}}</div>
+</body>
+</html>
+{{end}}
+
+{{define "video"}}{{template "head" .Title}}
+<p><a href="../index.html">Clip library</a> / <a href="index.html">{{.Channel}}</a></p>
+<h1>{{.Title}}</h1>
+{{with .Source}}<p><a href="{{.}}">Source video</a></p>{{end}}
+<div class="grid">
+{{range .Clips}}<div class="card">
+<video controls preload="metadata" src="{{.Clip}}"{{with .Cover}} poster="{{.}}"{{end}}></video>
+<h2>{{.Title}}{{if .Uploaded}} <span class="badge">on YouTube</span>{{end}}</h2>
+<p>{{.Description}}</p>
+{{with .Tags}}<p class="tags">{{range .}}#{{.}} {{end}}</p>{{end}}
+<p>{{with .Vertical}}<a href="{{.}}">Vertical version</a> {{end}}{{with .Published}}<a href="{{.}}">Published version</a>{{end}}</p>
+</div>
+{{end}}</div>
+</body>
+</html>
+{{end}}`))
+
+// siteChannel is a channel listed on the exported site.
+type siteChannel struct {
+	ID     string
+	Name   string
+	Clips  int
+	Videos []*siteVideo
+}
+
+// siteVideo is a source video page of the exported site.
+type siteVideo struct {
+	ID        string
+	Title     string
+	Channel   string
+	Source    string
+	Published string
+	Cover     string
+	Clips     []siteClip
+}
+
+// siteClip is a clip shown with an embedded player on a video page.
+// Clip, Vertical and Cover are relative to the page so the local files play
+// without being copied; Published links to the stored version when the feed
+// base URL of the channel is set.
+type siteClip struct {
+	Title       string
+	Description string
+	Tags        []string
+	Clip        string
+	Vertical    string
+	Cover       string
+	Published   string
+	Uploaded    bool
+}
+
+// ExportSite writes a static HTML gallery of the rendered clips of channels
+// into outDir: an index of the channels, a page per channel listing its source
+// videos and a page per source video with a player for each clip. The players
+// load the clips from the channel folders, so the site must be browsed from the
+// same machine or share. store, when set, marks the clips uploaded to YouTube.
+func ExportSite(channels []config.Channel, store *state.Store, outDir string) error {
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return fmt.Errorf("error creating site folder: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(outDir, "style.css"), []byte(siteStyle), 0644); err != nil {
+		return fmt.Errorf("error writing stylesheet: %v", err)
+	}
+
+	var index []siteChannel
+	for _, channel := range channels {
+		fmt.Println(commandStyle.Render("Exporting clips of channel: " + channel.Name))
+
+		site, err := exportChannel(channel, store, outDir)
+		if err != nil {
+			return err
+		}
+		index = append(index, site)
+	}
+
+	if err := writePage(filepath.Join(outDir, "index.html"), outDir, "index", index); err != nil {
+		return err
+	}
+
+	fmt.Println(successStyle.Render("Site exported to " + outDir))
+	return nil
+}
+
+// exportChannel writes the pages of a channel below outDir/{channel ID}.
+func exportChannel(channel config.Channel, store *state.Store, outDir string) (siteChannel, error) {
+	site := siteChannel{ID: safeFileName(channel.ID), Name: channel.Name}
+	pageDir := filepath.Join(outDir, site.ID)
+	if err := os.MkdirAll(pageDir, 0755); err != nil {
+		return site, fmt.Errorf("error creating site folder: %v", err)
+	}
+
+	clips, err := ListClips(channel.Folder)
+	if err != nil {
+		return site, fmt.Errorf("error listing clips of %s: %v", channel.Name, err)
+	}
+
+	videos := make(map[string]*siteVideo)
+	for _, clip := range clips {
+		video, ok := videos[clip.Source.ID]
+		if !ok {
+			video = &siteVideo{
+				ID:      clip.Source.ID,
+				Title:   clip.Source.Title,
+				Channel: channel.Name,
+				Source:  clip.Source.PageURL(),
+			}
+			if video.Title == "" {
+				video.Title = clip.Source.ID
+			}
+			if len(clip.Source.Published) >= len("2006-01-02") {
+				video.Published = clip.Source.Published[:len("2006-01-02")]
+			}
+			videos[clip.Source.ID] = video
+			site.Videos = append(site.Videos, video)
+		}
+
+		entry := siteClip{
+			Title:       clip.Metadata.Title,
+			Description: clip.Metadata.Description,
+			Tags:        clip.Metadata.Tags,
+			Clip:        relativeLink(pageDir, clip.Clip),
+		}
+		if channel.Feed.BaseURL != "" {
+			entry.Published = PublicLink(channel, clip.Clip)
+		}
+		if clip.Vertical != "" {
+			entry.Vertical = relativeLink(pageDir, clip.Vertical)
+		}
+		if clip.Cover != "" {
+			entry.Cover = relativeLink(pageDir, clip.Cover)
+			if video.Cover == "" {
+				video.Cover = entry.Cover
+			}
+		}
+		if store != nil {
+			entry.Uploaded = store.Uploaded(channel.ID, OutputKey(channel.Folder, clip.Clip)) ||
+				(clip.Vertical != "" && store.Uploaded(channel.ID, OutputKey(channel.Folder, clip.Vertical)))
+		}
+		video.Clips = append(video.Clips, entry)
+		site.Clips++
+	}
+
+	sort.SliceStable(site.Videos, func(i, j int) bool { return site.Videos[i].Published > site.Videos[j].Published })
+
+	for _, video := range site.Videos {
+		if err := writePage(filepath.Join(pageDir, video.ID+".html"), outDir, "video", video); err != nil {
+			return site, err
+		}
+	}
+
+	if err := writePage(filepath.Join(pageDir, "index.html"), outDir, "channel", site); err != nil {
+		return site, err
+	}
+
+	return site, nil
+}
+
+// writePage renders the named template into path. Links to files at the
+// root of the site are made relative to the page through the root function.
+func writePage(path string, outDir string, name string, data any) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("error creating %s: %v", path, err)
+	}
+	defer file.Close()
+
+	page, err := siteTemplates.Clone()
+	if err != nil {
+		return err
+	}
+	page.Funcs(template.FuncMap{
+		"root": func(file string) string { return relativeLink(filepath.Dir(path), filepath.Join(outDir, file)) },
+	})
+
+	if err := page.ExecuteTemplate(file, name, data); err != nil {
+		return fmt.Errorf("error rendering %s: %v", path, err)
+	}
+	return nil
+}
+
+// relativeLink returns the slash-separated path of target relative to dir,
+// or its absolute path when no relative path exists.
+func relativeLink(dir string, target string) string {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return filepath.ToSlash(target)
+	}
+	absTarget, err := filepath.Abs(target)
+	if err != nil {
+		return filepath.ToSlash(target)
+	}
+	rel, err := filepath.Rel(absDir, absTarget)
+	if err != nil {
+		return filepath.ToSlash(absTarget)
+	}
+	return filepath.ToSlash(rel)
+}
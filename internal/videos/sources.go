@@ -0,0 +1,388 @@
+package videos
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/rogersilvasouza/godeogoker/internal/config"
+	"google.golang.org/api/youtube/v3"
+)
+
+// Source lists the videos of one of the places a channel takes them from:
+// a YouTube channel or playlist, a page yt-dlp lists such as Twitch VODs or a
+// Vimeo channel, a podcast RSS feed or a local folder.
+type Source interface {
+	// List returns the videos of the source for channel, in the order the
+	// source gives them. Failures are returned as errors of kind ErrFeedFailed.
+	List(ctx context.Context, channel config.Channel) ([]Video, error)
+}
+
+// channelSources returns the sources of channel: its channel_id and the
+// entries of its sources. Handles and channel URLs are resolved to channel
+// IDs first; one that cannot be is reported and left out, and the last such
+// error is returned with the other sources.
+func (c *Client) channelSources(ctx context.Context, channel config.Channel) ([]Source, error) {
+	var sources []Source
+	var resolveErr error
+	addChannel := func(target string, live bool) {
+		id, err := c.resolveChannelID(ctx, target)
+		if err != nil {
+			fmt.Println(errorStyle.Render(err.Error()))
+			resolveErr = err
+			return
+		}
+		if id != "" {
+			sources = append(sources, feedSource{client: c, feed: channelFeed(id, live)})
+		}
+	}
+
+	addChannel(channel.ChannelID, false)
+	for _, source := range channel.Sources {
+		switch {
+		case source.URL != "":
+			sources = append(sources, pageSource{client: c, url: source.URL})
+		case source.RSS != "":
+			sources = append(sources, podcastSource{client: c, url: source.RSS})
+		case source.Dir != "":
+			sources = append(sources, folderSource{dir: source.Dir})
+		case source.PlaylistID != "":
+			sources = append(sources, feedSource{client: c, feed: playlistFeed(source.Playlist())})
+		case source.ChannelID != "":
+			addChannel(source.ChannelID, source.Live)
+		case source.Live:
+			addChannel(channel.ChannelID, true)
+		}
+	}
+	return sources, resolveErr
+}
+
+// feedSource is a YouTube channel, playlist or set of live streams, listed as
+// described in listFeed.
+type feedSource struct {
+	client *Client
+	feed   feed
+}
+
+// List returns the videos of the feed.
+func (s feedSource) List(ctx context.Context, channel config.Channel) ([]Video, error) {
+	return s.client.listFeed(ctx, s.feed, channel)
+}
+
+// pageSource is a channel or playlist page of any site yt-dlp supports, listed
+// with yt-dlp and downloaded from the page of each video.
+type pageSource struct {
+	client *Client
+	url    string
+}
+
+// List returns the videos yt-dlp lists for the page. Errors are reported and
+// yield no videos, as in fetchPlaylist.
+func (s pageSource) List(ctx context.Context, channel config.Channel) ([]Video, error) {
+	return s.client.fetchPlaylist(ctx, s.url, channel.YtdlpOptions), nil
+}
+
+// maxPodcastFeed is the part of a podcast RSS feed read.
+const maxPodcastFeed = 16 << 20
+
+// podcastFeed is the subset of a podcast RSS feed used to list its episodes.
+type podcastFeed struct {
+	Items []struct {
+		GUID        string `xml:"guid"`
+		Title       string `xml:"title"`
+		Description string `xml:"description"`
+		PubDate     string `xml:"pubDate"`
+		Enclosure   struct {
+			URL  string `xml:"url,attr"`
+			Type string `xml:"type,attr"`
+		} `xml:"enclosure"`
+	} `xml:"channel>item"`
+}
+
+// podcastSource is a podcast RSS feed, whose video episodes are downloaded by
+// yt-dlp from the URL of their enclosure.
+type podcastSource struct {
+	client *Client
+	url    string
+}
+
+// List returns the video episodes of the feed in feed order. Episodes whose
+// enclosure is audio are left out, since clips need a picture. The ID of an
+// episode is derived from its guid, so it stays the same when the feed moves
+// its files.
+func (s podcastSource) List(ctx context.Context, channel config.Channel) ([]Video, error) {
+	fmt.Println(descriptionStyle.Render("Fetching podcast feed: " + s.url))
+
+	ctx, cancel := withTimeout(ctx, feedTimeout(s.client.Config.FeedFetch), "podcast feed request")
+	defer cancel()
+
+	req, doer, err := s.client.feedRequest(ctx, s.url)
+	if err != nil {
+		return nil, newError(ErrFeedFailed, "", "", fmt.Errorf("error requesting podcast feed: %v", err))
+	}
+	resp, err := doer.Do(req)
+	if err != nil {
+		return nil, newError(ErrFeedFailed, "", "", fmt.Errorf("error requesting podcast feed: %v", timeoutCause(ctx, err)))
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, newError(ErrFeedFailed, "", "", fmt.Errorf("podcast feed %s answered %s", s.url, resp.Status))
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxPodcastFeed))
+	if err != nil {
+		return nil, newError(ErrFeedFailed, "", "", fmt.Errorf("error reading podcast feed: %v", err))
+	}
+	var podcast podcastFeed
+	if err := xml.Unmarshal(body, &podcast); err != nil {
+		return nil, newError(ErrFeedFailed, "", "", fmt.Errorf("error parsing podcast feed: %v", err))
+	}
+
+	var videos []Video
+	audio := 0
+	for _, item := range podcast.Items {
+		enclosure := item.Enclosure
+		if enclosure.URL == "" {
+			continue
+		}
+		if !isVideoEnclosure(enclosure.Type, enclosure.URL) {
+			audio++
+			continue
+		}
+		guid := strings.TrimSpace(item.GUID)
+		if guid == "" {
+			guid = enclosure.URL
+		}
+		video := Video{ID: episodeID(guid), Title: strings.TrimSpace(item.Title), Description: strings.TrimSpace(item.Description), URL: enclosure.URL}
+		if published, err := time.Parse(time.RFC1123Z, strings.TrimSpace(item.PubDate)); err == nil {
+			video.Published = published.UTC().Format(time.RFC3339)
+		} else if published, err := time.Parse(time.RFC1123, strings.TrimSpace(item.PubDate)); err == nil {
+			video.Published = published.UTC().Format(time.RFC3339)
+		}
+		videos = append(videos, video)
+	}
+	if audio > 0 {
+		fmt.Println(descriptionStyle.Render(fmt.Sprintf("Skipped %d audio-only episodes", audio)))
+	}
+	return videos, nil
+}
+
+// isVideoEnclosure reports whether the enclosure of a podcast episode, of
+// MIME type kind at address, is a video.
+func isVideoEnclosure(kind string, address string) bool {
+	if kind != "" {
+		return strings.HasPrefix(kind, "video/")
+	}
+	path, _, _ := strings.Cut(address, "?")
+	return slices.Contains(folderExtensions, strings.ToLower(filepath.Ext(path)))
+}
+
+// episodeID returns the video ID of the podcast episode with guid.
+func episodeID(guid string) string {
+	sum := sha256.Sum256([]byte(guid))
+	return "ep-" + hex.EncodeToString(sum[:])[:12]
+}
+
+// folderExtensions are the extensions of the files listed from a folder and
+// of the podcast enclosures without a type taken as videos. They are MP4
+// containers, copied as they are to the .mp4 source of the video.
+var folderExtensions = []string{".mp4", ".m4v", ".mov"}
+
+// folderSource is a local folder of videos, such as recordings exported by
+// hand, copied instead of downloaded.
+type folderSource struct {
+	dir string
+}
+
+// List returns the MP4 files of the folder from the newest, by modification
+// time. The ID of a video is made of its file name and path, as returned by
+// fileVideoID, and the files still being written, modified in the last
+// minute, are left for the next run.
+func (s folderSource) List(ctx context.Context, channel config.Channel) ([]Video, error) {
+	fmt.Println(descriptionStyle.Render("Listing folder: " + s.dir))
+
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, newError(ErrFeedFailed, "", "", fmt.Errorf("error listing folder %s: %v", s.dir, err))
+	}
+
+	var videos []Video
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || strings.HasPrefix(name, ".") || !slices.Contains(folderExtensions, strings.ToLower(filepath.Ext(name))) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || time.Since(info.ModTime()) < time.Minute {
+			continue
+		}
+		path, err := filepath.Abs(filepath.Join(s.dir, name))
+		if err != nil {
+			return nil, newError(ErrFeedFailed, "", "", err)
+		}
+		stem := strings.TrimSuffix(name, filepath.Ext(name))
+		videos = append(videos, Video{ID: fileVideoID(path), Title: stem, Published: info.ModTime().UTC().Format(time.RFC3339), File: path})
+	}
+
+	sort.SliceStable(videos, func(i, j int) bool { return videos[i].Published > videos[j].Published })
+	return videos, nil
+}
+
+// Limits of the listings made with the YouTube Data API.
+const (
+	maxFeedEntries = 15 // Videos the RSS feeds list
+	listPageSize   = 50 // Videos per page of the API, its maximum
+	maxListPages   = 10 // Pages read per feed, bounding the quota used by a poll
+)
+
+// feed is a channel, playlist or set of live streams listed by GetLastVideos.
+type feed struct {
+	query     string // Query of its RSS feed, such as channel_id=... or playlist_id=...
+	playlist  string // Playlist listing it with the YouTube Data API, the uploads playlist of a channel
+	channelID string // Channel of the live streams, empty for playlists
+	live      bool   // Only the finished live streams of the channel
+}
+
+// channelFeed returns the feed of the uploads of the channel id, or of its
+// finished live streams when live is set.
+func channelFeed(id string, live bool) feed {
+	return feed{
+		query:     "channel_id=" + id,
+		playlist:  "UU" + strings.TrimPrefix(id, "UC"),
+		channelID: id,
+		live:      live,
+	}
+}
+
+// playlistFeed returns the feed of the playlist id.
+func playlistFeed(id string) feed {
+	return feed{query: "playlist_id=" + id, playlist: id}
+}
+
+// listFeed returns the videos of f for channel. RSS feeds only list the
+// latest maxFeedEntries videos and no live streams, so when video_limit is
+// higher, or f lists live streams, the videos are paged through with the
+// YouTube Data API when godeogoker is logged in. Otherwise live streams are
+// listed from the streams tab of the channel with yt-dlp, and other feeds
+// fall back to RSS.
+func (c *Client) listFeed(ctx context.Context, f feed, channel config.Channel) ([]Video, error) {
+	limit := channel.VideoLimit
+	if limit <= 0 {
+		limit = maxFeedEntries
+	}
+	if !f.live && limit <= maxFeedEntries {
+		return c.fetchFeed(ctx, f.query)
+	}
+
+	if service, err := c.youtubeService(ctx); err == nil {
+		videos, err := c.listPlaylist(ctx, service, f, limit)
+		if err == nil {
+			return videos, nil
+		}
+		fmt.Println(errorStyle.Render(err.Error()))
+	}
+
+	if f.live {
+		streams := c.fetchPlaylist(ctx, "https://www.youtube.com/channel/"+f.channelID+"/streams", channel.YtdlpOptions, "--playlist-end", strconv.Itoa(limit))
+		for i := range streams {
+			streams[i].URL = ""
+		}
+		return streams, nil
+	}
+	return c.fetchFeed(ctx, f.query)
+}
+
+// listPlaylist returns up to limit videos of the playlist of f, in playlist
+// order, with the YouTube Data API. Private and deleted videos are left out,
+// and so are the videos that are not finished live streams when f lists
+// live streams.
+func (c *Client) listPlaylist(ctx context.Context, service *youtube.Service, f feed, limit int) ([]Video, error) {
+	fmt.Println(descriptionStyle.Render("Listing playlist with the YouTube Data API: " + f.playlist))
+
+	var videos []Video
+	page := ""
+	for range maxListPages {
+		call := service.PlaylistItems.List([]string{"snippet", "contentDetails"}).PlaylistId(f.playlist).MaxResults(listPageSize).Context(ctx)
+		if page != "" {
+			call = call.PageToken(page)
+		}
+		response, err := call.Do()
+		if err != nil {
+			return nil, newError(ErrFeedFailed, "", "", fmt.Errorf("error listing playlist %s: %v", f.playlist, err))
+		}
+
+		var ids []string
+		for _, item := range response.Items {
+			if item.ContentDetails != nil && item.ContentDetails.VideoPublishedAt != "" {
+				ids = append(ids, item.ContentDetails.VideoId)
+			}
+		}
+		finished := make(map[string]bool)
+		if f.live && len(ids) > 0 {
+			streams, err := service.Videos.List([]string{"liveStreamingDetails"}).Id(ids...).Context(ctx).Do()
+			if err != nil {
+				return nil, newError(ErrFeedFailed, "", "", fmt.Errorf("error listing live streams of %s: %v", f.channelID, err))
+			}
+			for _, stream := range streams.Items {
+				if stream.LiveStreamingDetails != nil && stream.LiveStreamingDetails.ActualEndTime != "" {
+					finished[stream.Id] = true
+				}
+			}
+		}
+
+		for _, item := range response.Items {
+			details := item.ContentDetails
+			if details == nil || details.VideoPublishedAt == "" || f.live && !finished[details.VideoId] {
+				continue
+			}
+			video := Video{ID: details.VideoId, Published: details.VideoPublishedAt}
+			if item.Snippet != nil {
+				video.Title, video.Description = item.Snippet.Title, item.Snippet.Description
+			}
+			videos = append(videos, video)
+			if len(videos) == limit {
+				return videos, nil
+			}
+		}
+
+		if page = response.NextPageToken; page == "" {
+			break
+		}
+	}
+	return videos, nil
+}
+
+// fileVideoID returns the video ID of the file at path, an absolute path:
+// its name, extension included, with the characters other than ASCII
+// letters, digits, _ and - replaced by -, so it is usable in folder names,
+// filters and job IDs, followed by a short hash of path, so talk.mp4 and
+// talk.mov, "My Talk" and "My-Talk" or the same name in two folders never
+// share an ID.
+func fileVideoID(path string) string {
+	sum := sha256.Sum256([]byte(path))
+	hash := hex.EncodeToString(sum[:])[:8]
+
+	name := strings.Map(func(r rune) rune {
+		if r < 128 && (unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '-') {
+			return r
+		}
+		return '-'
+	}, filepath.Base(path))
+	name = strings.Trim(name[:min(len(name), 48)], "-")
+	if name == "" {
+		return "file-" + hash
+	}
+	return name + "-" + hash
+}
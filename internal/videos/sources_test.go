@@ -0,0 +1,38 @@
+package videos
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFileVideoIDsDoNotCollide(t *testing.T) {
+	paths := []string{
+		"/videos/talk.mp4",
+		"/videos/talk.mov",
+		"/videos/My Talk.mp4",
+		"/videos/My-Talk.mp4",
+		"/archive/talk.mp4",
+		"/videos/café.mp4",
+		"/videos/çé.mp4",
+	}
+
+	seen := make(map[string]string)
+	for _, path := range paths {
+		id := fileVideoID(path)
+		if other, ok := seen[id]; ok {
+			t.Errorf("%s and %s share the ID %s", path, other, id)
+		}
+		seen[id] = path
+
+		if strings.Trim(id, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_-") != "" {
+			t.Errorf("ID %q of %s has other characters", id, path)
+		}
+		if fileVideoID(path) != id {
+			t.Errorf("ID of %s is not stable", path)
+		}
+	}
+
+	if id := fileVideoID("/videos/talk.mp4"); !strings.HasPrefix(id, "talk-mp4-") {
+		t.Errorf("ID = %q, want it to start with the file name", id)
+	}
+}
@@ -0,0 +1,69 @@
+package videos
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// speechRatio returns the fraction of the video covered by captions with
+// spoken text, ignoring music markers. The video is assumed to last until
+// the last caption.
+func speechRatio(entries []SubtitleEntry) float64 {
+	var spans []Span
+	for _, entry := range entries {
+		text := cleanSubtitleText(entry.Text)
+		if text == "" || isMusicMarker(text) {
+			continue
+		}
+		spans = append(spans, Span{Start: entry.StartTime.Seconds(), End: entry.EndTime.Seconds()})
+	}
+
+	var duration time.Duration
+	for _, entry := range entries {
+		duration = max(duration, entry.EndTime)
+	}
+	if len(spans) == 0 || duration <= 0 {
+		return 0
+	}
+
+	// Auto-captions overlap while they roll, so the covered time is the
+	// length of the union of the cues.
+	sort.Slice(spans, func(i, j int) bool { return spans[i].Start < spans[j].Start })
+	covered := 0.0
+	current := spans[0]
+	for _, span := range spans[1:] {
+		if span.Start > current.End {
+			covered += current.End - current.Start
+			current = span
+			continue
+		}
+		current.End = max(current.End, span.End)
+	}
+	covered += current.End - current.Start
+
+	return covered / duration.Seconds()
+}
+
+// lacksSpeech reports whether the captions of a video have too little speech
+// for cut detection to make sense, such as music videos or ambient streams,
+// printing the reason. It always returns false when min_speech_ratio is not set.
+func (p *pipeline) lacksSpeech(captions string) bool {
+	minRatio := p.channel.MinSpeechRatio
+	if minRatio <= 0 {
+		return false
+	}
+
+	entries, err := parseVTTFile(captions)
+	if err != nil {
+		return false
+	}
+
+	ratio := speechRatio(entries)
+	if ratio >= minRatio {
+		return false
+	}
+
+	fmt.Println(subtitleStyle.Render(fmt.Sprintf("Skipping video: speech covers %.0f%% of it, below the minimum of %.0f%% (music video or ambient stream?)", ratio*100, minRatio*100)))
+	return true
+}
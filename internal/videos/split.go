@@ -0,0 +1,86 @@
+package videos
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/rogersilvasouza/godeogoker/internal/config"
+)
+
+// Split layout: the clip and the secondary video each fill a splitHeight
+// pixels tall half of the 1080x1920 frame, and the captions sit on the seam,
+// splitCaptionsMargin above the bottom of the 288 pixel tall frame libass
+// measures SRT styles against.
+const (
+	splitHeight         = 960
+	splitCaptionsMargin = 138
+)
+
+// SplitLayout is how the clip and the secondary video of a split vertical
+// video are arranged.
+type SplitLayout struct {
+	Secondary string  // Video looped in the other half, such as a recorded reaction or gameplay
+	Swap      bool    // Put the clip in the bottom half instead of the top one
+	Volume    float64 // Volume of the secondary video mixed under the clip, 0 to mute it
+}
+
+// splitLayout returns the split layout of the channel, or nil when its
+// vertical videos use the background layout.
+func splitLayout(channel config.Channel) *SplitLayout {
+	if channel.VerticalLayout != "split" || channel.Split == nil {
+		return nil
+	}
+	return &SplitLayout{Secondary: channel.Split.Video, Swap: channel.Split.Swap, Volume: channel.Split.Volume}
+}
+
+// splitCaptionStyle returns style moved to the seam of the split layout,
+// unless the channel placed its vertical captions itself.
+func splitCaptionStyle(channel config.Channel, style SubtitleStyle) SubtitleStyle {
+	if channel.VerticalCaptions.MarginV == 0 {
+		style.MarginV = splitCaptionsMargin
+	}
+	return style
+}
+
+// Split composes the vertical version of clip in the split layout: the clip
+// and the looped secondary video of layout, each cropped to fill half of the
+// frame, stacked and with the SRT subtitles file burned with style, kept out
+// of zone. The audio is the one of the clip, with the secondary video mixed
+// under it at the volume of layout.
+func (r *FFmpegRenderer) Split(ctx context.Context, clip string, subtitles string, style SubtitleStyle, zone SafeZone, layout SplitLayout, output string) error {
+	rate := ""
+	if fps := r.compositeRate(ctx, r.Vertical, clip); fps != "" {
+		rate = "fps=" + fps + ","
+	}
+	half := fmt.Sprintf("scale=1080:%d:force_original_aspect_ratio=increase,crop=1080:%d,setsar=1", splitHeight, splitHeight)
+
+	top, bottom := "[main]", "[second]"
+	if layout.Swap {
+		top, bottom = bottom, top
+	}
+	filters := []string{
+		"[0:v]" + rate + half + "[main]",
+		"[1:v]" + rate + half + "[second]",
+		fmt.Sprintf("%s%svstack=inputs=2:shortest=1,%s[outv]", top, bottom, zone.apply(style).subtitles(subtitles)),
+	}
+	audio := "0:a"
+	if layout.Volume > 0 {
+		filters = append(filters, fmt.Sprintf("[1:a]volume=%g[seconda];[0:a][seconda]amix=inputs=2:duration=first:normalize=0[outa]", layout.Volume))
+		audio = "[outa]"
+	}
+
+	args := []string{
+		"-i", clip,
+		"-stream_loop", "-1",
+		"-i", layout.Secondary,
+		"-filter_complex", strings.Join(filters, ";"),
+		"-map", "[outv]",
+		"-map", audio,
+	}
+	args = append(args, r.encode(r.Vertical)...)
+	args = append(args, r.Vertical.args()...)
+	args = append(args, "-shortest")
+
+	return r.render(ctx, output, args...)
+}
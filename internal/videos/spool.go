@@ -0,0 +1,250 @@
+package videos
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/rogersilvasouza/godeogoker/internal/config"
+	"github.com/rogersilvasouza/godeogoker/internal/events"
+	"github.com/rogersilvasouza/godeogoker/internal/state"
+)
+
+// spoolFolder is the folder of the state folder holding the failed uploads,
+// with a subfolder per channel.
+const spoolFolder = "spool"
+
+// uploadAttempts is how many times an upload is tried before it is spooled.
+const uploadAttempts = 3
+
+// uploadRetryDelay is the wait before the second attempt of an upload,
+// growing with each attempt.
+const uploadRetryDelay = 30 * time.Second
+
+// Wait before the next drain attempt of a spooled upload, doubling after
+// each failed attempt up to the maximum.
+const (
+	spoolBackoff    = 15 * time.Minute
+	spoolMaxBackoff = 12 * time.Hour
+)
+
+// uploadDetails is what a spooled upload is sent with, saved next to its clip.
+type uploadDetails struct {
+	Title         string                  `json:"title"`
+	Description   string                  `json:"description"`
+	Tags          []string                `json:"tags"`
+	Language      string                  `json:"language,omitempty"`      // Language code of the title and description
+	Localizations map[string]Localization `json:"localizations,omitempty"` // Translations by language code
+	PublishAt     string                  `json:"publish_at,omitempty"`    // RFC 3339 time the upload goes public, empty to upload it as unlisted
+}
+
+// spoolDelay returns the wait before the next attempt of a spooled upload
+// that failed attempts times.
+func spoolDelay(attempts int) time.Duration {
+	delay := spoolBackoff
+	for i := 1; i < attempts && delay < spoolMaxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, spoolMaxBackoff)
+}
+
+// uploadClip uploads path to YouTube as unlisted, or scheduled to go public
+// at details.PublishAt, trying again after
+// transient failures, and returns its YouTube video ID. Quota and authentication errors are returned at once,
+// since trying again right away cannot succeed.
+func (c *Client) uploadClip(ctx context.Context, path string, details uploadDetails) (string, error) {
+	limit := timeoutOf(c.Config.Timeouts.Upload, DefaultUploadTimeout)
+
+	var id string
+	var err error
+	for attempt := 1; attempt <= uploadAttempts; attempt++ {
+		if attempt > 1 {
+			fmt.Println(subtitleStyle.Render(fmt.Sprintf("Upload failed, trying again (%d/%d): %v", attempt, uploadAttempts, err)))
+			if sleepContext(ctx, time.Duration(attempt-1)*uploadRetryDelay) != nil {
+				return "", err
+			}
+		}
+
+		uploadCtx, cancel := withTimeout(ctx, limit, "upload")
+		id, err = c.UploadToYouTube(uploadCtx, path, details.Title, details.Description, details.Tags, "unlisted", details.Language, details.Localizations, details.PublishAt)
+		err = timeoutCause(uploadCtx, err)
+		cancel()
+
+		if err == nil || errors.Is(err, ErrUploadQuota) || errors.Is(err, ErrAuth) || ctx.Err() != nil {
+			return id, err
+		}
+	}
+	return "", err
+}
+
+// spool keeps a failed upload of the clip at path, recorded under key, in the
+// spool folder of the channel, with a copy of the clip and its upload details,
+// so a drain of the spool can send it later. Nothing is spooled without a
+// state store.
+func (p *pipeline) spool(videoID string, cut Cut, path string, key string, details uploadDetails, cause error) {
+	store := p.client.State
+	if store == nil {
+		return
+	}
+
+	dir := filepath.Join(p.client.Config.StateDir(), spoolFolder, p.channel.ID)
+	base := filepath.Join(dir, strings.Replace(strings.NewReplacer("/", "_", "|", "_").Replace(key), filepath.Ext(path), "", 1))
+
+	entry := state.Spooled{
+		Key:      key,
+		VideoID:  videoID,
+		Cut:      cut.Title,
+		Clip:     base + filepath.Ext(path),
+		Metadata: base + ".json",
+	}
+	for _, spooled := range store.Spooled(p.channel.ID) {
+		if spooled.Key == key {
+			entry.Attempts = spooled.Attempts
+		}
+	}
+
+	if err := spoolFiles(dir, path, details, entry); err != nil {
+		fmt.Println(errorStyle.Render("Error spooling failed upload: " + err.Error()))
+		return
+	}
+
+	entry.Attempts++
+	entry.Error = cause.Error()
+	entry.Next = time.Now().Add(spoolDelay(entry.Attempts))
+	if err := store.SaveSpooled(p.channel.ID, entry); err != nil {
+		fmt.Println(errorStyle.Render("Error recording spooled upload: " + err.Error()))
+		return
+	}
+
+	fmt.Println(subtitleStyle.Render("Upload spooled for a later retry: " + filepath.Base(path)))
+}
+
+// spoolFiles links, or copies when linking is not possible, the clip at path
+// into the spool folder dir and writes its upload details next to it.
+func spoolFiles(dir string, path string, details uploadDetails, entry state.Spooled) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	// The clip may have been rendered again since it was first spooled.
+	os.Remove(entry.Clip)
+	if err := os.Link(path, entry.Clip); err != nil {
+		// The clip is spooled even while the run is being cancelled, so it is not lost.
+		if err := copyIfMissing(context.Background(), path, entry.Clip); err != nil {
+			return err
+		}
+	}
+
+	content, err := json.MarshalIndent(details, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(entry.Metadata, content, 0644)
+}
+
+// unspool deletes a spooled upload of channel and its files.
+func unspool(store *state.Store, channel string, entry state.Spooled) {
+	os.Remove(entry.Clip)
+	os.Remove(entry.Metadata)
+	if err := store.RemoveSpooled(channel, entry.Key); err != nil {
+		fmt.Println(errorStyle.Render("Error removing spooled upload: " + err.Error()))
+	}
+}
+
+// DrainSpool uploads the clips of channel waiting in the spool. Uploads whose
+// next attempt is not due yet are skipped unless all is set, and draining
+// stops at the first quota rejection. It returns the number of uploads left
+// in the spool, and an error when there is no state store.
+func DrainSpool(ctx context.Context, client *Client, channel config.Channel, all bool) (int, error) {
+	store := client.State
+	if store == nil {
+		return 0, fmt.Errorf("no state store available, nothing to drain")
+	}
+
+	spooled := store.Spooled(channel.ID)
+	if len(spooled) == 0 {
+		return 0, nil
+	}
+	fmt.Println(titleStyle.Render(fmt.Sprintf("Draining %d spooled uploads of channel: %s", len(spooled), channel.Name)))
+
+	remaining := 0
+	for i, entry := range spooled {
+		if ctx.Err() != nil {
+			remaining += len(spooled) - i
+			break
+		}
+
+		if store.Uploaded(channel.ID, entry.Key) {
+			unspool(store, channel.ID, entry)
+			continue
+		}
+		if !all && time.Now().Before(entry.Next) {
+			remaining++
+			continue
+		}
+
+		fmt.Println(commandStyle.Render("Uploading spooled " + entry.Key + " to YouTube..."))
+		id, details, err := uploadSpooled(ctx, client, channel, entry)
+		if err == nil {
+			fmt.Println(successStyle.Render("Video uploaded to YouTube successfully"))
+			client.Events.Emit(events.Event{Type: events.Uploaded, Channel: channel.ID, VideoID: entry.VideoID, Cut: entry.Cut, Path: filepath.Join(channel.Folder, filepath.FromSlash(outputOfKey(entry.Key)))})
+			if err := store.MarkUploaded(channel.ID, entry.Key, id); err != nil {
+				fmt.Println(errorStyle.Render("Error recording upload: " + err.Error()))
+			}
+			unspool(store, channel.ID, entry)
+			if entry.Key == OutputKey(channel.Folder, entry.Clip) {
+				if err := client.writeCommunityPost(ctx, channel, entry.VideoID, entry.Cut, entry.Clip, details, id); err != nil {
+					client.reportError(err)
+				}
+			}
+			client.writeManifest(channel, filepath.Join(channel.Folder, entry.VideoID), entry.VideoID)
+			continue
+		}
+
+		if ctx.Err() != nil {
+			remaining += len(spooled) - i
+			break
+		}
+
+		client.reportError(withContext(err, ErrUploadFailed, entry.VideoID, entry.Cut))
+		entry.Attempts++
+		entry.Error = err.Error()
+		entry.Next = time.Now().Add(spoolDelay(entry.Attempts))
+		if err := store.SaveSpooled(channel.ID, entry); err != nil {
+			fmt.Println(errorStyle.Render("Error recording spooled upload: " + err.Error()))
+		}
+
+		if errors.Is(err, ErrUploadQuota) {
+			fmt.Println(subtitleStyle.Render("Upload quota exhausted. Remaining spooled uploads are kept for a later drain."))
+			remaining += len(spooled) - i
+			break
+		}
+		remaining++
+	}
+
+	return remaining, nil
+}
+
+// uploadSpooled sends a spooled clip of channel with its saved upload
+// details, scheduled again when its publish time has passed, and returns its
+// YouTube video ID with the details uploaded.
+func uploadSpooled(ctx context.Context, client *Client, channel config.Channel, entry state.Spooled) (string, uploadDetails, error) {
+	var details uploadDetails
+	content, err := os.ReadFile(entry.Metadata)
+	if err != nil {
+		return "", details, fmt.Errorf("error reading upload details: %v", err)
+	}
+
+	if err := json.Unmarshal(content, &details); err != nil {
+		return "", details, fmt.Errorf("error parsing upload details: %v", err)
+	}
+	client.schedulePublish(ctx, channel, &details)
+
+	id, err := client.uploadClip(ctx, entry.Clip, details)
+	return id, details, err
+}
@@ -0,0 +1,63 @@
+package videos
+
+import (
+	"context"
+	"runtime"
+
+	"github.com/rogersilvasouza/godeogoker/internal/config"
+)
+
+// Stages limits how many downloads and renders run at the same time when
+// several channels are processed in parallel, so ffmpeg jobs do not overwhelm
+// the machine. A nil *Stages, like a stage without a limit, never waits.
+type Stages struct {
+	downloads chan struct{} // Slots of the video downloads, nil for no limit
+	renders   chan struct{} // Slots of the renders, nil for no limit
+}
+
+// NewStages returns the stage limits configured in parallel. Renders default
+// to a quarter of the CPU cores, since each ffmpeg job already uses several.
+func NewStages(parallel config.Parallel) *Stages {
+	renders := parallel.Renders
+	if renders <= 0 {
+		renders = max(1, runtime.NumCPU()/4)
+	}
+
+	s := &Stages{renders: make(chan struct{}, renders)}
+	if parallel.Downloads > 0 {
+		s.downloads = make(chan struct{}, parallel.Downloads)
+	}
+	return s
+}
+
+// download waits for a free download slot and returns the function freeing it.
+func (s *Stages) download(ctx context.Context) func() {
+	if s == nil {
+		return func() {}
+	}
+	return enter(ctx, s.downloads)
+}
+
+// render waits for a free render slot and returns the function freeing it.
+func (s *Stages) render(ctx context.Context) func() {
+	if s == nil {
+		return func() {}
+	}
+	return enter(ctx, s.renders)
+}
+
+// enter waits for a free slot of stage and returns the function freeing it.
+// It returns right away when the stage has no limit or ctx is cancelled; the
+// step that follows then fails with the cancellation as it would without a
+// limit.
+func enter(ctx context.Context, stage chan struct{}) func() {
+	if stage == nil {
+		return func() {}
+	}
+	select {
+	case stage <- struct{}{}:
+		return func() { <-stage }
+	case <-ctx.Done():
+		return func() {}
+	}
+}
@@ -0,0 +1,142 @@
+package videos
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/rogersilvasouza/godeogoker/internal/config"
+)
+
+// Sources are checked for a still image on a sample of stillSample seconds
+// from their middle, past any intro, and are still images when at least
+// stillShare of the sample is frozen.
+const (
+	stillSample = 60.0
+	stillShare  = 0.95
+)
+
+// Audiogram layout: the artwork fits in an artworkSize square and the
+// waveform spans the width of the frame, waveHeight pixels high, both
+// animated at audiogramFPS unless the vertical output sets a rate.
+const (
+	artworkSize      = 960
+	waveHeight       = 320
+	audiogramFPS     = 30
+	defaultArtworkY  = 260
+	defaultWaveY     = 1240
+	defaultWaveColor = "white"
+)
+
+// AudiogramLayout is where the artwork and the waveform of an audiogram go
+// and how the waveform is drawn.
+type AudiogramLayout struct {
+	ArtworkY  int    // Top of the artwork in pixels
+	WaveY     int    // Top of the waveform in pixels
+	WaveColor string // Color of the waveform
+}
+
+// audiogramLayout returns the audiogram layout of the channel with defaults applied.
+func audiogramLayout(channel config.Channel) AudiogramLayout {
+	layout := AudiogramLayout{ArtworkY: defaultArtworkY, WaveY: defaultWaveY, WaveColor: defaultWaveColor}
+	if audiogram := channel.Audiogram; audiogram != nil {
+		if audiogram.ArtworkY > 0 {
+			layout.ArtworkY = audiogram.ArtworkY
+		}
+		if audiogram.WaveY > 0 {
+			layout.WaveY = audiogram.WaveY
+		}
+		if audiogram.WaveColor != "" {
+			layout.WaveColor = audiogram.WaveColor
+		}
+	}
+	return layout
+}
+
+// Frozen returns how many of the length seconds of input from start are frozen.
+func (r *FFmpegRenderer) Frozen(ctx context.Context, input string, start float64, length float64) (float64, error) {
+	ctx, cancel := withTimeout(ctx, r.Timeout, "ffmpeg")
+	defer cancel()
+
+	output, err := newCommand(
+		ctx,
+		r.FFmpeg,
+		"-ss", fmt.Sprintf("%.3f", start),
+		"-t", fmt.Sprintf("%.3f", length),
+		"-i", input,
+		"-an",
+		"-vf", fmt.Sprintf("freezedetect=n=%s:d=%g", freezeNoise, freezeMinDuration),
+		"-f", "null",
+		"-",
+	).CombinedOutput()
+	if err != nil {
+		return 0, fmt.Errorf("error detecting frozen frames: %v", timeoutCause(ctx, err))
+	}
+
+	return frozenTime(string(output), length), nil
+}
+
+// Audiogram composes the vertical version of a clip of a still-image source:
+// the image of clip as artwork over the background, or a dark frame when
+// background is empty, with a waveform of its audio below and the SRT
+// subtitles file burned with style, kept out of zone.
+func (r *FFmpegRenderer) Audiogram(ctx context.Context, background string, clip string, subtitles string, style SubtitleStyle, zone SafeZone, layout AudiogramLayout, output string) error {
+	rate := audiogramFPS
+	if r.Vertical.FPS > 0 {
+		rate = r.Vertical.FPS
+	}
+
+	var args []string
+	if background == "" {
+		args = append(args, "-f", "lavfi", "-i", "color=c=0x111111:s=1080x1920")
+	} else {
+		args = append(args, "-i", background)
+	}
+	args = append(args, "-i", clip)
+
+	filters := []string{
+		fmt.Sprintf("[0:v]loop=loop=-1:size=1:start=0,scale=1080:1920:force_original_aspect_ratio=increase,crop=1080:1920,setsar=1,fps=%d[bg]", rate),
+		fmt.Sprintf("[1:v]fps=%d,scale=%d:%d:force_original_aspect_ratio=decrease[art]", rate, artworkSize, artworkSize),
+		"[1:a]asplit[outa][wavea]",
+		fmt.Sprintf("[wavea]showwaves=s=1080x%d:mode=cline:colors=%s:rate=%d[wave]", waveHeight, filterValue(layout.WaveColor), rate),
+		fmt.Sprintf("[bg][art]overlay=(W-w)/2:%d:shortest=1[framed]", layout.ArtworkY),
+		fmt.Sprintf("[framed][wave]overlay=0:%d:shortest=1,%s[outv]", layout.WaveY, zone.apply(style).subtitles(subtitles)),
+	}
+	args = append(args,
+		"-filter_complex", strings.Join(filters, ";"),
+		"-map", "[outv]",
+		"-map", "[outa]",
+	)
+	args = append(args, r.encode(r.Vertical)...)
+	args = append(args, r.Vertical.args()...)
+	args = append(args, "-shortest")
+
+	return r.render(ctx, output, args...)
+}
+
+// stillImage reports whether the source video at videoFile, lasting duration
+// seconds, is audio over a static image, such as a radio show upload, either
+// because the channel says so or because a sample of it is frozen.
+func (p *pipeline) stillImage(ctx context.Context, videoFile string, duration float64) bool {
+	switch p.channel.StillImage {
+	case "always":
+		return true
+	case "auto":
+	default:
+		return false
+	}
+
+	length := math.Min(stillSample, duration)
+	frozen, err := p.renderer.Frozen(ctx, videoFile, (duration-length)/2, length)
+	if err != nil {
+		fmt.Println(subtitleStyle.Render("Unable to check for a still image: " + err.Error()))
+		return false
+	}
+	if frozen < stillShare*length {
+		return false
+	}
+
+	fmt.Println(subtitleStyle.Render("The source is audio over a still image, vertical versions become audiograms"))
+	return true
+}
@@ -0,0 +1,89 @@
+package videos
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// tempMarker is inserted before the extension of outputs being written, so
+// they keep their container format for ffmpeg and are never mistaken for
+// finished outputs: clip.mp4 is written as clip.tmp.mp4.
+const tempMarker = ".tmp"
+
+// pendingTemps holds the temporary files being written, deleted by
+// RemoveTempFiles when the program panics or is killed.
+var pendingTemps = struct {
+	sync.Mutex
+	paths map[string]bool
+}{paths: make(map[string]bool)}
+
+// tempName returns the name output is written under until it is complete.
+func tempName(output string) string {
+	ext := filepath.Ext(output)
+	return strings.TrimSuffix(output, ext) + tempMarker + ext
+}
+
+// writeAtomically calls write with the temporary name of output and renames
+// the result over output once write succeeds, so an interrupted run never
+// leaves a half-written file under the final name. The temporary file is
+// removed when write fails or panics.
+func writeAtomically(output string, write func(tmp string) error) error {
+	tmp := tempName(output)
+
+	pendingTemps.Lock()
+	pendingTemps.paths[tmp] = true
+	pendingTemps.Unlock()
+
+	renamed := false
+	defer func() {
+		pendingTemps.Lock()
+		delete(pendingTemps.paths, tmp)
+		pendingTemps.Unlock()
+		if !renamed {
+			os.Remove(tmp)
+		}
+	}()
+
+	if err := write(tmp); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp, output); err != nil {
+		return err
+	}
+	renamed = true
+	return nil
+}
+
+// writeFileAtomic writes content to path through a temporary file, like os.WriteFile.
+func writeFileAtomic(path string, content []byte, perm os.FileMode) error {
+	return writeAtomically(path, func(tmp string) error {
+		return os.WriteFile(tmp, content, perm)
+	})
+}
+
+// RemoveTempFiles deletes the temporary files of the writes still in progress.
+// It runs when the program panics, since a panic ends the program without
+// running the deferred cleanups of the other goroutines.
+func RemoveTempFiles() {
+	pendingTemps.Lock()
+	defer pendingTemps.Unlock()
+
+	for path := range pendingTemps.paths {
+		os.Remove(path)
+		delete(pendingTemps.paths, path)
+	}
+}
+
+// RemoveTempFilesOnPanic deletes the temporary files of the writes still in
+// progress when the goroutine that deferred it panics, then panics again.
+// A recover only sees the panics of its own goroutine, so every goroutine
+// that can reach the pipeline defers it where it starts.
+func RemoveTempFilesOnPanic() {
+	if r := recover(); r != nil {
+		RemoveTempFiles()
+		panic(r)
+	}
+}
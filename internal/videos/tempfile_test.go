@@ -0,0 +1,95 @@
+package videos
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// assertMissing fails t when any of paths exists.
+func assertMissing(t *testing.T, paths ...string) {
+	t.Helper()
+	for _, path := range paths {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("%s exists after the interrupted write", filepath.Base(path))
+		}
+	}
+}
+
+func TestWriteAtomicallyPanicLeavesNoFiles(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "x.mp4")
+
+	recovered := make(chan any)
+	go func() {
+		defer func() { recovered <- recover() }()
+		defer RemoveTempFilesOnPanic()
+		writeAtomically(output, func(tmp string) error {
+			if err := os.WriteFile(tmp, []byte("half a video"), 0644); err != nil {
+				return err
+			}
+			panic("interrupted")
+		})
+	}()
+
+	if r := <-recovered; r != "interrupted" {
+		t.Fatalf("recovered %v, want the panic to be raised again", r)
+	}
+	assertMissing(t, output, tempName(output))
+}
+
+func TestPanicElsewhereRemovesWritesInProgress(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "x.mp4")
+
+	writing := make(chan struct{})
+	release := make(chan struct{})
+	written := make(chan error)
+	go func() {
+		written <- writeAtomically(output, func(tmp string) error {
+			if err := os.WriteFile(tmp, []byte("half a video"), 0644); err != nil {
+				return err
+			}
+			close(writing)
+			<-release
+			return errors.New("killed")
+		})
+	}()
+	<-writing
+
+	recovered := make(chan any)
+	go func() {
+		defer func() { recovered <- recover() }()
+		defer RemoveTempFilesOnPanic()
+		panic("worker crashed")
+	}()
+
+	if r := <-recovered; r != "worker crashed" {
+		t.Fatalf("recovered %v, want the panic to be raised again", r)
+	}
+	assertMissing(t, output, tempName(output))
+
+	close(release)
+	<-written
+	assertMissing(t, output, tempName(output))
+}
+
+func TestWriteAtomicallyFailureLeavesNoFiles(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "x.mp4")
+
+	err := writeAtomically(output, func(tmp string) error {
+		os.WriteFile(tmp, []byte("half a video"), 0644)
+		return errors.New("ffmpeg failed")
+	})
+	if err == nil {
+		t.Fatal("the failed write reported no error")
+	}
+	assertMissing(t, output, tempName(output))
+
+	if err := writeFileAtomic(output, []byte("video"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if content, err := os.ReadFile(output); err != nil || string(content) != "video" {
+		t.Errorf("output = %q, %v", content, err)
+	}
+	assertMissing(t, tempName(output))
+}
@@ -0,0 +1,58 @@
+package videos
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rogersilvasouza/godeogoker/internal/config"
+)
+
+// Default time limits of the pipeline stages, used when the configuration
+// does not set them.
+const (
+	DefaultDownloadTimeout = 60 * time.Minute
+	DefaultFFmpegTimeout   = 30 * time.Minute
+	DefaultUploadTimeout   = 30 * time.Minute
+	DefaultVideoTimeout    = 4 * time.Hour
+)
+
+// timeoutOf converts a limit in minutes from the configuration, returning
+// fallback when it is not set and 0, for no limit, when it is negative.
+func timeoutOf(minutes int, fallback time.Duration) time.Duration {
+	switch {
+	case minutes < 0:
+		return 0
+	case minutes == 0:
+		return fallback
+	default:
+		return time.Duration(minutes) * time.Minute
+	}
+}
+
+// downloadTimeout returns the time limit of each yt-dlp call.
+func downloadTimeout(timeouts config.Timeouts) time.Duration {
+	return timeoutOf(timeouts.Download, DefaultDownloadTimeout)
+}
+
+// withTimeout bounds ctx by limit, when positive. Once the limit is reached,
+// context.Cause of the returned context tells what timed out.
+func withTimeout(ctx context.Context, limit time.Duration, what string) (context.Context, context.CancelFunc) {
+	if limit <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeoutCause(ctx, limit, fmt.Errorf("%s timed out after %s", what, limit))
+}
+
+// timeoutCause returns the timeout that made the call bound to ctx fail
+// instead of err, which is only the signal that stopped it. Other errors,
+// including interruptions, are returned unchanged.
+func timeoutCause(ctx context.Context, err error) error {
+	if err == nil || ctx.Err() != context.DeadlineExceeded {
+		return err
+	}
+	if cause := context.Cause(ctx); cause != nil && cause != context.DeadlineExceeded {
+		return cause
+	}
+	return err
+}
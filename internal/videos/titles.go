@@ -0,0 +1,151 @@
+package videos
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/rogersilvasouza/godeogoker/internal/config"
+)
+
+// partSuffix matches the part number added to the titles of a series, kept
+// when a title is shortened.
+var partSuffix = regexp.MustCompile(`\s*\(Part \d+/\d+\)$`)
+
+// applyTitleRules corrects title to follow the title rules of a channel:
+// banned phrases are removed, the prefix is added with its placeholders
+// expanded from vars, the capitalization is applied to the rest and the title
+// is shortened on a word boundary to the maximum length. It returns the
+// corrected title and a description of each correction made.
+func applyTitleRules(title string, rules config.TitleRules, vars map[string]string) (string, []string) {
+	var corrections []string
+
+	suffix := partSuffix.FindString(title)
+	body := strings.TrimSuffix(title, suffix)
+
+	for _, phrase := range rules.Banned {
+		if strings.TrimSpace(phrase) == "" {
+			continue
+		}
+		banned := regexp.MustCompile(`(?i)(^|\W)` + regexp.QuoteMeta(phrase) + `($|\W)`)
+		if banned.MatchString(body) {
+			body = banned.ReplaceAllString(body, "$1$2")
+			corrections = append(corrections, fmt.Sprintf("removed %q", phrase))
+		}
+	}
+	body = cleanTitle(body)
+
+	prefix := titlePrefix(rules.Prefix, vars)
+	if prefix != "" {
+		// The model may have added the prefix already.
+		if strings.HasPrefix(strings.ToLower(body), strings.ToLower(prefix)) {
+			body = cleanTitle(body[len(prefix):])
+		} else {
+			corrections = append(corrections, "added prefix")
+		}
+		prefix += " "
+	}
+
+	if cased := applyCase(body, rules.Case); cased != body {
+		body = cased
+		corrections = append(corrections, rules.Case+" case")
+	}
+
+	if limit := rules.MaxLength; limit > 0 && len([]rune(prefix+body+suffix)) > limit {
+		body = shortenTitle(body, limit-len([]rune(prefix+suffix)))
+		corrections = append(corrections, fmt.Sprintf("shortened to %d characters", limit))
+	}
+
+	return prefix + body + suffix, corrections
+}
+
+// cleanTitle collapses the spaces of title and trims the separators left at
+// its ends once words are removed.
+func cleanTitle(title string) string {
+	title = strings.Join(strings.Fields(title), " ")
+	return strings.Trim(title, " |-–—:,;")
+}
+
+// titlePrefix expands the placeholders of prefix. A prefix with an empty
+// placeholder, such as "EP {episode} |" without an episode number, is left out.
+func titlePrefix(prefix string, vars map[string]string) string {
+	for name, value := range vars {
+		if value == "" && strings.Contains(prefix, "{"+name+"}") {
+			return ""
+		}
+	}
+	return strings.TrimSpace(expandTemplate(prefix, vars))
+}
+
+// applyCase capitalizes title in style: sentence, title, upper or lower.
+// Words written in capitals, such as acronyms, are kept in the sentence and
+// title styles. Other styles leave title unchanged.
+func applyCase(title string, style string) string {
+	switch style {
+	case "upper":
+		return strings.ToUpper(title)
+	case "lower":
+		return strings.ToLower(title)
+	case "sentence", "title":
+	default:
+		return title
+	}
+
+	words := strings.Fields(title)
+	for i, word := range words {
+		if isAcronym(word) {
+			continue
+		}
+		word = strings.ToLower(word)
+		if i == 0 || style == "title" {
+			word = capitalize(word)
+		}
+		words[i] = word
+	}
+	return strings.Join(words, " ")
+}
+
+// isAcronym reports whether word has at least two letters, all in capitals.
+func isAcronym(word string) bool {
+	letters := 0
+	for _, r := range word {
+		if unicode.IsLetter(r) {
+			if !unicode.IsUpper(r) {
+				return false
+			}
+			letters++
+		}
+	}
+	return letters >= 2
+}
+
+// capitalize writes the first letter of word in capitals.
+func capitalize(word string) string {
+	runes := []rune(word)
+	for i, r := range runes {
+		if unicode.IsLetter(r) {
+			runes[i] = unicode.ToUpper(r)
+			break
+		}
+	}
+	return string(runes)
+}
+
+// shortenTitle cuts title to at most limit characters, ending on a whole word
+// followed by an ellipsis.
+func shortenTitle(title string, limit int) string {
+	runes := []rune(title)
+	if len(runes) <= limit {
+		return title
+	}
+	if limit <= 1 {
+		return string(runes[:max(limit, 0)])
+	}
+
+	short := string(runes[:limit-1])
+	if i := strings.LastIndex(short, " "); i > 0 {
+		short = short[:i]
+	}
+	return cleanTitle(short) + "…"
+}
@@ -0,0 +1,301 @@
+package videos
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Defaults of the transcription backends.
+const (
+	DefaultWhisperCpp         = "whisper-cli"
+	DefaultTranscriptionModel = "whisper-1"
+)
+
+// transcriptionChunk is the length of the audio sent in each request to the
+// OpenAI API, well below its 25 MB upload limit at the bitrate used.
+const transcriptionChunk = 10 * time.Minute
+
+// Transcriber generates the captions of a video from its speech, for videos
+// yt-dlp finds no captions for.
+type Transcriber interface {
+	// Transcribe writes the captions of videoFile to captionsFile as WEBVTT.
+	Transcribe(ctx context.Context, videoFile string, captionsFile string) error
+}
+
+// NewTranscriber returns the Transcriber configured in the transcription
+// block, nil when none is. Supported providers are "whisper-cpp", running a
+// local whisper.cpp build, and "openai", calling the OpenAI audio API.
+func NewTranscriber(client *Client) (Transcriber, error) {
+	cfg := client.Config
+	transcription := cfg.Transcription
+	language := transcription.Language
+	if language == "" {
+		language = "pt"
+	}
+	timeout := timeoutOf(cfg.Timeouts.FFmpeg, DefaultFFmpegTimeout)
+
+	switch transcription.Provider {
+	case "":
+		return nil, nil
+	case "whisper-cpp":
+		if transcription.Model == "" {
+			return nil, fmt.Errorf("provider 'whisper-cpp' requires model to be set to a ggml model file")
+		}
+		path := transcription.Binary
+		if path == "" {
+			path = DefaultWhisperCpp
+		}
+		return &WhisperCppTranscriber{
+			Path:     path,
+			Model:    transcription.Model,
+			Language: language,
+			FFmpeg:   cfg.FFmpeg,
+			Timeout:  timeout,
+		}, nil
+	case "openai":
+		model := transcription.Model
+		if model == "" {
+			model = DefaultTranscriptionModel
+		}
+		return &OpenAITranscriber{
+			Client:   client,
+			Model:    model,
+			Language: language,
+			FFmpeg:   cfg.FFmpeg,
+			Timeout:  timeout,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown provider: %s", transcription.Provider)
+	}
+}
+
+// WhisperCppTranscriber transcribes videos with a local whisper.cpp build.
+type WhisperCppTranscriber struct {
+	Path     string        // Path to the whisper.cpp executable
+	Model    string        // Path to the ggml model file
+	Language string        // Language code of the speech
+	FFmpeg   string        // Path to the FFmpeg executable extracting the audio
+	Timeout  time.Duration // Time limit of the audio extraction, 0 for none
+}
+
+// Transcribe extracts the audio of videoFile as the 16 kHz mono WAV
+// whisper.cpp reads and has it write the captions.
+func (t *WhisperCppTranscriber) Transcribe(ctx context.Context, videoFile string, captionsFile string) error {
+	audio := filepath.Join(filepath.Dir(captionsFile), "temp_transcription.wav")
+	defer os.Remove(audio)
+	if err := extractAudio(ctx, t.FFmpeg, t.Timeout, videoFile, audio, "-c:a", "pcm_s16le"); err != nil {
+		return err
+	}
+
+	return writeAtomically(captionsFile, func(tmp string) error {
+		// whisper.cpp adds the .vtt extension to the output name itself.
+		args := []string{
+			"-m", t.Model,
+			"-f", audio,
+			"-l", t.Language,
+			"-ovtt",
+			"-of", strings.TrimSuffix(tmp, ".vtt"),
+			"-np",
+		}
+		if output, err := newCommand(ctx, t.Path, args...).CombinedOutput(); err != nil {
+			return fmt.Errorf("whisper.cpp: %v: %s", err, lastLine(output))
+		}
+		return nil
+	})
+}
+
+// OpenAITranscriber transcribes videos with the OpenAI audio API. The model
+// must return timestamps, as whisper-1 does.
+type OpenAITranscriber struct {
+	Client   *Client       // Client providing the transport, the API key and the spending limits
+	Model    string        // Transcription model
+	Language string        // Language code of the speech
+	FFmpeg   string        // Path to the FFmpeg executable extracting the audio
+	Timeout  time.Duration // Time limit of the audio extraction, 0 for none
+}
+
+// Transcribe extracts the audio of videoFile in chunks of compressed mono
+// audio, transcribes each of them and joins their captions, shifted by the
+// start of their chunk.
+func (t *OpenAITranscriber) Transcribe(ctx context.Context, videoFile string, captionsFile string) error {
+	dir := filepath.Dir(captionsFile)
+	pattern := filepath.Join(dir, "temp_transcription_%03d.mp3")
+	chunks := func() []string {
+		matches, _ := filepath.Glob(filepath.Join(dir, "temp_transcription_*.mp3"))
+		return matches
+	}
+	defer func() {
+		for _, chunk := range chunks() {
+			os.Remove(chunk)
+		}
+	}()
+
+	err := extractAudio(ctx, t.FFmpeg, t.Timeout, videoFile, pattern,
+		"-c:a", "libmp3lame", "-b:a", "32k",
+		"-f", "segment", "-segment_time", fmt.Sprint(transcriptionChunk.Seconds()), "-reset_timestamps", "1")
+	if err != nil {
+		return err
+	}
+
+	var entries []SubtitleEntry
+	for i, chunk := range chunks() {
+		fmt.Println(commandStyle.Render(fmt.Sprintf("Transcribing audio chunk %d...", i+1)))
+		captions, err := t.transcribeChunk(ctx, chunk)
+		if err != nil {
+			return err
+		}
+		offset := time.Duration(i) * transcriptionChunk
+		for _, entry := range parseVTT(captions) {
+			entry.Index = len(entries) + 1
+			entry.StartTime += offset
+			entry.EndTime += offset
+			entries = append(entries, entry)
+		}
+	}
+
+	return writeFileAtomic(captionsFile, formatVTT(entries), 0644)
+}
+
+// transcribeChunk sends the audio file chunk to the transcription endpoint and
+// returns its captions. Failed requests are retried up to three times with
+// exponential backoff, unless the spending limit of the channel is reached.
+func (t *OpenAITranscriber) transcribeChunk(ctx context.Context, chunk string) (string, error) {
+	audio, err := os.ReadFile(chunk)
+	if err != nil {
+		return "", err
+	}
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	for _, field := range [][2]string{{"model", t.Model}, {"language", t.Language}, {"response_format", "vtt"}} {
+		form.WriteField(field[0], field[1])
+	}
+	file, err := form.CreateFormFile("file", filepath.Base(chunk))
+	if err != nil {
+		return "", err
+	}
+	file.Write(audio)
+	if err := form.Close(); err != nil {
+		return "", err
+	}
+
+	maxRetries := 3
+	var lastErr error
+	for attempt := 0; attempt < maxRetries; attempt++ {
+		if attempt > 0 {
+			backoffDuration := time.Duration(2<<uint(attempt-1)) * time.Second
+			if err := sleepContext(ctx, backoffDuration); err != nil {
+				return "", err
+			}
+		}
+
+		if err := t.Client.Budget.Check(0); err != nil {
+			return "", err
+		}
+
+		captions, err := t.post(ctx, form.FormDataContentType(), body.Bytes())
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		return captions, nil
+	}
+	return "", lastErr
+}
+
+// post sends a transcription request and returns the body of the response.
+func (t *OpenAITranscriber) post(ctx context.Context, contentType string, body []byte) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.Client.TranscriptionURL, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Add("Content-Type", contentType)
+	req.Header.Add("Authorization", "Bearer "+t.Client.Config.OpenAI.Key)
+
+	res, err := t.Client.HTTP.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	respBody, err := io.ReadAll(res.Body)
+	if err != nil {
+		return "", err
+	}
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("status code %d", res.StatusCode)
+	}
+	return string(respBody), nil
+}
+
+// extractAudio writes the audio track of videoFile to output as 16 kHz mono,
+// encoded with the ffmpeg arguments of args.
+func extractAudio(ctx context.Context, ffmpeg string, timeout time.Duration, videoFile string, output string, args ...string) error {
+	fmt.Println(commandStyle.Render("Extracting audio for transcription..."))
+	ctx, cancel := withTimeout(ctx, timeout, "audio extraction")
+	defer cancel()
+
+	args = append([]string{"-y", "-i", videoFile, "-vn", "-ac", "1", "-ar", "16000"}, append(args, output)...)
+	if output, err := newCommand(ctx, ffmpeg, args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("audio extraction: %v: %s", timeoutCause(ctx, err), lastLine(output))
+	}
+	return nil
+}
+
+// lastLine returns the last non-empty line of the output of a command, which
+// usually tells why it failed.
+func lastLine(output []byte) string {
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
+
+// formatVTT formats entries as WEBVTT captions.
+func formatVTT(entries []SubtitleEntry) []byte {
+	var vtt strings.Builder
+	vtt.WriteString("WEBVTT\n\n")
+	for _, entry := range entries {
+		fmt.Fprintf(&vtt, "%s --> %s\n%s\n\n", formatVTTTimestamp(entry.StartTime), formatVTTTimestamp(entry.EndTime), entry.Text)
+	}
+	return []byte(vtt.String())
+}
+
+// formatVTTTimestamp formats d as a WEBVTT timestamp (HH:MM:SS.MMM).
+func formatVTTTimestamp(d time.Duration) string {
+	ms := d.Milliseconds()
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", ms/3600000, ms/60000%60, ms/1000%60, ms%1000)
+}
+
+// transcribe generates the captions of media with the configured transcriber
+// when the downloader found none, so videos without captions still get cuts,
+// and makes them the captions of media.
+// It returns an error of kind ErrNoCaptions when the transcription failed.
+func (p *pipeline) transcribe(ctx context.Context, videoID string, media *Media) error {
+	if media.Captions != "" {
+		return nil
+	}
+	if p.transcriber == nil {
+		fmt.Println(subtitleStyle.Render("No captions found for the video. Set transcription in config.json to generate them."))
+		return nil
+	}
+
+	fmt.Println(commandStyle.Render("No captions found, transcribing the video..."))
+	captions := captionsPath(media.SubtitleFile, transcribedCaptions)
+	if err := p.transcriber.Transcribe(ctx, media.VideoFile, captions); err != nil {
+		return withContext(err, ErrNoCaptions, videoID, "")
+	}
+	media.Captions, media.Language = captions, transcribedCaptions
+	fmt.Println(successStyle.Render("Video transcribed successfully"))
+	p.completeStage(videoID, StageTranscription)
+	return nil
+}
@@ -0,0 +1,151 @@
+package videos
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Localization is the metadata of a clip translated into another language.
+type Localization struct {
+	Title       string   `json:"title"`       // Translated title
+	Description string   `json:"description"` // Translated description
+	Tags        []string `json:"tags"`        // Translated tags without # symbol
+}
+
+// TranslateMetadata asks the language model to translate the title,
+// description and tags of metadata into each of languages, keyed by language
+// code with the language name as value. It returns the translations keyed by
+// language code. Failures are returned as *Error values of kind ErrLLMRequest
+// or ErrLLMParse.
+func (c *Client) TranslateMetadata(ctx context.Context, metadata *VideoMetadata, languages map[string]string) (map[string]Localization, error) {
+	var targets []string
+	for _, code := range sortedLanguages(languages) {
+		targets = append(targets, fmt.Sprintf("%q (%s)", code, languages[code]))
+	}
+
+	systemPrompt := `You are a professional translator of YouTube, TikTok and Instagram metadata.
+	Translate titles, descriptions and tags naturally for native speakers of each language, keeping names, brands and the tone of the original.
+	Keep each title under 100 characters and each description under 250 characters.
+	Return only a JSON object in the format: {"translations": {"language code": {"title": "...", "description": "...", "tags": ["..."]}}}`
+
+	original, _ := json.Marshal(Localization{Title: metadata.Title, Description: metadata.Description, Tags: metadata.Tags})
+	userPrompt := fmt.Sprintf("Translate this metadata into %s, using the language codes as keys:\n\n%s", strings.Join(targets, ", "), original)
+
+	var response struct {
+		Translations map[string]Localization `json:"translations"`
+	}
+	err := c.chatCompletion(ctx, purposeTranslations, 60*time.Second, systemPrompt, userPrompt, func(content string) error {
+		if err := json.Unmarshal([]byte(content), &response); err != nil {
+			return err
+		}
+		for code := range languages {
+			if response.Translations[code].Title == "" {
+				return fmt.Errorf("no translation into %s", code)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	translations := make(map[string]Localization, len(languages))
+	for code := range languages {
+		translations[code] = response.Translations[code]
+	}
+	return translations, nil
+}
+
+// translateMetadata adds the translations of metadata into the languages of
+// the channel. It returns false when translation failed.
+func (p *pipeline) translateMetadata(ctx context.Context, videoID string, cut Cut, metadata *VideoMetadata) bool {
+	languages := p.channel.Translations.Languages
+	if len(languages) == 0 {
+		return true
+	}
+	if !p.client.hasLanguageModel() {
+		fmt.Println(subtitleStyle.Render("No language model configured, skipping the translation of the metadata"))
+		return true
+	}
+
+	fmt.Println(commandStyle.Render("Translating metadata into " + strings.Join(sortedLanguages(languages), ", ") + "..."))
+	translations, err := p.client.TranslateMetadata(withPromptCut(ctx, cut.Title), metadata, languages)
+	if err != nil {
+		p.fail(withContext(err, ErrLLMRequest, videoID, cut.Title))
+		return false
+	}
+
+	metadata.Translations = translations
+	return true
+}
+
+// localizedUploads reports whether the channel uploads a copy of each clip
+// per translated language instead of attaching the translations to a single
+// upload.
+func (p *pipeline) localizedUploads() bool {
+	translations := p.channel.Translations
+	return translations != nil && translations.Mode == "uploads"
+}
+
+// detailsFor returns the title, description and tags a clip is uploaded
+// with. language selects a translation of metadata, or the original metadata
+// when empty; suffix is added to every title, e.g. " (Vertical)". Uploads of
+// the original metadata carry its translations as YouTube localizations
+// unless the channel uploads a copy per language.
+func (p *pipeline) detailsFor(metadata *VideoMetadata, suffix string, language string) uploadDetails {
+	if language != "" {
+		localization := metadata.Translations[language]
+		return uploadDetails{
+			Title:       localization.Title + suffix,
+			Description: p.withHashtags(localization.Description, metadata),
+			Tags:        localization.Tags,
+			Language:    language,
+		}
+	}
+
+	details := uploadDetails{
+		Title:       metadata.Title + suffix,
+		Description: p.withHashtags(metadata.Description, metadata),
+		Tags:        metadata.Tags,
+	}
+	if translations := p.channel.Translations; translations != nil && !p.localizedUploads() && len(metadata.Translations) > 0 {
+		details.Language = translations.Source
+		details.Localizations = make(map[string]Localization, len(metadata.Translations))
+		for code, localization := range metadata.Translations {
+			localization.Title += suffix
+			localization.Description = p.withHashtags(localization.Description, metadata)
+			details.Localizations[code] = localization
+		}
+	}
+	return details
+}
+
+// languageKey returns the key the copy of an output uploaded in language is
+// recorded under, or key itself when language is empty. The separator never
+// appears in output keys since cut titles cannot put it in file names.
+func languageKey(key string, language string) string {
+	if language == "" {
+		return key
+	}
+	return key + "|" + language
+}
+
+// outputOfKey returns the output key an upload recorded under key was made from.
+func outputOfKey(key string) string {
+	output, _, _ := strings.Cut(key, "|")
+	return output
+}
+
+// sortedLanguages returns the language codes of languages in order.
+func sortedLanguages(languages map[string]string) []string {
+	codes := make([]string, 0, len(languages))
+	for code := range languages {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
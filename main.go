@@ -4,13 +4,32 @@
 package main
 
 import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
 	"os"
+	"os/exec"
+	"os/signal"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
+	"syscall"
+	"time"
+	"unicode"
 
 	"github.com/charmbracelet/lipgloss"
-	"github.com/rogersilvasouza/godeogoker/internal/auth"
+	"github.com/rogersilvasouza/godeogoker/internal/cli"
 	"github.com/rogersilvasouza/godeogoker/internal/config"
+	"github.com/rogersilvasouza/godeogoker/internal/events"
+	"github.com/rogersilvasouza/godeogoker/internal/notify"
+	"github.com/rogersilvasouza/godeogoker/internal/queue"
+	"github.com/rogersilvasouza/godeogoker/internal/server"
+	"github.com/rogersilvasouza/godeogoker/internal/state"
 	"github.com/rogersilvasouza/godeogoker/internal/videos"
 )
 
@@ -48,46 +67,48 @@ var (
 
 // main is the entry point of the application.
 // It parses command-line arguments and routes to the appropriate handlers.
+// SIGINT and SIGTERM cancel the shared context so in-flight work stops cleanly,
+// and a panic removes the outputs that were still being written.
 func main() {
-	args := os.Args[1:]
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	if len(args) == 0 {
-		printUsage()
-		os.Exit(1)
-	}
+	defer videos.RemoveTempFilesOnPanic()
 
-	switch args[0] {
-	case "login":
-		fmt.Println(subtitleStyle.Render("🔑 Starting Google authentication process..."))
-		if err := auth.Login(); err != nil {
-			fmt.Println(errorStyle.Render(fmt.Sprintf("Login error: %v", err)))
-			os.Exit(1)
+	if err := newRootCommand().Execute(ctx, os.Args[1:]); err != nil {
+		if errors.Is(err, cli.ErrUsage) {
+			os.Exit(exitUsage)
 		}
-		fmt.Println(successStyle.Render("🎉 Login successful! You're ready to download videos!"))
-	case "exec":
-		fmt.Println(subtitleStyle.Render("🚀 Preparing to download awesome content..."))
-		handleExec(args[1:])
-	case "help":
-		printExtendedHelp()
-	default:
-		printUsage()
+		fmt.Println(errorStyle.Render(fmt.Sprintf("Error: %v", err)))
 		os.Exit(1)
 	}
 }
 
-// printUsage displays styled help information showing available commands and options.
-func printUsage() {
-	fmt.Println(commandStyle.Render("Usage:"), descriptionStyle.Render("godeogoker <command> [options]"))
-	fmt.Println()
-	fmt.Println(commandStyle.Render("Commands:"))
-	fmt.Println(optionStyle.Render("  - login:"), descriptionStyle.Render("Authenticate with Google (you'll need this first!)"))
-	fmt.Println(optionStyle.Render("  - exec [channelID] [--force] [-v=videoID]:"), descriptionStyle.Render("Download videos"))
-	fmt.Println(descriptionStyle.Render("    [channelID]: Optional. Specific channel ID for download"))
-	fmt.Println(descriptionStyle.Render("    [--force]: Optional. Force reprocessing even if folder exists"))
-	fmt.Println(descriptionStyle.Render("    [-v=videoID]: Optional. Specific video ID for processing"))
-	fmt.Println(optionStyle.Render("  - help:"), descriptionStyle.Render("Show extended help with examples"))
-	fmt.Println()
-	fmt.Println(subtitleStyle.Render("💡 Tip:"), descriptionStyle.Render("Start with 'godeogoker login' to authenticate!"))
+// nonInteractive is set by --non-interactive.
+var nonInteractive bool
+
+// stdinIsTerminal reports whether stdin is attached to a terminal.
+func stdinIsTerminal() bool {
+	info, err := os.Stdin.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// loadConfig returns the configuration with the global flags applied. It
+// never prompts with --non-interactive, when CI is set or when stdin is not a
+// terminal. A configuration that cannot be loaded exits the program.
+func loadConfig() *config.Config {
+	cfg, err := config.Get()
+	if err != nil {
+		fmt.Println(errorStyle.Render(fmt.Sprintf("Error: %v", err)))
+		os.Exit(1)
+	}
+	if nonInteractive || os.Getenv("CI") != "" || !stdinIsTerminal() {
+		cfg.NonInteractive = true
+	}
+	return cfg
 }
 
 // printExtendedHelp displays detailed help information with examples
@@ -115,10 +136,129 @@ func printExtendedHelp() {
 	fmt.Println(descriptionStyle.Render("  godeogoker exec mrbeast -v=0e3GPea1Tyg"))
 	fmt.Println()
 
+	fmt.Println(optionStyle.Render("- Clip a one-off video around a different theme:"))
+	fmt.Println(descriptionStyle.Render("  godeogoker exec mrbeast -v=0e3GPea1Tyg --topics=\"cooking,food\" --prompt-extra=\"Prefer the challenges\""))
+	fmt.Println()
+
+	fmt.Println(optionStyle.Render("- Process four channels at once:"))
+	fmt.Println(descriptionStyle.Render("  godeogoker exec --parallel=4"))
+	fmt.Println()
+
 	fmt.Println(optionStyle.Render("- Force reprocessing of existing videos:"))
 	fmt.Println(descriptionStyle.Render("  godeogoker exec --force"))
 	fmt.Println()
 
+	fmt.Println(optionStyle.Render("- Render the clips of a processed video again:"))
+	fmt.Println(descriptionStyle.Render("  godeogoker exec mrbeast -v=0e3GPea1Tyg --force=render"))
+	fmt.Println()
+
+	fmt.Println(optionStyle.Render("- Find new cuts and render them without uploading:"))
+	fmt.Println(descriptionStyle.Render("  godeogoker exec mrbeast -v=0e3GPea1Tyg --from=cuts --until=render"))
+	fmt.Println()
+
+	fmt.Println(optionStyle.Render("- Retry the steps that failed in previous runs:"))
+	fmt.Println(descriptionStyle.Render("  godeogoker exec --retry-failed"))
+	fmt.Println()
+
+	fmt.Println(optionStyle.Render("- Upload the clips whose upload failed, every 30 minutes until all are sent:"))
+	fmt.Println(descriptionStyle.Render("  godeogoker upload --drain --every=30m"))
+	fmt.Println()
+
+	fmt.Println(optionStyle.Render("- Apply the hand edits of clips to their metadata, covers and uploads:"))
+	fmt.Println(descriptionStyle.Render("  godeogoker upload --apply-edits mrbeast"))
+	fmt.Println()
+
+	fmt.Println(optionStyle.Render("- Approve the clips held back by the quality gate and upload them:"))
+	fmt.Println(descriptionStyle.Render("  godeogoker review mrbeast --approve=all"))
+	fmt.Println(descriptionStyle.Render("  godeogoker exec mrbeast --retry-failed"))
+	fmt.Println()
+
+	fmt.Println(optionStyle.Render("- Adjust the boundaries of a clip and render it again:"))
+	fmt.Println(descriptionStyle.Render("  godeogoker review mrbeast --preview=dQw4w9WgXcQ/horizontal/The_Big_Reveal.mp4"))
+	fmt.Println()
+
+	fmt.Println(optionStyle.Render("- Move the configuration to YAML, to keep comments next to the channels:"))
+	fmt.Println(descriptionStyle.Render("  godeogoker config convert config.yaml"))
+	fmt.Println()
+
+	fmt.Println(optionStyle.Render("- Take the OpenAI key from the environment and print the settings in use:"))
+	fmt.Println(descriptionStyle.Render("  GODEOGOKER_OPENAI_KEY=sk-... godeogoker config show"))
+	fmt.Println()
+
+	fmt.Println(optionStyle.Render("- Add a channel by its @handle, with the settings of another one:"))
+	fmt.Println(descriptionStyle.Render("  godeogoker channels add --url=@veritasium --topics=\"science, engineering\" --like=mrbeast"))
+	fmt.Println()
+
+	fmt.Println(optionStyle.Render("- Check a new channel configuration before spending any quota:"))
+	fmt.Println(descriptionStyle.Render("  godeogoker exec mrbeast --dry-run"))
+	fmt.Println()
+
+	fmt.Println(optionStyle.Render("- List the Community post teasers of the clips uploaded this week:"))
+	fmt.Println(descriptionStyle.Render("  godeogoker community mrbeast --last=7d"))
+	fmt.Println()
+
+	fmt.Println(optionStyle.Render("- Regenerate only what changed after editing the channel settings:"))
+	fmt.Println(descriptionStyle.Render("  godeogoker exec --changed"))
+	fmt.Println()
+
+	fmt.Println(optionStyle.Render("- Audit the prompts behind the cuts and metadata of a video:"))
+	fmt.Println(descriptionStyle.Render("  godeogoker prompts mrbeast dQw4w9WgXcQ --full"))
+	fmt.Println()
+
+	fmt.Println(optionStyle.Render("- Run the pipeline of another client profile:"))
+	fmt.Println(descriptionStyle.Render("  godeogoker --profile=acme login"))
+	fmt.Println(descriptionStyle.Render("  godeogoker --profile=acme exec"))
+	fmt.Println()
+
+	fmt.Println(optionStyle.Render("- Stream lifecycle events to a file for a dashboard:"))
+	fmt.Println(descriptionStyle.Render("  godeogoker exec --events=events.jsonl"))
+	fmt.Println()
+
+	fmt.Println(optionStyle.Render("- Spread the videos over several machines:"))
+	fmt.Println(descriptionStyle.Render("  godeogoker exec --enqueue    # on the coordinator"))
+	fmt.Println(descriptionStyle.Render("  godeogoker worker            # on every worker"))
+	fmt.Println()
+
+	fmt.Println(optionStyle.Render("- Keep watching the channels and process new videos as they appear:"))
+	fmt.Println(descriptionStyle.Render("  godeogoker daemon"))
+	fmt.Println()
+
+	fmt.Println(optionStyle.Render("- Process a single video as a Kubernetes Job:"))
+	fmt.Println(descriptionStyle.Render("  GODEOGOKER_CONFIG=/secrets/config.json godeogoker run-job --channel mrbeast --video-id 0e3GPea1Tyg"))
+	fmt.Println()
+
+	fmt.Println(optionStyle.Render("- Process videos sent by a bot through a signed webhook:"))
+	fmt.Println(descriptionStyle.Render("  godeogoker serve --addr=:8080"))
+	fmt.Println()
+
+	fmt.Println(optionStyle.Render("- Email the daily digest of every channel (e.g. from cron):"))
+	fmt.Println(descriptionStyle.Render("  godeogoker digest"))
+	fmt.Println()
+
+	fmt.Println(optionStyle.Render("- Browse the clip library of the team in a browser:"))
+	fmt.Println(descriptionStyle.Render("  godeogoker export site --out=public"))
+	fmt.Println()
+
+	fmt.Println(optionStyle.Render("- Upload a weekly best of with the clips of the last seven days:"))
+	fmt.Println(descriptionStyle.Render("  godeogoker compile mrbeast --since=168h --upload"))
+	fmt.Println()
+
+	fmt.Println(optionStyle.Render("- Compile the ten best clips of the month by AI score and views:"))
+	fmt.Println(descriptionStyle.Render("  godeogoker bestof --channel mrbeast --last 30d --top 10 --compile"))
+	fmt.Println()
+
+	fmt.Println(optionStyle.Render("- Work through the whole history of a channel, a few hundred videos a day:"))
+	fmt.Println(descriptionStyle.Render("  godeogoker backfill mrbeast --max 500"))
+	fmt.Println()
+
+	fmt.Println(optionStyle.Render("- Fill the content calendar spreadsheet with the clips and their views:"))
+	fmt.Println(descriptionStyle.Render("  godeogoker export metadata --format=xlsx --stats"))
+	fmt.Println()
+
+	fmt.Println(optionStyle.Render("- Complete commands, flags and channel IDs with Tab in bash:"))
+	fmt.Println(descriptionStyle.Render("  source <(godeogoker completion bash)"))
+	fmt.Println()
+
 	fmt.Println(commandStyle.Render("Troubleshooting:"))
 	fmt.Println(descriptionStyle.Render("- If you encounter authentication issues, try 'godeogoker login' again"))
 	fmt.Println(descriptionStyle.Render("- Make sure your channel IDs are correct in the configuration"))
@@ -127,59 +267,1026 @@ func printExtendedHelp() {
 	fmt.Println(subtitleStyle.Render("📝 Fun fact:"), descriptionStyle.Render("The average YouTube channel produces about 600 hours of content yearly!"))
 }
 
-// handleExec processes the exec command with its arguments.
-// It parses flags and options, then initiates the video download process
-// for either a specific channel or all configured channels.
-func handleExec(args []string) {
-	force := false
-	var videoID string
+// feedRetryDelay is how long exec waits before fetching a feed that failed again.
+const feedRetryDelay = 30 * time.Second
+
+// handleExec processes the exec command with its flags, initiating the video
+// download process for the channel channelID or, when empty, for all
+// configured channels. A channel whose feed cannot be fetched is tried once
+// more and then skipped, as is one whose backends cannot be configured or
+// whose error budget is spent; an authentication failure stops the run, since
+// every channel would fail the same way. Skipped channels make the command
+// exit with 1 once the others are processed. Up to --parallel channels, or
+// parallel.channels, are processed at once, each by its own worker.
+func handleExec(ctx context.Context, options execOptions, channelID string) {
+	cfg := loadConfig()
+	channels := selectChannels(cfg, channelID)
+	client, closeClient := newClient(cfg, options.events)
+	defer closeClient()
+
+	var jobs queue.Queue
+	if options.enqueue {
+		q, err := queue.Open(ctx, cfg.Queue)
+		if err != nil {
+			fmt.Println(errorStyle.Render(fmt.Sprintf("Error: %v", err)))
+			os.Exit(1)
+		}
+		defer q.Close()
+		jobs = q
+	}
+
+	// An authentication failure cancels runCtx, stopping the other channels
+	// without reporting the run as interrupted.
+	runCtx, abort := context.WithCancel(ctx)
+	defer abort()
+
+	process := func(channel config.Channel) error {
+		// The overrides only apply to this run, the configuration is left untouched.
+		if options.topics != "" {
+			channel.Topics = options.topics
+		}
+		if options.promptExtra != "" {
+			channel.PromptExtra = options.promptExtra
+		}
 
-	i := 0
-	for i < len(args) {
 		switch {
-		case args[i] == "--force":
-			force = true
-			args = append(args[:i], args[i+1:]...)
-		case strings.HasPrefix(args[i], "-v=") || strings.HasPrefix(args[i], "--v="):
-			videoID = strings.Split(args[i], "=")[1]
-			args = append(args[:i], args[i+1:]...)
+		case options.dryRun:
+			return videos.PlanChannel(runCtx, client, channel, options.stages)
+		case jobs != nil:
+			return enqueueChannel(runCtx, client, jobs, channel, options.stages.Force)
+		case options.retryFailed:
+			return videos.RetryFailed(runCtx, client, channel)
+		case options.changed:
+			return videos.RefreshChanged(runCtx, client, channel)
 		default:
-			i++
+			return videos.DownloadVideo(runCtx, client, channel, options.stages)
 		}
 	}
 
-	channels := config.GetChannels()
+	var mu sync.Mutex
+	skipped := make(map[string]bool)
+	authFailed := false
+	run := func(channel config.Channel) {
+		if !options.dryRun {
+			fmt.Println(subtitleStyle.Render(fmt.Sprintf("📥 Downloading videos for channel: %s", channel.Name)))
+		}
 
-	if len(args) > 0 {
-		channelID := args[0]
-		channelFound := false
+		err := process(channel)
+		if errors.Is(err, videos.ErrFeedFailed) && runCtx.Err() == nil {
+			fmt.Println(subtitleStyle.Render(fmt.Sprintf("Feed unavailable, trying again in %s: %v", feedRetryDelay, err)))
+			select {
+			case <-runCtx.Done():
+			case <-time.After(feedRetryDelay):
+				err = process(channel)
+			}
+		}
 
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case err == nil || runCtx.Err() != nil:
+		case errors.Is(err, videos.ErrAuth):
+			fmt.Println(errorStyle.Render(fmt.Sprintf("Error: %v", err)))
+			authFailed = true
+			abort()
+		default:
+			fmt.Println(errorStyle.Render(fmt.Sprintf("Skipping channel %s: %v", channel.Name, err)))
+			skipped[channel.ID] = true
+		}
+	}
+
+	parallel := cfg.Parallel.Channels
+	if options.parallel > 0 {
+		parallel = options.parallel
+	}
+	parallel = max(1, min(parallel, len(channels)))
+
+	if channelID == "" {
+		fmt.Println(subtitleStyle.Render("🎯 Starting batch download for all channels..."))
+		if parallel > 1 {
+			fmt.Println(subtitleStyle.Render(fmt.Sprintf("Processing %d channels at once", parallel)))
+		}
+	}
+
+	pending := make(chan config.Channel)
+	var wg sync.WaitGroup
+	for range parallel {
+		wg.Add(1)
+		go func() {
+			defer videos.RemoveTempFilesOnPanic()
+			defer wg.Done()
+			for channel := range pending {
+				run(channel)
+			}
+		}()
+	}
+
+feed:
+	for _, channel := range channels {
+		if client.Errors.RunSpent() {
+			fmt.Println(errorStyle.Render(fmt.Sprintf("Error budget spent after %d failed videos, skipping the remaining channels", client.Errors.Failed())))
+			break
+		}
+		if options.videoID != "" {
+			channel.ChannelID = "v=" + options.videoID
+		}
+		select {
+		case pending <- channel:
+		case <-runCtx.Done():
+			break feed
+		}
+	}
+	close(pending)
+	wg.Wait()
+
+	if summary := client.Models.Summary(); summary != "" {
+		fmt.Println(subtitleStyle.Render("🤖 " + summary))
+	}
+
+	if ctx.Err() != nil {
+		fmt.Println(errorStyle.Render("🛑 Interrupted. Run the same command again to resume."))
+		os.Exit(130)
+	}
+
+	if authFailed {
+		fmt.Println(errorStyle.Render("Authentication failed, stopping the run. Try 'godeogoker login' again."))
+		os.Exit(1)
+	}
+
+	if len(skipped) > 0 {
+		var ids []string
 		for _, channel := range channels {
-			if channel.ID == channelID {
-				if videoID != "" {
-					channel.ChannelID = "v=" + videoID
-				}
-				fmt.Println(subtitleStyle.Render(fmt.Sprintf("📥 Downloading videos for channel: %s", channel.Name)))
-				videos.DownloadVideo(channel, force)
-				channelFound = true
-				break
+			if skipped[channel.ID] {
+				ids = append(ids, channel.ID)
+			}
+		}
+		fmt.Println(errorStyle.Render(fmt.Sprintf("Completed with %d skipped channels: %s", len(ids), strings.Join(ids, ", "))))
+		os.Exit(1)
+	}
+
+	if options.dryRun {
+		fmt.Println(successStyle.Render("Dry run completed, nothing was downloaded or processed"))
+		return
+	}
+	fmt.Println(successStyle.Render("🎉 Download completed successfully! Enjoy your videos!"))
+}
+
+// enqueueChannel sends the latest videos of channel to the distributed queue
+// instead of processing them. Videos sent before are skipped unless force is
+// set. It returns an error when the feed of the channel cannot be fetched.
+func enqueueChannel(ctx context.Context, client *videos.Client, jobs queue.Queue, channel config.Channel, force bool) error {
+	latest, err := client.GetLastVideos(ctx, channel)
+	if err != nil {
+		return err
+	}
+	for _, video := range latest {
+		if ctx.Err() != nil {
+			return nil
+		}
+		if !force && client.State.Queued(channel.ID, video.ID) {
+			fmt.Println(descriptionStyle.Render("Already queued, skipping: " + video.ID))
+			continue
+		}
+
+		if err := jobs.Enqueue(ctx, queue.Job{Channel: channel.ID, Video: video}); err != nil {
+			fmt.Println(errorStyle.Render(fmt.Sprintf("Error queueing video %s: %v", video.ID, err)))
+			continue
+		}
+		if err := client.State.MarkQueued(channel.ID, video.ID); err != nil {
+			fmt.Println(errorStyle.Render("Error recording queued video: " + err.Error()))
+		}
+		fmt.Println(successStyle.Render("Queued video: " + video.ID))
+	}
+	return nil
+}
+
+// handleWorker processes the worker command, claiming jobs from the
+// distributed queue and processing them until the program is interrupted.
+// A job interrupted by a shutdown is released for another worker.
+func handleWorker(ctx context.Context, eventsTarget string) {
+	cfg := loadConfig()
+	client, closeClient := newClient(cfg, eventsTarget)
+	defer closeClient()
+
+	jobs, err := queue.Open(ctx, cfg.Queue)
+	if err != nil {
+		fmt.Println(errorStyle.Render(fmt.Sprintf("Error: %v", err)))
+		os.Exit(1)
+	}
+	defer jobs.Close()
+
+	fmt.Println(subtitleStyle.Render("👷 Waiting for jobs..."))
+	for ctx.Err() == nil {
+		claim, err := jobs.Claim(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				fmt.Println(errorStyle.Render(fmt.Sprintf("Error: %v", err)))
+				time.Sleep(5 * time.Second)
 			}
+			continue
+		}
+
+		job := claim.Job()
+		channel, ok := findChannel(cfg, job.Channel)
+		if !ok {
+			fmt.Println(errorStyle.Render(fmt.Sprintf("Error: Channel with ID '%s' not found, dropping job", job.Channel)))
+			claim.Ack(context.Background())
+			continue
 		}
 
-		if !channelFound {
+		done, err := videos.ProcessVideo(ctx, client, channel, job.Video, videos.StageOptions{})
+		if err != nil {
+			// Every job would fail the same way on this worker: give it back and stop.
+			claim.Release(context.Background())
+			fmt.Println(errorStyle.Render(fmt.Sprintf("Error: %v", err)))
+			os.Exit(1)
+		}
+		if !done {
+			if err := claim.Release(context.Background()); err != nil {
+				fmt.Println(errorStyle.Render(fmt.Sprintf("Error releasing job: %v", err)))
+			}
+			if ctx.Err() == nil {
+				// Paused by a spending limit: leave the job to others for a while.
+				time.Sleep(time.Minute)
+			}
+			continue
+		}
+		if err := claim.Ack(context.Background()); err != nil {
+			fmt.Println(errorStyle.Render(fmt.Sprintf("Error acknowledging job: %v", err)))
+		}
+	}
+
+	fmt.Println(successStyle.Render("Worker stopped"))
+}
+
+// handleDaemon processes the daemon command, polling the feed of every
+// channel, or of channelID, on its schedule and processing the new videos
+// until the program is interrupted.
+func handleDaemon(ctx context.Context, channelID string, eventsTarget string) {
+	cfg := loadConfig()
+	channels := selectChannels(cfg, channelID)
+	client, closeClient := newClient(cfg, eventsTarget)
+	defer closeClient()
+
+	interval := videos.DefaultDaemonInterval
+	if cfg.Daemon.Interval > 0 {
+		interval = time.Duration(cfg.Daemon.Interval) * time.Minute
+	}
+
+	fmt.Println(subtitleStyle.Render("👀 Watching channels for new videos..."))
+	if err := videos.Watch(ctx, client, channels, interval); err != nil {
+		fmt.Println(errorStyle.Render(fmt.Sprintf("Error: %v", err)))
+		os.Exit(1)
+	}
+	fmt.Println(successStyle.Render("Daemon stopped"))
+}
+
+// findChannel returns the configured channel with the given ID.
+func findChannel(cfg *config.Config, id string) (config.Channel, bool) {
+	for _, channel := range cfg.Channels {
+		if channel.ID == id {
+			return channel, true
+		}
+	}
+	return config.Channel{}, false
+}
+
+// newClient returns the pipeline client of cfg like openClient. Errors exit the program.
+func newClient(cfg *config.Config, eventsTarget string) (*videos.Client, func()) {
+	client, closeClient, err := openClient(cfg, eventsTarget)
+	if err != nil {
+		fmt.Println(errorStyle.Render(fmt.Sprintf("Error: %v", err)))
+		os.Exit(1)
+	}
+	return client, closeClient
+}
+
+// openClient returns the pipeline client of cfg with its state store and, when
+// an events target is given or configured, its event stream. The returned
+// function closes the event stream.
+func openClient(cfg *config.Config, eventsTarget string) (*videos.Client, func(), error) {
+	client := videos.NewClient(cfg, &http.Client{})
+
+	feedHTTP, err := videos.NewFeedHTTP(cfg.FeedFetch)
+	if err != nil {
+		return nil, nil, err
+	}
+	client.FeedHTTP = feedHTTP
+
+	store, err := state.Open(cfg.StateDir())
+	if err != nil {
+		return nil, nil, err
+	}
+	client.State = store
+
+	if eventsTarget == "" {
+		eventsTarget = cfg.Events
+	}
+	if eventsTarget == "" {
+		return client, func() {}, nil
+	}
+
+	w, err := events.Open(eventsTarget)
+	if err != nil {
+		return nil, nil, err
+	}
+	client.Events = events.NewBus()
+	client.Events.Redact(cfg.Redact)
+	client.Events.Subscribe(events.JSONLines(w))
+	return client, func() { w.Close() }, nil
+}
+
+// Exit codes of the run-job command.
+const (
+	exitCompleted   = 0   // Video processed, or already processed before
+	exitSetup       = 1   // Configuration, state or backends could not be set up
+	exitUsage       = 2   // Missing or invalid arguments, or unknown channel
+	exitFailed      = 3   // One or more steps failed, see the failures of the result
+	exitPaused      = 4   // Spending limit reached, run the job again later
+	exitInterrupted = 130 // Stopped by SIGINT or SIGTERM, the video stays resumable
+)
+
+// jobVideoIDPattern accepts YouTube video IDs and the IDs yt-dlp reports for other sites.
+var jobVideoIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// jobResult is the JSON document printed by run-job.
+type jobResult struct {
+	Status   string          `json:"status"`             // completed, failed, paused, interrupted or error
+	Channel  string          `json:"channel"`            // Channel ID from the configuration
+	VideoID  string          `json:"video_id"`           // Processed video
+	Clips    []jobClip       `json:"clips,omitempty"`    // Rendered clips of the video
+	Failures []state.Failure `json:"failures,omitempty"` // Steps that failed
+	Error    string          `json:"error,omitempty"`    // Why the job could not run
+}
+
+// jobClip is a rendered clip listed in the run-job result.
+type jobClip struct {
+	Title        string `json:"title"`                   // Generated title
+	Path         string `json:"path"`                    // Storage key of the horizontal clip
+	VerticalPath string `json:"vertical_path,omitempty"` // Storage key of the vertical version
+	CoverPath    string `json:"cover_path,omitempty"`    // Storage key of the cover
+	Link         string `json:"link,omitempty"`          // Public URL when the feed base URL is set
+}
+
+// handleRunJob processes the run-job command, running the pipeline for a
+// single video and exiting, as a Kubernetes Job would. The channel and video
+// flags default to the GODEOGOKER_CHANNEL and GODEOGOKER_VIDEO_ID environment
+// variables. The progress goes to stderr and the JSON result to stdout, and to
+// the --result file when given (such as /dev/termination-log); the exit code
+// tells the outcome.
+func handleRunJob(ctx context.Context, options runJobOptions) {
+	result := jobResult{Channel: options.channel, VideoID: options.videoID}
+	resultFile := options.resultFile
+
+	out := os.Stdout
+	os.Stdout = os.Stderr
+
+	finish := func(status string, code int) {
+		result.Status = status
+		content, _ := json.MarshalIndent(result, "", "  ")
+		fmt.Fprintln(out, string(content))
+		if resultFile != "" {
+			if err := os.WriteFile(resultFile, content, 0644); err != nil {
+				fmt.Println(errorStyle.Render(fmt.Sprintf("Error writing result: %v", err)))
+			}
+		}
+		os.Exit(code)
+	}
+
+	if result.Channel == "" || !jobVideoIDPattern.MatchString(result.VideoID) {
+		result.Error = "--channel and a valid --video-id are required"
+		finish("error", exitUsage)
+	}
+
+	cfg := loadConfig()
+	channel, ok := findChannel(cfg, result.Channel)
+	if !ok {
+		result.Error = fmt.Sprintf("channel with ID '%s' not found", result.Channel)
+		finish("error", exitUsage)
+	}
+
+	client, closeClient, err := openClient(cfg, options.events)
+	if err != nil {
+		result.Error = err.Error()
+		finish("error", exitSetup)
+	}
+
+	done, err := videos.ProcessVideo(ctx, client, channel, videos.Video{ID: result.VideoID}, options.stages)
+	closeClient()
+	if err != nil {
+		result.Error = err.Error()
+		finish("error", exitSetup)
+	}
+
+	for _, failure := range client.State.Failures(channel.ID) {
+		if failure.VideoID == result.VideoID {
+			result.Failures = append(result.Failures, failure)
+		}
+	}
+
+	clips, err := videos.ListClips(channel.Folder)
+	if err != nil {
+		fmt.Println(errorStyle.Render(fmt.Sprintf("Error listing clips: %v", err)))
+	}
+	for _, clip := range clips {
+		if clip.Source.ID != result.VideoID {
+			continue
+		}
+		entry := jobClip{Title: clip.Metadata.Title, Path: videos.OutputKey(channel.Folder, clip.Clip)}
+		if clip.Vertical != "" {
+			entry.VerticalPath = videos.OutputKey(channel.Folder, clip.Vertical)
+		}
+		if clip.Cover != "" {
+			entry.CoverPath = videos.OutputKey(channel.Folder, clip.Cover)
+		}
+		if channel.Feed.BaseURL != "" {
+			entry.Link = videos.PublicLink(channel, clip.Clip)
+		}
+		result.Clips = append(result.Clips, entry)
+	}
+
+	switch {
+	case ctx.Err() != nil:
+		finish("interrupted", exitInterrupted)
+	case !done:
+		finish("paused", exitPaused)
+	case len(result.Failures) > 0:
+		finish("failed", exitFailed)
+	default:
+		finish("completed", exitCompleted)
+	}
+}
+
+// handleServe processes the serve command, running the webhook server and,
+// when enabled, the gRPC API until the program is interrupted.
+func handleServe(ctx context.Context, addr string, grpcAddr string, eventsTarget string) {
+	cfg := loadConfig()
+	client, closeClient := newClient(cfg, eventsTarget)
+	defer closeClient()
+
+	srv, err := server.New(cfg, client, addr, grpcAddr)
+	if err != nil {
+		fmt.Println(errorStyle.Render(fmt.Sprintf("Error: %v", err)))
+		os.Exit(1)
+	}
+
+	if err := srv.Run(ctx); err != nil {
+		fmt.Println(errorStyle.Render(fmt.Sprintf("Server error: %v", err)))
+		os.Exit(1)
+	}
+	fmt.Println(successStyle.Render("Server stopped"))
+}
+
+// handleExportSite processes the export site command, exporting a static
+// HTML gallery of the rendered clips of every channel, or of channelID, to out.
+func handleExportSite(channelID string, out string) {
+	cfg := loadConfig()
+	channels := selectChannels(cfg, channelID)
+
+	store, err := state.Open(cfg.StateDir())
+	if err != nil {
+		fmt.Println(errorStyle.Render(fmt.Sprintf("Error: %v", err)))
+		os.Exit(1)
+	}
+
+	if err := videos.ExportSite(channels, store, out); err != nil {
+		fmt.Println(errorStyle.Render(fmt.Sprintf("Export error: %v", err)))
+		os.Exit(1)
+	}
+}
+
+// handleExportMetadata processes the export metadata command, exporting a CSV
+// or XLSX table of the metadata of the clips of every channel, or of
+// channelID, to out.
+func handleExportMetadata(ctx context.Context, channelID string, format string, out string, stats bool) {
+	cfg := loadConfig()
+	channels := selectChannels(cfg, channelID)
+
+	client, closeClient := newClient(cfg, "")
+	defer closeClient()
+	if err := videos.ExportMetadata(ctx, client, channels, format, out, stats); err != nil {
+		fmt.Println(errorStyle.Render(fmt.Sprintf("Export error: %v", err)))
+		os.Exit(1)
+	}
+}
+
+// selectChannels returns the channel channelID, or every channel that is not
+// disabled when it is empty. An unknown channel exits the program.
+func selectChannels(cfg *config.Config, channelID string) []config.Channel {
+	if channelID == "" {
+		return cfg.Enabled()
+	}
+	channel, ok := findChannel(cfg, channelID)
+	if !ok {
+		fmt.Println(errorStyle.Render(fmt.Sprintf("Error: Channel with ID '%s' not found", channelID)))
+		os.Exit(1)
+	}
+	return []config.Channel{channel}
+}
+
+// handleDigest processes the digest command, emailing the digest of the given
+// channel or of every channel, covering period when set.
+func handleDigest(ctx context.Context, channelID string, period time.Duration, dryRun bool) {
+	cfg := loadConfig()
+	channels := selectChannels(cfg, channelID)
+
+	store, err := state.Open(cfg.StateDir())
+	if err != nil {
+		fmt.Println(errorStyle.Render(fmt.Sprintf("Error: %v", err)))
+		os.Exit(1)
+	}
+
+	var mailer notify.Mailer
+	if !dryRun {
+		mailer, err = notify.New(cfg.Digest)
+		if err != nil {
+			fmt.Println(errorStyle.Render(fmt.Sprintf("Error: %v", err)))
+			os.Exit(1)
+		}
+	}
+
+	if err := videos.SendDigests(ctx, channels, cfg.Digest, store, mailer, period); err != nil {
+		fmt.Println(errorStyle.Render(fmt.Sprintf("Digest error: %v", err)))
+		os.Exit(1)
+	}
+}
+
+// handleUpload processes the upload command. With applyEdits, the editing
+// sidecars of the clips are applied first. With drain, the clips waiting in
+// the spool of failed uploads are uploaded, once or, with every, at that
+// interval until the spool is empty.
+func handleUpload(ctx context.Context, channelID string, drain bool, applyEdits bool, every time.Duration, eventsTarget string) {
+	cfg := loadConfig()
+	channels := selectChannels(cfg, channelID)
+
+	client, closeClient := newClient(cfg, eventsTarget)
+	defer closeClient()
+
+	if applyEdits {
+		edited := 0
+		for _, channel := range channels {
+			if ctx.Err() != nil {
+				return
+			}
+			n, err := videos.ApplyEdits(ctx, client, channel)
+			if err != nil {
+				fmt.Println(errorStyle.Render(fmt.Sprintf("Skipping channel %s: %v", channel.Name, err)))
+				continue
+			}
+			edited += n
+		}
+		fmt.Println(successStyle.Render(fmt.Sprintf("Edits applied to %d clips", edited)))
+	}
+	if !drain {
+		return
+	}
+
+	for {
+		remaining := 0
+		for _, channel := range channels {
+			if ctx.Err() != nil {
+				return
+			}
+			// A single drain tries every upload right away, a periodic one
+			// waits for the backoff of each upload.
+			left, err := videos.DrainSpool(ctx, client, channel, every == 0)
+			if err != nil {
+				fmt.Println(errorStyle.Render("Error: " + err.Error()))
+				os.Exit(1)
+			}
+			remaining += left
+		}
+
+		if remaining == 0 {
+			fmt.Println(successStyle.Render("The spool of failed uploads is empty"))
+			return
+		}
+		fmt.Println(subtitleStyle.Render(fmt.Sprintf("%d uploads left in the spool", remaining)))
+
+		if every == 0 {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(every):
+		}
+	}
+}
+
+// handleCompile processes the compile command, joining the selected clips of
+// a channel into a single compilation video.
+func handleCompile(ctx context.Context, channelID string, options videos.CompileOptions) {
+	cfg := loadConfig()
+	channel, ok := findChannel(cfg, channelID)
+	if !ok {
+		fmt.Println(errorStyle.Render(fmt.Sprintf("Error: Channel with ID '%s' not found", channelID)))
+		os.Exit(1)
+	}
+
+	client, closeClient := newClient(cfg, "")
+	defer closeClient()
+
+	if err := videos.CompileClips(ctx, client, channel, options); err != nil {
+		fmt.Println(errorStyle.Render(fmt.Sprintf("Compile error: %v", err)))
+		os.Exit(1)
+	}
+}
+
+// handleBackfill processes the backfill command, walking the upload history
+// of a channel from its oldest video.
+func handleBackfill(ctx context.Context, channelID string, options videos.BackfillOptions, events string) {
+	cfg := loadConfig()
+	channel, ok := findChannel(cfg, channelID)
+	if !ok {
+		fmt.Println(errorStyle.Render(fmt.Sprintf("Error: Channel with ID '%s' not found", channelID)))
+		os.Exit(1)
+	}
+
+	client, closeClient := newClient(cfg, events)
+	defer closeClient()
+
+	err := videos.Backfill(ctx, client, channel, options)
+	if ctx.Err() != nil {
+		fmt.Println(errorStyle.Render("🛑 Interrupted. Run the same command again to resume."))
+		os.Exit(130)
+	}
+	if err != nil {
+		fmt.Println(errorStyle.Render(fmt.Sprintf("Backfill error: %v", err)))
+		os.Exit(1)
+	}
+}
+
+// handleBestOf processes the bestof command, ranking the clips of a channel
+// rendered in a period and reporting, or compiling, the winners.
+func handleBestOf(ctx context.Context, channelID string, options videos.BestOfOptions) {
+	cfg := loadConfig()
+	channel, ok := findChannel(cfg, channelID)
+	if !ok {
+		fmt.Println(errorStyle.Render(fmt.Sprintf("Error: Channel with ID '%s' not found", channelID)))
+		os.Exit(1)
+	}
+
+	client, closeClient := newClient(cfg, "")
+	defer closeClient()
+
+	if err := videos.BestOf(ctx, client, channel, options); err != nil {
+		fmt.Println(errorStyle.Render(fmt.Sprintf("Best of error: %v", err)))
+		os.Exit(1)
+	}
+}
+
+// parseWindow parses a period such as 72h, or a number of days such as 30d.
+func parseWindow(value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, err
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(value)
+}
+
+// handleReview processes the review command, listing the clips the quality
+// gate held back or approving them so the next retry uploads them, or
+// previewing the boundaries of a clip.
+func handleReview(ctx context.Context, channelID string, approve string, preview string) {
+	cfg := loadConfig()
+	if preview != "" {
+		handlePreview(ctx, cfg, channelID, preview)
+		return
+	}
+	channels := cfg.Channels
+	if channelID != "" {
+		channel, ok := findChannel(cfg, channelID)
+		if !ok {
 			fmt.Println(errorStyle.Render(fmt.Sprintf("Error: Channel with ID '%s' not found", channelID)))
 			os.Exit(1)
 		}
+		channels = []config.Channel{channel}
+	}
+
+	store, err := state.Open(cfg.StateDir())
+	if err != nil {
+		fmt.Println(errorStyle.Render(fmt.Sprintf("Error: %v", err)))
+		os.Exit(1)
+	}
+
+	held, approved := 0, 0
+	for _, channel := range channels {
+		for _, review := range store.Reviews(channel.ID) {
+			if review.Approved {
+				continue
+			}
+			held++
+
+			if approve == "all" || approve == review.Key {
+				if err := store.ApproveReview(channel.ID, review.Key); err != nil {
+					fmt.Println(errorStyle.Render(fmt.Sprintf("Error: %v", err)))
+					os.Exit(1)
+				}
+				fmt.Println(successStyle.Render(fmt.Sprintf("Approved %s of channel %s", review.Key, channel.ID)))
+				approved++
+				continue
+			}
+			if approve == "" {
+				fmt.Println(optionStyle.Render(fmt.Sprintf("%s (%s)", review.Key, channel.ID)), descriptionStyle.Render(fmt.Sprintf("%s, held back %s", review.Reason, review.Time.Format(time.DateTime))))
+			}
+		}
+	}
+
+	switch {
+	case held == 0:
+		fmt.Println(successStyle.Render("No clips held back for review"))
+	case approve != "" && approved == 0:
+		fmt.Println(errorStyle.Render("Error: no clip held back for review: " + approve))
+		os.Exit(1)
+	case approve != "":
+		fmt.Println(subtitleStyle.Render("Run 'godeogoker exec --retry-failed' to upload the approved clips"))
+	}
+}
+
+// handleConfigCheck processes the config check command, reporting every
+// problem Validate finds in the configuration in use.
+func handleConfigCheck() {
+	cfg := loadConfig()
+	var invalid *config.ValidationError
+	if err := cfg.Validate(); errors.As(err, &invalid) {
+		fmt.Println(errorStyle.Render(fmt.Sprintf("%s has %d problems:", config.Path(), len(invalid.Problems))))
+		for _, problem := range invalid.Problems {
+			fmt.Println(descriptionStyle.Render("  - " + problem))
+		}
+		os.Exit(1)
+	}
+	fmt.Println(successStyle.Render(fmt.Sprintf("%s is valid, %d channels configured", config.Path(), len(cfg.Channels))))
+}
+
+// handleConfigShow processes the config show command, printing the
+// configuration in use in format with its credentials redacted.
+func handleConfigShow(format string) {
+	cfg := loadConfig()
+	content, err := config.Encode(cfg.Redacted(), format)
+	if err != nil {
+		fmt.Println(errorStyle.Render(fmt.Sprintf("Error: %v", err)))
+		os.Exit(1)
+	}
+	os.Stdout.Write(content)
+}
+
+// handleConfigConvert processes the config convert command, writing the
+// configuration file in use to output in the format of its extension.
+func handleConfigConvert(output string, overwrite bool) {
+	// The file alone is converted, so values set in the environment, such
+	// as API keys, are not written to the output.
+	cfg, err := config.Load(config.Path())
+	if err != nil {
+		fmt.Println(errorStyle.Render(fmt.Sprintf("Error: %v", err)))
+		os.Exit(1)
+	}
+	if _, err := os.Stat(output); err == nil && !overwrite {
+		fmt.Println(errorStyle.Render(fmt.Sprintf("Error: %s already exists, use --overwrite to replace it", output)))
+		os.Exit(1)
+	}
+
+	content, err := config.Encode(cfg, config.Format(output))
+	if err != nil {
+		fmt.Println(errorStyle.Render(fmt.Sprintf("Error: %v", err)))
+		os.Exit(1)
+	}
+	if err := os.WriteFile(output, content, 0600); err != nil {
+		fmt.Println(errorStyle.Render(fmt.Sprintf("Error: %v", err)))
+		os.Exit(1)
+	}
+	fmt.Println(successStyle.Render(fmt.Sprintf("%s converted to %s", config.Path(), output)))
+}
+
+// channelsAddOptions holds the flags of the channels add command.
+type channelsAddOptions struct {
+	url    string
+	id     string
+	name   string
+	folder string
+	topics string
+	like   string
+}
+
+// handleChannelsAdd processes the channels add command, looking up the
+// YouTube channel of options.url and adding it to the configuration file,
+// with the settings of the channel options.like when given.
+func handleChannelsAdd(ctx context.Context, options channelsAddOptions) {
+	cfg := loadConfig()
+	found, err := videos.ResolveChannel(ctx, videos.NewClient(cfg, &http.Client{}), options.url)
+	if err != nil {
+		fmt.Println(errorStyle.Render(fmt.Sprintf("Error: %v", err)))
+		os.Exit(1)
+	}
+
+	channel := config.Channel{}
+	if options.like != "" {
+		like, ok := findChannel(cfg, options.like)
+		if !ok {
+			fmt.Println(errorStyle.Render(fmt.Sprintf("Error: Channel with ID '%s' not found", options.like)))
+			os.Exit(1)
+		}
+		channel = like
+		channel.Sources, channel.LastCheck, channel.Disabled = nil, "", false
+	}
+	channel.ID = options.id
+	if channel.ID == "" {
+		channel.ID = channelSlug(strings.TrimPrefix(found.Handle, "@"))
+	}
+	if channel.ID == "" {
+		channel.ID = channelSlug(found.Title)
+	}
+	channel.Name = cmp.Or(options.name, found.Title)
+	channel.ChannelID = found.ID
+	channel.URL = found.URL()
+	channel.Description = found.Description
+	channel.Folder = cmp.Or(options.folder, channel.ID)
+	channel.Topics = cmp.Or(options.topics, channel.Topics)
+
+	updateConfig(func(file *config.Config) error {
+		for _, existing := range file.Channels {
+			switch {
+			case existing.ID == channel.ID:
+				return fmt.Errorf("a channel with ID '%s' already exists, choose another with --id", channel.ID)
+			case existing.ChannelID == channel.ChannelID:
+				return fmt.Errorf("%s is already configured as channel '%s'", found.Title, existing.ID)
+			}
+		}
+		file.Channels = append(file.Channels, channel)
+		return nil
+	})
+	fmt.Println(successStyle.Render(fmt.Sprintf("Added %s (%s) as channel '%s', stored in %s", channel.Name, channel.ChannelID, channel.ID, channel.Folder)))
+}
+
+// handleChannelsRemove processes the channels remove command, deleting the
+// channel channelID from the configuration file. Its outputs and state are
+// left in place.
+func handleChannelsRemove(channelID string) {
+	updateConfig(func(file *config.Config) error {
+		i := slices.IndexFunc(file.Channels, func(channel config.Channel) bool { return channel.ID == channelID })
+		if i == -1 {
+			return fmt.Errorf("Channel with ID '%s' not found", channelID)
+		}
+		file.Channels = slices.Delete(file.Channels, i, i+1)
+		return nil
+	})
+	fmt.Println(successStyle.Render(fmt.Sprintf("Removed channel '%s', its folder and state are left in place", channelID)))
+}
+
+// handleChannelsEnable processes the channels enable and disable commands,
+// including channelID in the commands run for every channel or leaving it
+// out of them.
+func handleChannelsEnable(channelID string, enabled bool) {
+	updateConfig(func(file *config.Config) error {
+		i := slices.IndexFunc(file.Channels, func(channel config.Channel) bool { return channel.ID == channelID })
+		if i == -1 {
+			return fmt.Errorf("Channel with ID '%s' not found", channelID)
+		}
+		file.Channels[i].Disabled = !enabled
+		return nil
+	})
+	if enabled {
+		fmt.Println(successStyle.Render(fmt.Sprintf("Channel '%s' enabled", channelID)))
 	} else {
-		fmt.Println(subtitleStyle.Render("🎯 Starting batch download for all channels..."))
-		for _, channel := range channels {
-			if videoID != "" {
-				channel.ChannelID = "v=" + videoID
+		fmt.Println(successStyle.Render(fmt.Sprintf("Channel '%s' disabled, exec and daemon skip it unless it is named", channelID)))
+	}
+}
+
+// updateConfig applies change to the configuration file in use and writes
+// it back in its format. The file alone is read, so values set in the
+// environment, such as API keys, are not written to it. Errors exit the
+// program.
+func updateConfig(change func(file *config.Config) error) {
+	path := config.Path()
+	file, err := config.Load(path)
+	if err == nil {
+		err = change(file)
+	}
+	if err == nil {
+		err = config.Save(file, path)
+	}
+	if err != nil {
+		fmt.Println(errorStyle.Render(fmt.Sprintf("Error: %v", err)))
+		os.Exit(1)
+	}
+}
+
+// channelSlug returns name lower-cased with the runs of other characters than
+// letters and digits replaced by hyphens, as an ID for a channel.
+func channelSlug(name string) string {
+	var slug strings.Builder
+	hyphen := false
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if hyphen && slug.Len() > 0 {
+				slug.WriteByte('-')
 			}
-			fmt.Println(subtitleStyle.Render(fmt.Sprintf("📥 Downloading videos for channel: %s", channel.Name)))
-			videos.DownloadVideo(channel, force)
+			slug.WriteRune(r)
+			hyphen = false
+		} else {
+			hyphen = true
 		}
 	}
+	return slug.String()
+}
 
-	fmt.Println(successStyle.Render("🎉 Download completed successfully! Enjoy your videos!"))
+// handleCommunity processes the community command, listing the Community
+// posts written for the clips of channelID, or of every channel, during the
+// last period so they can be posted.
+func handleCommunity(channelID string, last time.Duration) {
+	cfg := loadConfig()
+	channels := cfg.Channels
+	if channelID != "" {
+		channel, ok := findChannel(cfg, channelID)
+		if !ok {
+			fmt.Println(errorStyle.Render(fmt.Sprintf("Error: Channel with ID '%s' not found", channelID)))
+			os.Exit(1)
+		}
+		channels = []config.Channel{channel}
+	}
+
+	listed := 0
+	for _, channel := range channels {
+		posts, err := videos.CommunityPosts(channel, time.Now().Add(-last))
+		if err != nil {
+			fmt.Println(errorStyle.Render(fmt.Sprintf("Error: %v", err)))
+			os.Exit(1)
+		}
+		for _, post := range posts {
+			listed++
+			fmt.Println(optionStyle.Render(fmt.Sprintf("%s (%s)", post.Key, channel.ID)), descriptionStyle.Render("written "+post.Created.Format(time.DateTime)))
+			if post.PublishAt != "" {
+				fmt.Println(subtitleStyle.Render("Post after the clip goes public at " + post.PublishAt))
+			}
+			fmt.Println(post.Text)
+			fmt.Println(post.URL)
+			if post.Poll != nil {
+				fmt.Println(commandStyle.Render("Poll: " + post.Poll.Question))
+				for _, option := range post.Poll.Options {
+					fmt.Println(descriptionStyle.Render("  - " + option))
+				}
+			}
+			fmt.Println()
+		}
+	}
+
+	if listed == 0 {
+		fmt.Println(successStyle.Render("No Community posts written in this period"))
+	}
+}
+
+// handlePreview shows the boundaries of the cut of a clip of channelID and
+// lets them be moved with single keys, rendering the clip again after each move.
+func handlePreview(ctx context.Context, cfg *config.Config, channelID string, key string) {
+	if channelID == "" {
+		fmt.Println(errorStyle.Render("Error: --preview needs the channel ID of the clip"))
+		os.Exit(1)
+	}
+	channel, ok := findChannel(cfg, channelID)
+	if !ok {
+		fmt.Println(errorStyle.Render(fmt.Sprintf("Error: Channel with ID '%s' not found", channelID)))
+		os.Exit(1)
+	}
+
+	client, closeClient := newClient(cfg, "")
+	defer closeClient()
+
+	restore := cbreak()
+	if restore == nil {
+		fmt.Println(subtitleStyle.Render("Press Enter after each key"))
+		restore = func() {}
+	}
+
+	// The terminal is restored before exiting, which skips deferred calls,
+	// so an interrupted preview does not leave the shell without echo.
+	err := videos.PreviewBoundaries(ctx, client, channel, key, os.Stdin)
+	restore()
+	if err != nil {
+		fmt.Println(errorStyle.Render(fmt.Sprintf("Preview error: %v", err)))
+		closeClient()
+		os.Exit(1)
+	}
+}
+
+// cbreak switches the terminal to pass keys on as they are pressed, without
+// echoing them, and returns a function restoring it. It returns nil when the
+// terminal cannot be switched, such as when stty is missing.
+func cbreak() func() {
+	stty := func(args ...string) ([]byte, error) {
+		cmd := exec.Command("stty", args...)
+		cmd.Stdin = os.Stdin
+		return cmd.Output()
+	}
+
+	saved, err := stty("-g")
+	if err != nil {
+		return nil
+	}
+	if _, err := stty("-icanon", "-echo", "min", "1"); err != nil {
+		return nil
+	}
+	return func() { stty(strings.TrimSpace(string(saved))) }
 }